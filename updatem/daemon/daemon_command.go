@@ -31,6 +31,9 @@ func setupCommandFlags(cmd *cobra.Command) {
 
 	// init things client
 	flagSet.StringVar(&cfg.ThingsConfig.ThingsMetaPath, "things-home-dir", cfg.ThingsConfig.ThingsMetaPath, "Specify the home directory for the Things Update Manager persistent storage")
+	flagSet.StringVar(&cfg.ThingsConfig.ManifestValuesFile, "things-manifest-values-file", cfg.ThingsConfig.ManifestValuesFile, "Specify the path to the JSON or YAML file with the vehicle-specific variables substituted in the update manifests")
+	flagSet.StringVar(&cfg.ThingsConfig.ManifestPolicyFile, "things-manifest-policy-file", cfg.ThingsConfig.ManifestPolicyFile, "Specify the path to the JSON or YAML admission policy file, which restricts the namespaces, kinds, API groups, pod privileges and image registries the update manifests may use")
+	flagSet.StringVar(&cfg.ThingsConfig.ManifestVariant, "things-manifest-variant", cfg.ThingsConfig.ManifestVariant, "Specify the vehicle variant, whose overlay is built from the kustomization archives provided as update manifests")
 	flagSet.StringSliceVar(&cfg.ThingsConfig.Features, "things-features", cfg.ThingsConfig.Features, "Specify the desired Ditto features that will be registered for the Ditto thing")
 	flagSet.StringVar(&cfg.ThingsConfig.ThingsConnectionConfig.BrokerURL, "things-conn-broker", cfg.ThingsConfig.ThingsConnectionConfig.BrokerURL, "Specify the MQTT broker URL to connect to")
 	flagSet.Int64Var(&cfg.ThingsConfig.ThingsConnectionConfig.KeepAlive, "things-conn-keep-alive", cfg.ThingsConfig.ThingsConnectionConfig.KeepAlive, "Specify the keep alive duration for the MQTT requests in milliseconds")
@@ -42,11 +45,40 @@ func setupCommandFlags(cmd *cobra.Command) {
 	flagSet.Int64Var(&cfg.ThingsConfig.ThingsConnectionConfig.SubscribeTimeout, "things-conn-sub-timeout", cfg.ThingsConfig.ThingsConnectionConfig.SubscribeTimeout, "Specify the subscribe timeout for the MQTT requests in milliseconds")
 	flagSet.Int64Var(&cfg.ThingsConfig.ThingsConnectionConfig.UnsubscribeTimeout, "things-conn-unsub-timeout", cfg.ThingsConfig.ThingsConnectionConfig.UnsubscribeTimeout, "Specify the unsubscribe timeout for the MQTT requests in milliseconds")
 
+	// init orchestration backend config
+	flagSet.StringVar(&cfg.Orchestration.Backend, "orchestration-backend", cfg.Orchestration.Backend, "Specify the orchestration backend, which applies the manifests - possible values are k8s, kanto and simulation")
+
 	// init k8s config
 	flagSet.StringVar(&cfg.Orchestration.K8s.Kubeconfig, "k8s-kubeconfig", cfg.Orchestration.K8s.Kubeconfig, "Specify the absolute path to the k8s condiguration")
+	flagSet.BoolVar(&cfg.Orchestration.K8s.ServerSideApply, "k8s-server-side-apply", cfg.Orchestration.K8s.ServerSideApply, "Enable applying the manifest resources via k8s server-side apply")
+	flagSet.StringVar(&cfg.Orchestration.K8s.FieldManager, "k8s-field-manager", cfg.Orchestration.K8s.FieldManager, "Specify the field manager name used when applying the manifest resources server-side")
+	flagSet.StringVar(&cfg.Orchestration.K8s.ConflictPolicy, "k8s-conflict-policy", cfg.Orchestration.K8s.ConflictPolicy, "Specify how field ownership conflicts are handled by the server-side apply - possible values are fail and force")
+	flagSet.StringVar(&cfg.Orchestration.K8s.ReadinessTimeout, "k8s-readiness-timeout", cfg.Orchestration.K8s.ReadinessTimeout, "Specify how long to wait for the applied workload resources to become ready, e.g. 5m - 0 disables the readiness check")
+	flagSet.StringVar(&cfg.Orchestration.K8s.PrePullTimeout, "k8s-pre-pull-timeout", cfg.Orchestration.K8s.PrePullTimeout, "Specify how long to wait for the container images referenced in the manifest to be pulled before applying it, e.g. 10m - 0 disables the pre-pull")
+	flagSet.StringSliceVar(&cfg.Orchestration.K8s.WatchedResources, "k8s-watched-resources", cfg.Orchestration.K8s.WatchedResources, "Specify the resources in the <resource>.<version>.<group> format that are watched and reported as current state, e.g. pods.v1.,deployments.v1.apps")
+	flagSet.StringSliceVar(&cfg.Orchestration.K8s.WatchedNamespaces, "k8s-watched-namespaces", cfg.Orchestration.K8s.WatchedNamespaces, "Specify the namespaces, in which the namespaced resources are watched - all namespaces are watched if not set")
+	flagSet.StringSliceVar(&cfg.Orchestration.K8s.MetadataOnlyResources, "k8s-metadata-only-resources", cfg.Orchestration.K8s.MetadataOnlyResources, "Specify the watched resources in the <resource>.<version>.<group> format, for which only the metadata is cached and reported as current state, e.g. nodes.v1.")
+	flagSet.Int64Var(&cfg.Orchestration.K8s.DiagnosticsLogLines, "k8s-diagnostics-log-lines", cfg.Orchestration.K8s.DiagnosticsLogLines, "Specify how many of the last log lines of the failing containers are reported when an update fails - 0 disables the log collection")
+	flagSet.BoolVar(&cfg.Orchestration.K8s.Prune, "k8s-prune", cfg.Orchestration.K8s.Prune, "Enable deleting the resources applied by the update manager that are no longer part of the update manifest")
+
+	// init kanto config
+	flagSet.StringVar(&cfg.Orchestration.Kanto.Address, "kanto-address", cfg.Orchestration.Kanto.Address, "Specify the address of the Eclipse Kanto container management local API, used by the kanto orchestration backend")
+	flagSet.StringVar(&cfg.Orchestration.Kanto.StopTimeout, "kanto-stop-timeout", cfg.Orchestration.Kanto.StopTimeout, "Specify how long to wait for a container to stop gracefully before it is removed by the kanto orchestration backend, e.g. 30s - 0 removes it immediately")
+	flagSet.BoolVar(&cfg.Orchestration.Kanto.Prune, "kanto-prune", cfg.Orchestration.Kanto.Prune, "Enable removing the containers created by the update manager that are no longer part of the update manifest")
+
+	// init simulation config
+	flagSet.StringVar(&cfg.Orchestration.Simulation.ApplyDelay, "simulation-apply-delay", cfg.Orchestration.Simulation.ApplyDelay, "Specify how long applying a manifest takes in the simulation orchestration backend, e.g. 2s")
+	flagSet.StringVar(&cfg.Orchestration.Simulation.ReadinessDelay, "simulation-readiness-delay", cfg.Orchestration.Simulation.ReadinessDelay, "Specify how long the applied Pod and Deployment resources take to become ready in the simulation orchestration backend, e.g. 5s")
+	flagSet.StringSliceVar(&cfg.Orchestration.Simulation.FailingResources, "simulation-failing-resources", cfg.Orchestration.Simulation.FailingResources, "Specify the resources in the <kind>/<name> format that fail to be applied by the simulation orchestration backend, e.g. deployment/hello-world")
+	flagSet.StringSliceVar(&cfg.Orchestration.Simulation.CrashingResources, "simulation-crashing-resources", cfg.Orchestration.Simulation.CrashingResources, "Specify the Pod and Deployment resources in the <kind>/<name> format whose containers crash in the simulation orchestration backend, e.g. pod/hello-world")
 
 	// init self update config
 	flagSet.BoolVar(&cfg.Orchestration.SelfUpdate.EnableReboot, "self-update-enable-reboot", cfg.Orchestration.SelfUpdate.EnableReboot, "Specify the enable reboot flag to the self update condiguration")
 	flagSet.StringVar(&cfg.Orchestration.SelfUpdate.Timeout, "self-update-timeout", cfg.Orchestration.SelfUpdate.Timeout, "Specify the timeout in cron format to wait for completing a self update operation")
 	flagSet.StringVar(&cfg.Orchestration.SelfUpdate.RebootTimeout, "self-update-reboot-timeout", cfg.Orchestration.SelfUpdate.RebootTimeout, "Specify the timeout in cron format to wait before a reboot process is initiated after a self update operation")
+	flagSet.StringVar(&cfg.Orchestration.SelfUpdate.SameVersionPolicy, "self-update-same-version-policy", cfg.Orchestration.SelfUpdate.SameVersionPolicy, "Specify how a self update bundle with the version currently reported by the self update agent is handled - possible values are skip and reject")
+
+	// init reconcile config
+	flagSet.StringVar(&cfg.Orchestration.Reconcile.Interval, "reconcile-interval", cfg.Orchestration.Reconcile.Interval, "Specify how often the last applied manifest is re-applied to revert the local modifications, e.g. 10m - 0 disables the reconciliation")
+	flagSet.StringVar(&cfg.Orchestration.Reconcile.Backoff, "reconcile-backoff", cfg.Orchestration.Reconcile.Backoff, "Specify the delay before a modified or deleted resource of the last applied manifest is reconciled, e.g. 10s - it is doubled after each failed reconciliation up to the reconcile interval")
 }
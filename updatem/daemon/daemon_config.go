@@ -26,6 +26,9 @@ type config struct {
 type thingsConfig struct {
 	ThingsMetaPath         string                  `json:"home_dir,omitempty"`
 	Features               []string                `json:"features,omitempty"`
+	ManifestValuesFile     string                  `json:"manifest_values_file,omitempty"`
+	ManifestPolicyFile     string                  `json:"manifest_policy_file,omitempty"`
+	ManifestVariant        string                  `json:"manifest_variant,omitempty"`
 	ThingsConnectionConfig *thingsConnectionConfig `json:"connection,omitempty"`
 }
 
@@ -44,18 +47,54 @@ type thingsConnectionConfig struct {
 
 // k8s execution config
 type k8sExecutionConfig struct {
-	Kubeconfig string `json:"kubeconfig,omitempty"`
+	Kubeconfig            string   `json:"kubeconfig,omitempty"`
+	ServerSideApply       bool     `json:"server_side_apply,omitempty"`
+	FieldManager          string   `json:"field_manager,omitempty"`
+	ConflictPolicy        string   `json:"conflict_policy,omitempty"`
+	ReadinessTimeout      string   `json:"readiness_timeout,omitempty"`
+	PrePullTimeout        string   `json:"pre_pull_timeout,omitempty"`
+	Prune                 bool     `json:"prune,omitempty"`
+	WatchedResources      []string `json:"watched_resources,omitempty"`
+	WatchedNamespaces     []string `json:"watched_namespaces,omitempty"`
+	MetadataOnlyResources []string `json:"metadata_only_resources,omitempty"`
+	DiagnosticsLogLines   int64    `json:"diagnostics_log_lines,omitempty"`
+}
+
+// kanto execution config
+type kantoExecutionConfig struct {
+	Address     string `json:"address,omitempty"`
+	StopTimeout string `json:"stop_timeout,omitempty"`
+	Prune       bool   `json:"prune,omitempty"`
+}
+
+// simulation execution config
+type simulationExecutionConfig struct {
+	ApplyDelay        string   `json:"apply_delay,omitempty"`
+	ReadinessDelay    string   `json:"readiness_delay,omitempty"`
+	FailingResources  []string `json:"failing_resources,omitempty"`
+	CrashingResources []string `json:"crashing_resources,omitempty"`
 }
 
 // self update executor config
 type selfUpdateExecutionConfig struct {
-	EnableReboot  bool   `json:"enable_reboot,omitempty"`
-	Timeout       string `json:"timeout,omitempty"`
-	RebootTimeout string `json:"reboot_timeout,omitempty"`
+	EnableReboot      bool   `json:"enable_reboot,omitempty"`
+	Timeout           string `json:"timeout,omitempty"`
+	RebootTimeout     string `json:"reboot_timeout,omitempty"`
+	SameVersionPolicy string `json:"same_version_policy,omitempty"`
+}
+
+// reconcile config
+type reconcileConfig struct {
+	Interval string `json:"interval,omitempty"`
+	Backoff  string `json:"backoff,omitempty"`
 }
 
 // orchestration config
 type orchestrationConfig struct {
+	Backend    string                     `json:"backend,omitempty"`
 	K8s        *k8sExecutionConfig        `json:"k8s,omitempty"`
+	Kanto      *kantoExecutionConfig      `json:"kanto,omitempty"`
+	Simulation *simulationExecutionConfig `json:"simulation,omitempty"`
 	SelfUpdate *selfUpdateExecutionConfig `json:"self_update,omitempty"`
+	Reconcile  *reconcileConfig           `json:"reconcile,omitempty"`
 }
@@ -13,6 +13,8 @@ package main
 
 import (
 	"github.com/eclipse-kanto/container-management/containerm/log"
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/orchestration/k8s"
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/orchestration/updateorchestrator"
 	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/things"
 )
 
@@ -26,6 +28,9 @@ const (
 	// default things connection config
 	thingsEnableDefault                      = true
 	thingsMetaPathDefault                    = "/var/lib/updatemanagerd"
+	thingsManifestValuesFileDefault          = ""
+	thingsManifestPolicyFileDefault          = ""
+	thingsManifestVariantDefault             = ""
 	thingsConnectionBrokerURLDefault         = "tcp://localhost:1883"
 	thingsConnectionKeepAliveDefault         = 20000
 	thingsConnectionDisconnectTimeoutDefault = 250
@@ -48,16 +53,49 @@ const (
 	k8sKubeconfigDefault          = "" //etc/rancher/k3s/k3s.yaml
 	k8sCreateUpdateTimeoutDefault = 20
 	k8sDeleteTimeoutDefault       = 20
+	k8sServerSideApplyDefault     = false
+	k8sFieldManagerDefault        = k8s.FieldManagerDefault
+	k8sConflictPolicyDefault      = k8s.ConflictPolicyFail
+	k8sReadinessTimeoutDefault    = "5m"
+	k8sPrePullTimeoutDefault      = "10m"
+	k8sPruneDefault               = true
+	k8sDiagnosticsLogLinesDefault = 20
+
+	// default orchestration backend config
+	orchestrationBackendDefault = updateorchestrator.BackendK8s
+
+	// default kanto config
+	kantoAddressDefault     = "/run/container-management/container-management.sock"
+	kantoStopTimeoutDefault = "30s"
+	kantoPruneDefault       = true
+
+	// default simulation config
+	simulationApplyDelayDefault     = "2s"
+	simulationReadinessDelayDefault = "5s"
 
 	// default self update config
-	selfUpdateTimeoutDefault       = "10m"
-	selfUpdateRebootTimeoutDefault = "30s"
-	selfUpdateEnableRebootDefault  = false
+	selfUpdateTimeoutDefault           = "10m"
+	selfUpdateRebootTimeoutDefault     = "30s"
+	selfUpdateEnableRebootDefault      = false
+	selfUpdateSameVersionPolicyDefault = "skip"
+
+	// default reconcile config
+	reconcileIntervalDefault = "0"
+	reconcileBackoffDefault  = "10s"
 )
 
 var (
 	// default things service features config
 	thingsServiceFeaturesDefault = []string{things.SoftwareUpdatableManifestsFeatureID}
+
+	// default k8s watched resources config
+	k8sWatchedResourcesDefault      = []string{"pods.v1.", "nodes.v1."}
+	k8sWatchedNamespacesDefault     = []string{}
+	k8sMetadataOnlyResourcesDefault = []string{}
+
+	// default simulation injected failures config
+	simulationFailingResourcesDefault  = []string{}
+	simulationCrashingResourcesDefault = []string{}
 )
 
 func getDefaultInstance() *config {
@@ -71,8 +109,11 @@ func getDefaultInstance() *config {
 			Syslog:        logEnableSyslogDefault,
 		},
 		ThingsConfig: &thingsConfig{
-			ThingsMetaPath: thingsMetaPathDefault,
-			Features:       thingsServiceFeaturesDefault,
+			ThingsMetaPath:     thingsMetaPathDefault,
+			Features:           thingsServiceFeaturesDefault,
+			ManifestValuesFile: thingsManifestValuesFileDefault,
+			ManifestPolicyFile: thingsManifestPolicyFileDefault,
+			ManifestVariant:    thingsManifestVariantDefault,
 			ThingsConnectionConfig: &thingsConnectionConfig{
 				BrokerURL:          thingsConnectionBrokerURLDefault,
 				KeepAlive:          thingsConnectionKeepAliveDefault,
@@ -86,13 +127,40 @@ func getDefaultInstance() *config {
 			},
 		},
 		Orchestration: &orchestrationConfig{
+			Backend: orchestrationBackendDefault,
 			K8s: &k8sExecutionConfig{
-				Kubeconfig: k8sKubeconfigDefault,
+				Kubeconfig:            k8sKubeconfigDefault,
+				ServerSideApply:       k8sServerSideApplyDefault,
+				FieldManager:          k8sFieldManagerDefault,
+				ConflictPolicy:        k8sConflictPolicyDefault,
+				ReadinessTimeout:      k8sReadinessTimeoutDefault,
+				PrePullTimeout:        k8sPrePullTimeoutDefault,
+				Prune:                 k8sPruneDefault,
+				WatchedResources:      k8sWatchedResourcesDefault,
+				WatchedNamespaces:     k8sWatchedNamespacesDefault,
+				MetadataOnlyResources: k8sMetadataOnlyResourcesDefault,
+				DiagnosticsLogLines:   k8sDiagnosticsLogLinesDefault,
+			},
+			Kanto: &kantoExecutionConfig{
+				Address:     kantoAddressDefault,
+				StopTimeout: kantoStopTimeoutDefault,
+				Prune:       kantoPruneDefault,
+			},
+			Simulation: &simulationExecutionConfig{
+				ApplyDelay:        simulationApplyDelayDefault,
+				ReadinessDelay:    simulationReadinessDelayDefault,
+				FailingResources:  simulationFailingResourcesDefault,
+				CrashingResources: simulationCrashingResourcesDefault,
 			},
 			SelfUpdate: &selfUpdateExecutionConfig{
-				Timeout:       selfUpdateTimeoutDefault,
-				RebootTimeout: selfUpdateRebootTimeoutDefault,
-				EnableReboot:  selfUpdateEnableRebootDefault,
+				Timeout:           selfUpdateTimeoutDefault,
+				RebootTimeout:     selfUpdateRebootTimeoutDefault,
+				EnableReboot:      selfUpdateEnableRebootDefault,
+				SameVersionPolicy: selfUpdateSameVersionPolicyDefault,
+			},
+			Reconcile: &reconcileConfig{
+				Interval: reconcileIntervalDefault,
+				Backoff:  reconcileBackoffDefault,
 			},
 		},
 	}
@@ -14,7 +14,9 @@ package main
 import (
 	"github.com/eclipse-kanto/container-management/containerm/log"
 	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/orchestration/k8s"
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/orchestration/kanto"
 	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/orchestration/selfupdate"
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/orchestration/simulation"
 	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/orchestration/updateorchestrator"
 	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/things"
 
@@ -31,6 +33,8 @@ const daemonConfigFileFlagID = "cfg-file"
 func extractUpdateManagerOptions(daemonConfig *config) interface{} {
 	mgrOpts := map[string]interface{}{}
 	mgrOpts["k8s"] = extractUpdateManagerK8sOptions(daemonConfig)
+	mgrOpts["kanto"] = extractUpdateManagerKantoOptions(daemonConfig)
+	mgrOpts["simulation"] = extractUpdateManagerSimulationOptions(daemonConfig)
 	mgrOpts["self_update"] = extractUpdateManagerSelfUpdateOptions(daemonConfig)
 	mgrOpts["update_orchestrator"] = extractUpdateOrchestratorOptions(daemonConfig)
 	return mgrOpts
@@ -40,6 +44,37 @@ func extractUpdateManagerK8sOptions(daemonConfig *config) []k8s.MgrOpt {
 	mgrOpts := []k8s.MgrOpt{}
 	mgrOpts = append(mgrOpts,
 		k8s.WithKubeConfig(daemonConfig.Orchestration.K8s.Kubeconfig),
+		k8s.WithServerSideApply(daemonConfig.Orchestration.K8s.ServerSideApply),
+		k8s.WithFieldManager(daemonConfig.Orchestration.K8s.FieldManager),
+		k8s.WithConflictPolicy(daemonConfig.Orchestration.K8s.ConflictPolicy),
+		k8s.WithReadinessTimeout(daemonConfig.Orchestration.K8s.ReadinessTimeout),
+		k8s.WithPrePullTimeout(daemonConfig.Orchestration.K8s.PrePullTimeout),
+		k8s.WithPrune(daemonConfig.Orchestration.K8s.Prune),
+		k8s.WithWatchedResources(daemonConfig.Orchestration.K8s.WatchedResources),
+		k8s.WithWatchedNamespaces(daemonConfig.Orchestration.K8s.WatchedNamespaces),
+		k8s.WithMetadataOnlyResources(daemonConfig.Orchestration.K8s.MetadataOnlyResources),
+		k8s.WithDiagnosticsLogLines(daemonConfig.Orchestration.K8s.DiagnosticsLogLines),
+	)
+	return mgrOpts
+}
+
+func extractUpdateManagerKantoOptions(daemonConfig *config) []kanto.MgrOpt {
+	mgrOpts := []kanto.MgrOpt{}
+	mgrOpts = append(mgrOpts,
+		kanto.WithConnectionAddress(daemonConfig.Orchestration.Kanto.Address),
+		kanto.WithStopTimeout(daemonConfig.Orchestration.Kanto.StopTimeout),
+		kanto.WithPrune(daemonConfig.Orchestration.Kanto.Prune),
+	)
+	return mgrOpts
+}
+
+func extractUpdateManagerSimulationOptions(daemonConfig *config) []simulation.MgrOpt {
+	mgrOpts := []simulation.MgrOpt{}
+	mgrOpts = append(mgrOpts,
+		simulation.WithApplyDelay(daemonConfig.Orchestration.Simulation.ApplyDelay),
+		simulation.WithReadinessDelay(daemonConfig.Orchestration.Simulation.ReadinessDelay),
+		simulation.WithFailingResources(daemonConfig.Orchestration.Simulation.FailingResources),
+		simulation.WithCrashingResources(daemonConfig.Orchestration.Simulation.CrashingResources),
 	)
 	return mgrOpts
 }
@@ -50,6 +85,7 @@ func extractUpdateManagerSelfUpdateOptions(daemonConfig *config) []selfupdate.Mg
 		selfupdate.WithEnableReboot(daemonConfig.Orchestration.SelfUpdate.EnableReboot),
 		selfupdate.WithRebootTimeout(daemonConfig.Orchestration.SelfUpdate.RebootTimeout),
 		selfupdate.WithTimeout(daemonConfig.Orchestration.SelfUpdate.Timeout),
+		selfupdate.WithSameVersionPolicy(daemonConfig.Orchestration.SelfUpdate.SameVersionPolicy),
 		selfupdate.WithConnectionBroker(daemonConfig.ThingsConfig.ThingsConnectionConfig.BrokerURL),
 		selfupdate.WithConnectionKeepAlive(time.Duration(daemonConfig.ThingsConfig.ThingsConnectionConfig.KeepAlive)*time.Millisecond),
 		selfupdate.WithConnectionAcknowledgeTimeout(time.Duration(daemonConfig.ThingsConfig.ThingsConnectionConfig.AcknowledgeTimeout)*time.Millisecond),
@@ -66,6 +102,10 @@ func extractUpdateManagerSelfUpdateOptions(daemonConfig *config) []selfupdate.Mg
 func extractUpdateOrchestratorOptions(daemonConfig *config) []updateorchestrator.MgrOpt {
 	mgrOpts := []updateorchestrator.MgrOpt{}
 	mgrOpts = append(mgrOpts,
+		updateorchestrator.WithMetaPath(daemonConfig.ThingsConfig.ThingsMetaPath),
+		updateorchestrator.WithBackend(daemonConfig.Orchestration.Backend),
+		updateorchestrator.WithReconcileInterval(daemonConfig.Orchestration.Reconcile.Interval),
+		updateorchestrator.WithReconcileBackoff(daemonConfig.Orchestration.Reconcile.Backoff),
 		updateorchestrator.WithConnectionBroker(daemonConfig.ThingsConfig.ThingsConnectionConfig.BrokerURL),
 		updateorchestrator.WithConnectionKeepAlive(time.Duration(daemonConfig.ThingsConfig.ThingsConnectionConfig.KeepAlive)*time.Millisecond),
 		updateorchestrator.WithConnectionAcknowledgeTimeout(time.Duration(daemonConfig.ThingsConfig.ThingsConnectionConfig.AcknowledgeTimeout)*time.Millisecond),
@@ -84,6 +124,9 @@ func extractThingsOptions(daemonConfig *config) []things.UpdateThingsManagerOpt
 	thingsOpts = append(thingsOpts,
 		things.WithMetaPath(daemonConfig.ThingsConfig.ThingsMetaPath),
 		things.WithFeatures(daemonConfig.ThingsConfig.Features),
+		things.WithManifestValuesFile(daemonConfig.ThingsConfig.ManifestValuesFile),
+		things.WithManifestPolicyFile(daemonConfig.ThingsConfig.ManifestPolicyFile),
+		things.WithManifestVariant(daemonConfig.ThingsConfig.ManifestVariant),
 		things.WithConnectionBroker(daemonConfig.ThingsConfig.ThingsConnectionConfig.BrokerURL),
 		things.WithConnectionKeepAlive(time.Duration(daemonConfig.ThingsConfig.ThingsConnectionConfig.KeepAlive)*time.Millisecond),
 		things.WithConnectionDisconnectTimeout(time.Duration(daemonConfig.ThingsConfig.ThingsConnectionConfig.DisconnectTimeout)*time.Millisecond),
@@ -172,6 +215,9 @@ func dumpThingsClient(configInstance *config) {
 	if configInstance.ThingsConfig != nil {
 		log.Debug("[daemon_cfg][things-home-dir] : %s", configInstance.ThingsConfig.ThingsMetaPath)
 		log.Debug("[daemon_cfg][things-features] : %s", configInstance.ThingsConfig.Features)
+		log.Debug("[daemon_cfg][things-manifest-values-file] : %s", configInstance.ThingsConfig.ManifestValuesFile)
+		log.Debug("[daemon_cfg][things-manifest-policy-file] : %s", configInstance.ThingsConfig.ManifestPolicyFile)
+		log.Debug("[daemon_cfg][things-manifest-variant] : %s", configInstance.ThingsConfig.ManifestVariant)
 		if configInstance.ThingsConfig.ThingsConnectionConfig != nil {
 			log.Debug("[daemon_cfg][things-conn-broker] : %s", configInstance.ThingsConfig.ThingsConnectionConfig.BrokerURL)
 			log.Debug("[daemon_cfg][things-conn-keep-alive] : %d", configInstance.ThingsConfig.ThingsConnectionConfig.KeepAlive)
@@ -186,9 +232,30 @@ func dumpThingsClient(configInstance *config) {
 
 func dumpOrchestration(configInstance *config) {
 	if configInstance.Orchestration != nil {
+		log.Debug("[daemon_cfg][orchestration-backend] : %v", configInstance.Orchestration.Backend)
 		log.Debug("[daemon_cfg][k8s-kubeconfig] : %v", configInstance.Orchestration.K8s.Kubeconfig)
+		log.Debug("[daemon_cfg][k8s-server-side-apply] : %v", configInstance.Orchestration.K8s.ServerSideApply)
+		log.Debug("[daemon_cfg][k8s-field-manager] : %v", configInstance.Orchestration.K8s.FieldManager)
+		log.Debug("[daemon_cfg][k8s-conflict-policy] : %v", configInstance.Orchestration.K8s.ConflictPolicy)
+		log.Debug("[daemon_cfg][k8s-readiness-timeout] : %v", configInstance.Orchestration.K8s.ReadinessTimeout)
+		log.Debug("[daemon_cfg][k8s-pre-pull-timeout] : %v", configInstance.Orchestration.K8s.PrePullTimeout)
+		log.Debug("[daemon_cfg][k8s-prune] : %v", configInstance.Orchestration.K8s.Prune)
+		log.Debug("[daemon_cfg][k8s-watched-resources] : %s", configInstance.Orchestration.K8s.WatchedResources)
+		log.Debug("[daemon_cfg][k8s-watched-namespaces] : %s", configInstance.Orchestration.K8s.WatchedNamespaces)
+		log.Debug("[daemon_cfg][k8s-metadata-only-resources] : %s", configInstance.Orchestration.K8s.MetadataOnlyResources)
+		log.Debug("[daemon_cfg][k8s-diagnostics-log-lines] : %d", configInstance.Orchestration.K8s.DiagnosticsLogLines)
+		log.Debug("[daemon_cfg][kanto-address] : %v", configInstance.Orchestration.Kanto.Address)
+		log.Debug("[daemon_cfg][kanto-stop-timeout] : %v", configInstance.Orchestration.Kanto.StopTimeout)
+		log.Debug("[daemon_cfg][kanto-prune] : %v", configInstance.Orchestration.Kanto.Prune)
+		log.Debug("[daemon_cfg][simulation-apply-delay] : %v", configInstance.Orchestration.Simulation.ApplyDelay)
+		log.Debug("[daemon_cfg][simulation-readiness-delay] : %v", configInstance.Orchestration.Simulation.ReadinessDelay)
+		log.Debug("[daemon_cfg][simulation-failing-resources] : %s", configInstance.Orchestration.Simulation.FailingResources)
+		log.Debug("[daemon_cfg][simulation-crashing-resources] : %s", configInstance.Orchestration.Simulation.CrashingResources)
 		log.Debug("[daemon_cfg][self-update-enable-reboot] : %v", configInstance.Orchestration.SelfUpdate.EnableReboot)
 		log.Debug("[daemon_cfg][self-update-timeout] : %v", configInstance.Orchestration.SelfUpdate.Timeout)
 		log.Debug("[daemon_cfg][self-update-reboot-timeout] : %v", configInstance.Orchestration.SelfUpdate.RebootTimeout)
+		log.Debug("[daemon_cfg][self-update-same-version-policy] : %v", configInstance.Orchestration.SelfUpdate.SameVersionPolicy)
+		log.Debug("[daemon_cfg][reconcile-interval] : %v", configInstance.Orchestration.Reconcile.Interval)
+		log.Debug("[daemon_cfg][reconcile-backoff] : %v", configInstance.Orchestration.Reconcile.Backoff)
 	}
 }
@@ -86,6 +86,14 @@ func TestThingsServiceFeaturesConfig(t *testing.T) {
 	testutil.AssertEqual(t, local.ThingsConfig.Features, []string{things.UpdateOrchestratorFeatureID, things.SoftwareUpdatableManifestsFeatureID})
 }
 
+func TestK8sWatchConfig(t *testing.T) {
+	local := &config{}
+	_ = loadLocalConfig("../pkg/testutil/testdata/config/daemon-k8s-watch-config.json", local)
+	testutil.AssertEqual(t, []string{"pods.v1.", "deployments.v1.apps", "selfupdatebundles.v1alpha1.sdv.eclipse.org"}, local.Orchestration.K8s.WatchedResources)
+	testutil.AssertEqual(t, []string{"default", "sdv"}, local.Orchestration.K8s.WatchedNamespaces)
+	testutil.AssertEqual(t, []string{"nodes.v1."}, local.Orchestration.K8s.MetadataOnlyResources)
+}
+
 func TestExtractOpts(t *testing.T) {
 	t.Run("test_extract_things_opts", func(t *testing.T) {
 		opts := extractThingsOptions(cfg)
@@ -169,6 +177,18 @@ func TestSetCommandFlags(t *testing.T) {
 			flag:         "things-home-dir",
 			expectedType: reflect.String.String(),
 		},
+		"test_flags_things-manifest-values-file": {
+			flag:         "things-manifest-values-file",
+			expectedType: reflect.String.String(),
+		},
+		"test_flags_things-manifest-policy-file": {
+			flag:         "things-manifest-policy-file",
+			expectedType: reflect.String.String(),
+		},
+		"test_flags_things-manifest-variant": {
+			flag:         "things-manifest-variant",
+			expectedType: reflect.String.String(),
+		},
 		"test_flags_things-features": {
 			flag:         "things-features",
 			expectedType: "stringSlice",
@@ -213,6 +233,78 @@ func TestSetCommandFlags(t *testing.T) {
 			flag:         "k8s-kubeconfig",
 			expectedType: reflect.String.String(),
 		},
+		"test_flags_orchestration-k8s-server-side-apply": {
+			flag:         "k8s-server-side-apply",
+			expectedType: reflect.Bool.String(),
+		},
+		"test_flags_orchestration-k8s-field-manager": {
+			flag:         "k8s-field-manager",
+			expectedType: reflect.String.String(),
+		},
+		"test_flags_orchestration-k8s-conflict-policy": {
+			flag:         "k8s-conflict-policy",
+			expectedType: reflect.String.String(),
+		},
+		"test_flags_orchestration-k8s-readiness-timeout": {
+			flag:         "k8s-readiness-timeout",
+			expectedType: reflect.String.String(),
+		},
+		"test_flags_orchestration-k8s-pre-pull-timeout": {
+			flag:         "k8s-pre-pull-timeout",
+			expectedType: reflect.String.String(),
+		},
+		"test_flags_orchestration-k8s-prune": {
+			flag:         "k8s-prune",
+			expectedType: reflect.Bool.String(),
+		},
+		"test_flags_orchestration-k8s-watched-resources": {
+			flag:         "k8s-watched-resources",
+			expectedType: "stringSlice",
+		},
+		"test_flags_orchestration-k8s-watched-namespaces": {
+			flag:         "k8s-watched-namespaces",
+			expectedType: "stringSlice",
+		},
+		"test_flags_orchestration-k8s-metadata-only-resources": {
+			flag:         "k8s-metadata-only-resources",
+			expectedType: "stringSlice",
+		},
+		"test_flags_orchestration-k8s-diagnostics-log-lines": {
+			flag:         "k8s-diagnostics-log-lines",
+			expectedType: reflect.Int64.String(),
+		},
+		"test_flags_orchestration-backend": {
+			flag:         "orchestration-backend",
+			expectedType: reflect.String.String(),
+		},
+		"test_flags_orchestration-kanto-address": {
+			flag:         "kanto-address",
+			expectedType: reflect.String.String(),
+		},
+		"test_flags_orchestration-kanto-stop-timeout": {
+			flag:         "kanto-stop-timeout",
+			expectedType: reflect.String.String(),
+		},
+		"test_flags_orchestration-kanto-prune": {
+			flag:         "kanto-prune",
+			expectedType: reflect.Bool.String(),
+		},
+		"test_flags_orchestration-simulation-apply-delay": {
+			flag:         "simulation-apply-delay",
+			expectedType: reflect.String.String(),
+		},
+		"test_flags_orchestration-simulation-readiness-delay": {
+			flag:         "simulation-readiness-delay",
+			expectedType: reflect.String.String(),
+		},
+		"test_flags_orchestration-simulation-failing-resources": {
+			flag:         "simulation-failing-resources",
+			expectedType: "stringSlice",
+		},
+		"test_flags_orchestration-simulation-crashing-resources": {
+			flag:         "simulation-crashing-resources",
+			expectedType: "stringSlice",
+		},
 		"test_flags_self-update-enable-reboot": {
 			flag:         "self-update-enable-reboot",
 			expectedType: reflect.Bool.String(),
@@ -225,6 +317,18 @@ func TestSetCommandFlags(t *testing.T) {
 			flag:         "self-update-timeout",
 			expectedType: reflect.String.String(),
 		},
+		"test_flags_self-update-same-version-policy": {
+			flag:         "self-update-same-version-policy",
+			expectedType: reflect.String.String(),
+		},
+		"test_flags_reconcile-interval": {
+			flag:         "reconcile-interval",
+			expectedType: reflect.String.String(),
+		},
+		"test_flags_reconcile-backoff": {
+			flag:         "reconcile-backoff",
+			expectedType: reflect.String.String(),
+		},
 	}
 
 	for testName, testCase := range tests {
@@ -17,10 +17,14 @@ import (
 
 	"github.com/eclipse-kanto/container-management/containerm/log"
 	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/events"
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/orchestration"
 	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
-	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/metadata"
 )
 
 // UpdateManagerK8sServiceID is the ID os the locally registered k8s update manager implementation
@@ -29,73 +33,138 @@ const UpdateManagerK8sServiceID = "orchestration.mgr"
 type k8sUpdateManager struct {
 	cfg                      *mgrOpts
 	k8sClient                dynamic.Interface
+	k8sMetadataClient        metadata.Interface
+	k8sClientset             kubernetes.Interface
+	k8sDiscoveryClient       discovery.DiscoveryInterface
 	k8sRESTMapper            meta.RESTMapper
+	k8sOpenAPIParser         openAPIResourcesParser
 	eventsMgr                events.UpdateEventsManager
 	applyLock                sync.Mutex
-	flagPublishResourceEvent bool
+	flagPublishResourceEvent int32
+	watchers                 []*resourceWatcher
+	watchStop                chan struct{}
+	disposeOnce              sync.Once
+
+	// managedNamespaces are the namespaces of the applied resources, which are pruned by the next apply even if it does not use them anymore
+	managedNamespaces []string
+
+	// appliedWatches are the names of the applied resources per type and namespace, which are watched in addition to the configured ones
+	appliedWatches map[watchKey]sets.String
+	watchCtx       context.Context
+
+	// clientsLock guards the k8s clients and the resource watchers, which are recreated on reconnect
+	clientsLock           sync.RWMutex
+	connectionErr         error
+	connectionStop        chan struct{}
+	kubeconfigFingerprint string
+	disposed              bool
 }
 
-var (
-	coreV1PodGVK = schema.GroupVersionKind{
-		Group:   "", // core API does not have a group
-		Version: "v1",
-		Kind:    "Pod",
-	}
-)
-
 func (updMgr *k8sUpdateManager) Apply(ctx context.Context, mf []*unstructured.Unstructured) interface{} {
 	updMgr.applyLock.Lock()
-	updMgr.flagPublishResourceEvent = false
+	updMgr.setPublishResourceEvent(false)
 
 	log.Debug("processing apply manifest - start")
 
 	defer func() {
-		updMgr.flagPublishResourceEvent = true
+		updMgr.setPublishResourceEvent(true)
 		updMgr.applyLock.Unlock()
 	}()
 
-	cmd, err := newKubectlApply(&updMgr.cfg.kubeconfig)
+	if err := updMgr.unavailableError(); err != nil {
+		log.ErrorErr(err, "cannot apply the manifest")
+		return &orchestration.ApplyResult{Err: err}
+	}
+
+	dryRun := orchestration.IsUpdateMgrDryRunContext(ctx)
+	reconcile := orchestration.IsUpdateMgrReconcileContext(ctx)
+	cmd, err := newKubectlApply(updMgr.cfg, dryRun)
 	if err != nil {
 		log.Error("error while creating apply manifest command ", err)
-		return err
+		return &orchestration.ApplyResult{Err: err}
+	}
+	if reconcile {
+		// the reconciliation re-applies a subset of the desired manifest, so nothing must be pruned
+		cmd.applyOptions.Prune = false
+	} else {
+		cmd.managedNamespaces = updMgr.managedNamespaces
 	}
 
-	err = cmd.apply(mf)
+	resources, err := cmd.apply(ctx, mf)
+	if !dryRun && containsCRDs(mf) {
+		updMgr.resetDiscovery()
+	}
+	if !dryRun && !reconcile {
+		updMgr.updateManagedNamespaces(resources, err == nil)
+	}
 	if err != nil {
 		log.Error("error while applying manifest ", err)
-		return err
+		return &orchestration.ApplyResult{Resources: resources, Err: err}
+	}
+
+	if updMgr.cfg.readinessTimeout > 0 && !dryRun && !reconcile {
+		if err := updMgr.waitForReadiness(ctx, mf); err != nil {
+			log.Error("error while waiting for the manifest resources to become ready ", err)
+			return &orchestration.ApplyResult{Resources: resources, Err: err}
+		}
 	}
 
+	if !dryRun {
+		updMgr.watchAppliedResources(mf, reconcile)
+	}
 	log.Debug("finished applying manifest")
-	return nil
+	return &orchestration.ApplyResult{Resources: resources}
+}
+
+// updateManagedNamespaces records the namespaces of the applied resources.
+// The previous namespaces are kept if the apply failed, as their resources may not be pruned yet.
+func (updMgr *k8sUpdateManager) updateManagedNamespaces(resources []*orchestration.ResourceResult, applied bool) {
+	namespaces := sets.NewString()
+	if !applied {
+		namespaces.Insert(updMgr.managedNamespaces...)
+	}
+	for _, resource := range resources {
+		if resource.Namespace != "" && resource.Outcome != orchestration.ResourceOutcomePruned {
+			namespaces.Insert(resource.Namespace)
+		}
+	}
+	updMgr.managedNamespaces = namespaces.List()
 }
 
 func (updMgr *k8sUpdateManager) Dispose(ctx context.Context) error {
+	updMgr.disposeOnce.Do(func() {
+		log.Debug("stop watching the k8s resources")
+		updMgr.clientsLock.Lock()
+		defer updMgr.clientsLock.Unlock()
+		updMgr.disposed = true
+		if updMgr.connectionStop != nil {
+			close(updMgr.connectionStop)
+		}
+		if updMgr.watchStop != nil {
+			close(updMgr.watchStop)
+		}
+	})
 	return nil
 }
 
+// Get returns copies of the watched resources from the informer caches, so that the callers can modify them.
+// The resources watched only because they are applied are not returned.
 func (updMgr *k8sUpdateManager) Get(ctx context.Context) []*unstructured.Unstructured {
-	updMgr.applyLock.Lock()
-
-	log.Debug("list existing k8s pods & nodes ")
-	defer updMgr.applyLock.Unlock()
-
-	// List pods
-	podsUnstructured, err := updMgr.listAllResources(ctx, coreV1PodGVK)
-	if err != nil {
-		log.Error("cannot list the existing pods ", err)
-		return nil
-	}
-
-	// List nodes
-	nodesUnstructured, err := updMgr.listAllResources(ctx, coreV1NodeGVK)
-	if err != nil {
-		log.Error("cannot list the existing nodes ", err)
-		return nil
-	}
+	log.Debug("list existing k8s watched resources")
+	updMgr.clientsLock.RLock()
+	defer updMgr.clientsLock.RUnlock()
 
 	result := []*unstructured.Unstructured{}
-	result = append(result, podsUnstructured...)
-	result = append(result, nodesUnstructured...)
+	for _, watcher := range updMgr.watchers {
+		if watcher.applied {
+			continue
+		}
+		if !watcher.informer.HasSynced() {
+			log.Debug("the cache of the watched %s in namespace [%s] is not synced yet", watcher.gvr.String(), watcher.namespace)
+		}
+		for _, u := range watcher.list() {
+			result = append(result, u.DeepCopy())
+		}
+	}
 	return result
 }
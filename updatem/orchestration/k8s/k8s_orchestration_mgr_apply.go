@@ -12,13 +12,24 @@
 package k8s
 
 import (
+	"github.com/eclipse-kanto/container-management/containerm/log"
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/orchestration"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/rest"
 
+	"context"
+	"io"
 	"io/ioutil"
+	"reflect"
 
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/cli-runtime/pkg/printers"
@@ -34,15 +45,44 @@ type kubectlApply struct {
 	applyOptions *apply.ApplyOptions
 	restConfig   *rest.Config
 	ioStreams    genericclioptions.IOStreams
+	results      []*orchestration.ResourceResult
+	// discoveryClient provides the served resources, which are pruned in server-side apply mode
+	discoveryClient discovery.DiscoveryInterface
+	// dryRunKinds holds the kinds defined by the custom resource definitions planned in dry-run mode, which are not served yet
+	dryRunKinds map[schema.GroupKind]bool
+	// managedNamespaces are the namespaces of the previously applied manifest, which are pruned in addition to the visited ones
+	managedNamespaces []string
 }
 
-func newKubectlApply(kubeconfig *string) (*kubectlApply, error) {
-	factory := cmdutil.NewFactory(&genericclioptions.ConfigFlags{KubeConfig: kubeconfig})
+const (
+	// managedByLabelKey and managedByLabelValue mark the resources applied by the update manager, so that only they are subject to pruning
+	managedByLabelKey   = "sdv.eclipse.org/managed-by"
+	managedByLabelValue = "vehicle-update-manager"
+)
+
+// printerOperationOutcomes maps the operations reported by the kubectl apply printers to resource outcomes
+var printerOperationOutcomes = map[string]orchestration.ResourceOutcome{
+	"created":    orchestration.ResourceOutcomeCreated,
+	"configured": orchestration.ResourceOutcomeConfigured,
+	"unchanged":  orchestration.ResourceOutcomeUnchanged,
+	"pruned":     orchestration.ResourceOutcomePruned,
+}
+
+func newKubectlApply(cfg *mgrOpts, dryRun bool) (*kubectlApply, error) {
+	factory := cmdutil.NewFactory(&genericclioptions.ConfigFlags{KubeConfig: &cfg.kubeconfig})
 	ioStreams := genericclioptions.IOStreams{
 		Out:    ioutil.Discard,
 		ErrOut: ioutil.Discard,
 	}
-	applyOptions, err := createApplyOptions(factory, ioStreams)
+	dryRunStrategy := cmdutil.DryRunNone
+	if dryRun {
+		dryRunStrategy = cmdutil.DryRunServer
+	}
+	k := &kubectlApply{
+		factory:   factory,
+		ioStreams: ioStreams,
+	}
+	applyOptions, err := createApplyOptions(factory, ioStreams, cfg, dryRunStrategy, k.recordResult)
 	if err != nil {
 		return nil, err
 	}
@@ -50,16 +90,19 @@ func newKubectlApply(kubeconfig *string) (*kubectlApply, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &kubectlApply{
-		factory:      factory,
-		restConfig:   restConfig,
-		ioStreams:    ioStreams,
-		applyOptions: applyOptions,
-	}, nil
+	k.restConfig = restConfig
+	k.applyOptions = applyOptions
+	if cfg.serverSideApply {
+		if k.discoveryClient, err = factory.ToDiscoveryClient(); err != nil {
+			return nil, err
+		}
+		k.applyOptions.PostProcessorFn = k.serverSideApplyPrune
+	}
+	return k, nil
 }
 
-func createApplyOptions(factory cmdutil.Factory, ioStreams genericclioptions.IOStreams) (*apply.ApplyOptions, error) {
-	dryRunStrategy := cmdutil.DryRunNone
+func createApplyOptions(factory cmdutil.Factory, ioStreams genericclioptions.IOStreams, cfg *mgrOpts,
+	dryRunStrategy cmdutil.DryRunStrategy, recordFn func(obj runtime.Object, operation string)) (*apply.ApplyOptions, error) {
 	dynamicClient, err := factory.DynamicClient()
 	if err != nil {
 		return nil, err
@@ -70,7 +113,14 @@ func createApplyOptions(factory cmdutil.Factory, ioStreams genericclioptions.IOS
 	toPrinter := func(operation string) (printers.ResourcePrinter, error) {
 		printFlags.NamePrintFlags.Operation = operation
 		cmdutil.PrintFlagsWithDryRunStrategy(printFlags, dryRunStrategy)
-		return printFlags.ToPrinter()
+		printer, err := printFlags.ToPrinter()
+		if err != nil {
+			return nil, err
+		}
+		return printers.ResourcePrinterFunc(func(obj runtime.Object, w io.Writer) error {
+			recordFn(obj, operation)
+			return printer.PrintObj(obj, w)
+		}), nil
 	}
 
 	recorder, err := genericclioptions.NewRecordFlags().ToRecorder()
@@ -100,18 +150,26 @@ func createApplyOptions(factory cmdutil.Factory, ioStreams genericclioptions.IOS
 		return nil, err
 	}
 
+	fieldManager := apply.FieldManagerClientSideApply
+	if cfg.serverSideApply {
+		fieldManager = cfg.fieldManager
+		if fieldManager == "" {
+			fieldManager = FieldManagerDefault
+		}
+	}
+
 	applyOptions := &apply.ApplyOptions{
 		PrintFlags: printFlags,
 
 		DeleteOptions:   deleteOptions,
 		ToPrinter:       toPrinter,
-		ServerSideApply: false,
-		ForceConflicts:  false,
-		FieldManager:    "kubectl-client-side-apply",
+		ServerSideApply: cfg.serverSideApply,
+		ForceConflicts:  cfg.conflictPolicy == ConflictPolicyForce,
+		FieldManager:    fieldManager,
 		DryRunStrategy:  dryRunStrategy,
 		DryRunVerifier:  dryRunVerifier,
-		Prune:           true,
-		All:             true,
+		Prune:           cfg.prune,
+		Selector:        managedByLabelKey + "=" + managedByLabelValue,
 		Overwrite:       true,
 		OpenAPIPatch:    true,
 
@@ -135,29 +193,251 @@ func createApplyOptions(factory cmdutil.Factory, ioStreams genericclioptions.IOS
 	return applyOptions, nil
 }
 
-func (k *kubectlApply) apply(mf []*unstructured.Unstructured) error {
-	objects, err := k.createResourceInfos(mf, k.restConfig, k.applyOptions.Mapper)
+func (k *kubectlApply) apply(ctx context.Context, mf []*unstructured.Unstructured) ([]*orchestration.ResourceResult, error) {
+	waves, err := newApplyWaves(mf)
+	if err != nil {
+		return nil, err
+	}
+	// the namespaces of the previously applied manifest are pruned as well, so that the resources of the namespaces no longer used are deleted
+	k.applyOptions.VisitedNamespaces.Insert(k.managedNamespaces...)
+	// the pruning is performed only once all waves are applied
+	postProcessorFn := k.applyOptions.PostProcessorFn
+	dryRun := k.applyOptions.DryRunStrategy == cmdutil.DryRunServer
+	for i, wave := range waves {
+		lastWave := i == len(waves)-1
+		k.applyOptions.PostProcessorFn = nil
+		if lastWave {
+			k.applyOptions.PostProcessorFn = postProcessorFn
+		}
+		log.Debug("applying sync wave %d with %d resource(s)", wave.syncWave, len(wave.resources))
+		if err := k.applyWave(wave); err != nil {
+			return k.results, err
+		}
+		crds := wave.crds()
+		if len(crds) == 0 || lastWave {
+			continue
+		}
+		if dryRun {
+			// the planned custom resource definitions are not created, so their custom resources cannot be mapped
+			k.addDryRunKinds(crds)
+			continue
+		}
+		if err := k.waitForCRDsEstablished(ctx, crds); err != nil {
+			return k.results, err
+		}
+		if err := k.resetRESTMapper(); err != nil {
+			return k.results, err
+		}
+	}
+	return k.results, nil
+}
+
+// applyWave applies each object of the wave separately, so that the failures can be reported per object.
+// The pruning is performed only if all objects are applied successfully.
+func (k *kubectlApply) applyWave(wave *applyWave) error {
+	var (
+		errs      []error
+		conflicts int
+	)
+	if k.applyOptions.EnforceNamespace {
+		k.applyOptions.VisitedNamespaces.Insert(k.applyOptions.Namespace)
+	}
+	postProcessorFn := k.applyOptions.PostProcessorFn
+	k.applyOptions.PostProcessorFn = nil
+	defer func() {
+		k.applyOptions.PostProcessorFn = postProcessorFn
+	}()
+	for _, u := range wave.resources {
+		info, err := k.createResourceInfo(u, k.restConfig, k.applyOptions.Mapper)
+		if err != nil && meta.IsNoMatchError(err) && k.dryRunKinds[u.GroupVersionKind().GroupKind()] {
+			k.results = append(k.results, newDryRunResourceResult(u))
+			continue
+		}
+		if err == nil {
+			err = k.applyObject(info)
+		}
+		if err == nil {
+			continue
+		}
+		if apierrors.IsConflict(err) && k.applyOptions.ServerSideApply {
+			log.Warn("the resource %s has field ownership conflicts: %v", info.String(), err)
+			k.results = append(k.results, newConflictResourceResult(info, err))
+			conflicts++
+			continue
+		}
+		log.Warn("the resource %s cannot be applied: %v", resourceName(u), err)
+		k.results = append(k.results, newFailedResourceResult(u, err))
+		errs = append(errs, err)
+	}
+	if conflicts > 0 {
+		errs = append(errs, log.NewErrorf("%d resource(s) not applied due to field ownership conflicts", conflicts))
+	}
+	if len(errs) > 0 {
+		return utilerrors.NewAggregate(errs)
+	}
+	if postProcessorFn != nil {
+		return postProcessorFn()
+	}
+	return nil
+}
+
+func (k *kubectlApply) applyObject(info *resource.Info) error {
+	if k.applyOptions.ServerSideApply {
+		return k.serverSideApplyObject(info)
+	}
+	k.applyOptions.SetObjects([]*resource.Info{info})
+	return k.applyOptions.Run()
+}
+
+// recordResult records the outcome for an object as reported by the kubectl apply printers
+func (k *kubectlApply) recordResult(obj runtime.Object, operation string) {
+	outcome, ok := printerOperationOutcomes[operation]
+	if !ok {
+		return
+	}
+	result, err := newResourceResult(obj, outcome)
+	if err != nil {
+		log.WarnErr(err, "cannot record the %s outcome of an object", operation)
+		return
+	}
+	k.results = append(k.results, result)
+}
+
+// serverSideApplyObject sends the object as an apply patch, so that the field ownership conflicts can be reported per object
+func (k *kubectlApply) serverSideApplyObject(info *resource.Info) error {
+	k.applyOptions.MarkNamespaceVisited(info)
+
+	data, err := runtime.Encode(unstructured.UnstructuredJSONScheme, info.Object)
 	if err != nil {
 		return err
 	}
-	k.applyOptions.SetObjects(objects)
+	dryRun := k.applyOptions.DryRunStrategy == cmdutil.DryRunServer
+	if dryRun {
+		if err := k.applyOptions.DryRunVerifier.HasSupport(info.Mapping.GroupVersionKind); err != nil {
+			return err
+		}
+	}
+	helper := resource.NewHelper(info.Client, info.Mapping).
+		DryRun(dryRun).
+		WithFieldManager(k.applyOptions.FieldManager)
 
-	if err := k.applyOptions.Run(); err != nil {
+	live, err := helper.Get(info.Namespace, info.Name)
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return err
+		}
+		live = nil
+	}
+	obj, err := helper.Patch(info.Namespace, info.Name, types.ApplyPatchType, data, &metav1.PatchOptions{
+		Force: &k.applyOptions.ForceConflicts,
+	})
+	if err != nil {
+		return err
+	}
+	info.Refresh(obj, true)
+	if err := k.applyOptions.MarkObjectVisited(info); err != nil {
 		return err
 	}
+	result, err := newResourceResult(obj, serverSideApplyOutcome(live, obj))
+	if err != nil {
+		return err
+	}
+	k.results = append(k.results, result)
 	return nil
 }
 
-func (k *kubectlApply) createResourceInfos(mf []*unstructured.Unstructured, restConfig *rest.Config, mapper meta.RESTMapper) ([]*resource.Info, error) {
-	infos := []*resource.Info{}
-	for _, u := range mf {
-		info, err := k.createResourceInfo(u, restConfig, mapper)
-		if err != nil {
-			return nil, err
+// serverSideApplyOutcome compares the live object with the applied one, ignoring the fields maintained by the server
+func serverSideApplyOutcome(live, applied runtime.Object) orchestration.ResourceOutcome {
+	if live == nil {
+		return orchestration.ResourceOutcomeCreated
+	}
+	liveContent, err := toComparableContent(live)
+	if err != nil {
+		return orchestration.ResourceOutcomeConfigured
+	}
+	appliedContent, err := toComparableContent(applied)
+	if err != nil {
+		return orchestration.ResourceOutcomeConfigured
+	}
+	if reflect.DeepEqual(liveContent, appliedContent) {
+		return orchestration.ResourceOutcomeUnchanged
+	}
+	return orchestration.ResourceOutcomeConfigured
+}
+
+func toComparableContent(obj runtime.Object) (map[string]interface{}, error) {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+	content = runtime.DeepCopyJSON(content)
+	unstructured.RemoveNestedField(content, "metadata", "managedFields")
+	unstructured.RemoveNestedField(content, "metadata", "resourceVersion")
+	unstructured.RemoveNestedField(content, "metadata", "generation")
+	return content, nil
+}
+
+func newResourceResult(obj runtime.Object, outcome orchestration.ResourceOutcome) (*orchestration.ResourceResult, error) {
+	metadata, err := meta.Accessor(obj)
+	if err != nil {
+		return nil, err
+	}
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	return &orchestration.ResourceResult{
+		APIVersion: gvk.GroupVersion().String(),
+		Kind:       gvk.Kind,
+		Namespace:  metadata.GetNamespace(),
+		Name:       metadata.GetName(),
+		Outcome:    outcome,
+	}, nil
+}
+
+func newConflictResourceResult(info *resource.Info, err error) *orchestration.ResourceResult {
+	gvk := info.Object.GetObjectKind().GroupVersionKind()
+	result := &orchestration.ResourceResult{
+		APIVersion: gvk.GroupVersion().String(),
+		Kind:       gvk.Kind,
+		Name:       info.Name,
+		Outcome:    orchestration.ResourceOutcomeConflict,
+		Message:    err.Error(),
+	}
+	if info.Namespaced() {
+		result.Namespace = info.Namespace
+	}
+	if status, ok := err.(apierrors.APIStatus); ok && status.Status().Details != nil {
+		for _, cause := range status.Status().Details.Causes {
+			if cause.Type == metav1.CauseTypeFieldManagerConflict {
+				result.Conflicts = append(result.Conflicts, &orchestration.FieldConflict{
+					Field:   cause.Field,
+					Message: cause.Message,
+				})
+			}
 		}
-		infos = append(infos, info)
 	}
-	return infos, nil
+	return result
+}
+
+// newDryRunResourceResult reports a custom resource, whose definition is planned in the same manifest, as created without sending it to the API server
+func newDryRunResourceResult(u *unstructured.Unstructured) *orchestration.ResourceResult {
+	return &orchestration.ResourceResult{
+		APIVersion: u.GetAPIVersion(),
+		Kind:       u.GetKind(),
+		Namespace:  u.GetNamespace(),
+		Name:       u.GetName(),
+		Outcome:    orchestration.ResourceOutcomeCreated,
+		Message:    "would be created once its custom resource definition is established",
+	}
+}
+
+func newFailedResourceResult(u *unstructured.Unstructured, err error) *orchestration.ResourceResult {
+	return &orchestration.ResourceResult{
+		APIVersion: u.GetAPIVersion(),
+		Kind:       u.GetKind(),
+		Namespace:  u.GetNamespace(),
+		Name:       u.GetName(),
+		Outcome:    orchestration.ResourceOutcomeFailed,
+		Message:    err.Error(),
+	}
 }
 
 func (k *kubectlApply) createResourceInfo(mf *unstructured.Unstructured, restConfig *rest.Config, mapper meta.RESTMapper) (*resource.Info, error) {
@@ -187,12 +467,24 @@ func (k *kubectlApply) createResourceInfo(mf *unstructured.Unstructured, restCon
 		Mapping:         restMapping,
 		ResourceVersion: restMapping.Resource.Version,
 
-		Object: mf,
+		Object: withManagedByLabel(mf),
 	}
 
 	return info, nil
 }
 
+// withManagedByLabel returns a copy of the resource marked as managed by the update manager
+func withManagedByLabel(u *unstructured.Unstructured) *unstructured.Unstructured {
+	labeled := u.DeepCopy()
+	labels := labeled.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labels[managedByLabelKey] = managedByLabelValue
+	labeled.SetLabels(labels)
+	return labeled
+}
+
 func (k *kubectlApply) newRestClient(restConfig *rest.Config, gv schema.GroupVersion) (rest.Interface, error) {
 	restConfig.ContentConfig = resource.UnstructuredPlusDefaultContentConfig()
 	restConfig.GroupVersion = &gv
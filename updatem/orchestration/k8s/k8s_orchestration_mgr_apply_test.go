@@ -0,0 +1,236 @@
+// Copyright (c) 2022 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Apache License 2.0 which is available at
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package k8s
+
+import (
+	"testing"
+
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/orchestration"
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/pkg/testutil"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/rest"
+	"k8s.io/kubectl/pkg/cmd/apply"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+func TestNewConflictResourceResult(t *testing.T) {
+	testDeployment := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata": map[string]interface{}{
+				"name":      "nginx-deployment",
+				"namespace": "default",
+			},
+		},
+	}
+	testInfo := &resource.Info{
+		Namespace: "default",
+		Name:      "nginx-deployment",
+		Mapping: &meta.RESTMapping{
+			Resource: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"},
+			Scope:    meta.RESTScopeNamespace,
+		},
+		Object: testDeployment,
+	}
+	testErr := apierrors.NewApplyConflict([]metav1.StatusCause{
+		{
+			Type:    metav1.CauseTypeFieldManagerConflict,
+			Message: `conflict with "kube-controller-manager" using apps/v1`,
+			Field:   ".spec.replicas",
+		},
+		{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Message: "not a conflict",
+		},
+	}, "Apply failed with 1 conflict")
+
+	result := newConflictResourceResult(testInfo, testErr)
+
+	testutil.AssertEqual(t, &orchestration.ResourceResult{
+		APIVersion: "apps/v1",
+		Kind:       "Deployment",
+		Namespace:  "default",
+		Name:       "nginx-deployment",
+		Outcome:    orchestration.ResourceOutcomeConflict,
+		Message:    testErr.Error(),
+		Conflicts: []*orchestration.FieldConflict{
+			{
+				Field:   ".spec.replicas",
+				Message: `conflict with "kube-controller-manager" using apps/v1`,
+			},
+		},
+	}, result)
+}
+
+func TestServerSideApplyOutcome(t *testing.T) {
+	newTestConfigMap := func(resourceVersion string, data map[string]interface{}) *unstructured.Unstructured {
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "ConfigMap",
+				"metadata": map[string]interface{}{
+					"name":            "test-config",
+					"namespace":       "default",
+					"resourceVersion": resourceVersion,
+					"managedFields": []interface{}{
+						map[string]interface{}{"manager": resourceVersion},
+					},
+				},
+				"data": data,
+			},
+		}
+	}
+	tests := map[string]struct {
+		live            *unstructured.Unstructured
+		applied         *unstructured.Unstructured
+		expectedOutcome orchestration.ResourceOutcome
+	}{
+		"test_created": {
+			applied:         newTestConfigMap("1", map[string]interface{}{"key": "value"}),
+			expectedOutcome: orchestration.ResourceOutcomeCreated,
+		},
+		"test_unchanged": {
+			live:            newTestConfigMap("1", map[string]interface{}{"key": "value"}),
+			applied:         newTestConfigMap("2", map[string]interface{}{"key": "value"}),
+			expectedOutcome: orchestration.ResourceOutcomeUnchanged,
+		},
+		"test_configured": {
+			live:            newTestConfigMap("1", map[string]interface{}{"key": "value"}),
+			applied:         newTestConfigMap("2", map[string]interface{}{"key": "new-value"}),
+			expectedOutcome: orchestration.ResourceOutcomeConfigured,
+		},
+	}
+	for testName, testCase := range tests {
+		t.Run(testName, func(t *testing.T) {
+			t.Log(testName)
+			if testCase.live == nil {
+				testutil.AssertEqual(t, testCase.expectedOutcome, serverSideApplyOutcome(nil, testCase.applied))
+				return
+			}
+			testutil.AssertEqual(t, testCase.expectedOutcome, serverSideApplyOutcome(testCase.live, testCase.applied))
+			testutil.AssertEqual(t, "1", testCase.live.GetResourceVersion())
+		})
+	}
+}
+
+func TestRecordResult(t *testing.T) {
+	testPod := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Pod",
+			"metadata": map[string]interface{}{
+				"name":      "test-pod",
+				"namespace": "default",
+			},
+		},
+	}
+	k := &kubectlApply{}
+	k.recordResult(testPod, "pruned")
+	k.recordResult(testPod, "serverside-applied")
+
+	testutil.AssertEqual(t, []*orchestration.ResourceResult{{
+		APIVersion: "v1",
+		Kind:       "Pod",
+		Namespace:  "default",
+		Name:       "test-pod",
+		Outcome:    orchestration.ResourceOutcomePruned,
+	}}, k.results)
+}
+
+func TestWithManagedByLabel(t *testing.T) {
+	testConfigMap := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata": map[string]interface{}{
+				"name":   "test-config",
+				"labels": map[string]interface{}{"app": "test"},
+			},
+		},
+	}
+
+	labeled := withManagedByLabel(testConfigMap)
+
+	testutil.AssertEqual(t, map[string]string{"app": "test", managedByLabelKey: managedByLabelValue}, labeled.GetLabels())
+	testutil.AssertEqual(t, map[string]string{"app": "test"}, testConfigMap.GetLabels())
+}
+
+func TestApplyWaveFailed(t *testing.T) {
+	testDeployment := newTestManifestResource("apps/v1", "Deployment", "test-deployment", "")
+	postProcessed := false
+	k := &kubectlApply{
+		restConfig: &rest.Config{Host: "localhost"},
+		applyOptions: &apply.ApplyOptions{
+			Mapper: meta.NewDefaultRESTMapper(nil),
+			PostProcessorFn: func() error {
+				postProcessed = true
+				return nil
+			},
+		},
+	}
+
+	err := k.applyWave(&applyWave{resources: []*unstructured.Unstructured{testDeployment}})
+	testutil.AssertNotNil(t, err)
+	testutil.AssertFalse(t, postProcessed)
+	testutil.AssertNotNil(t, k.applyOptions.PostProcessorFn)
+	testutil.AssertEqual(t, []*orchestration.ResourceResult{{
+		APIVersion: "apps/v1",
+		Kind:       "Deployment",
+		Name:       "test-deployment",
+		Outcome:    orchestration.ResourceOutcomeFailed,
+		Message:    `no matches for kind "Deployment" in version "apps/v1"`,
+	}}, k.results)
+}
+
+func TestApplyDryRunWithCRD(t *testing.T) {
+	testCRD := newTestCRD("selfupdatebundles.sdv.eclipse.org")
+	testCRD.Object["spec"] = map[string]interface{}{
+		"group": "sdv.eclipse.org",
+		"names": map[string]interface{}{"kind": "SelfUpdateBundle"},
+	}
+	testBundle := newTestManifestResource("sdv.eclipse.org/v1alpha1", "SelfUpdateBundle", "test-bundle", "")
+	testDeployment := newTestManifestResource("apps/v1", "Deployment", "test-deployment", "")
+
+	k := &kubectlApply{
+		restConfig: &rest.Config{Host: "localhost"},
+		applyOptions: &apply.ApplyOptions{
+			Mapper:         meta.NewDefaultRESTMapper(nil),
+			DryRunStrategy: cmdutil.DryRunServer,
+		},
+	}
+	k.addDryRunKinds([]*unstructured.Unstructured{testCRD})
+
+	err := k.applyWave(&applyWave{resources: []*unstructured.Unstructured{testBundle, testDeployment}})
+	testutil.AssertNotNil(t, err)
+	testutil.AssertEqual(t, []*orchestration.ResourceResult{
+		{
+			APIVersion: "sdv.eclipse.org/v1alpha1",
+			Kind:       "SelfUpdateBundle",
+			Name:       "test-bundle",
+			Outcome:    orchestration.ResourceOutcomeCreated,
+			Message:    "would be created once its custom resource definition is established",
+		},
+		{
+			APIVersion: "apps/v1",
+			Kind:       "Deployment",
+			Name:       "test-deployment",
+			Outcome:    orchestration.ResourceOutcomeFailed,
+			Message:    `no matches for kind "Deployment" in version "apps/v1"`,
+		},
+	}, k.results)
+}
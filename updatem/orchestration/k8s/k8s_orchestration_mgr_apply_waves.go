@@ -0,0 +1,198 @@
+// Copyright (c) 2022 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Apache License 2.0 which is available at
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package k8s
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eclipse-kanto/container-management/containerm/log"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/restmapper"
+)
+
+// syncWaveAnnotation assigns a resource to an explicit sync wave - the waves are applied in ascending order, the default wave is 0
+const syncWaveAnnotation = "sdv.eclipse.org/sync-wave"
+
+const (
+	kindWaveDefinitions = iota
+	kindWaveConfiguration
+	kindWaveWorkloads
+)
+
+var (
+	crdGroupKind = schema.GroupKind{Group: "apiextensions.k8s.io", Kind: "CustomResourceDefinition"}
+	crdGVR       = schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"}
+
+	crdEstablishedTimeout      = time.Minute
+	crdEstablishedPollInterval = time.Second
+
+	// kindWaves orders the resources within a sync wave - the kinds, on which the workloads depend, are applied first
+	kindWaves = map[schema.GroupKind]int{
+		{Kind: "Namespace"}: kindWaveDefinitions,
+		crdGroupKind:        kindWaveDefinitions,
+
+		{Kind: "ServiceAccount"}:                                         kindWaveConfiguration,
+		{Group: "rbac.authorization.k8s.io", Kind: "ClusterRole"}:        kindWaveConfiguration,
+		{Group: "rbac.authorization.k8s.io", Kind: "ClusterRoleBinding"}: kindWaveConfiguration,
+		{Group: "rbac.authorization.k8s.io", Kind: "Role"}:               kindWaveConfiguration,
+		{Group: "rbac.authorization.k8s.io", Kind: "RoleBinding"}:        kindWaveConfiguration,
+
+		{Kind: "ConfigMap"}:                                 kindWaveConfiguration,
+		{Kind: "Secret"}:                                    kindWaveConfiguration,
+		{Kind: "ResourceQuota"}:                             kindWaveConfiguration,
+		{Kind: "LimitRange"}:                                kindWaveConfiguration,
+		{Kind: "PersistentVolume"}:                          kindWaveConfiguration,
+		{Kind: "PersistentVolumeClaim"}:                     kindWaveConfiguration,
+		{Group: "storage.k8s.io", Kind: "StorageClass"}:     kindWaveConfiguration,
+		{Group: "scheduling.k8s.io", Kind: "PriorityClass"}: kindWaveConfiguration,
+	}
+)
+
+// applyWave is a group of manifest resources applied together
+type applyWave struct {
+	syncWave  int
+	kindWave  int
+	resources []*unstructured.Unstructured
+}
+
+// crds returns the custom resource definitions in the wave - the REST mapper has to be refreshed before applying their custom resources
+func (wave *applyWave) crds() []*unstructured.Unstructured {
+	crds := []*unstructured.Unstructured{}
+	for _, u := range wave.resources {
+		if u.GroupVersionKind().GroupKind() == crdGroupKind {
+			crds = append(crds, u)
+		}
+	}
+	return crds
+}
+
+// containsCRDs checks if the manifest contains custom resource definitions, which change the served kinds
+func containsCRDs(mf []*unstructured.Unstructured) bool {
+	for _, u := range mf {
+		if u.GroupVersionKind().GroupKind() == crdGroupKind {
+			return true
+		}
+	}
+	return false
+}
+
+// addDryRunKinds records the kinds defined by the custom resource definitions, which are planned but not created in dry-run mode
+func (k *kubectlApply) addDryRunKinds(crds []*unstructured.Unstructured) {
+	if k.dryRunKinds == nil {
+		k.dryRunKinds = map[schema.GroupKind]bool{}
+	}
+	for _, crd := range crds {
+		group, _, _ := unstructured.NestedString(crd.Object, "spec", "group")
+		kind, _, _ := unstructured.NestedString(crd.Object, "spec", "names", "kind")
+		if kind != "" {
+			k.dryRunKinds[schema.GroupKind{Group: group, Kind: kind}] = true
+		}
+	}
+}
+
+// newApplyWaves groups the manifest resources by their sync wave and kind, keeping the manifest order within a group
+func newApplyWaves(mf []*unstructured.Unstructured) ([]*applyWave, error) {
+	waves := []*applyWave{}
+	for _, u := range mf {
+		syncWave, err := resourceSyncWave(u)
+		if err != nil {
+			return nil, err
+		}
+		kindWave, ok := kindWaves[u.GroupVersionKind().GroupKind()]
+		if !ok {
+			kindWave = kindWaveWorkloads
+		}
+		wave := findApplyWave(waves, syncWave, kindWave)
+		if wave == nil {
+			wave = &applyWave{syncWave: syncWave, kindWave: kindWave}
+			waves = append(waves, wave)
+		}
+		wave.resources = append(wave.resources, u)
+	}
+	sort.SliceStable(waves, func(i, j int) bool {
+		if waves[i].syncWave != waves[j].syncWave {
+			return waves[i].syncWave < waves[j].syncWave
+		}
+		return waves[i].kindWave < waves[j].kindWave
+	})
+	return waves, nil
+}
+
+func findApplyWave(waves []*applyWave, syncWave, kindWave int) *applyWave {
+	for _, wave := range waves {
+		if wave.syncWave == syncWave && wave.kindWave == kindWave {
+			return wave
+		}
+	}
+	return nil
+}
+
+func resourceSyncWave(u *unstructured.Unstructured) (int, error) {
+	value, ok := u.GetAnnotations()[syncWaveAnnotation]
+	if !ok {
+		return 0, nil
+	}
+	syncWave, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		return 0, log.NewErrorf("invalid %s annotation %s of resource %s - an integer is expected", syncWaveAnnotation, value, resourceName(u))
+	}
+	return syncWave, nil
+}
+
+// waitForCRDsEstablished waits for the applied custom resource definitions to be served by the API server
+func (k *kubectlApply) waitForCRDsEstablished(ctx context.Context, crds []*unstructured.Unstructured) error {
+	log.Debug("waiting up to %s for %d custom resource definition(s) to become established", crdEstablishedTimeout, len(crds))
+	var notEstablished []string
+	// the wait is interrupted as soon as the update operation is cancelled
+	err := wait.PollImmediateWithContext(ctx, crdEstablishedPollInterval, crdEstablishedTimeout, func(ctx context.Context) (bool, error) {
+		notEstablished = nil
+		for _, crd := range crds {
+			live, err := k.applyOptions.DynamicClient.Resource(crdGVR).Get(ctx, crd.GetName(), metav1.GetOptions{})
+			if err != nil {
+				notEstablished = append(notEstablished, crd.GetName())
+				continue
+			}
+			if status, reason, message := findCondition(live, "NamesAccepted"); status == "False" {
+				return false, log.NewErrorf("the names of custom resource definition %s are not accepted - %s: %s", crd.GetName(), reason, message)
+			}
+			if status, _, _ := findCondition(live, "Established"); status != "True" {
+				notEstablished = append(notEstablished, crd.GetName())
+			}
+		}
+		return len(notEstablished) == 0, nil
+	})
+	if err == wait.ErrWaitTimeout && ctx.Err() != nil {
+		return log.NewErrorf("the wait for the custom resource definition(s) to become established is cancelled: %s", strings.Join(notEstablished, ", "))
+	}
+	if err == wait.ErrWaitTimeout {
+		return log.NewErrorf("timeout of %s expired, custom resource definition(s) not established: %s", crdEstablishedTimeout, strings.Join(notEstablished, ", "))
+	}
+	return err
+}
+
+// resetRESTMapper discards the cached discovery information, so that the kinds defined by the applied custom resource definitions can be mapped
+func (k *kubectlApply) resetRESTMapper() error {
+	discoveryClient, err := k.factory.ToDiscoveryClient()
+	if err != nil {
+		return err
+	}
+	discoveryClient.Invalidate()
+	k.applyOptions.Mapper = restmapper.NewShortcutExpander(restmapper.NewDeferredDiscoveryRESTMapper(discoveryClient), discoveryClient)
+	return nil
+}
@@ -0,0 +1,157 @@
+// Copyright (c) 2022 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Apache License 2.0 which is available at
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package k8s
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/pkg/testutil"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/kubectl/pkg/cmd/apply"
+)
+
+func newTestManifestResource(apiVersion, kind, name, syncWave string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetAPIVersion(apiVersion)
+	u.SetKind(kind)
+	u.SetName(name)
+	if syncWave != "" {
+		u.SetAnnotations(map[string]string{syncWaveAnnotation: syncWave})
+	}
+	return u
+}
+
+func newTestCRD(name string, conditions ...interface{}) *unstructured.Unstructured {
+	crd := newTestManifestResource("apiextensions.k8s.io/v1", "CustomResourceDefinition", name, "")
+	if len(conditions) > 0 {
+		crd.Object["status"] = map[string]interface{}{"conditions": conditions}
+	}
+	return crd
+}
+
+func TestNewApplyWaves(t *testing.T) {
+	tests := map[string]struct {
+		manifest      []*unstructured.Unstructured
+		expectedWaves [][]string
+		expectedErr   bool
+	}{
+		"test_kind_order": {
+			manifest: []*unstructured.Unstructured{
+				newTestManifestResource("apps/v1", "Deployment", "test-deployment", ""),
+				newTestManifestResource("sdv.eclipse.org/v1alpha1", "SelfUpdateBundle", "test-bundle", ""),
+				newTestManifestResource("v1", "ConfigMap", "test-config", ""),
+				newTestCRD("selfupdatebundles.sdv.eclipse.org"),
+				newTestManifestResource("rbac.authorization.k8s.io/v1", "Role", "test-role", ""),
+				newTestManifestResource("v1", "Namespace", "test-namespace", ""),
+			},
+			expectedWaves: [][]string{
+				{"selfupdatebundles.sdv.eclipse.org", "test-namespace"},
+				{"test-config", "test-role"},
+				{"test-deployment", "test-bundle"},
+			},
+		},
+		"test_sync_waves": {
+			manifest: []*unstructured.Unstructured{
+				newTestManifestResource("apps/v1", "Deployment", "test-deployment-late", "1"),
+				newTestManifestResource("apps/v1", "Deployment", "test-deployment", ""),
+				newTestManifestResource("v1", "ConfigMap", "test-config-late", "1"),
+				newTestManifestResource("apps/v1", "Deployment", "test-deployment-early", "-1"),
+			},
+			expectedWaves: [][]string{
+				{"test-deployment-early"},
+				{"test-deployment"},
+				{"test-config-late"},
+				{"test-deployment-late"},
+			},
+		},
+		"test_invalid_sync_wave": {
+			manifest: []*unstructured.Unstructured{
+				newTestManifestResource("apps/v1", "Deployment", "test-deployment", "first"),
+			},
+			expectedErr: true,
+		},
+	}
+	for testName, testCase := range tests {
+		t.Run(testName, func(t *testing.T) {
+			t.Log(testName)
+			waves, err := newApplyWaves(testCase.manifest)
+			testutil.AssertEqual(t, testCase.expectedErr, err != nil)
+			if testCase.expectedErr {
+				return
+			}
+			names := [][]string{}
+			for _, wave := range waves {
+				waveNames := []string{}
+				for _, u := range wave.resources {
+					waveNames = append(waveNames, u.GetName())
+				}
+				names = append(names, waveNames)
+			}
+			testutil.AssertEqual(t, testCase.expectedWaves, names)
+		})
+	}
+}
+
+func TestWaitForCRDsEstablished(t *testing.T) {
+	crdEstablishedPollInterval = 10 * time.Millisecond
+	crdEstablishedTimeout = 100 * time.Millisecond
+	defer func() {
+		crdEstablishedPollInterval = time.Second
+		crdEstablishedTimeout = time.Minute
+	}()
+
+	testCRDName := "selfupdatebundles.sdv.eclipse.org"
+	tests := map[string]struct {
+		live        *unstructured.Unstructured
+		cancelled   bool
+		expectedErr bool
+	}{
+		"test_established": {
+			live: newTestCRD(testCRDName, map[string]interface{}{"type": "Established", "status": "True"}),
+		},
+		"test_not_established": {
+			live:        newTestCRD(testCRDName),
+			expectedErr: true,
+		},
+		"test_names_not_accepted": {
+			live:        newTestCRD(testCRDName, map[string]interface{}{"type": "NamesAccepted", "status": "False", "reason": "NameConflict"}),
+			expectedErr: true,
+		},
+		"test_cancelled": {
+			live:        newTestCRD(testCRDName),
+			cancelled:   true,
+			expectedErr: true,
+		},
+	}
+	for testName, testCase := range tests {
+		t.Run(testName, func(t *testing.T) {
+			t.Log(testName)
+			k := &kubectlApply{
+				applyOptions: &apply.ApplyOptions{DynamicClient: dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), testCase.live)},
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if testCase.cancelled {
+				cancel()
+			}
+			err := k.waitForCRDsEstablished(ctx, []*unstructured.Unstructured{newTestCRD(testCRDName)})
+			testutil.AssertEqual(t, testCase.expectedErr, err != nil)
+			if testCase.cancelled {
+				testutil.AssertContainsString(t, err.Error(), "cancelled")
+			}
+		})
+	}
+}
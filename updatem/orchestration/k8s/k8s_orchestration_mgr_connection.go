@@ -0,0 +1,185 @@
+// Copyright (c) 2022 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Apache License 2.0 which is available at
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/cenkalti/backoff/v3"
+	"github.com/eclipse-kanto/container-management/containerm/log"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/metadata"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/kubectl/pkg/util/openapi"
+)
+
+var (
+	connectionCheckInterval         = 30 * time.Second
+	reconnectBackOffInitialInterval = time.Second
+	reconnectBackOffMaxInterval     = time.Minute
+)
+
+// connect (re)creates the k8s clients from the kubeconfig and restarts the resource watchers on them
+func (updMgr *k8sUpdateManager) connect(ctx context.Context) error {
+	fingerprint := kubeconfigFingerprint(updMgr.cfg.kubeconfig)
+	config, err := clientcmd.BuildConfigFromFlags("", updMgr.cfg.kubeconfig)
+	if err != nil {
+		return log.NewErrorf("error parsing the provided kubeconfig: %v", err)
+	}
+	k8sDiscoveryClient, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return log.NewErrorf("error connecting k8s discovery client to the provided k8s instance: %v", err)
+	}
+	if _, err := k8sDiscoveryClient.ServerVersion(); err != nil {
+		return log.NewErrorf("the k8s API server is not reachable: %v", err)
+	}
+	k8sClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return log.NewErrorf("error connecting to the provided k8s instance: %v", err)
+	}
+	k8sMetadataClient, err := metadata.NewForConfig(config)
+	if err != nil {
+		return log.NewErrorf("error connecting k8s metadata client to the provided k8s instance: %v", err)
+	}
+	k8sClientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return log.NewErrorf("error connecting k8s clientset to the provided k8s instance: %v", err)
+	}
+	log.Debug("successfully connected via the provided kubeconfig %s", updMgr.cfg.kubeconfig)
+
+	updMgr.clientsLock.Lock()
+	defer updMgr.clientsLock.Unlock()
+	if updMgr.disposed {
+		return log.NewError("the k8s update manager is disposed")
+	}
+	if updMgr.watchStop != nil {
+		close(updMgr.watchStop)
+	}
+	updMgr.k8sDiscoveryClient = k8sDiscoveryClient
+	updMgr.k8sClient = k8sClient
+	updMgr.k8sMetadataClient = k8sMetadataClient
+	updMgr.k8sClientset = k8sClientset
+	updMgr.setDiscovery(k8sDiscoveryClient)
+	updMgr.kubeconfigFingerprint = fingerprint
+	updMgr.connectionErr = nil
+	updMgr.watchers = nil
+	updMgr.loopWatchResources(ctx)
+	return nil
+}
+
+// setDiscovery caches the discovery information of the k8s API server, which is used to map and validate the resources
+func (updMgr *k8sUpdateManager) setDiscovery(k8sDiscoveryClient discovery.DiscoveryInterface) {
+	cachedDiscoveryClient := memory.NewMemCacheClient(k8sDiscoveryClient)
+	updMgr.k8sRESTMapper = restmapper.NewDeferredDiscoveryRESTMapper(cachedDiscoveryClient)
+	updMgr.k8sOpenAPIParser = openapi.NewOpenAPIParser(cachedDiscoveryClient)
+}
+
+// resetDiscovery discards the cached discovery information after custom resource definitions are applied or removed,
+// so that their kinds can be mapped, watched and validated
+func (updMgr *k8sUpdateManager) resetDiscovery() {
+	updMgr.clientsLock.Lock()
+	defer updMgr.clientsLock.Unlock()
+	if updMgr.k8sDiscoveryClient != nil {
+		updMgr.setDiscovery(updMgr.k8sDiscoveryClient)
+	}
+}
+
+// monitorConnection periodically checks the connection to the k8s API server and reconnects with backoff when it is lost or the kubeconfig changes
+func (updMgr *k8sUpdateManager) monitorConnection(ctx context.Context) {
+	for {
+		if err := updMgr.checkConnection(); err != nil {
+			updMgr.setConnectionError(err)
+			log.WarnErr(err, "the k8s orchestrator is unavailable - will reconnect")
+			if !updMgr.reconnect(ctx) {
+				return
+			}
+		}
+		select {
+		case <-updMgr.connectionStop:
+			return
+		case <-time.After(connectionCheckInterval):
+		}
+	}
+}
+
+func (updMgr *k8sUpdateManager) checkConnection() error {
+	updMgr.clientsLock.RLock()
+	defer updMgr.clientsLock.RUnlock()
+	if updMgr.connectionErr != nil {
+		return updMgr.connectionErr
+	}
+	if kubeconfigFingerprint(updMgr.cfg.kubeconfig) != updMgr.kubeconfigFingerprint {
+		return log.NewErrorf("the kubeconfig %s has changed", updMgr.cfg.kubeconfig)
+	}
+	if _, err := updMgr.k8sDiscoveryClient.ServerVersion(); err != nil {
+		return log.NewErrorf("the k8s API server is not reachable: %v", err)
+	}
+	return nil
+}
+
+// reconnect retries to connect with an exponential backoff until it succeeds or the manager is disposed
+func (updMgr *k8sUpdateManager) reconnect(ctx context.Context) bool {
+	b := backoff.NewExponentialBackOff()
+	b.InitialInterval = reconnectBackOffInitialInterval
+	b.MaxInterval = reconnectBackOffMaxInterval
+	b.MaxElapsedTime = 0
+	b.Reset()
+	for {
+		select {
+		case <-updMgr.connectionStop:
+			return false
+		case <-time.After(b.NextBackOff()):
+		}
+		err := updMgr.connect(ctx)
+		if err == nil {
+			log.Debug("reconnected to the k8s API server")
+			return true
+		}
+		log.Debug("cannot reconnect to the k8s API server: %v", err)
+		updMgr.setConnectionError(err)
+	}
+}
+
+func (updMgr *k8sUpdateManager) setConnectionError(err error) {
+	updMgr.clientsLock.Lock()
+	defer updMgr.clientsLock.Unlock()
+	updMgr.connectionErr = err
+}
+
+// unavailableError returns an error if the manager is not connected to the k8s API server
+func (updMgr *k8sUpdateManager) unavailableError() error {
+	updMgr.clientsLock.RLock()
+	defer updMgr.clientsLock.RUnlock()
+	if updMgr.connectionErr != nil {
+		return log.NewErrorf("orchestrator unavailable: %v", updMgr.connectionErr)
+	}
+	return nil
+}
+
+// kubeconfigFingerprint identifies the current version of the kubeconfig file - it is empty if the file is not present
+func kubeconfigFingerprint(kubeconfig string) string {
+	if kubeconfig == "" {
+		return ""
+	}
+	info, err := os.Stat(kubeconfig)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%d-%d", info.ModTime().UnixNano(), info.Size())
+}
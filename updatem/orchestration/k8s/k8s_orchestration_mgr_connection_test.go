@@ -0,0 +1,107 @@
+// Copyright (c) 2022 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Apache License 2.0 which is available at
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package k8s
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/orchestration"
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/pkg/testutil"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const testUnreachableKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: test
+  cluster:
+    server: https://127.0.0.1:1
+contexts:
+- name: test
+  context:
+    cluster: test
+current-context: test
+`
+
+func TestApplyUnavailable(t *testing.T) {
+	updMgr := newTestK8sUpdateManager(&mgrOpts{})
+	updMgr.connectionErr = os.ErrNotExist
+
+	result := updMgr.Apply(context.Background(), []*unstructured.Unstructured{newTestResource("Pod", "default", "test-pod")}).(*orchestration.ApplyResult)
+	testutil.AssertNotNil(t, result.Err)
+	testutil.AssertContainsString(t, result.Err.Error(), "orchestrator unavailable")
+}
+
+func TestConnect(t *testing.T) {
+	kubeconfigDir, err := ioutil.TempDir("", "updatem-kubeconfig")
+	testutil.AssertNil(t, err)
+	defer os.RemoveAll(kubeconfigDir)
+
+	unreachableKubeconfig := filepath.Join(kubeconfigDir, "unreachable.yaml")
+	testutil.AssertNil(t, ioutil.WriteFile(unreachableKubeconfig, []byte(testUnreachableKubeconfig), 0600))
+	invalidKubeconfig := filepath.Join(kubeconfigDir, "invalid.yaml")
+	testutil.AssertNil(t, ioutil.WriteFile(invalidKubeconfig, []byte("clusters: invalid"), 0600))
+
+	tests := map[string]struct {
+		kubeconfig string
+	}{
+		"test_unreachable_server": {kubeconfig: unreachableKubeconfig},
+		"test_invalid_kubeconfig": {kubeconfig: invalidKubeconfig},
+	}
+	for testName, testCase := range tests {
+		t.Run(testName, func(t *testing.T) {
+			t.Log(testName)
+			updMgr := &k8sUpdateManager{cfg: &mgrOpts{kubeconfig: testCase.kubeconfig}}
+			testutil.AssertNotNil(t, updMgr.connect(context.Background()))
+			testutil.AssertNil(t, updMgr.k8sClient)
+		})
+	}
+}
+
+func TestReconnectDisposed(t *testing.T) {
+	updMgr := newTestK8sUpdateManager(&mgrOpts{})
+	updMgr.connectionStop = make(chan struct{})
+	testutil.AssertNil(t, updMgr.Dispose(context.Background()))
+
+	done := make(chan bool)
+	go func() {
+		done <- updMgr.reconnect(context.Background())
+	}()
+	select {
+	case reconnected := <-done:
+		testutil.AssertFalse(t, reconnected)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the reconnect is not stopped on dispose")
+	}
+}
+
+func TestKubeconfigFingerprint(t *testing.T) {
+	kubeconfigDir, err := ioutil.TempDir("", "updatem-kubeconfig")
+	testutil.AssertNil(t, err)
+	defer os.RemoveAll(kubeconfigDir)
+
+	kubeconfig := filepath.Join(kubeconfigDir, "config.yaml")
+	testutil.AssertEqual(t, "", kubeconfigFingerprint(kubeconfig))
+
+	testutil.AssertNil(t, ioutil.WriteFile(kubeconfig, []byte(testUnreachableKubeconfig), 0600))
+	fingerprint := kubeconfigFingerprint(kubeconfig)
+	testutil.AssertNotEqual(t, "", fingerprint)
+	testutil.AssertEqual(t, fingerprint, kubeconfigFingerprint(kubeconfig))
+
+	testutil.AssertNil(t, ioutil.WriteFile(kubeconfig, []byte(testUnreachableKubeconfig+"preferences: {}\n"), 0600))
+	testutil.AssertNotEqual(t, fingerprint, kubeconfigFingerprint(kubeconfig))
+}
@@ -0,0 +1,229 @@
+// Copyright (c) 2022 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Apache License 2.0 which is available at
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/eclipse-kanto/container-management/containerm/log"
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/orchestration"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// Constants that bound the diagnostics collected for a failed update, so that they fit in the reported status
+const (
+	diagnosticsMaxEvents     = 10
+	diagnosticsMaxPods       = 3
+	diagnosticsMaxLogBytes   = 8 * 1024
+	diagnosticsMaxMessageLen = 512
+)
+
+// Diagnose collects the warning events and the last log lines of the failing containers of the manifest workloads.
+// The diagnostics are collected on a best effort basis and the workloads, which are healthy, are not reported.
+func (updMgr *k8sUpdateManager) Diagnose(ctx context.Context, mf []*unstructured.Unstructured) []*orchestration.ResourceDiagnostics {
+	updMgr.clientsLock.RLock()
+	defer updMgr.clientsLock.RUnlock()
+	if updMgr.connectionErr != nil || updMgr.k8sClientset == nil {
+		log.Debug("the orchestrator is unavailable - skipping the diagnostics collection")
+		return nil
+	}
+
+	diagnostics := []*orchestration.ResourceDiagnostics{}
+	for _, u := range mf {
+		path, ok := orchestration.PodSpecPath(u)
+		if !ok {
+			continue
+		}
+		namespace := u.GetNamespace()
+		if namespace == "" {
+			namespace = metav1.NamespaceDefault
+		}
+		pods, err := updMgr.workloadPods(ctx, u, namespace, path)
+		if err != nil {
+			log.DebugErr(err, "cannot get the pods of %s %s/%s", u.GetKind(), namespace, u.GetName())
+		}
+		diag := &orchestration.ResourceDiagnostics{
+			APIVersion: u.GetAPIVersion(),
+			Kind:       u.GetKind(),
+			Namespace:  namespace,
+			Name:       u.GetName(),
+		}
+		involved := map[string]string{u.GetName(): u.GetKind()}
+		for _, pod := range pods {
+			involved[pod.Name] = "Pod"
+			diag.Containers = append(diag.Containers, updMgr.failingContainers(ctx, pod)...)
+		}
+		diag.Events = updMgr.warningEvents(ctx, namespace, involved)
+		if len(diag.Events) > 0 || len(diag.Containers) > 0 {
+			diagnostics = append(diagnostics, diag)
+		}
+	}
+	return diagnostics
+}
+
+// workloadPods returns up to diagnosticsMaxPods pods of the workload, selected by the labels of its pod template
+func (updMgr *k8sUpdateManager) workloadPods(ctx context.Context, u *unstructured.Unstructured, namespace string, podSpecPath []string) ([]corev1.Pod, error) {
+	pods := updMgr.k8sClientset.CoreV1().Pods(namespace)
+	if u.GetKind() == "Pod" {
+		pod, err := pods.Get(ctx, u.GetName(), metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return []corev1.Pod{*pod}, nil
+	}
+	templateLabels, _, _ := unstructured.NestedStringMap(u.Object, append(podSpecPath[:len(podSpecPath)-1], "metadata", "labels")...)
+	if len(templateLabels) == 0 {
+		return nil, nil
+	}
+	podList, err := pods.List(ctx, metav1.ListOptions{LabelSelector: labels.SelectorFromSet(templateLabels).String()})
+	if err != nil {
+		return nil, err
+	}
+	items := podList.Items
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Name < items[j].Name
+	})
+	if len(items) > diagnosticsMaxPods {
+		items = items[:diagnosticsMaxPods]
+	}
+	return items, nil
+}
+
+// failingContainers returns the state and the last log lines of the containers of the pod, which are not ready or have been restarted
+func (updMgr *k8sUpdateManager) failingContainers(ctx context.Context, pod corev1.Pod) []*orchestration.ContainerDiagnostics {
+	containers := []*orchestration.ContainerDiagnostics{}
+	statuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+	for _, status := range statuses {
+		state, failing := containerState(status)
+		if !failing {
+			continue
+		}
+		container := &orchestration.ContainerDiagnostics{
+			Pod:          pod.Name,
+			Container:    status.Name,
+			State:        state,
+			RestartCount: status.RestartCount,
+		}
+		if updMgr.cfg.diagnosticsLogLines > 0 {
+			logs, err := updMgr.containerLogs(ctx, pod, status)
+			if err != nil {
+				container.Error = err.Error()
+			}
+			container.Logs = logs
+		}
+		containers = append(containers, container)
+	}
+	return containers
+}
+
+// containerState describes the current state of the container and reports if the container is failing
+func containerState(status corev1.ContainerStatus) (string, bool) {
+	switch {
+	case status.State.Waiting != nil:
+		return strings.TrimSpace(fmt.Sprintf("waiting: %s %s", status.State.Waiting.Reason, truncate(status.State.Waiting.Message))), true
+	case status.State.Terminated != nil:
+		terminated := status.State.Terminated
+		return strings.TrimSpace(fmt.Sprintf("terminated: %s (exit code %d) %s", terminated.Reason, terminated.ExitCode, truncate(terminated.Message))),
+			terminated.ExitCode != 0 || status.RestartCount > 0
+	default:
+		return "running", !status.Ready || status.RestartCount > 0
+	}
+}
+
+// containerLogs returns the last log lines of the container - the logs of the previous instance are returned if the container is waiting to be restarted
+func (updMgr *k8sUpdateManager) containerLogs(ctx context.Context, pod corev1.Pod, status corev1.ContainerStatus) ([]string, error) {
+	tailLines := updMgr.cfg.diagnosticsLogLines
+	limitBytes := int64(diagnosticsMaxLogBytes)
+	logOpts := &corev1.PodLogOptions{
+		Container:  status.Name,
+		TailLines:  &tailLines,
+		LimitBytes: &limitBytes,
+		Previous:   status.RestartCount > 0 && status.State.Waiting != nil,
+	}
+	data, err := updMgr.k8sClientset.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, logOpts).DoRaw(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > diagnosticsMaxLogBytes {
+		data = data[len(data)-diagnosticsMaxLogBytes:]
+	}
+	logs := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	if len(logs) == 1 && logs[0] == "" {
+		return nil, nil
+	}
+	return logs, nil
+}
+
+// warningEvents returns the last diagnosticsMaxEvents warning events of the given objects, keyed by name
+func (updMgr *k8sUpdateManager) warningEvents(ctx context.Context, namespace string, involved map[string]string) []*orchestration.DiagnosticsEvent {
+	eventList, err := updMgr.k8sClientset.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("type", corev1.EventTypeWarning).String(),
+	})
+	if err != nil {
+		log.DebugErr(err, "cannot list the events in namespace %s", namespace)
+		return nil
+	}
+	items := []corev1.Event{}
+	for _, event := range eventList.Items {
+		if event.Type != corev1.EventTypeWarning {
+			continue
+		}
+		if kind, ok := involved[event.InvolvedObject.Name]; ok && kind == event.InvolvedObject.Kind {
+			items = append(items, event)
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return eventTime(items[i]).Before(eventTime(items[j]))
+	})
+	if len(items) > diagnosticsMaxEvents {
+		items = items[len(items)-diagnosticsMaxEvents:]
+	}
+	events := []*orchestration.DiagnosticsEvent{}
+	for _, event := range items {
+		diagEvent := &orchestration.DiagnosticsEvent{
+			Object:  strings.ToLower(event.InvolvedObject.Kind) + "/" + event.InvolvedObject.Name,
+			Reason:  event.Reason,
+			Message: truncate(event.Message),
+			Count:   event.Count,
+		}
+		if lastTime := eventTime(event); !lastTime.IsZero() {
+			diagEvent.LastTimestamp = lastTime.UTC().Format(time.RFC3339)
+		}
+		events = append(events, diagEvent)
+	}
+	return events
+}
+
+func eventTime(event corev1.Event) time.Time {
+	if !event.LastTimestamp.IsZero() {
+		return event.LastTimestamp.Time
+	}
+	if !event.EventTime.IsZero() {
+		return event.EventTime.Time
+	}
+	return event.FirstTimestamp.Time
+}
+
+func truncate(message string) string {
+	if len(message) > diagnosticsMaxMessageLen {
+		return message[:diagnosticsMaxMessageLen] + "..."
+	}
+	return message
+}
@@ -0,0 +1,177 @@
+// Copyright (c) 2022 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Apache License 2.0 which is available at
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/pkg/testutil"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newTestDiagnosticsDeployment(name string, labels map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"name": name, "namespace": "default"},
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{"labels": labels},
+				"spec": map[string]interface{}{
+					"containers": []interface{}{map[string]interface{}{"name": "app", "image": "app:1.0.0"}},
+				},
+			},
+		},
+	}}
+}
+
+func newTestDiagnosticsPod(name string, labels map[string]string, status corev1.ContainerStatus) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: labels},
+		Status:     corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{status}},
+	}
+}
+
+func newTestDiagnosticsEvent(name, kind, object, eventType string, lastTimestamp time.Time) *corev1.Event {
+	return &corev1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Name: name, Namespace: "default"},
+		InvolvedObject: corev1.ObjectReference{Kind: kind, Name: object, Namespace: "default"},
+		Type:           eventType,
+		Reason:         "BackOff",
+		Message:        "Back-off restarting failed container",
+		Count:          3,
+		LastTimestamp:  metav1.NewTime(lastTimestamp),
+	}
+}
+
+func TestDiagnose(t *testing.T) {
+	now := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+	crashing := corev1.ContainerStatus{
+		Name:         "app",
+		RestartCount: 2,
+		State:        corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+	}
+	healthy := corev1.ContainerStatus{
+		Name:  "app",
+		Ready: true,
+		State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+	}
+	objects := []runtime.Object{
+		newTestDiagnosticsPod("test-app-1", map[string]string{"app": "test-app"}, crashing),
+		newTestDiagnosticsPod("test-healthy-1", map[string]string{"app": "test-healthy"}, healthy),
+		newTestDiagnosticsPod("test-other-1", map[string]string{"app": "test-other"}, crashing),
+		newTestDiagnosticsEvent("event-pod", "Pod", "test-app-1", corev1.EventTypeWarning, now),
+		newTestDiagnosticsEvent("event-deployment", "Deployment", "test-app", corev1.EventTypeWarning, now.Add(-time.Minute)),
+		newTestDiagnosticsEvent("event-normal", "Pod", "test-app-1", corev1.EventTypeNormal, now),
+		newTestDiagnosticsEvent("event-other", "Pod", "test-other-1", corev1.EventTypeWarning, now),
+	}
+	for i := 0; i < diagnosticsMaxEvents; i++ {
+		objects = append(objects, newTestDiagnosticsEvent(fmt.Sprintf("event-old-%d", i), "Pod", "test-app-1", corev1.EventTypeWarning, now.Add(-time.Hour)))
+	}
+	mf := []*unstructured.Unstructured{
+		newTestDiagnosticsDeployment("test-app", map[string]interface{}{"app": "test-app"}),
+		newTestDiagnosticsDeployment("test-healthy", map[string]interface{}{"app": "test-healthy"}),
+		newTestResource("ConfigMap", "default", "test-config"),
+	}
+
+	tests := map[string]struct {
+		logLines     int64
+		expectedLogs []string
+	}{
+		"test_with_logs": {
+			logLines:     20,
+			expectedLogs: []string{"fake logs"},
+		},
+		"test_without_logs": {},
+	}
+	for testName, testCase := range tests {
+		t.Run(testName, func(t *testing.T) {
+			t.Log(testName)
+			updMgr := newTestK8sUpdateManager(&mgrOpts{diagnosticsLogLines: testCase.logLines})
+			updMgr.k8sClientset = fake.NewSimpleClientset(objects...)
+
+			diagnostics := updMgr.Diagnose(context.Background(), mf)
+			testutil.AssertEqual(t, 1, len(diagnostics))
+			diag := diagnostics[0]
+			testutil.AssertEqual(t, "Deployment", diag.Kind)
+			testutil.AssertEqual(t, "test-app", diag.Name)
+			testutil.AssertEqual(t, "default", diag.Namespace)
+
+			testutil.AssertEqual(t, 1, len(diag.Containers))
+			testutil.AssertEqual(t, "test-app-1", diag.Containers[0].Pod)
+			testutil.AssertEqual(t, "app", diag.Containers[0].Container)
+			testutil.AssertEqual(t, "waiting: CrashLoopBackOff", diag.Containers[0].State)
+			testutil.AssertEqual(t, int32(2), diag.Containers[0].RestartCount)
+			testutil.AssertEqual(t, testCase.expectedLogs, diag.Containers[0].Logs)
+
+			// the events are bounded, keeping the latest ones
+			testutil.AssertEqual(t, diagnosticsMaxEvents, len(diag.Events))
+			testutil.AssertEqual(t, "deployment/test-app", diag.Events[diagnosticsMaxEvents-2].Object)
+			testutil.AssertEqual(t, "pod/test-app-1", diag.Events[diagnosticsMaxEvents-1].Object)
+			testutil.AssertEqual(t, "BackOff", diag.Events[diagnosticsMaxEvents-1].Reason)
+			testutil.AssertEqual(t, int32(3), diag.Events[diagnosticsMaxEvents-1].Count)
+			testutil.AssertEqual(t, "2022-10-01T12:00:00Z", diag.Events[diagnosticsMaxEvents-1].LastTimestamp)
+		})
+	}
+}
+
+func TestDiagnoseUnavailable(t *testing.T) {
+	updMgr := newTestK8sUpdateManager(&mgrOpts{})
+	testutil.AssertNil(t, updMgr.Diagnose(context.Background(), []*unstructured.Unstructured{newTestDiagnosticsDeployment("test-app", nil)}))
+}
+
+func TestContainerState(t *testing.T) {
+	tests := map[string]struct {
+		status          corev1.ContainerStatus
+		expectedState   string
+		expectedFailing bool
+	}{
+		"test_running_ready": {
+			status:        corev1.ContainerStatus{Ready: true, State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}},
+			expectedState: "running",
+		},
+		"test_running_not_ready": {
+			status:          corev1.ContainerStatus{State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}},
+			expectedState:   "running",
+			expectedFailing: true,
+		},
+		"test_waiting": {
+			status:          corev1.ContainerStatus{State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff", Message: "not found"}}},
+			expectedState:   "waiting: ImagePullBackOff not found",
+			expectedFailing: true,
+		},
+		"test_completed": {
+			status:        corev1.ContainerStatus{State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "Completed"}}},
+			expectedState: "terminated: Completed (exit code 0)",
+		},
+		"test_terminated_error": {
+			status:          corev1.ContainerStatus{State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "Error", ExitCode: 1}}},
+			expectedState:   "terminated: Error (exit code 1)",
+			expectedFailing: true,
+		},
+	}
+	for testName, testCase := range tests {
+		t.Run(testName, func(t *testing.T) {
+			t.Log(testName)
+			state, failing := containerState(testCase.status)
+			testutil.AssertEqual(t, testCase.expectedState, state)
+			testutil.AssertEqual(t, testCase.expectedFailing, failing)
+		})
+	}
+}
@@ -0,0 +1,246 @@
+// Copyright (c) 2022 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Apache License 2.0 which is available at
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/eclipse-kanto/container-management/containerm/log"
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/orchestration"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// driftIgnoredFields are not compared, as they are set by the k8s API server and the controllers
+var driftIgnoredFields = map[string]bool{"apiVersion": true, "kind": true, "metadata": true, "status": true}
+
+// driftMetadataFields are the metadata fields that are compared, the rest of the metadata is managed by the k8s API server
+var driftMetadataFields = []string{"labels", "annotations"}
+
+// Drift compares the applied manifest resources against the live ones in the informer caches.
+// Only the fields set in the applied manifest are compared, so that the defaults set by the k8s API server are not reported.
+// The resources, which are not watched, are retrieved from the k8s API server.
+func (updMgr *k8sUpdateManager) Drift(ctx context.Context, mf []*unstructured.Unstructured) []*orchestration.ResourceDrift {
+	updMgr.clientsLock.RLock()
+	defer updMgr.clientsLock.RUnlock()
+	if updMgr.connectionErr != nil {
+		log.Debug("the orchestrator is unavailable - skipping the drift detection")
+		return nil
+	}
+
+	drifts := []*orchestration.ResourceDrift{}
+	for _, u := range mf {
+		gvk := u.GroupVersionKind()
+		mapping, err := updMgr.k8sRESTMapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			log.DebugErr(err, "cannot resolve the resource of %s - skipping its drift detection", gvk.String())
+			continue
+		}
+		namespace := ""
+		if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+			namespace = u.GetNamespace()
+			if namespace == "" {
+				namespace = metav1.NamespaceDefault
+			}
+		}
+		live, metadataOnly, err := updMgr.getLiveResource(ctx, mapping.Resource, namespace, u.GetName())
+		if err != nil {
+			log.DebugErr(err, "cannot get the live %s %s/%s - skipping its drift detection", mapping.Resource.String(), namespace, u.GetName())
+			continue
+		}
+		drift := &orchestration.ResourceDrift{
+			APIVersion: u.GetAPIVersion(),
+			Kind:       u.GetKind(),
+			Namespace:  namespace,
+			Name:       u.GetName(),
+		}
+		if live == nil {
+			drift.Type = orchestration.DriftTypeDeleted
+			drifts = append(drifts, drift)
+			continue
+		}
+		if drift.Fields = driftedResourceFields(u, live, metadataOnly); len(drift.Fields) > 0 {
+			drift.Type = orchestration.DriftTypeModified
+			drifts = append(drifts, drift)
+		}
+	}
+	return drifts
+}
+
+// getLiveResource returns the resource from the informer cache if it is watched, otherwise from the k8s API server.
+// The returned resource is nil if it does not exist and has only metadata if it is watched metadata-only.
+func (updMgr *k8sUpdateManager) getLiveResource(ctx context.Context, gvr schema.GroupVersionResource, namespace, name string) (*unstructured.Unstructured, bool, error) {
+	if watcher := updMgr.getWatcher(gvr, namespace); watcher != nil && watcher.informer.HasSynced() {
+		key := name
+		if namespace != "" {
+			key = namespace + "/" + name
+		}
+		obj, exists, err := watcher.informer.GetStore().GetByKey(key)
+		if err != nil || !exists {
+			return nil, false, err
+		}
+		live := watcher.toUnstructured(obj)
+		if live == nil {
+			return nil, false, log.NewErrorf("unexpected cached object of type %T", obj)
+		}
+		return live, watcher.metadataOnly, nil
+	}
+	live, err := updMgr.k8sClient.Resource(gvr).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	return live, false, nil
+}
+
+// getWatcher returns the watcher caching the resource in the namespace or nil if the resource is not watched
+func (updMgr *k8sUpdateManager) getWatcher(gvr schema.GroupVersionResource, namespace string) *resourceWatcher {
+	for _, watcher := range updMgr.watchers {
+		if watcher.gvr == gvr && (watcher.namespace == metav1.NamespaceAll || watcher.namespace == namespace) {
+			return watcher
+		}
+	}
+	return nil
+}
+
+// driftedResourceFields returns the paths of the applied fields, which differ in the live resource.
+// Only the metadata is compared for the resources that are watched metadata-only.
+func driftedResourceFields(applied, live *unstructured.Unstructured, metadataOnly bool) []string {
+	fields := []string{}
+	for _, field := range driftMetadataFields {
+		appliedValue, _, _ := unstructured.NestedFieldNoCopy(applied.Object, "metadata", field)
+		liveValue, _, _ := unstructured.NestedFieldNoCopy(live.Object, "metadata", field)
+		fields = append(fields, driftedFields("metadata."+field, appliedValue, liveValue)...)
+	}
+	if metadataOnly {
+		return fields
+	}
+	for _, key := range sortedKeys(applied.Object) {
+		if !driftIgnoredFields[key] {
+			fields = append(fields, driftedFields(key, applied.Object[key], live.Object[key])...)
+		}
+	}
+	return fields
+}
+
+// driftedFields returns the paths of the fields set in the applied value, which differ in the live value
+func driftedFields(path string, applied, live interface{}) []string {
+	switch appliedValue := applied.(type) {
+	case nil:
+		return nil
+	case map[string]interface{}:
+		liveValue, ok := live.(map[string]interface{})
+		if !ok {
+			if live == nil && len(appliedValue) == 0 {
+				return nil
+			}
+			return []string{path}
+		}
+		fields := []string{}
+		for _, key := range sortedKeys(appliedValue) {
+			fields = append(fields, driftedFields(fieldPath(path, key), appliedValue[key], liveValue[key])...)
+		}
+		return fields
+	case []interface{}:
+		liveValue, ok := live.([]interface{})
+		if !ok {
+			if live == nil && len(appliedValue) == 0 {
+				return nil
+			}
+			return []string{path}
+		}
+		if len(appliedValue) != len(liveValue) {
+			return []string{path}
+		}
+		fields := []string{}
+		for i := range appliedValue {
+			fields = append(fields, driftedFields(fmt.Sprintf("%s[%d]", path, i), appliedValue[i], liveValue[i])...)
+		}
+		return fields
+	default:
+		if live == nil && reflect.ValueOf(applied).IsZero() {
+			// the zero values are omitted by the k8s API server
+			return nil
+		}
+		if !equalFieldValues(applied, live) {
+			return []string{path}
+		}
+		return nil
+	}
+}
+
+// equalFieldValues compares two scalar field values, tolerating the numeric types and the quantity formats normalized by the k8s API server
+func equalFieldValues(applied, live interface{}) bool {
+	if reflect.DeepEqual(applied, live) {
+		return true
+	}
+	appliedNumber, appliedIsNumber := toFloat64(applied)
+	liveNumber, liveIsNumber := toFloat64(live)
+	if appliedIsNumber && liveIsNumber {
+		return appliedNumber == liveNumber
+	}
+	if _, isBool := applied.(bool); isBool {
+		return false
+	}
+	if _, isBool := live.(bool); isBool {
+		return false
+	}
+	appliedQuantity, err := resource.ParseQuantity(fmt.Sprint(applied))
+	if err != nil {
+		return false
+	}
+	liveQuantity, err := resource.ParseQuantity(fmt.Sprint(live))
+	if err != nil {
+		return false
+	}
+	return appliedQuantity.Cmp(liveQuantity) == 0
+}
+
+func toFloat64(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+func fieldPath(path, key string) string {
+	if strings.Contains(key, ".") {
+		return fmt.Sprintf("%s[%s]", path, key)
+	}
+	return path + "." + key
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
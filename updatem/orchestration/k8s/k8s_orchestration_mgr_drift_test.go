@@ -0,0 +1,195 @@
+// Copyright (c) 2022 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Apache License 2.0 which is available at
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package k8s
+
+import (
+	"context"
+	"testing"
+
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/orchestration"
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/pkg/testutil"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
+)
+
+func newTestDriftPod(name, image, cpu string, labels map[string]string) *unstructured.Unstructured {
+	u := newTestResource("Pod", "default", name)
+	u.SetLabels(labels)
+	u.Object["spec"] = map[string]interface{}{
+		"containers": []interface{}{map[string]interface{}{
+			"name":  "app",
+			"image": image,
+			"resources": map[string]interface{}{
+				"limits": map[string]interface{}{"cpu": cpu},
+			},
+		}},
+	}
+	return u
+}
+
+func TestDrift(t *testing.T) {
+	applied := newTestDriftPod("test-pod", "test-app:1.0.0", "0.5", map[string]string{"app": "test-app"})
+
+	live := newTestDriftPod("test-pod", "test-app:1.0.0", "500m", map[string]string{"app": "test-app", "sdv.eclipse.org/managed-by": "vehicle-update-manager"})
+	live.SetResourceVersion("42")
+	// the defaults set by the k8s API server are not reported
+	unstructured.SetNestedField(live.Object, "Always", "spec", "restartPolicy")
+	unstructured.SetNestedField(live.Object, "Running", "status", "phase")
+
+	edited := newTestDriftPod("test-pod", "test-app:2.0.0", "1", map[string]string{"app": "edited"})
+
+	tests := map[string]struct {
+		cfg           *mgrOpts
+		live          []*unstructured.Unstructured
+		expectedDrift []*orchestration.ResourceDrift
+	}{
+		"test_unchanged": {
+			cfg:           &mgrOpts{watchedResources: []schema.GroupVersionResource{testPodsGVR}},
+			live:          []*unstructured.Unstructured{live},
+			expectedDrift: []*orchestration.ResourceDrift{},
+		},
+		"test_modified": {
+			cfg:  &mgrOpts{watchedResources: []schema.GroupVersionResource{testPodsGVR}},
+			live: []*unstructured.Unstructured{edited},
+			expectedDrift: []*orchestration.ResourceDrift{{
+				APIVersion: "v1",
+				Kind:       "Pod",
+				Namespace:  "default",
+				Name:       "test-pod",
+				Type:       orchestration.DriftTypeModified,
+				Fields: []string{
+					"metadata.labels.app",
+					"spec.containers[0].image",
+					"spec.containers[0].resources.limits.cpu",
+				},
+			}},
+		},
+		"test_modified_metadata_only": {
+			cfg: &mgrOpts{
+				watchedResources:      []schema.GroupVersionResource{testPodsGVR},
+				metadataOnlyResources: []schema.GroupVersionResource{testPodsGVR},
+			},
+			live: []*unstructured.Unstructured{edited},
+			expectedDrift: []*orchestration.ResourceDrift{{
+				APIVersion: "v1",
+				Kind:       "Pod",
+				Namespace:  "default",
+				Name:       "test-pod",
+				Type:       orchestration.DriftTypeModified,
+				Fields:     []string{"metadata.labels.app"},
+			}},
+		},
+		"test_deleted": {
+			cfg: &mgrOpts{watchedResources: []schema.GroupVersionResource{testPodsGVR}},
+			expectedDrift: []*orchestration.ResourceDrift{{
+				APIVersion: "v1",
+				Kind:       "Pod",
+				Namespace:  "default",
+				Name:       "test-pod",
+				Type:       orchestration.DriftTypeDeleted,
+			}},
+		},
+		"test_not_watched_namespace": {
+			cfg: &mgrOpts{
+				watchedResources:  []schema.GroupVersionResource{testPodsGVR},
+				watchedNamespaces: []string{"kube-system"},
+			},
+			live: []*unstructured.Unstructured{edited},
+			expectedDrift: []*orchestration.ResourceDrift{{
+				APIVersion: "v1",
+				Kind:       "Pod",
+				Namespace:  "default",
+				Name:       "test-pod",
+				Type:       orchestration.DriftTypeModified,
+				Fields: []string{
+					"metadata.labels.app",
+					"spec.containers[0].image",
+					"spec.containers[0].resources.limits.cpu",
+				},
+			}},
+		},
+		"test_not_watched_resource": {
+			cfg:           &mgrOpts{watchedResources: []schema.GroupVersionResource{testNodesGVR}},
+			live:          []*unstructured.Unstructured{live},
+			expectedDrift: []*orchestration.ResourceDrift{},
+		},
+		"test_not_watched_resource_deleted": {
+			cfg: &mgrOpts{watchedResources: []schema.GroupVersionResource{testNodesGVR}},
+			expectedDrift: []*orchestration.ResourceDrift{{
+				APIVersion: "v1",
+				Kind:       "Pod",
+				Namespace:  "default",
+				Name:       "test-pod",
+				Type:       orchestration.DriftTypeDeleted,
+			}},
+		},
+	}
+	for testName, testCase := range tests {
+		t.Run(testName, func(t *testing.T) {
+			t.Log(testName)
+			updMgr := newTestK8sUpdateManager(testCase.cfg, testCase.live...)
+			updMgr.loopWatchResources(context.Background())
+			defer updMgr.Dispose(context.Background())
+
+			for _, watcher := range updMgr.watchers {
+				testutil.AssertTrue(t, cache.WaitForCacheSync(updMgr.watchStop, watcher.informer.HasSynced))
+			}
+			testutil.AssertEqual(t, testCase.expectedDrift, updMgr.Drift(context.Background(), []*unstructured.Unstructured{applied}))
+		})
+	}
+}
+
+func TestDriftedFields(t *testing.T) {
+	tests := map[string]struct {
+		applied        interface{}
+		live           interface{}
+		expectedFields []string
+	}{
+		"test_equal_numbers": {
+			applied:        map[string]interface{}{"replicas": float64(2)},
+			live:           map[string]interface{}{"replicas": int64(2)},
+			expectedFields: []string{},
+		},
+		"test_omitted_zero_values": {
+			applied:        map[string]interface{}{"hostNetwork": false, "env": []interface{}{}, "securityContext": map[string]interface{}{}},
+			live:           map[string]interface{}{},
+			expectedFields: []string{},
+		},
+		"test_removed_list_item": {
+			applied:        map[string]interface{}{"ports": []interface{}{int64(80), int64(443)}},
+			live:           map[string]interface{}{"ports": []interface{}{int64(80)}},
+			expectedFields: []string{"spec.ports"},
+		},
+		"test_removed_field": {
+			applied:        map[string]interface{}{"serviceAccountName": "test-sa"},
+			live:           map[string]interface{}{},
+			expectedFields: []string{"spec.serviceAccountName"},
+		},
+		"test_changed_type": {
+			applied:        map[string]interface{}{"paused": true},
+			live:           map[string]interface{}{"paused": "true"},
+			expectedFields: []string{"spec.paused"},
+		},
+		"test_dotted_key": {
+			applied:        map[string]interface{}{"kubernetes.io/arch": "arm64"},
+			live:           map[string]interface{}{"kubernetes.io/arch": "amd64"},
+			expectedFields: []string{"spec[kubernetes.io/arch]"},
+		},
+	}
+	for testName, testCase := range tests {
+		t.Run(testName, func(t *testing.T) {
+			t.Log(testName)
+			testutil.AssertEqual(t, testCase.expectedFields, driftedFields("spec", testCase.applied, testCase.live))
+		})
+	}
+}
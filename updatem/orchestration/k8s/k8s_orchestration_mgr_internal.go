@@ -13,24 +13,37 @@ package k8s
 
 import (
 	"context"
+	"sort"
+	"sync/atomic"
 	"time"
 
 	"github.com/eclipse-kanto/container-management/containerm/log"
 	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/events"
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/orchestration"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
-	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/metadata/metadatainformer"
 	"k8s.io/client-go/tools/cache"
 )
 
-var coreV1NodeGVK = schema.GroupVersionKind{
-	Group:   "",
-	Version: "v1",
-	Kind:    "Node",
+// setPublishResourceEvent enables or disables the resource events of the informers, which read the flag concurrently to the update operations
+func (updMgr *k8sUpdateManager) setPublishResourceEvent(publish bool) {
+	var flag int32
+	if publish {
+		flag = 1
+	}
+	atomic.StoreInt32(&updMgr.flagPublishResourceEvent, flag)
+}
+
+func (updMgr *k8sUpdateManager) isPublishResourceEvent() bool {
+	return atomic.LoadInt32(&updMgr.flagPublishResourceEvent) == 1
 }
 
 func (updMgr *k8sUpdateManager) publishResourceEvent(ctx context.Context, eventAction events.EventAction, eventSource unstructured.Unstructured, err error) {
@@ -43,7 +56,7 @@ func (updMgr *k8sUpdateManager) publishResourceEvent(ctx context.Context, eventA
 		Error:   err,
 	}
 
-	if updMgr.flagPublishResourceEvent {
+	if updMgr.isPublishResourceEvent() {
 		//log.Trace("publishing resource event [%+v]", e)
 		if pubErr := updMgr.eventsMgr.Publish(ctx, e); pubErr != nil {
 			log.ErrorErr(pubErr, "failed to publish resource event [%+v]", e)
@@ -53,9 +66,24 @@ func (updMgr *k8sUpdateManager) publishResourceEvent(ctx context.Context, eventA
 	//log.Trace("publishing resource event skipped, ongoing apply")
 }
 
+func (updMgr *k8sUpdateManager) publishOrchestrationEvent(ctx context.Context, eventAction events.EventAction, eventSource interface{}) {
+	e := &events.Event{
+		Type:    orchestration.EventTypeOrchestration,
+		Action:  eventAction,
+		Source:  eventSource,
+		Time:    time.Now().UTC().Unix(),
+		Context: ctx,
+	}
+	if pubErr := updMgr.eventsMgr.Publish(ctx, e); pubErr != nil {
+		log.ErrorErr(pubErr, "failed to publish orchestration event [%+v]", e)
+	}
+}
+
 // k8s apimachinery wrapper -------------------------------------
 
 func (updMgr *k8sUpdateManager) getResourceByGVK(gvk schema.GroupVersionKind, namespace string) (dynamic.ResourceInterface, error) {
+	updMgr.clientsLock.RLock()
+	defer updMgr.clientsLock.RUnlock()
 	mapping, mappingErr := updMgr.k8sRESTMapper.RESTMapping(gvk.GroupKind(), gvk.Version)
 	if mappingErr != nil {
 		return nil, mappingErr
@@ -67,64 +95,261 @@ func (updMgr *k8sUpdateManager) getResourceByGVK(gvk schema.GroupVersionKind, na
 	return updMgr.k8sClient.Resource(mapping.Resource), nil
 }
 
-func (updMgr *k8sUpdateManager) listAllResources(ctx context.Context, gvk schema.GroupVersionKind) ([]*unstructured.Unstructured, error) {
-	result := []*unstructured.Unstructured{}
-
-	resourceByNs, err := updMgr.getResourceByGVK(gvk, "")
+// watchedNamespaces returns the namespaces, in which the resource is watched - a single empty namespace stands for all namespaces
+func (updMgr *k8sUpdateManager) watchedNamespaces(gvr schema.GroupVersionResource) []string {
+	if len(updMgr.cfg.watchedNamespaces) == 0 {
+		return []string{metav1.NamespaceAll}
+	}
+	gvk, err := updMgr.k8sRESTMapper.KindFor(gvr)
 	if err != nil {
-		return nil, err
+		log.WarnErr(err, "cannot resolve the scope of the watched resource %s - will assume it is namespaced", gvr.String())
+		return updMgr.cfg.watchedNamespaces
+	}
+	mapping, err := updMgr.k8sRESTMapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		log.WarnErr(err, "cannot resolve the scope of the watched resource %s - will assume it is namespaced", gvr.String())
+		return updMgr.cfg.watchedNamespaces
+	}
+	if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+		return []string{metav1.NamespaceAll}
+	}
+	return updMgr.cfg.watchedNamespaces
+}
+
+// watchKey identifies the watched resources of a single type in a single namespace
+type watchKey struct {
+	gvr       schema.GroupVersionResource
+	namespace string
+}
+
+// resourceWatcher caches the watched resources of a single type in a single namespace via a shared informer
+type resourceWatcher struct {
+	gvr          schema.GroupVersionResource
+	gvk          schema.GroupVersionKind
+	namespace    string
+	metadataOnly bool
+	// applied is set if the resources are watched only because they are applied, so that their local modifications are detected
+	applied  bool
+	informer cache.SharedIndexInformer
+	// stop stops the watcher alone, e.g. when its resources are no longer applied
+	stop chan struct{}
+}
+
+// run runs the informer until all watchers or the watcher itself are stopped
+func (watcher *resourceWatcher) run(watchStop <-chan struct{}) {
+	stop := make(chan struct{})
+	go func() {
+		defer close(stop)
+		select {
+		case <-watchStop:
+		case <-watcher.stop:
+		}
+	}()
+	watcher.informer.Run(stop)
+}
+
+// list returns the cached resources sorted by namespace and name
+func (watcher *resourceWatcher) list() []*unstructured.Unstructured {
+	result := []*unstructured.Unstructured{}
+	for _, obj := range watcher.informer.GetStore().List() {
+		if u := watcher.toUnstructured(obj); u != nil {
+			result = append(result, u)
+		}
 	}
-	if resourceByNs != nil {
-		resourcesByNs, err := resourceByNs.List(ctx, metav1.ListOptions{})
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].GetNamespace() != result[j].GetNamespace() {
+			return result[i].GetNamespace() < result[j].GetNamespace()
+		}
+		return result[i].GetName() < result[j].GetName()
+	})
+	return result
+}
+
+// toUnstructured converts a cached object to unstructured - the metadata-only objects are converted to resources with metadata only
+func (watcher *resourceWatcher) toUnstructured(obj interface{}) *unstructured.Unstructured {
+	switch o := obj.(type) {
+	case *unstructured.Unstructured:
+		return o
+	case *metav1.PartialObjectMetadata:
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(o)
 		if err != nil {
-			return nil, err
+			log.ErrorErr(err, "cannot convert the metadata of %s %s/%s", watcher.gvr.String(), o.GetNamespace(), o.GetName())
+			return nil
 		}
-		for _, resourceUnstructured := range resourcesByNs.Items {
-			result = append(result, resourceUnstructured.DeepCopy())
+		u := &unstructured.Unstructured{Object: content}
+		u.SetGroupVersionKind(watcher.gvk)
+		return u
+	case cache.DeletedFinalStateUnknown:
+		return watcher.toUnstructured(o.Obj)
+	}
+	log.Warn("unexpected cached object of type %T for the watched %s", obj, watcher.gvr.String())
+	return nil
+}
+
+func (updMgr *k8sUpdateManager) isMetadataOnly(gvr schema.GroupVersionResource) bool {
+	for _, metadataOnlyGVR := range updMgr.cfg.metadataOnlyResources {
+		if metadataOnlyGVR == gvr {
+			return true
 		}
 	}
-	return result, nil
+	return false
 }
 
 func (updMgr *k8sUpdateManager) loopWatchResources(ctx context.Context) {
-	updMgr.loopWatchResource(ctx, "pods.v1.")
-	updMgr.loopWatchResource(ctx, "nodes.v1.")
+	updMgr.watchStop = make(chan struct{})
+	updMgr.watchCtx = ctx
+	for _, gvr := range updMgr.cfg.watchedResources {
+		for _, namespace := range updMgr.watchedNamespaces(gvr) {
+			updMgr.watchers = append(updMgr.watchers, updMgr.loopWatchResource(ctx, gvr, namespace))
+		}
+	}
+	for key := range updMgr.appliedWatches {
+		updMgr.loopWatchAppliedResource(key)
+	}
 }
 
-func (updMgr *k8sUpdateManager) loopWatchResource(ctx context.Context, resource string) {
+// watchAppliedResources watches the types of the applied resources, which are not watched yet in their namespaces,
+// so that their local modifications and deletions are published as resource events.
+// Unless reconciled, the applied resources replace the previous ones and the watchers of the types no longer applied are stopped.
+func (updMgr *k8sUpdateManager) watchAppliedResources(mf []*unstructured.Unstructured, reconcile bool) {
+	updMgr.clientsLock.Lock()
+	defer updMgr.clientsLock.Unlock()
+	if updMgr.disposed || updMgr.connectionErr != nil || updMgr.watchStop == nil {
+		return
+	}
+	appliedWatches := map[watchKey]sets.String{}
+	if reconcile {
+		for key, names := range updMgr.appliedWatches {
+			appliedWatches[key] = names
+		}
+	}
+	for _, u := range mf {
+		key, ok := updMgr.appliedWatchKey(u)
+		if !ok {
+			continue
+		}
+		if appliedWatches[key] == nil {
+			appliedWatches[key] = sets.NewString()
+		}
+		appliedWatches[key].Insert(u.GetName())
+	}
+	for key := range updMgr.appliedWatches {
+		if _, ok := appliedWatches[key]; !ok {
+			updMgr.stopAppliedWatch(key)
+		}
+	}
+	updMgr.appliedWatches = appliedWatches
+	for key := range appliedWatches {
+		updMgr.loopWatchAppliedResource(key)
+	}
+}
 
-	log.Debug("Start watching %s ...", resource)
+// unwatchRemovedResources stops the watchers of the types, which are no longer applied after the resources are removed
+func (updMgr *k8sUpdateManager) unwatchRemovedResources(mf []*unstructured.Unstructured) {
+	updMgr.clientsLock.Lock()
+	defer updMgr.clientsLock.Unlock()
+	if updMgr.disposed || updMgr.connectionErr != nil || updMgr.watchStop == nil {
+		return
+	}
+	for _, u := range mf {
+		key, ok := updMgr.appliedWatchKey(u)
+		if !ok || updMgr.appliedWatches[key] == nil {
+			continue
+		}
+		updMgr.appliedWatches[key].Delete(u.GetName())
+		if updMgr.appliedWatches[key].Len() == 0 {
+			delete(updMgr.appliedWatches, key)
+			updMgr.stopAppliedWatch(key)
+		}
+	}
+}
+
+// appliedWatchKey returns the type and namespace, in which the applied resource is watched
+func (updMgr *k8sUpdateManager) appliedWatchKey(u *unstructured.Unstructured) (watchKey, bool) {
+	gvk := u.GroupVersionKind()
+	mapping, err := updMgr.k8sRESTMapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		log.DebugErr(err, "cannot resolve the resource of %s - it is not watched", gvk.String())
+		return watchKey{}, false
+	}
+	key := watchKey{gvr: mapping.Resource, namespace: metav1.NamespaceAll}
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		key.namespace = u.GetNamespace()
+		if key.namespace == "" {
+			key.namespace = metav1.NamespaceDefault
+		}
+	}
+	return key, true
+}
+
+func (updMgr *k8sUpdateManager) loopWatchAppliedResource(key watchKey) {
+	if updMgr.getWatcher(key.gvr, key.namespace) != nil {
+		return
+	}
+	watcher := updMgr.loopWatchResource(updMgr.watchCtx, key.gvr, key.namespace)
+	watcher.applied = true
+	updMgr.watchers = append(updMgr.watchers, watcher)
+}
+
+// stopAppliedWatch stops the watcher started only because the resources of its type are applied in its namespace
+func (updMgr *k8sUpdateManager) stopAppliedWatch(key watchKey) {
+	for i, watcher := range updMgr.watchers {
+		if watcher.applied && watcher.gvr == key.gvr && watcher.namespace == key.namespace {
+			log.Debug("Stop watching %s in namespace [%s] - no longer applied", key.gvr.String(), key.namespace)
+			close(watcher.stop)
+			updMgr.watchers = append(updMgr.watchers[:i], updMgr.watchers[i+1:]...)
+			return
+		}
+	}
+}
+
+func (updMgr *k8sUpdateManager) loopWatchResource(ctx context.Context, gvr schema.GroupVersionResource, namespace string) *resourceWatcher {
+	watcher := &resourceWatcher{
+		gvr:          gvr,
+		gvk:          gvr.GroupVersion().WithKind(""),
+		namespace:    namespace,
+		metadataOnly: updMgr.isMetadataOnly(gvr),
+		stop:         make(chan struct{}),
+	}
+	log.Debug("Start watching %s in namespace [%s], metadata only = %v ...", gvr.String(), namespace, watcher.metadataOnly)
 
 	resyncPeriod := 0 * time.Minute
-	di := dynamicinformer.NewDynamicSharedInformerFactory(updMgr.k8sClient, resyncPeriod)
-	// Retrieve a "GroupVersionResource" type that needed when generating informer from dynamic factory
-	gvr, _ := schema.ParseResourceArg(resource) //pods.v1. //nodes.v1.
-	// Create informer
-	i := di.ForResource(*gvr)
+	var informer informers.GenericInformer
+	if watcher.metadataOnly {
+		// the metadata-only objects do not carry the kind of the resource
+		if gvk, err := updMgr.k8sRESTMapper.KindFor(gvr); err != nil {
+			log.WarnErr(err, "cannot resolve the kind of the watched resource %s", gvr.String())
+		} else {
+			watcher.gvk = gvk
+		}
+		informer = metadatainformer.NewFilteredMetadataInformer(updMgr.k8sMetadataClient, gvr, namespace, resyncPeriod, cache.Indexers{}, nil)
+	} else {
+		informer = dynamicinformer.NewFilteredDynamicInformer(updMgr.k8sClient, gvr, namespace, resyncPeriod, cache.Indexers{}, nil)
+	}
+	watcher.informer = informer.Informer()
 
-	i.Informer().AddEventHandler(
+	watcher.informer.AddEventHandler(
 		cache.ResourceEventHandlerFuncs{
 			AddFunc: func(obj interface{}) {
-				u := obj.(*unstructured.Unstructured)
-				log.Debug("Received add event! %s - %s ", u.GetNamespace(), u.GetName())
-				updMgr.publishResourceEvent(ctx, events.EventActionResourcesAdded, *u, nil)
+				if u := watcher.toUnstructured(obj); u != nil {
+					log.Debug("Received add event! %s - %s ", u.GetNamespace(), u.GetName())
+					updMgr.publishResourceEvent(ctx, events.EventActionResourcesAdded, *u, nil)
+				}
 			},
 			UpdateFunc: func(oldObj interface{}, newObj interface{}) {
-				//uOld := oldObj.(*unstructured.Unstructured)
-				uNew := newObj.(*unstructured.Unstructured)
-				//log.Debug("Received update Old event! %s - %s ", uOld.GetNamespace(), uOld.GetName())
-				//log.Debug("%s\n",oldObj)
-				//log.Debug("Received update New event! %s - %s ", uNew.GetNamespace(), uNew.GetName())
-				//log.Debug("%s\n",uNew)
-				updMgr.publishResourceEvent(ctx, events.EventActionResourcesUpdated, *uNew, nil)
+				if uNew := watcher.toUnstructured(newObj); uNew != nil {
+					updMgr.publishResourceEvent(ctx, events.EventActionResourcesUpdated, *uNew, nil)
+				}
 			},
 			DeleteFunc: func(obj interface{}) {
-				u := obj.(*unstructured.Unstructured)
-				log.Debug("Received delete event! %s - %s ", u.GetNamespace(), u.GetName())
-				updMgr.publishResourceEvent(ctx, events.EventActionResourcesDeleted, *u, nil)
+				if u := watcher.toUnstructured(obj); u != nil {
+					log.Debug("Received delete event! %s - %s ", u.GetNamespace(), u.GetName())
+					updMgr.publishResourceEvent(ctx, events.EventActionResourcesDeleted, *u, nil)
+				}
 			},
 		},
 	)
 
-	di.Start(wait.NeverStop)
+	go watcher.run(updMgr.watchStop)
+	return watcher
 }
@@ -11,11 +11,38 @@
 
 package k8s
 
+import (
+	"time"
+
+	"github.com/eclipse-kanto/container-management/containerm/log"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// ConflictPolicyFail makes the server-side apply fail for the resources with field ownership conflicts
+	ConflictPolicyFail = "fail"
+	// ConflictPolicyForce makes the server-side apply take over the ownership of the conflicting fields
+	ConflictPolicyForce = "force"
+
+	// FieldManagerDefault is the name of the field manager used when applying the manifest resources server-side if none is configured
+	FieldManagerDefault = "vehicle-update-manager"
+)
+
 // MgrOpt defines the creation configuration options for a k8s API-compatible orchestration implementation
 type MgrOpt func(mgrOptions *mgrOpts) error
 
 type mgrOpts struct {
-	kubeconfig string
+	kubeconfig            string
+	serverSideApply       bool
+	fieldManager          string
+	conflictPolicy        string
+	readinessTimeout      time.Duration
+	prePullTimeout        time.Duration
+	prune                 bool
+	watchedResources      []schema.GroupVersionResource
+	watchedNamespaces     []string
+	metadataOnlyResources []schema.GroupVersionResource
+	diagnosticsLogLines   int64
 }
 
 func applyOptsMgr(mgrOpts *mgrOpts, opts ...MgrOpt) error {
@@ -34,3 +61,131 @@ func WithKubeConfig(kubeconfig string) MgrOpt {
 		return nil
 	}
 }
+
+// WithServerSideApply configures whether the manifest resources are applied via server-side apply
+func WithServerSideApply(serverSideApply bool) MgrOpt {
+	return func(mgrOptions *mgrOpts) error {
+		mgrOptions.serverSideApply = serverSideApply
+		return nil
+	}
+}
+
+// WithFieldManager configures the name of the field manager used when applying the manifest resources server-side - an empty value stands for the default one
+func WithFieldManager(fieldManager string) MgrOpt {
+	return func(mgrOptions *mgrOpts) error {
+		if fieldManager == "" {
+			fieldManager = FieldManagerDefault
+		}
+		mgrOptions.fieldManager = fieldManager
+		return nil
+	}
+}
+
+// WithConflictPolicy configures how the server-side apply handles field ownership conflicts - possible values are fail and force, an empty value stands for fail
+func WithConflictPolicy(conflictPolicy string) MgrOpt {
+	return func(mgrOptions *mgrOpts) error {
+		if conflictPolicy == "" {
+			conflictPolicy = ConflictPolicyFail
+		}
+		if conflictPolicy != ConflictPolicyFail && conflictPolicy != ConflictPolicyForce {
+			return log.NewErrorf("unsupported conflict policy %s", conflictPolicy)
+		}
+		mgrOptions.conflictPolicy = conflictPolicy
+		return nil
+	}
+}
+
+// WithReadinessTimeout configures how long to wait for the applied workload resources to become ready - an empty or zero value disables the readiness check
+func WithReadinessTimeout(readinessTimeout string) MgrOpt {
+	return func(mgrOptions *mgrOpts) error {
+		if readinessTimeout == "" {
+			mgrOptions.readinessTimeout = 0
+			return nil
+		}
+		timeout, err := time.ParseDuration(readinessTimeout)
+		if err != nil || timeout < 0 {
+			return log.NewErrorf("invalid readiness timeout %s", readinessTimeout)
+		}
+		mgrOptions.readinessTimeout = timeout
+		return nil
+	}
+}
+
+// WithPrePullTimeout configures how long to wait for the container images referenced in the manifest to be pulled before applying it - an empty or zero value disables the pre-pull
+func WithPrePullTimeout(prePullTimeout string) MgrOpt {
+	return func(mgrOptions *mgrOpts) error {
+		if prePullTimeout == "" {
+			mgrOptions.prePullTimeout = 0
+			return nil
+		}
+		timeout, err := time.ParseDuration(prePullTimeout)
+		if err != nil || timeout < 0 {
+			return log.NewErrorf("invalid pre-pull timeout %s", prePullTimeout)
+		}
+		mgrOptions.prePullTimeout = timeout
+		return nil
+	}
+}
+
+// WithPrune configures whether the resources applied by a previous manifest and missing from the current one are deleted
+func WithPrune(prune bool) MgrOpt {
+	return func(mgrOptions *mgrOpts) error {
+		mgrOptions.prune = prune
+		return nil
+	}
+}
+
+func parseResources(resources []string) ([]schema.GroupVersionResource, error) {
+	gvrs := []schema.GroupVersionResource{}
+	for _, resource := range resources {
+		gvr, _ := schema.ParseResourceArg(resource)
+		if gvr == nil || gvr.Resource == "" || gvr.Version == "" {
+			return nil, log.NewErrorf("invalid watched resource %s - the expected format is <resource>.<version>.<group>", resource)
+		}
+		gvrs = append(gvrs, *gvr)
+	}
+	return gvrs, nil
+}
+
+// WithWatchedResources configures the resources in the <resource>.<version>.<group> format (e.g. pods.v1., deployments.v1.apps) that are watched and reported as current state
+func WithWatchedResources(watchedResources []string) MgrOpt {
+	return func(mgrOptions *mgrOpts) error {
+		gvrs, err := parseResources(watchedResources)
+		if err != nil {
+			return err
+		}
+		mgrOptions.watchedResources = gvrs
+		return nil
+	}
+}
+
+// WithWatchedNamespaces configures the namespaces, in which the namespaced resources are watched - if empty, all namespaces are watched
+func WithWatchedNamespaces(watchedNamespaces []string) MgrOpt {
+	return func(mgrOptions *mgrOpts) error {
+		mgrOptions.watchedNamespaces = watchedNamespaces
+		return nil
+	}
+}
+
+// WithMetadataOnlyResources configures the watched resources in the <resource>.<version>.<group> format, for which only the metadata is cached and reported as current state
+func WithMetadataOnlyResources(metadataOnlyResources []string) MgrOpt {
+	return func(mgrOptions *mgrOpts) error {
+		gvrs, err := parseResources(metadataOnlyResources)
+		if err != nil {
+			return err
+		}
+		mgrOptions.metadataOnlyResources = gvrs
+		return nil
+	}
+}
+
+// WithDiagnosticsLogLines configures how many of the last log lines of the failing containers are collected when an update fails - zero disables the log collection
+func WithDiagnosticsLogLines(diagnosticsLogLines int64) MgrOpt {
+	return func(mgrOptions *mgrOpts) error {
+		if diagnosticsLogLines < 0 {
+			return log.NewErrorf("invalid number of diagnostics log lines %d", diagnosticsLogLines)
+		}
+		mgrOptions.diagnosticsLogLines = diagnosticsLogLines
+		return nil
+	}
+}
@@ -13,9 +13,11 @@ package k8s
 
 import (
 	"testing"
+	"time"
 
 	"github.com/eclipse-kanto/container-management/containerm/log"
 	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/pkg/testutil"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
 func TestMgrOpts(t *testing.T) {
@@ -36,12 +38,95 @@ func TestMgrOpts(t *testing.T) {
 		"test_no_error": {
 			opts: []MgrOpt{
 				WithKubeConfig("some/path"),
+				WithServerSideApply(true),
+				WithFieldManager("test-manager"),
+				WithConflictPolicy(ConflictPolicyForce),
+				WithReadinessTimeout("90s"),
+				WithPrePullTimeout("15m"),
+				WithPrune(true),
+				WithWatchedResources([]string{"pods.v1.", "deployments.v1.apps"}),
+				WithWatchedNamespaces([]string{"default"}),
+				WithMetadataOnlyResources([]string{"pods.v1."}),
+				WithDiagnosticsLogLines(50),
 			},
 			expectedOpts: &mgrOpts{
-				kubeconfig: "some/path",
+				kubeconfig:       "some/path",
+				serverSideApply:  true,
+				fieldManager:     "test-manager",
+				conflictPolicy:   ConflictPolicyForce,
+				readinessTimeout: 90 * time.Second,
+				prePullTimeout:   15 * time.Minute,
+				prune:            true,
+				watchedResources: []schema.GroupVersionResource{
+					{Version: "v1", Resource: "pods"},
+					{Group: "apps", Version: "v1", Resource: "deployments"},
+				},
+				watchedNamespaces:     []string{"default"},
+				metadataOnlyResources: []schema.GroupVersionResource{{Version: "v1", Resource: "pods"}},
+				diagnosticsLogLines:   50,
+			},
+			expectedErr: nil,
+		},
+		"test_defaults": {
+			opts: []MgrOpt{
+				WithFieldManager(""),
+				WithConflictPolicy(""),
+			},
+			expectedOpts: &mgrOpts{
+				fieldManager:   FieldManagerDefault,
+				conflictPolicy: ConflictPolicyFail,
 			},
 			expectedErr: nil,
 		},
+		"test_error_conflict_policy": {
+			opts: []MgrOpt{
+				WithConflictPolicy("skip"),
+			},
+			expectedOpts: &mgrOpts{},
+			expectedErr:  log.NewError("unsupported conflict policy skip"),
+		},
+		"test_readiness_timeout_disabled": {
+			opts: []MgrOpt{
+				WithReadinessTimeout(""),
+			},
+			expectedOpts: &mgrOpts{},
+			expectedErr:  nil,
+		},
+		"test_error_readiness_timeout": {
+			opts: []MgrOpt{
+				WithReadinessTimeout("5 minutes"),
+			},
+			expectedOpts: &mgrOpts{},
+			expectedErr:  log.NewError("invalid readiness timeout 5 minutes"),
+		},
+		"test_error_pre_pull_timeout": {
+			opts: []MgrOpt{
+				WithPrePullTimeout("-1m"),
+			},
+			expectedOpts: &mgrOpts{},
+			expectedErr:  log.NewError("invalid pre-pull timeout -1m"),
+		},
+		"test_error_watched_resources": {
+			opts: []MgrOpt{
+				WithWatchedResources([]string{"pods"}),
+			},
+			expectedOpts: &mgrOpts{},
+			expectedErr:  log.NewError("invalid watched resource pods - the expected format is <resource>.<version>.<group>"),
+		},
+		"test_error_metadata_only_resources": {
+			opts: []MgrOpt{
+				WithMetadataOnlyResources([]string{"nodes.v1"}),
+			},
+			expectedOpts: &mgrOpts{},
+			expectedErr:  log.NewError("invalid watched resource nodes.v1 - the expected format is <resource>.<version>.<group>"),
+		},
+		"test_error_diagnostics_log_lines": {
+			opts: []MgrOpt{
+				WithDiagnosticsLogLines(-1),
+			},
+			expectedOpts: &mgrOpts{},
+			expectedErr:  log.NewError("invalid number of diagnostics log lines -1"),
+		},
 	}
 	for testCaseName, testCase := range testCases {
 		t.Run(testCaseName, func(t *testing.T) {
@@ -49,6 +134,9 @@ func TestMgrOpts(t *testing.T) {
 			actualOpts := &mgrOpts{}
 			err := applyOptsMgr(actualOpts, testCase.opts...)
 			testutil.AssertError(t, testCase.expectedErr, err)
+			if testCase.expectedOpts != nil {
+				testutil.AssertEqual(t, testCase.expectedOpts, actualOpts)
+			}
 		})
 	}
 }
@@ -0,0 +1,312 @@
+// Copyright (c) 2022 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Apache License 2.0 which is available at
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/eclipse-kanto/container-management/containerm/log"
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/orchestration"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+)
+
+const (
+	prePullPodGenerateName = "vehicle-update-manager-pre-pull-"
+	// prePullCommand does not exist in the pulled images, so that their entrypoints are never run - the container fails to start once its image is present
+	prePullCommand = "/vehicle-update-manager-pre-pull"
+)
+
+var (
+	prePullPollInterval = 2 * time.Second
+
+	podGVK            = schema.GroupVersionKind{Version: "v1", Kind: "Pod"}
+	serviceAccountGVK = schema.GroupVersionKind{Version: "v1", Kind: "ServiceAccount"}
+
+	// imagePullFailureReasons are the reasons of a waiting container, for which the image cannot be pulled
+	imagePullFailureReasons = map[string]bool{
+		"ErrImagePull":      true,
+		"ImagePullBackOff":  true,
+		"InvalidImageName":  true,
+		"ErrImageNeverPull": true,
+	}
+)
+
+// prePullGroup holds the container images of the manifest workloads, which are pulled by a single pod with the same pull credentials
+type prePullGroup struct {
+	namespace          string
+	serviceAccountName string
+	imagePullSecrets   []string
+	images             []string
+
+	pod  *unstructured.Unstructured
+	pods dynamic.ResourceInterface
+}
+
+// PullImages pulls the container images referenced in the manifest workloads via a pod per workload namespace, which is deleted afterwards.
+// The pods use the image pull secrets and the service accounts of the workloads, so that the images of private registries can be pulled.
+// The pull progress is published as running orchestration events in the download phase.
+func (updMgr *k8sUpdateManager) PullImages(ctx context.Context, mf []*unstructured.Unstructured) error {
+	if updMgr.cfg.prePullTimeout == 0 {
+		return nil
+	}
+	groups := manifestPrePullGroups(mf)
+	if len(groups) == 0 {
+		return nil
+	}
+	updMgr.applyLock.Lock()
+	updMgr.setPublishResourceEvent(false)
+	defer func() {
+		updMgr.setPublishResourceEvent(true)
+		updMgr.applyLock.Unlock()
+	}()
+
+	if err := updMgr.unavailableError(); err != nil {
+		return err
+	}
+	defer updMgr.deletePrePullPods(groups)
+	total := 0
+	for _, group := range groups {
+		if err := updMgr.createPrePullPod(ctx, group); err != nil {
+			return err
+		}
+		if group.pod != nil {
+			total += len(group.images)
+		}
+	}
+	if total == 0 {
+		return nil
+	}
+	log.Debug("waiting up to %s for %d container image(s) to be pulled", updMgr.cfg.prePullTimeout, total)
+
+	lastPulled := -1
+	var notPulled []string
+	err := wait.PollImmediateWithContext(ctx, prePullPollInterval, updMgr.cfg.prePullTimeout, func(ctx context.Context) (bool, error) {
+		pulledCount := 0
+		notPulled = nil
+		for _, group := range groups {
+			if group.pod == nil {
+				continue
+			}
+			live, err := group.pods.Get(ctx, group.pod.GetName(), metav1.GetOptions{})
+			if err != nil {
+				log.Debug("cannot get the pod %s pulling the container images: %v", group.pod.GetName(), err)
+				notPulled = append(notPulled, group.images...)
+				continue
+			}
+			pulled, failed := imagePullStatus(live, group.images)
+			if len(failed) > 0 {
+				return false, log.NewErrorf("cannot pull container image(s) in namespace %s: %s", group.namespace, strings.Join(failed, ", "))
+			}
+			for _, image := range group.images {
+				if !pulled[image] {
+					notPulled = append(notPulled, image)
+				}
+			}
+			pulledCount += len(pulled)
+		}
+		if pulledCount != lastPulled {
+			lastPulled = pulledCount
+			updMgr.publishOrchestrationEvent(ctx, orchestration.EventActionOrchestrationRunning, &orchestration.Progress{
+				Phase:    orchestration.ProgressPhaseDownload,
+				Progress: pulledCount * 100 / total,
+				Message:  fmt.Sprintf("%d of %d container image(s) pulled", pulledCount, total),
+			})
+		}
+		return len(notPulled) == 0, nil
+	})
+	if err == wait.ErrWaitTimeout {
+		return log.NewErrorf("pre-pull timeout of %s expired, container image(s) not pulled: %s", updMgr.cfg.prePullTimeout, strings.Join(notPulled, ", "))
+	}
+	return err
+}
+
+// createPrePullPod creates the pod pulling the images of the group.
+// No pod is created if the namespace of the workloads does not exist yet - the images are pulled on apply then, which is published as a download progress message.
+func (updMgr *k8sUpdateManager) createPrePullPod(ctx context.Context, group *prePullGroup) error {
+	pods, err := updMgr.getResourceByGVK(podGVK, group.namespace)
+	if err != nil {
+		return err
+	}
+	pod, err := newPrePullPod(group.images, group.namespace, updMgr.prePullServiceAccount(ctx, group), group.imagePullSecrets)
+	if err != nil {
+		return err
+	}
+	pod, err = pods.Create(ctx, pod, metav1.CreateOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			log.Warn("the namespace %s does not exist yet, the container images of its workloads are not pre-pulled", group.namespace)
+			updMgr.publishOrchestrationEvent(ctx, orchestration.EventActionOrchestrationRunning, &orchestration.Progress{
+				Phase:   orchestration.ProgressPhaseDownload,
+				Message: fmt.Sprintf("namespace %s does not exist yet, %d container image(s) will be pulled on apply: %s", group.namespace, len(group.images), strings.Join(group.images, ", ")),
+			})
+			return nil
+		}
+		return log.NewErrorf("cannot create the pod pulling the container images in namespace %s: %v", group.namespace, err)
+	}
+	log.Debug("the pod %s pulls %d container image(s) in namespace %s", pod.GetName(), len(group.images), group.namespace)
+	group.pod = pod
+	group.pods = pods
+	return nil
+}
+
+// prePullServiceAccount returns the service account of the group workloads if it exists - the service accounts created by the manifest itself cannot be used yet
+func (updMgr *k8sUpdateManager) prePullServiceAccount(ctx context.Context, group *prePullGroup) string {
+	if group.serviceAccountName == "" {
+		return ""
+	}
+	serviceAccounts, err := updMgr.getResourceByGVK(serviceAccountGVK, group.namespace)
+	if err == nil {
+		_, err = serviceAccounts.Get(ctx, group.serviceAccountName, metav1.GetOptions{})
+	}
+	if err != nil {
+		log.Debug("the service account %s in namespace %s is not used to pull the container images: %v", group.serviceAccountName, group.namespace, err)
+		return ""
+	}
+	return group.serviceAccountName
+}
+
+func (updMgr *k8sUpdateManager) deletePrePullPods(groups []*prePullGroup) {
+	for _, group := range groups {
+		if group.pod == nil {
+			continue
+		}
+		if err := group.pods.Delete(context.Background(), group.pod.GetName(), metav1.DeleteOptions{}); err != nil {
+			log.WarnErr(err, "cannot delete the pod %s pulling the container images", group.pod.GetName())
+		}
+	}
+}
+
+// manifestPrePullGroups groups the distinct container images of the manifest workloads by namespace and service account in order of appearance.
+// The image pull secrets of all workloads of a group are used to pull its images.
+func manifestPrePullGroups(mf []*unstructured.Unstructured) []*prePullGroup {
+	groups := []*prePullGroup{}
+	for _, u := range mf {
+		path, ok := orchestration.PodSpecPath(u)
+		if !ok {
+			continue
+		}
+		namespace := u.GetNamespace()
+		if namespace == "" {
+			namespace = metav1.NamespaceDefault
+		}
+		serviceAccountName, _, _ := unstructured.NestedString(u.Object, append(path, "serviceAccountName")...)
+		group := findPrePullGroup(groups, namespace, serviceAccountName)
+		if group == nil {
+			group = &prePullGroup{namespace: namespace, serviceAccountName: serviceAccountName}
+			groups = append(groups, group)
+		}
+		secrets, _, _ := unstructured.NestedSlice(u.Object, append(path, "imagePullSecrets")...)
+		for _, secret := range secrets {
+			if name, _, _ := unstructured.NestedString(secret.(map[string]interface{}), "name"); name != "" {
+				group.imagePullSecrets = appendDistinct(group.imagePullSecrets, name)
+			}
+		}
+		for _, field := range []string{"initContainers", "containers"} {
+			containers, _, _ := unstructured.NestedSlice(u.Object, append(path, field)...)
+			for _, container := range containers {
+				if image, _, _ := unstructured.NestedString(container.(map[string]interface{}), "image"); image != "" {
+					group.images = appendDistinct(group.images, image)
+				}
+			}
+		}
+	}
+	result := []*prePullGroup{}
+	for _, group := range groups {
+		if len(group.images) > 0 {
+			result = append(result, group)
+		}
+	}
+	return result
+}
+
+func findPrePullGroup(groups []*prePullGroup, namespace, serviceAccountName string) *prePullGroup {
+	for _, group := range groups {
+		if group.namespace == namespace && group.serviceAccountName == serviceAccountName {
+			return group
+		}
+	}
+	return nil
+}
+
+func appendDistinct(values []string, value string) []string {
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
+
+func newPrePullPod(images []string, namespace, serviceAccountName string, imagePullSecrets []string) (*unstructured.Unstructured, error) {
+	automountToken := false
+	pod := &corev1.Pod{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+		ObjectMeta: metav1.ObjectMeta{GenerateName: prePullPodGenerateName, Namespace: namespace},
+		Spec: corev1.PodSpec{
+			RestartPolicy:                corev1.RestartPolicyNever,
+			Tolerations:                  []corev1.Toleration{{Operator: corev1.TolerationOpExists}},
+			ServiceAccountName:           serviceAccountName,
+			AutomountServiceAccountToken: &automountToken,
+		},
+	}
+	for _, secret := range imagePullSecrets {
+		pod.Spec.ImagePullSecrets = append(pod.Spec.ImagePullSecrets, corev1.LocalObjectReference{Name: secret})
+	}
+	for i, image := range images {
+		pod.Spec.Containers = append(pod.Spec.Containers, corev1.Container{
+			Name:            fmt.Sprintf("image-%d", i),
+			Image:           image,
+			ImagePullPolicy: corev1.PullIfNotPresent,
+			Command:         []string{prePullCommand},
+		})
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(pod)
+	if err != nil {
+		return nil, err
+	}
+	return &unstructured.Unstructured{Object: content}, nil
+}
+
+// imagePullStatus returns the pulled images and a description of the images that cannot be pulled based on the statuses of the pod containers
+func imagePullStatus(pod *unstructured.Unstructured, images []string) (map[string]bool, []string) {
+	pulled := map[string]bool{}
+	var failed []string
+	statuses, _, _ := unstructured.NestedSlice(pod.Object, "status", "containerStatuses")
+	for _, status := range statuses {
+		containerStatus := &corev1.ContainerStatus{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(status.(map[string]interface{}), containerStatus); err != nil {
+			continue
+		}
+		var index int
+		if _, err := fmt.Sscanf(containerStatus.Name, "image-%d", &index); err != nil || index < 0 || index >= len(images) {
+			continue
+		}
+		image := images[index]
+		switch {
+		case containerStatus.ImageID != "", containerStatus.State.Running != nil, containerStatus.State.Terminated != nil:
+			pulled[image] = true
+		case containerStatus.State.Waiting != nil && imagePullFailureReasons[containerStatus.State.Waiting.Reason]:
+			failed = append(failed, fmt.Sprintf("%s (%s: %s)", image, containerStatus.State.Waiting.Reason, containerStatus.State.Waiting.Message))
+		}
+	}
+	return pulled, failed
+}
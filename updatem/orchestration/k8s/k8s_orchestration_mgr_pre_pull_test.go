@@ -0,0 +1,206 @@
+// Copyright (c) 2022 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Apache License 2.0 which is available at
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package k8s
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/events"
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/orchestration"
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/pkg/testutil"
+	mocksevents "github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/pkg/testutil/mocks/events"
+	"github.com/golang/mock/gomock"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+const testPrePullPodName = "test-pre-pull"
+
+func newTestImagesWorkload(apiVersion, kind string, podSpecPath []string, images ...string) *unstructured.Unstructured {
+	u := newTestManifestResource(apiVersion, kind, "test-"+kind, "")
+	containers := []interface{}{}
+	for _, image := range images {
+		containers = append(containers, map[string]interface{}{"name": "test", "image": image})
+	}
+	unstructured.SetNestedSlice(u.Object, containers, append(podSpecPath, "containers")...)
+	return u
+}
+
+func newTestContainerStatus(index string, status map[string]interface{}) map[string]interface{} {
+	status["name"] = "image-" + index
+	return status
+}
+
+func TestManifestPrePullGroups(t *testing.T) {
+	testPod := newTestImagesWorkload("v1", "Pod", []string{"spec"}, "test-app:1.0", "test-sidecar:1.0")
+	unstructured.SetNestedSlice(testPod.Object, []interface{}{map[string]interface{}{"name": "init", "image": "test-init:1.0"}}, "spec", "initContainers")
+	unstructured.SetNestedSlice(testPod.Object, []interface{}{map[string]interface{}{"name": "test-registry"}}, "spec", "imagePullSecrets")
+	testDeployment := newTestImagesWorkload("apps/v1", "Deployment", []string{"spec", "template", "spec"}, "test-app:1.0", "test-app:2.0")
+	unstructured.SetNestedSlice(testDeployment.Object, []interface{}{
+		map[string]interface{}{"name": "test-registry"},
+		map[string]interface{}{"name": "test-other-registry"},
+	}, "spec", "template", "spec", "imagePullSecrets")
+	testCronJob := newTestImagesWorkload("batch/v1", "CronJob", []string{"spec", "jobTemplate", "spec", "template", "spec"}, "test-job:1.0")
+	testCronJob.SetNamespace("test-jobs")
+	unstructured.SetNestedField(testCronJob.Object, "test-jobs-sa", "spec", "jobTemplate", "spec", "template", "spec", "serviceAccountName")
+
+	groups := manifestPrePullGroups([]*unstructured.Unstructured{
+		newTestManifestResource("v1", "ConfigMap", "test-config", ""),
+		testPod,
+		testDeployment,
+		testCronJob,
+		newTestImagesWorkload("sdv.eclipse.org/v1alpha1", "SelfUpdateBundle", []string{"spec"}, "test-bundle:1.0"),
+	})
+	testutil.AssertEqual(t, []*prePullGroup{
+		{
+			namespace:        "default",
+			imagePullSecrets: []string{"test-registry", "test-other-registry"},
+			images:           []string{"test-init:1.0", "test-app:1.0", "test-sidecar:1.0", "test-app:2.0"},
+		},
+		{
+			namespace:          "test-jobs",
+			serviceAccountName: "test-jobs-sa",
+			images:             []string{"test-job:1.0"},
+		},
+	}, groups)
+}
+
+func TestPullImages(t *testing.T) {
+	prePullPollInterval = 10 * time.Millisecond
+	defer func() {
+		prePullPollInterval = 2 * time.Second
+	}()
+
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{{Version: "v1"}})
+	mapper.Add(podGVK, meta.RESTScopeNamespace)
+	testDeployment := newTestImagesWorkload("apps/v1", "Deployment", []string{"spec", "template", "spec"}, "test-app:1.0", "test-sidecar:1.0")
+	testDeployment.SetNamespace("test-apps")
+	unstructured.SetNestedSlice(testDeployment.Object, []interface{}{map[string]interface{}{"name": "test-registry"}}, "spec", "template", "spec", "imagePullSecrets")
+	testManifest := []*unstructured.Unstructured{testDeployment}
+
+	tests := map[string]struct {
+		containerStatuses []interface{}
+		expectedProgress  []int
+		expectedErr       bool
+	}{
+		"test_pulled": {
+			containerStatuses: []interface{}{
+				newTestContainerStatus("0", map[string]interface{}{"imageID": "sha256:app", "state": map[string]interface{}{"terminated": map[string]interface{}{"exitCode": int64(128)}}}),
+				newTestContainerStatus("1", map[string]interface{}{"imageID": "sha256:sidecar"}),
+			},
+			expectedProgress: []int{100},
+		},
+		"test_pull_error": {
+			containerStatuses: []interface{}{
+				newTestContainerStatus("0", map[string]interface{}{"imageID": "sha256:app"}),
+				newTestContainerStatus("1", map[string]interface{}{"state": map[string]interface{}{"waiting": map[string]interface{}{"reason": "ImagePullBackOff"}}}),
+			},
+			expectedProgress: []int{},
+			expectedErr:      true,
+		},
+		"test_timeout": {
+			containerStatuses: []interface{}{
+				newTestContainerStatus("0", map[string]interface{}{"imageID": "sha256:app"}),
+				newTestContainerStatus("1", map[string]interface{}{"state": map[string]interface{}{"waiting": map[string]interface{}{"reason": "ContainerCreating"}}}),
+			},
+			expectedProgress: []int{50},
+			expectedErr:      true,
+		},
+	}
+	for testName, testCase := range tests {
+		t.Run(testName, func(t *testing.T) {
+			t.Log(testName)
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			progress := []int{}
+			mockEventsMgr := mocksevents.NewMockUpdateEventsManager(controller)
+			mockEventsMgr.EXPECT().Publish(gomock.Any(), gomock.Any()).Do(func(ctx context.Context, event *events.Event) {
+				testutil.AssertEqual(t, orchestration.EventActionOrchestrationRunning, event.Action)
+				testutil.AssertEqual(t, orchestration.ProgressPhaseDownload, event.Source.(*orchestration.Progress).Phase)
+				progress = append(progress, event.Source.(*orchestration.Progress).Progress)
+			}).Return(nil).AnyTimes()
+
+			k8sClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+			k8sClient.PrependReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				// the images are pulled in the namespace of the workload with its image pull secrets
+				testutil.AssertEqual(t, "test-apps", action.GetNamespace())
+				pod := action.(k8stesting.CreateAction).GetObject().(*unstructured.Unstructured)
+				secrets, _, _ := unstructured.NestedSlice(pod.Object, "spec", "imagePullSecrets")
+				testutil.AssertEqual(t, []interface{}{map[string]interface{}{"name": "test-registry"}}, secrets)
+				pod.SetName(testPrePullPodName)
+				unstructured.SetNestedSlice(pod.Object, testCase.containerStatuses, "status", "containerStatuses")
+				return false, nil, nil
+			})
+
+			updMgr := &k8sUpdateManager{
+				cfg:           &mgrOpts{prePullTimeout: 100 * time.Millisecond},
+				k8sClient:     k8sClient,
+				k8sRESTMapper: mapper,
+				eventsMgr:     mockEventsMgr,
+			}
+			err := updMgr.PullImages(context.Background(), testManifest)
+			testutil.AssertEqual(t, testCase.expectedErr, err != nil)
+			testutil.AssertEqual(t, testCase.expectedProgress, progress)
+
+			// the pod pulling the images is always deleted
+			_, err = k8sClient.Resource(testPodsGVR).Namespace("test-apps").Get(context.Background(), testPrePullPodName, metav1.GetOptions{})
+			testutil.AssertNotNil(t, err)
+		})
+	}
+}
+
+func TestPullImagesDisabled(t *testing.T) {
+	updMgr := &k8sUpdateManager{cfg: &mgrOpts{}}
+	testutil.AssertNil(t, updMgr.PullImages(context.Background(), []*unstructured.Unstructured{
+		newTestImagesWorkload("v1", "Pod", []string{"spec"}, "test-app:1.0"),
+	}))
+}
+
+func TestPullImagesNamespaceNotFound(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{{Version: "v1"}})
+	mapper.Add(podGVK, meta.RESTScopeNamespace)
+	testDeployment := newTestImagesWorkload("apps/v1", "Deployment", []string{"spec", "template", "spec"}, "test-app:1.0")
+	testDeployment.SetNamespace("test-new-namespace")
+
+	k8sClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+	k8sClient.PrependReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewNotFound(schema.GroupResource{Resource: "namespaces"}, action.GetNamespace())
+	})
+	// the skipped pre-pull is reported as a download progress message
+	mockEventsMgr := mocksevents.NewMockUpdateEventsManager(controller)
+	mockEventsMgr.EXPECT().Publish(gomock.Any(), gomock.Any()).Do(func(ctx context.Context, event *events.Event) {
+		testutil.AssertEqual(t, orchestration.EventActionOrchestrationRunning, event.Action)
+		testutil.AssertEqual(t, orchestration.ProgressPhaseDownload, event.Source.(*orchestration.Progress).Phase)
+		testutil.AssertContainsString(t, event.Source.(*orchestration.Progress).Message, "test-new-namespace")
+		testutil.AssertContainsString(t, event.Source.(*orchestration.Progress).Message, "test-app:1.0")
+	}).Return(nil).Times(1)
+	updMgr := &k8sUpdateManager{
+		cfg:           &mgrOpts{prePullTimeout: 100 * time.Millisecond},
+		k8sClient:     k8sClient,
+		k8sRESTMapper: mapper,
+		eventsMgr:     mockEventsMgr,
+	}
+	// the namespace is created by the manifest, so its images are pulled on apply
+	testutil.AssertNil(t, updMgr.PullImages(context.Background(), []*unstructured.Unstructured{testDeployment}))
+}
@@ -0,0 +1,136 @@
+// Copyright (c) 2022 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Apache License 2.0 which is available at
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package k8s
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/eclipse-kanto/container-management/containerm/log"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+// serverSideApplyPrune deletes the resources labeled as managed by the update manager, which are not part of the applied manifest.
+// The kubectl pruning relies on the last-applied-configuration annotation, which is not set by the server-side apply.
+func (k *kubectlApply) serverSideApplyPrune() error {
+	if !k.applyOptions.Prune {
+		return nil
+	}
+	mappings, err := k.prunableMappings()
+	if err != nil {
+		return err
+	}
+	for _, mapping := range mappings {
+		if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+			if err := k.pruneUnvisited(mapping, metav1.NamespaceNone); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, namespace := range k.applyOptions.VisitedNamespaces.List() {
+			if err := k.pruneUnvisited(mapping, namespace); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// prunableMappings returns the served resources, which can be listed and deleted, so that the managed resources of any kind, including the custom ones, are pruned.
+// The resources of the API groups that cannot be discovered are not pruned.
+func (k *kubectlApply) prunableMappings() ([]*meta.RESTMapping, error) {
+	lists, err := discovery.ServerPreferredResources(k.discoveryClient)
+	if err != nil {
+		if !discovery.IsGroupDiscoveryFailedError(err) {
+			return nil, log.NewErrorf("cannot discover the resources to be pruned: %v", err)
+		}
+		log.WarnErr(err, "the resources of the API groups that cannot be discovered are not pruned")
+	}
+	mappings := []*meta.RESTMapping{}
+	for _, list := range discovery.FilteredBy(discovery.SupportsAllVerbs{Verbs: []string{"list", "delete"}}, lists) {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			log.DebugErr(err, "the resources of %s are not pruned", list.GroupVersion)
+			continue
+		}
+		for _, resource := range list.APIResources {
+			// the subresources are deleted together with their resources
+			if strings.Contains(resource.Name, "/") {
+				continue
+			}
+			scope := meta.RESTScopeRoot
+			if resource.Namespaced {
+				scope = meta.RESTScopeNamespace
+			}
+			mappings = append(mappings, &meta.RESTMapping{
+				Resource:         gv.WithResource(resource.Name),
+				GroupVersionKind: gv.WithKind(resource.Kind),
+				Scope:            scope,
+			})
+		}
+	}
+	// the API groups are discovered concurrently, so the resources are pruned in a stable order
+	sort.Slice(mappings, func(i, j int) bool {
+		if mappings[i].Resource.Group != mappings[j].Resource.Group {
+			return mappings[i].Resource.Group < mappings[j].Resource.Group
+		}
+		return mappings[i].Resource.Resource < mappings[j].Resource.Resource
+	})
+	return mappings, nil
+}
+
+func (k *kubectlApply) pruneUnvisited(mapping *meta.RESTMapping, namespace string) error {
+	resource := k.applyOptions.DynamicClient.Resource(mapping.Resource).Namespace(namespace)
+	list, err := resource.List(context.Background(), metav1.ListOptions{LabelSelector: k.applyOptions.Selector})
+	if err != nil {
+		return log.NewErrorf("error pruning %s: %v", mapping.GroupVersionKind, err)
+	}
+	for i := range list.Items {
+		u := &list.Items[i]
+		// the resources created by the controllers of the applied ones are deleted together with their owners
+		if k.applyOptions.VisitedUids.Has(string(u.GetUID())) || metav1.GetControllerOf(u) != nil {
+			continue
+		}
+		if err := resource.Delete(context.Background(), u.GetName(), k.pruneDeleteOptions()); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return log.NewErrorf("error pruning %s: %v", resourceName(u), err)
+		}
+		// the same resources may be served by several API groups, e.g. the events, so they are pruned once
+		k.applyOptions.VisitedUids.Insert(string(u.GetUID()))
+		log.Debug("the resource %s is pruned", resourceName(u))
+		u.SetGroupVersionKind(mapping.GroupVersionKind)
+		k.recordResult(u, "pruned")
+	}
+	return nil
+}
+
+func (k *kubectlApply) pruneDeleteOptions() metav1.DeleteOptions {
+	options := metav1.DeleteOptions{}
+	if k.applyOptions.DeleteOptions != nil {
+		if k.applyOptions.DeleteOptions.GracePeriod >= 0 {
+			options = *metav1.NewDeleteOptions(int64(k.applyOptions.DeleteOptions.GracePeriod))
+		}
+		options.PropagationPolicy = &k.applyOptions.DeleteOptions.CascadingStrategy
+	}
+	if k.applyOptions.DryRunStrategy == cmdutil.DryRunServer {
+		options.DryRun = []string{metav1.DryRunAll}
+	}
+	return options
+}
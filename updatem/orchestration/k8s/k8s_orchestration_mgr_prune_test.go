@@ -0,0 +1,132 @@
+// Copyright (c) 2022 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Apache License 2.0 which is available at
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package k8s
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/orchestration"
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/pkg/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	discoveryfake "k8s.io/client-go/discovery/fake"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/kubectl/pkg/cmd/apply"
+)
+
+func newTestManagedResource(kind, name string, managed bool) *unstructured.Unstructured {
+	u := newTestResource(kind, "default", name)
+	u.SetUID(types.UID(name + "-uid"))
+	if managed {
+		u.SetLabels(map[string]string{managedByLabelKey: managedByLabelValue})
+	}
+	return u
+}
+
+func TestServerSideApplyPrune(t *testing.T) {
+	testConfigMapsGVR := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	testWidgetsGVR := schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "widgets"}
+
+	tests := map[string]struct {
+		prune             bool
+		expectedRemaining []string
+		expectedResults   []*orchestration.ResourceResult
+	}{
+		"test_prune_dropped_resource": {
+			prune:             true,
+			expectedRemaining: []string{"test-applied", "test-owned", "test-unmanaged"},
+			expectedResults: []*orchestration.ResourceResult{{
+				APIVersion: "v1",
+				Kind:       "ConfigMap",
+				Namespace:  "default",
+				Name:       "test-dropped",
+				Outcome:    orchestration.ResourceOutcomePruned,
+			}, {
+				APIVersion: "example.com/v1",
+				Kind:       "Widget",
+				Namespace:  "default",
+				Name:       "test-dropped-widget",
+				Outcome:    orchestration.ResourceOutcomePruned,
+			}},
+		},
+		"test_prune_disabled": {
+			expectedRemaining: []string{"test-applied", "test-dropped", "test-dropped-widget", "test-owned", "test-unmanaged"},
+		},
+	}
+
+	for testName, testCase := range tests {
+		t.Run(testName, func(t *testing.T) {
+			t.Log(testName)
+			// test-dropped is applied by the previous manifest and is missing from the current one
+			applied := newTestManagedResource("ConfigMap", "test-applied", true)
+			dropped := newTestManagedResource("ConfigMap", "test-dropped", true)
+			unmanaged := newTestManagedResource("ConfigMap", "test-unmanaged", false)
+			owned := newTestManagedResource("Pod", "test-owned", true)
+			controller := true
+			owned.SetOwnerReferences([]metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "test-rs", UID: "test-rs-uid", Controller: &controller}})
+
+			droppedWidget := newTestManagedResource("Widget", "test-dropped-widget", true)
+			droppedWidget.SetAPIVersion("example.com/v1")
+
+			dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+				testConfigMapsGVR: "ConfigMapList",
+				testPodsGVR:       "PodList",
+				testWidgetsGVR:    "WidgetList",
+			}, applied, dropped, unmanaged, owned, droppedWidget)
+
+			// the custom resources and the subresources are discovered as well
+			discoveryClient := &discoveryfake.FakeDiscovery{Fake: &k8stesting.Fake{}}
+			discoveryClient.Resources = []*metav1.APIResourceList{{
+				GroupVersion: "v1",
+				APIResources: []metav1.APIResource{
+					{Name: "configmaps", Kind: "ConfigMap", Namespaced: true, Verbs: metav1.Verbs{"list", "delete"}},
+					{Name: "pods", Kind: "Pod", Namespaced: true, Verbs: metav1.Verbs{"list", "delete"}},
+					{Name: "pods/status", Kind: "Pod", Namespaced: true, Verbs: metav1.Verbs{"get", "patch"}},
+				},
+			}, {
+				GroupVersion: "example.com/v1",
+				APIResources: []metav1.APIResource{{Name: "widgets", Kind: "Widget", Namespaced: true, Verbs: metav1.Verbs{"list", "delete"}}},
+			}}
+			k := &kubectlApply{
+				discoveryClient: discoveryClient,
+				applyOptions: &apply.ApplyOptions{
+					ServerSideApply:   true,
+					Prune:             testCase.prune,
+					Selector:          managedByLabelKey + "=" + managedByLabelValue,
+					DynamicClient:     dynamicClient,
+					VisitedUids:       sets.NewString(string(applied.GetUID())),
+					VisitedNamespaces: sets.NewString("default"),
+				},
+			}
+			testutil.AssertNil(t, k.serverSideApplyPrune())
+			testutil.AssertEqual(t, testCase.expectedResults, k.results)
+
+			remaining := []string{}
+			for _, gvr := range []schema.GroupVersionResource{testConfigMapsGVR, testPodsGVR, testWidgetsGVR} {
+				list, err := dynamicClient.Resource(gvr).Namespace("default").List(context.Background(), metav1.ListOptions{})
+				testutil.AssertNil(t, err)
+				for _, u := range list.Items {
+					remaining = append(remaining, u.GetName())
+				}
+			}
+			sort.Strings(remaining)
+			testutil.AssertEqual(t, testCase.expectedRemaining, remaining)
+		})
+	}
+}
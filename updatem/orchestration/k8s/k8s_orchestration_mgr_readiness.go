@@ -0,0 +1,240 @@
+// Copyright (c) 2022 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Apache License 2.0 which is available at
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/eclipse-kanto/container-management/containerm/log"
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/orchestration"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// readinessCheck reports whether a workload resource is ready and the reason if it is not.
+// An error is returned if the resource has failed and will not become ready.
+type readinessCheck func(u *unstructured.Unstructured) (bool, string, error)
+
+var (
+	readinessPollInterval = 2 * time.Second
+
+	readinessChecks = map[schema.GroupKind]readinessCheck{
+		{Group: "apps", Kind: "Deployment"}:  deploymentReadiness,
+		{Group: "apps", Kind: "StatefulSet"}: statefulSetReadiness,
+		{Group: "apps", Kind: "DaemonSet"}:   daemonSetReadiness,
+		{Group: "batch", Kind: "Job"}:        jobReadiness,
+		{Group: "", Kind: "Pod"}:             podReadiness,
+	}
+)
+
+func (updMgr *k8sUpdateManager) waitForReadiness(ctx context.Context, mf []*unstructured.Unstructured) error {
+	workloads := []*unstructured.Unstructured{}
+	for _, u := range mf {
+		if _, ok := readinessChecks[u.GroupVersionKind().GroupKind()]; ok {
+			workloads = append(workloads, u)
+		}
+	}
+	if len(workloads) == 0 {
+		return nil
+	}
+	log.Debug("waiting up to %s for %d workload resource(s) to become ready", updMgr.cfg.readinessTimeout, len(workloads))
+
+	lastReady := -1
+	var notReady []string
+	// the wait is interrupted as soon as the update operation is cancelled or the update manager is disposed
+	err := wait.PollImmediateWithContext(ctx, readinessPollInterval, updMgr.cfg.readinessTimeout, func(ctx context.Context) (bool, error) {
+		ready := 0
+		notReady = nil
+		for _, u := range workloads {
+			isReady, reason, err := updMgr.checkReadiness(ctx, u)
+			if err != nil {
+				return false, err
+			}
+			if isReady {
+				ready++
+			} else {
+				notReady = append(notReady, fmt.Sprintf("%s (%s)", resourceName(u), reason))
+			}
+		}
+		if ready != lastReady {
+			lastReady = ready
+			updMgr.publishOrchestrationEvent(ctx, orchestration.EventActionOrchestrationRunning, &orchestration.Progress{
+				Phase:    orchestration.ProgressPhaseReadiness,
+				Progress: ready * 100 / len(workloads),
+				Message:  fmt.Sprintf("%d of %d workload resource(s) ready", ready, len(workloads)),
+			})
+		}
+		return ready == len(workloads), nil
+	})
+	if err == wait.ErrWaitTimeout && ctx.Err() != nil {
+		return log.NewErrorf("the wait for readiness is cancelled, resource(s) not ready: %s", strings.Join(notReady, ", "))
+	}
+	if err == wait.ErrWaitTimeout {
+		return log.NewErrorf("readiness timeout of %s expired, resource(s) not ready: %s", updMgr.cfg.readinessTimeout, strings.Join(notReady, ", "))
+	}
+	return err
+}
+
+func (updMgr *k8sUpdateManager) checkReadiness(ctx context.Context, u *unstructured.Unstructured) (bool, string, error) {
+	namespace := u.GetNamespace()
+	if namespace == "" {
+		namespace = "default"
+	}
+	resource, err := updMgr.getResourceByGVK(u.GroupVersionKind(), namespace)
+	if err != nil {
+		return false, err.Error(), nil
+	}
+	live, err := resource.Get(ctx, u.GetName(), metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, "not found", nil
+		}
+		return false, err.Error(), nil
+	}
+	ready, reason, err := readinessChecks[u.GroupVersionKind().GroupKind()](live)
+	if err != nil {
+		return false, "", log.NewErrorf("the resource %s has failed: %v", resourceName(u), err)
+	}
+	return ready, reason, nil
+}
+
+func resourceName(u *unstructured.Unstructured) string {
+	return fmt.Sprintf("%s/%s", strings.ToLower(u.GetKind()), u.GetName())
+}
+
+func observedLatestGeneration(u *unstructured.Unstructured) bool {
+	observedGeneration, found, _ := unstructured.NestedInt64(u.Object, "status", "observedGeneration")
+	return found && observedGeneration >= u.GetGeneration()
+}
+
+func specReplicas(u *unstructured.Unstructured) int64 {
+	replicas, found, _ := unstructured.NestedInt64(u.Object, "spec", "replicas")
+	if !found {
+		return 1
+	}
+	return replicas
+}
+
+func statusInt64(u *unstructured.Unstructured, field string) int64 {
+	value, _, _ := unstructured.NestedInt64(u.Object, "status", field)
+	return value
+}
+
+// findCondition returns the status, reason and message of the status condition with the given type
+func findCondition(u *unstructured.Unstructured, conditionType string) (string, string, string) {
+	conditions, _, _ := unstructured.NestedSlice(u.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok || condition["type"] != conditionType {
+			continue
+		}
+		status, _ := condition["status"].(string)
+		reason, _ := condition["reason"].(string)
+		message, _ := condition["message"].(string)
+		return status, reason, message
+	}
+	return "", "", ""
+}
+
+func deploymentReadiness(u *unstructured.Unstructured) (bool, string, error) {
+	if _, reason, message := findCondition(u, "Progressing"); reason == "ProgressDeadlineExceeded" {
+		return false, "", log.NewErrorf("progress deadline exceeded: %s", message)
+	}
+	if !observedLatestGeneration(u) {
+		return false, "waiting for the rollout to be observed", nil
+	}
+	replicas := specReplicas(u)
+	updated := statusInt64(u, "updatedReplicas")
+	if updated < replicas {
+		return false, fmt.Sprintf("%d of %d replicas updated", updated, replicas), nil
+	}
+	if total := statusInt64(u, "replicas"); total > updated {
+		return false, fmt.Sprintf("%d old replica(s) pending termination", total-updated), nil
+	}
+	if available := statusInt64(u, "availableReplicas"); available < updated {
+		return false, fmt.Sprintf("%d of %d replicas available", available, updated), nil
+	}
+	return true, "", nil
+}
+
+func statefulSetReadiness(u *unstructured.Unstructured) (bool, string, error) {
+	if !observedLatestGeneration(u) {
+		return false, "waiting for the rollout to be observed", nil
+	}
+	replicas := specReplicas(u)
+	if strategy, _, _ := unstructured.NestedString(u.Object, "spec", "updateStrategy", "type"); strategy != "OnDelete" {
+		partition, _, _ := unstructured.NestedInt64(u.Object, "spec", "updateStrategy", "rollingUpdate", "partition")
+		if updated := statusInt64(u, "updatedReplicas"); updated < replicas-partition {
+			return false, fmt.Sprintf("%d of %d replicas updated", updated, replicas-partition), nil
+		}
+	}
+	if ready := statusInt64(u, "readyReplicas"); ready < replicas {
+		return false, fmt.Sprintf("%d of %d replicas ready", ready, replicas), nil
+	}
+	return true, "", nil
+}
+
+func daemonSetReadiness(u *unstructured.Unstructured) (bool, string, error) {
+	if !observedLatestGeneration(u) {
+		return false, "waiting for the rollout to be observed", nil
+	}
+	desired := statusInt64(u, "desiredNumberScheduled")
+	if updated := statusInt64(u, "updatedNumberScheduled"); updated < desired {
+		return false, fmt.Sprintf("%d of %d pods updated", updated, desired), nil
+	}
+	if available := statusInt64(u, "numberAvailable"); available < desired {
+		return false, fmt.Sprintf("%d of %d pods available", available, desired), nil
+	}
+	return true, "", nil
+}
+
+func jobReadiness(u *unstructured.Unstructured) (bool, string, error) {
+	if status, reason, message := findCondition(u, "Failed"); status == "True" {
+		return false, "", log.NewErrorf("%s: %s", reason, message)
+	}
+	if status, _, _ := findCondition(u, "Complete"); status == "True" {
+		return true, "", nil
+	}
+	return false, "not completed", nil
+}
+
+func podReadiness(u *unstructured.Unstructured) (bool, string, error) {
+	phase, _, _ := unstructured.NestedString(u.Object, "status", "phase")
+	switch phase {
+	case "Succeeded":
+		return true, "", nil
+	case "Failed":
+		reason, _, _ := unstructured.NestedString(u.Object, "status", "reason")
+		message, _, _ := unstructured.NestedString(u.Object, "status", "message")
+		return false, "", log.NewErrorf("%s: %s", reason, message)
+	}
+	if status, _, _ := findCondition(u, "Ready"); status == "True" {
+		return true, "", nil
+	}
+	containerStatuses, _, _ := unstructured.NestedSlice(u.Object, "status", "containerStatuses")
+	for _, cs := range containerStatuses {
+		containerStatus, ok := cs.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if reason, found, _ := unstructured.NestedString(containerStatus, "state", "waiting", "reason"); found {
+			return false, fmt.Sprintf("container %s is waiting: %s", containerStatus["name"], reason), nil
+		}
+	}
+	return false, fmt.Sprintf("pod is %s", phase), nil
+}
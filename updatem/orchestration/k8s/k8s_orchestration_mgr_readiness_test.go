@@ -0,0 +1,238 @@
+// Copyright (c) 2022 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Apache License 2.0 which is available at
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package k8s
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/events"
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/orchestration"
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/pkg/testutil"
+	mocksevents "github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/pkg/testutil/mocks/events"
+	"github.com/golang/mock/gomock"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func newTestWorkload(apiVersion, kind string, spec, status map[string]interface{}) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": apiVersion,
+			"kind":       kind,
+			"metadata": map[string]interface{}{
+				"name":       "test-" + kind,
+				"namespace":  "default",
+				"generation": int64(2),
+			},
+		},
+	}
+	if spec != nil {
+		u.Object["spec"] = spec
+	}
+	if status != nil {
+		u.Object["status"] = status
+	}
+	return u
+}
+
+func TestReadinessChecks(t *testing.T) {
+	tests := map[string]struct {
+		workload      *unstructured.Unstructured
+		expectedReady bool
+		expectedErr   bool
+	}{
+		"test_deployment_ready": {
+			workload: newTestWorkload("apps/v1", "Deployment", map[string]interface{}{"replicas": int64(2)}, map[string]interface{}{
+				"observedGeneration": int64(2), "replicas": int64(2), "updatedReplicas": int64(2), "availableReplicas": int64(2),
+			}),
+			expectedReady: true,
+		},
+		"test_deployment_not_observed": {
+			workload: newTestWorkload("apps/v1", "Deployment", nil, map[string]interface{}{
+				"observedGeneration": int64(1), "replicas": int64(1), "updatedReplicas": int64(1), "availableReplicas": int64(1),
+			}),
+		},
+		"test_deployment_old_replicas": {
+			workload: newTestWorkload("apps/v1", "Deployment", nil, map[string]interface{}{
+				"observedGeneration": int64(2), "replicas": int64(2), "updatedReplicas": int64(1), "availableReplicas": int64(1),
+			}),
+		},
+		"test_deployment_progress_deadline_exceeded": {
+			workload: newTestWorkload("apps/v1", "Deployment", nil, map[string]interface{}{
+				"conditions": []interface{}{
+					map[string]interface{}{"type": "Progressing", "status": "False", "reason": "ProgressDeadlineExceeded"},
+				},
+			}),
+			expectedErr: true,
+		},
+		"test_statefulset_ready": {
+			workload: newTestWorkload("apps/v1", "StatefulSet", map[string]interface{}{"replicas": int64(3)}, map[string]interface{}{
+				"observedGeneration": int64(2), "updatedReplicas": int64(3), "readyReplicas": int64(3),
+			}),
+			expectedReady: true,
+		},
+		"test_statefulset_partition": {
+			workload: newTestWorkload("apps/v1", "StatefulSet", map[string]interface{}{
+				"replicas": int64(3), "updateStrategy": map[string]interface{}{"rollingUpdate": map[string]interface{}{"partition": int64(2)}},
+			}, map[string]interface{}{
+				"observedGeneration": int64(2), "updatedReplicas": int64(1), "readyReplicas": int64(3),
+			}),
+			expectedReady: true,
+		},
+		"test_statefulset_not_ready": {
+			workload: newTestWorkload("apps/v1", "StatefulSet", map[string]interface{}{"replicas": int64(3)}, map[string]interface{}{
+				"observedGeneration": int64(2), "updatedReplicas": int64(3), "readyReplicas": int64(2),
+			}),
+		},
+		"test_daemonset_ready": {
+			workload: newTestWorkload("apps/v1", "DaemonSet", nil, map[string]interface{}{
+				"observedGeneration": int64(2), "desiredNumberScheduled": int64(1), "updatedNumberScheduled": int64(1), "numberAvailable": int64(1),
+			}),
+			expectedReady: true,
+		},
+		"test_daemonset_not_available": {
+			workload: newTestWorkload("apps/v1", "DaemonSet", nil, map[string]interface{}{
+				"observedGeneration": int64(2), "desiredNumberScheduled": int64(1), "updatedNumberScheduled": int64(1),
+			}),
+		},
+		"test_job_complete": {
+			workload: newTestWorkload("batch/v1", "Job", nil, map[string]interface{}{
+				"conditions": []interface{}{map[string]interface{}{"type": "Complete", "status": "True"}},
+			}),
+			expectedReady: true,
+		},
+		"test_job_failed": {
+			workload: newTestWorkload("batch/v1", "Job", nil, map[string]interface{}{
+				"conditions": []interface{}{map[string]interface{}{"type": "Failed", "status": "True", "reason": "BackoffLimitExceeded"}},
+			}),
+			expectedErr: true,
+		},
+		"test_pod_ready": {
+			workload: newTestWorkload("v1", "Pod", nil, map[string]interface{}{
+				"phase":      "Running",
+				"conditions": []interface{}{map[string]interface{}{"type": "Ready", "status": "True"}},
+			}),
+			expectedReady: true,
+		},
+		"test_pod_crash_looping": {
+			workload: newTestWorkload("v1", "Pod", nil, map[string]interface{}{
+				"phase": "Running",
+				"containerStatuses": []interface{}{map[string]interface{}{
+					"name":  "test-container",
+					"state": map[string]interface{}{"waiting": map[string]interface{}{"reason": "CrashLoopBackOff"}},
+				}},
+			}),
+		},
+		"test_pod_failed": {
+			workload:    newTestWorkload("v1", "Pod", nil, map[string]interface{}{"phase": "Failed"}),
+			expectedErr: true,
+		},
+	}
+	for testName, testCase := range tests {
+		t.Run(testName, func(t *testing.T) {
+			t.Log(testName)
+			ready, reason, err := readinessChecks[testCase.workload.GroupVersionKind().GroupKind()](testCase.workload)
+			testutil.AssertEqual(t, testCase.expectedReady, ready)
+			testutil.AssertEqual(t, testCase.expectedErr, err != nil)
+			if !testCase.expectedReady && !testCase.expectedErr {
+				testutil.AssertNotEqual(t, "", reason)
+			}
+		})
+	}
+}
+
+func TestWaitForReadiness(t *testing.T) {
+	readinessPollInterval = 10 * time.Millisecond
+	defer func() {
+		readinessPollInterval = 2 * time.Second
+	}()
+
+	readyPod := newTestWorkload("v1", "Pod", nil, map[string]interface{}{
+		"phase":      "Running",
+		"conditions": []interface{}{map[string]interface{}{"type": "Ready", "status": "True"}},
+	})
+	pendingPod := newTestWorkload("v1", "Pod", nil, map[string]interface{}{"phase": "Pending"})
+	testConfigMap := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]interface{}{"name": "test-config"},
+	}}
+
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{{Version: "v1"}})
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Pod"}, meta.RESTScopeNamespace)
+
+	tests := map[string]struct {
+		live             *unstructured.Unstructured
+		cancelled        bool
+		expectedProgress int
+		expectedErr      bool
+	}{
+		"test_ready": {
+			live:             readyPod,
+			expectedProgress: 100,
+		},
+		"test_timeout": {
+			live:             pendingPod,
+			expectedProgress: 0,
+			expectedErr:      true,
+		},
+		"test_cancelled": {
+			live:             pendingPod,
+			cancelled:        true,
+			expectedProgress: 0,
+			expectedErr:      true,
+		},
+	}
+	for testName, testCase := range tests {
+		t.Run(testName, func(t *testing.T) {
+			t.Log(testName)
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			mockEventsMgr := mocksevents.NewMockUpdateEventsManager(controller)
+			mockEventsMgr.EXPECT().Publish(gomock.Any(), gomock.Any()).Do(func(ctx context.Context, event *events.Event) {
+				testutil.AssertEqual(t, orchestration.EventTypeOrchestration, event.Type)
+				testutil.AssertEqual(t, orchestration.EventActionOrchestrationRunning, event.Action)
+				progress := event.Source.(*orchestration.Progress)
+				testutil.AssertEqual(t, orchestration.ProgressPhaseReadiness, progress.Phase)
+				testutil.AssertEqual(t, testCase.expectedProgress, progress.Progress)
+			}).Return(nil).Times(1)
+
+			readinessTimeout := 100 * time.Millisecond
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if testCase.cancelled {
+				// the cancellation interrupts the wait long before the readiness timeout expires
+				readinessTimeout = time.Minute
+				cancel()
+			}
+			updMgr := &k8sUpdateManager{
+				cfg:           &mgrOpts{readinessTimeout: readinessTimeout},
+				k8sClient:     dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), testCase.live),
+				k8sRESTMapper: mapper,
+				eventsMgr:     mockEventsMgr,
+			}
+			start := time.Now()
+			err := updMgr.waitForReadiness(ctx, []*unstructured.Unstructured{testConfigMap, testCase.live})
+			testutil.AssertEqual(t, testCase.expectedErr, err != nil)
+			if testCase.cancelled {
+				testutil.AssertContainsString(t, err.Error(), "cancelled")
+				testutil.AssertTrue(t, time.Since(start) < time.Second)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright (c) 2022 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Apache License 2.0 which is available at
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package k8s
+
+import (
+	"context"
+
+	"github.com/eclipse-kanto/container-management/containerm/log"
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/orchestration"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/dynamic"
+)
+
+// Remove deletes the manifest resources, e.g. the resources of an uninstalled Helm release, leaving the other applied resources unchanged.
+// Only the resources labeled as managed by the update manager are deleted, the rest and the ones that do not exist are skipped.
+func (updMgr *k8sUpdateManager) Remove(ctx context.Context, mf []*unstructured.Unstructured) interface{} {
+	updMgr.applyLock.Lock()
+	updMgr.setPublishResourceEvent(false)
+
+	log.Debug("processing remove manifest - start")
+
+	defer func() {
+		updMgr.setPublishResourceEvent(true)
+		updMgr.applyLock.Unlock()
+	}()
+
+	if err := updMgr.unavailableError(); err != nil {
+		log.ErrorErr(err, "cannot remove the manifest resources")
+		return &orchestration.ApplyResult{Err: err}
+	}
+	updMgr.clientsLock.RLock()
+	client, mapper := updMgr.k8sClient, updMgr.k8sRESTMapper
+	updMgr.clientsLock.RUnlock()
+
+	propagationPolicy := metav1.DeletePropagationBackground
+	options := metav1.DeleteOptions{PropagationPolicy: &propagationPolicy}
+	if orchestration.IsUpdateMgrDryRunContext(ctx) {
+		options.DryRun = []string{metav1.DryRunAll}
+	}
+	result := &orchestration.ApplyResult{}
+	var (
+		errs      []error
+		unwatched []*unstructured.Unstructured
+	)
+	for _, u := range mf {
+		removed, err := removeResource(ctx, client, mapper, u, options)
+		if err != nil {
+			log.Warn("the resource %s cannot be removed: %v", resourceName(u), err)
+			result.Resources = append(result.Resources, newFailedResourceResult(u, err))
+			errs = append(errs, err)
+			continue
+		}
+		if removed != nil {
+			result.Resources = append(result.Resources, removed)
+		}
+		unwatched = append(unwatched, u)
+	}
+	if len(errs) > 0 {
+		result.Err = utilerrors.NewAggregate(errs)
+	}
+	if !orchestration.IsUpdateMgrDryRunContext(ctx) {
+		updMgr.unwatchRemovedResources(unwatched)
+		if containsCRDs(mf) {
+			updMgr.resetDiscovery()
+		}
+	}
+	log.Debug("finished removing manifest")
+	return result
+}
+
+// removeResource deletes the live resource if it is managed by the update manager and returns its outcome or nil if it is skipped
+func removeResource(ctx context.Context, client dynamic.Interface, mapper meta.RESTMapper, u *unstructured.Unstructured, options metav1.DeleteOptions) (*orchestration.ResourceResult, error) {
+	gvk := u.GroupVersionKind()
+	mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		if meta.IsNoMatchError(err) {
+			log.Debug("the kind %s is not served - skipping the removal of %s", gvk.String(), resourceName(u))
+			return nil, nil
+		}
+		return nil, err
+	}
+	namespace := ""
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		namespace = u.GetNamespace()
+		if namespace == "" {
+			namespace = metav1.NamespaceDefault
+		}
+	}
+	resource := client.Resource(mapping.Resource).Namespace(namespace)
+	live, err := resource.Get(ctx, u.GetName(), metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if live.GetLabels()[managedByLabelKey] != managedByLabelValue {
+		log.Debug("the resource %s is not managed by the update manager - skipping its removal", resourceName(u))
+		return nil, nil
+	}
+	if err := resource.Delete(ctx, u.GetName(), options); err != nil && !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+	log.Debug("the resource %s is removed", resourceName(u))
+	return &orchestration.ResourceResult{
+		APIVersion: u.GetAPIVersion(),
+		Kind:       u.GetKind(),
+		Namespace:  namespace,
+		Name:       u.GetName(),
+		Outcome:    orchestration.ResourceOutcomePruned,
+	}, nil
+}
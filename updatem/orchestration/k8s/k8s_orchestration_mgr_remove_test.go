@@ -0,0 +1,107 @@
+// Copyright (c) 2022 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Apache License 2.0 which is available at
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package k8s
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/orchestration"
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/pkg/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestRemove(t *testing.T) {
+	managed := newTestManagedResource("Pod", "test-managed", true)
+	unmanaged := newTestManagedResource("Pod", "test-unmanaged", false)
+	other := newTestManagedResource("Pod", "test-other", true)
+
+	tests := map[string]struct {
+		removed           []*unstructured.Unstructured
+		expectedRemaining []string
+		expectedResources []*orchestration.ResourceResult
+	}{
+		"test_remove_managed": {
+			removed:           []*unstructured.Unstructured{newTestResource("Pod", "", "test-managed")},
+			expectedRemaining: []string{"test-other", "test-unmanaged"},
+			expectedResources: []*orchestration.ResourceResult{{
+				APIVersion: "v1",
+				Kind:       "Pod",
+				Namespace:  "default",
+				Name:       "test-managed",
+				Outcome:    orchestration.ResourceOutcomePruned,
+			}},
+		},
+		"test_remove_unmanaged": {
+			removed:           []*unstructured.Unstructured{newTestResource("Pod", "default", "test-unmanaged")},
+			expectedRemaining: []string{"test-managed", "test-other", "test-unmanaged"},
+		},
+		"test_remove_missing": {
+			removed:           []*unstructured.Unstructured{newTestResource("Pod", "default", "test-missing")},
+			expectedRemaining: []string{"test-managed", "test-other", "test-unmanaged"},
+		},
+		"test_remove_not_served_kind": {
+			removed:           []*unstructured.Unstructured{newTestResource("Service", "default", "test-managed")},
+			expectedRemaining: []string{"test-managed", "test-other", "test-unmanaged"},
+		},
+	}
+	for testName, testCase := range tests {
+		t.Run(testName, func(t *testing.T) {
+			t.Log(testName)
+			updMgr := newTestK8sUpdateManager(&mgrOpts{}, managed.DeepCopy(), unmanaged.DeepCopy(), other.DeepCopy())
+
+			result := updMgr.Remove(context.Background(), testCase.removed).(*orchestration.ApplyResult)
+			testutil.AssertNil(t, result.Err)
+			testutil.AssertEqual(t, testCase.expectedResources, result.Resources)
+			testutil.AssertTrue(t, updMgr.isPublishResourceEvent())
+
+			list, err := updMgr.k8sClient.Resource(testPodsGVR).Namespace("default").List(context.Background(), metav1.ListOptions{})
+			testutil.AssertNil(t, err)
+			remaining := []string{}
+			for _, u := range list.Items {
+				remaining = append(remaining, u.GetName())
+			}
+			sort.Strings(remaining)
+			testutil.AssertEqual(t, testCase.expectedRemaining, remaining)
+		})
+	}
+}
+
+func TestUpdateManagedNamespaces(t *testing.T) {
+	resources := []*orchestration.ResourceResult{
+		{Kind: "Pod", Namespace: "test-ns", Outcome: orchestration.ResourceOutcomeCreated},
+		{Kind: "Pod", Namespace: "test-pruned-ns", Outcome: orchestration.ResourceOutcomePruned},
+		{Kind: "Namespace", Outcome: orchestration.ResourceOutcomeUnchanged},
+	}
+	tests := map[string]struct {
+		applied            bool
+		expectedNamespaces []string
+	}{
+		"test_applied": {
+			applied:            true,
+			expectedNamespaces: []string{"test-ns"},
+		},
+		"test_failed": {
+			expectedNamespaces: []string{"test-ns", "test-previous-ns"},
+		},
+	}
+	for testName, testCase := range tests {
+		t.Run(testName, func(t *testing.T) {
+			t.Log(testName)
+			updMgr := &k8sUpdateManager{managedNamespaces: []string{"test-previous-ns"}}
+			updMgr.updateManagedNamespaces(resources, testCase.applied)
+			testutil.AssertEqual(t, testCase.expectedNamespaces, updMgr.managedNamespaces)
+		})
+	}
+}
@@ -0,0 +1,228 @@
+// Copyright (c) 2022 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Apache License 2.0 which is available at
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package k8s
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/pkg/testutil"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	discoveryfake "k8s.io/client-go/discovery/fake"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	metadatafake "k8s.io/client-go/metadata/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+)
+
+var (
+	testPodsGVR  = schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+	testNodesGVR = schema.GroupVersionResource{Version: "v1", Resource: "nodes"}
+)
+
+func newTestResource(kind, namespace, name string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetAPIVersion("v1")
+	u.SetKind(kind)
+	u.SetNamespace(namespace)
+	u.SetName(name)
+	return u
+}
+
+func newTestResourceMetadata(u *unstructured.Unstructured) *metav1.PartialObjectMetadata {
+	return &metav1.PartialObjectMetadata{
+		TypeMeta:   metav1.TypeMeta{APIVersion: u.GetAPIVersion(), Kind: u.GetKind()},
+		ObjectMeta: metav1.ObjectMeta{Namespace: u.GetNamespace(), Name: u.GetName(), Labels: u.GetLabels(), Annotations: u.GetAnnotations()},
+	}
+}
+
+func newTestK8sUpdateManager(cfg *mgrOpts, objects ...*unstructured.Unstructured) *k8sUpdateManager {
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{{Version: "v1"}})
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Pod"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Node"}, meta.RESTScopeRoot)
+
+	dynamicObjects := []runtime.Object{}
+	metadataObjects := []runtime.Object{}
+	for _, u := range objects {
+		dynamicObjects = append(dynamicObjects, u)
+		metadataObjects = append(metadataObjects, newTestResourceMetadata(u))
+	}
+	metadataScheme := runtime.NewScheme()
+	metav1.AddMetaToScheme(metadataScheme)
+	return &k8sUpdateManager{
+		cfg: cfg,
+		k8sClient: dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+			testPodsGVR:  "PodList",
+			testNodesGVR: "NodeList",
+		}, dynamicObjects...),
+		k8sMetadataClient: metadatafake.NewSimpleMetadataClient(metadataScheme, metadataObjects...),
+		k8sRESTMapper:     mapper,
+	}
+}
+
+func TestGet(t *testing.T) {
+	objects := []*unstructured.Unstructured{
+		newTestResource("Pod", "default", "test-pod-default"),
+		newTestResource("Pod", "kube-system", "test-pod-system"),
+		newTestResource("Node", "", "test-node"),
+	}
+	tests := map[string]struct {
+		cfg           *mgrOpts
+		expectedNames []string
+	}{
+		"test_all_namespaces": {
+			cfg:           &mgrOpts{watchedResources: []schema.GroupVersionResource{testPodsGVR, testNodesGVR}},
+			expectedNames: []string{"test-node", "test-pod-default", "test-pod-system"},
+		},
+		"test_watched_namespaces": {
+			cfg: &mgrOpts{
+				watchedResources:  []schema.GroupVersionResource{testPodsGVR, testNodesGVR},
+				watchedNamespaces: []string{"default"},
+			},
+			expectedNames: []string{"test-node", "test-pod-default"},
+		},
+		"test_watched_resources": {
+			cfg:           &mgrOpts{watchedResources: []schema.GroupVersionResource{testNodesGVR}},
+			expectedNames: []string{"test-node"},
+		},
+		"test_metadata_only_resources": {
+			cfg: &mgrOpts{
+				watchedResources:      []schema.GroupVersionResource{testPodsGVR, testNodesGVR},
+				metadataOnlyResources: []schema.GroupVersionResource{testNodesGVR},
+			},
+			expectedNames: []string{"test-node", "test-pod-default", "test-pod-system"},
+		},
+		"test_no_watched_resources": {
+			cfg:           &mgrOpts{},
+			expectedNames: []string{},
+		},
+	}
+	for testName, testCase := range tests {
+		t.Run(testName, func(t *testing.T) {
+			t.Log(testName)
+			updMgr := newTestK8sUpdateManager(testCase.cfg, objects...)
+			updMgr.loopWatchResources(context.Background())
+			defer updMgr.Dispose(context.Background())
+
+			for _, watcher := range updMgr.watchers {
+				testutil.AssertTrue(t, cache.WaitForCacheSync(updMgr.watchStop, watcher.informer.HasSynced))
+			}
+
+			names := []string{}
+			for _, u := range updMgr.Get(context.Background()) {
+				names = append(names, u.GetName())
+				testutil.AssertEqual(t, "v1", u.GetAPIVersion())
+				if u.GetNamespace() == "" {
+					testutil.AssertEqual(t, "Node", u.GetKind())
+				} else {
+					testutil.AssertEqual(t, "Pod", u.GetKind())
+				}
+				// the returned resources are copies of the cached ones
+				u.SetLabels(map[string]string{"modified": "true"})
+			}
+			sort.Strings(names)
+			testutil.AssertEqual(t, testCase.expectedNames, names)
+			for _, u := range updMgr.Get(context.Background()) {
+				testutil.AssertEqual(t, 0, len(u.GetLabels()))
+			}
+		})
+	}
+}
+
+func TestDispose(t *testing.T) {
+	updMgr := newTestK8sUpdateManager(&mgrOpts{watchedResources: []schema.GroupVersionResource{testPodsGVR}})
+	updMgr.loopWatchResources(context.Background())
+
+	testutil.AssertNil(t, updMgr.Dispose(context.Background()))
+	testutil.AssertNil(t, updMgr.Dispose(context.Background()))
+	select {
+	case <-updMgr.watchStop:
+	default:
+		t.Fatal("the resource watchers are not stopped")
+	}
+}
+
+func TestWatchAppliedResources(t *testing.T) {
+	pod := newTestResource("Pod", "", "test-pod")
+	updMgr := newTestK8sUpdateManager(&mgrOpts{watchedResources: []schema.GroupVersionResource{testNodesGVR}}, newTestResource("Pod", "default", "test-pod"))
+	updMgr.loopWatchResources(context.Background())
+	defer updMgr.Dispose(context.Background())
+
+	// the applied pods are watched once in their namespace
+	updMgr.watchAppliedResources([]*unstructured.Unstructured{pod, pod}, false)
+	testutil.AssertEqual(t, 2, len(updMgr.watchers))
+	watcher := updMgr.getWatcher(testPodsGVR, "default")
+	testutil.AssertNotNil(t, watcher)
+	testutil.AssertTrue(t, watcher.applied)
+	testutil.AssertTrue(t, cache.WaitForCacheSync(updMgr.watchStop, watcher.informer.HasSynced))
+
+	// the applied resources are not reported as the watched ones
+	testutil.AssertEqual(t, 0, len(updMgr.Get(context.Background())))
+
+	// the applied resources are watched again once the watchers are recreated
+	close(updMgr.watchStop)
+	updMgr.watchers = nil
+	updMgr.loopWatchResources(context.Background())
+	testutil.AssertNotNil(t, updMgr.getWatcher(testPodsGVR, "default"))
+
+	// the reconciled resources are watched in addition to the applied ones
+	updMgr.watchAppliedResources([]*unstructured.Unstructured{newTestResource("Pod", "kube-system", "test-pod")}, true)
+	testutil.AssertNotNil(t, updMgr.getWatcher(testPodsGVR, "default"))
+	testutil.AssertNotNil(t, updMgr.getWatcher(testPodsGVR, "kube-system"))
+
+	// the watchers of the types no longer applied are stopped
+	systemWatcher := updMgr.getWatcher(testPodsGVR, "kube-system")
+	updMgr.watchAppliedResources([]*unstructured.Unstructured{newTestResource("Pod", "kube-system", "test-pod")}, false)
+	testutil.AssertNil(t, updMgr.getWatcher(testPodsGVR, "default"))
+	testutil.AssertEqual(t, systemWatcher, updMgr.getWatcher(testPodsGVR, "kube-system"))
+
+	// the watchers of the removed resources are stopped
+	updMgr.unwatchRemovedResources([]*unstructured.Unstructured{newTestResource("Pod", "kube-system", "test-pod")})
+	testutil.AssertNil(t, updMgr.getWatcher(testPodsGVR, "kube-system"))
+	testutil.AssertEqual(t, 1, len(updMgr.watchers))
+	testutil.AssertEqual(t, 0, len(updMgr.appliedWatches))
+	select {
+	case <-systemWatcher.stop:
+	default:
+		t.Fatal("the watcher of the removed resources is not stopped")
+	}
+}
+
+func TestResetDiscovery(t *testing.T) {
+	k8sDiscoveryClient := &discoveryfake.FakeDiscovery{Fake: &k8stesting.Fake{}}
+	k8sDiscoveryClient.Resources = []*metav1.APIResourceList{{
+		GroupVersion: "v1",
+		APIResources: []metav1.APIResource{{Name: "pods", Kind: "Pod", Namespaced: true}},
+	}}
+	updMgr := &k8sUpdateManager{k8sDiscoveryClient: k8sDiscoveryClient}
+	updMgr.setDiscovery(k8sDiscoveryClient)
+	crdKind := schema.GroupKind{Group: "example.com", Kind: "Widget"}
+	_, err := updMgr.k8sRESTMapper.RESTMapping(crdKind, "v1")
+	testutil.AssertTrue(t, meta.IsNoMatchError(err))
+
+	// the kind of the applied custom resource definition is mapped once the discovery information is reset
+	k8sDiscoveryClient.Resources = append(k8sDiscoveryClient.Resources, &metav1.APIResourceList{
+		GroupVersion: "example.com/v1",
+		APIResources: []metav1.APIResource{{Name: "widgets", Kind: "Widget", Namespaced: true}},
+	})
+	_, err = updMgr.k8sRESTMapper.RESTMapping(crdKind, "v1")
+	testutil.AssertTrue(t, meta.IsNoMatchError(err))
+	updMgr.resetDiscovery()
+	mapping, err := updMgr.k8sRESTMapper.RESTMapping(crdKind, "v1")
+	testutil.AssertNil(t, err)
+	testutil.AssertEqual(t, "widgets", mapping.Resource.Resource)
+}
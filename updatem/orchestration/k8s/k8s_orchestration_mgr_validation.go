@@ -0,0 +1,77 @@
+// Copyright (c) 2022 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Apache License 2.0 which is available at
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/eclipse-kanto/container-management/containerm/log"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/kubectl/pkg/util/openapi"
+	"k8s.io/kubectl/pkg/util/openapi/validation"
+)
+
+// openAPIResourcesParser provides the OpenAPI schema of the k8s resources
+type openAPIResourcesParser interface {
+	Parse() (openapi.Resources, error)
+}
+
+// Validate checks each resource of the manifest against the OpenAPI schema from the cached discovery data and returns all validation errors.
+// The kinds, which are not known by the cluster yet, e.g. defined by the custom resource definitions in the manifest, are not validated.
+func (updMgr *k8sUpdateManager) Validate(ctx context.Context, mf []*unstructured.Unstructured) error {
+	updMgr.clientsLock.RLock()
+	parser := updMgr.k8sOpenAPIParser
+	updMgr.clientsLock.RUnlock()
+	if parser == nil {
+		log.Warn("the k8s OpenAPI schema is not available - the manifest will not be validated")
+		return nil
+	}
+	resources, err := parser.Parse()
+	if err != nil {
+		log.WarnErr(err, "cannot get the k8s OpenAPI schema - the manifest will not be validated")
+		return nil
+	}
+	schemaValidation := validation.NewSchemaValidation(resources)
+	validationErrors := []string{}
+	for _, u := range mf {
+		data, err := u.MarshalJSON()
+		if err != nil {
+			validationErrors = append(validationErrors, fmt.Sprintf("%s: %v", resourceID(u), err))
+			continue
+		}
+		err = schemaValidation.ValidateBytes(data)
+		if err == nil {
+			continue
+		}
+		if aggregate, ok := err.(utilerrors.Aggregate); ok {
+			for _, e := range aggregate.Errors() {
+				validationErrors = append(validationErrors, fmt.Sprintf("%s: %v", resourceID(u), e))
+			}
+		} else {
+			validationErrors = append(validationErrors, fmt.Sprintf("%s: %v", resourceID(u), err))
+		}
+	}
+	if len(validationErrors) > 0 {
+		return log.NewErrorf("the manifest is not valid: %s", strings.Join(validationErrors, "; "))
+	}
+	return nil
+}
+
+func resourceID(u *unstructured.Unstructured) string {
+	if u.GetNamespace() == "" {
+		return fmt.Sprintf("%s %s", u.GetKind(), u.GetName())
+	}
+	return fmt.Sprintf("%s %s/%s", u.GetKind(), u.GetNamespace(), u.GetName())
+}
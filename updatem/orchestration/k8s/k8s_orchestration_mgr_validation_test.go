@@ -0,0 +1,96 @@
+// Copyright (c) 2022 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Apache License 2.0 which is available at
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package k8s
+
+import (
+	"context"
+	"testing"
+
+	"github.com/eclipse-kanto/container-management/containerm/log"
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/pkg/testutil"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/kubectl/pkg/util/openapi"
+	openapitesting "k8s.io/kubectl/pkg/util/openapi/testing"
+)
+
+const testOpenAPISchema = "../../pkg/testutil/testdata/k8s/openapi.json"
+
+type testOpenAPIParser struct {
+	err error
+}
+
+func (parser *testOpenAPIParser) Parse() (openapi.Resources, error) {
+	if parser.err != nil {
+		return nil, parser.err
+	}
+	return openapitesting.NewFakeResources(testOpenAPISchema), nil
+}
+
+func newTestValidationDeployment(spec map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"name": "test-app", "namespace": "default"},
+		"spec":       spec,
+	}}
+}
+
+func TestValidate(t *testing.T) {
+	tests := map[string]struct {
+		parser         openAPIResourcesParser
+		mf             []*unstructured.Unstructured
+		expectedErrors []string
+	}{
+		"test_valid": {
+			parser: &testOpenAPIParser{},
+			mf: []*unstructured.Unstructured{
+				newTestValidationDeployment(map[string]interface{}{"replicas": int64(1), "selector": map[string]interface{}{"app": "test"}}),
+				// the kinds not known by the cluster are not validated
+				newTestManifestResource("sdv.eclipse.org/v1alpha1", "SelfUpdateBundle", "test-bundle", ""),
+			},
+		},
+		"test_invalid": {
+			parser: &testOpenAPIParser{},
+			mf: []*unstructured.Unstructured{
+				newTestValidationDeployment(map[string]interface{}{"replica": int64(1), "selector": map[string]interface{}{"app": "test"}}),
+				newTestValidationDeployment(map[string]interface{}{"replicas": "one"}),
+			},
+			expectedErrors: []string{
+				"Deployment default/test-app: ValidationError(Deployment.spec): unknown field \"replica\"",
+				"Deployment default/test-app: ValidationError(Deployment.spec.replicas): invalid type for io.k8s.api.apps.v1.DeploymentSpec.replicas: got \"string\", expected \"integer\"",
+				"Deployment default/test-app: ValidationError(Deployment.spec): missing required field \"selector\"",
+			},
+		},
+		"test_no_schema": {
+			parser: &testOpenAPIParser{err: log.NewError("test error")},
+			mf:     []*unstructured.Unstructured{newTestValidationDeployment(map[string]interface{}{"replica": int64(1)})},
+		},
+		"test_not_connected": {
+			mf: []*unstructured.Unstructured{newTestValidationDeployment(map[string]interface{}{"replica": int64(1)})},
+		},
+	}
+	for testName, testCase := range tests {
+		t.Run(testName, func(t *testing.T) {
+			t.Log(testName)
+			updMgr := &k8sUpdateManager{cfg: &mgrOpts{}, k8sOpenAPIParser: testCase.parser}
+			err := updMgr.Validate(context.Background(), testCase.mf)
+			if len(testCase.expectedErrors) == 0 {
+				testutil.AssertNil(t, err)
+				return
+			}
+			testutil.AssertNotNil(t, err)
+			for _, expectedError := range testCase.expectedErrors {
+				testutil.AssertContainsString(t, err.Error(), expectedError)
+			}
+		})
+	}
+}
@@ -0,0 +1,209 @@
+// Copyright (c) 2022 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Apache License 2.0 which is available at
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package kanto
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/eclipse-kanto/container-management/containerm/client"
+	"github.com/eclipse-kanto/container-management/containerm/containers/types"
+	"github.com/eclipse-kanto/container-management/containerm/log"
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/orchestration"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+type kantoUpdateManager struct {
+	cfg       *mgrOpts
+	client    client.Client
+	applyLock sync.Mutex
+}
+
+// Apply creates, recreates or removes the Kanto containers, so that they match the Pod resources of the manifest.
+// The containers of the pods missing from the manifest are removed only if the pruning is enabled.
+// The changed containers are recreated, as the Kanto container management can update only the restart policy and the resources of a container.
+func (updMgr *kantoUpdateManager) Apply(ctx context.Context, mf []*unstructured.Unstructured) interface{} {
+	updMgr.applyLock.Lock()
+	defer updMgr.applyLock.Unlock()
+
+	log.Debug("processing apply manifest - start")
+	pods, err := manifestPods(mf)
+	if err != nil {
+		log.ErrorErr(err, "cannot apply the manifest")
+		return &orchestration.ApplyResult{Err: err}
+	}
+	current, err := updMgr.managedContainers(ctx)
+	if err != nil {
+		log.ErrorErr(err, "cannot apply the manifest")
+		return &orchestration.ApplyResult{Err: log.NewErrorf("cannot get the containers from the Kanto container management: %v", err)}
+	}
+
+	dryRun := orchestration.IsUpdateMgrDryRunContext(ctx)
+	applyResult := &orchestration.ApplyResult{}
+	failed := []string{}
+	for _, p := range pods {
+		resourceResult := &orchestration.ResourceResult{
+			APIVersion: p.resource.GetAPIVersion(),
+			Kind:       p.resource.GetKind(),
+			Namespace:  p.resource.GetNamespace(),
+			Name:       p.resource.GetName(),
+		}
+		resourceResult.Outcome, err = updMgr.applyPod(ctx, p, current[p.key], dryRun)
+		if err != nil {
+			resourceResult.Outcome = orchestration.ResourceOutcomeFailed
+			resourceResult.Message = err.Error()
+			failed = append(failed, fmt.Sprintf("Pod %s: %v", p.key, err))
+		}
+		applyResult.Resources = append(applyResult.Resources, resourceResult)
+		delete(current, p.key)
+	}
+
+	// the reconciliation re-applies a subset of the desired manifest, so nothing must be pruned
+	if updMgr.cfg.prune && !orchestration.IsUpdateMgrReconcileContext(ctx) {
+		for _, key := range sortedPodKeys(current) {
+			resourceResult := &orchestration.ResourceResult{
+				APIVersion: "v1",
+				Kind:       "Pod",
+				Namespace:  key.namespace,
+				Name:       key.name,
+				Outcome:    orchestration.ResourceOutcomePruned,
+			}
+			if !dryRun {
+				if err := updMgr.removeContainers(ctx, current[key]); err != nil {
+					resourceResult.Outcome = orchestration.ResourceOutcomeFailed
+					resourceResult.Message = err.Error()
+					failed = append(failed, fmt.Sprintf("Pod %s: %v", key, err))
+				}
+			}
+			applyResult.Resources = append(applyResult.Resources, resourceResult)
+		}
+	}
+
+	if len(failed) > 0 {
+		applyResult.Err = log.NewErrorf("cannot apply the manifest: %s", strings.Join(failed, "; "))
+	}
+	log.Debug("processing apply manifest - done")
+	return applyResult
+}
+
+// Get returns the containers managed by the update manager as Pod resources
+func (updMgr *kantoUpdateManager) Get(ctx context.Context) []*unstructured.Unstructured {
+	result := []*unstructured.Unstructured{}
+	current, err := updMgr.managedContainers(ctx)
+	if err != nil {
+		log.ErrorErr(err, "cannot get the containers from the Kanto container management")
+		return result
+	}
+	for _, key := range sortedPodKeys(current) {
+		result = append(result, toPodResource(key, current[key]))
+	}
+	return result
+}
+
+func (updMgr *kantoUpdateManager) Dispose(ctx context.Context) error {
+	return updMgr.client.Dispose()
+}
+
+// managedContainers returns the containers created by the update manager grouped by pod
+func (updMgr *kantoUpdateManager) managedContainers(ctx context.Context) (map[podKey][]*types.Container, error) {
+	containers, err := updMgr.client.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pods := map[podKey][]*types.Container{}
+	for _, ctr := range containers {
+		if key, ok := containerPod(ctr); ok {
+			pods[key] = append(pods[key], ctr)
+		}
+	}
+	return pods, nil
+}
+
+// applyPod recreates the changed containers of the pod and removes the ones that are no longer part of it
+func (updMgr *kantoUpdateManager) applyPod(ctx context.Context, p *pod, existing []*types.Container, dryRun bool) (orchestration.ResourceOutcome, error) {
+	existingByName := map[string]*types.Container{}
+	for _, ctr := range existing {
+		existingByName[ctr.Name] = ctr
+	}
+	changed := []*types.Container{}
+	for _, desired := range p.containers {
+		ctr, ok := existingByName[desired.Name]
+		if ok && !containerChanged(ctr, desired) {
+			delete(existingByName, desired.Name)
+			continue
+		}
+		changed = append(changed, desired)
+	}
+	if len(changed) == 0 && len(existingByName) == 0 {
+		return orchestration.ResourceOutcomeUnchanged, nil
+	}
+	outcome := orchestration.ResourceOutcomeConfigured
+	if len(existing) == 0 {
+		outcome = orchestration.ResourceOutcomeCreated
+	}
+	if dryRun {
+		return outcome, nil
+	}
+
+	obsolete := []*types.Container{}
+	for _, ctr := range existingByName {
+		obsolete = append(obsolete, ctr)
+	}
+	if err := updMgr.removeContainers(ctx, obsolete); err != nil {
+		return outcome, err
+	}
+	for _, desired := range changed {
+		ctr, err := updMgr.client.Create(ctx, desired)
+		if err != nil {
+			return outcome, log.NewErrorf("cannot create container %s: %v", desired.Name, err)
+		}
+		if err := updMgr.client.Start(ctx, ctr.ID); err != nil {
+			return outcome, log.NewErrorf("cannot start container %s: %v", desired.Name, err)
+		}
+		log.Debug("container %s with ID %s is started", ctr.Name, ctr.ID)
+	}
+	return outcome, nil
+}
+
+// removeContainers stops the running containers gracefully within the stop timeout and removes them
+func (updMgr *kantoUpdateManager) removeContainers(ctx context.Context, containers []*types.Container) error {
+	for _, ctr := range containers {
+		if ctr.State != nil && ctr.State.Running && updMgr.cfg.stopTimeout > 0 {
+			stopOpts := &types.StopOpts{Timeout: int64(updMgr.cfg.stopTimeout.Seconds()), Force: true}
+			if err := updMgr.client.Stop(ctx, ctr.ID, stopOpts); err != nil {
+				log.WarnErr(err, "cannot stop container %s gracefully", ctr.Name)
+			}
+		}
+		if err := updMgr.client.Remove(ctx, ctr.ID, true); err != nil {
+			return log.NewErrorf("cannot remove container %s: %v", ctr.Name, err)
+		}
+		log.Debug("container %s with ID %s is removed", ctr.Name, ctr.ID)
+	}
+	return nil
+}
+
+func sortedPodKeys(pods map[podKey][]*types.Container) []podKey {
+	keys := []podKey{}
+	for key := range pods {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].namespace != keys[j].namespace {
+			return keys[i].namespace < keys[j].namespace
+		}
+		return keys[i].name < keys[j].name
+	})
+	return keys
+}
@@ -0,0 +1,417 @@
+// Copyright (c) 2022 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Apache License 2.0 which is available at
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package kanto
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/eclipse-kanto/container-management/containerm/containers/types"
+	"github.com/eclipse-kanto/container-management/containerm/log"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	// podNameEnv is set in the containers created for a pod - it marks the containers managed by the update manager and groups them by pod
+	podNameEnv = "SDV_POD_NAME"
+	// podNamespaceEnv is set in the containers created for a pod - the containers without it belong to a pod in the default namespace
+	podNamespaceEnv = "SDV_POD_NAMESPACE"
+	// containerNameSeparator separates the namespace, the pod and the container name in the Kanto container name, it is not allowed in k8s names
+	containerNameSeparator = "_"
+
+	hostIPDefault = "0.0.0.0"
+	protoDefault  = "tcp"
+
+	kb = 1024
+	mb = 1024 * kb
+)
+
+// podKey identifies a pod by its namespace and name
+type podKey struct {
+	namespace string
+	name      string
+}
+
+func (key podKey) String() string {
+	return key.namespace + "/" + key.name
+}
+
+// pod holds the Kanto containers translated from a Pod resource of the update manifest
+type pod struct {
+	key        podKey
+	resource   *unstructured.Unstructured
+	containers []*types.Container
+}
+
+// manifestPods translates the Pod resources of the manifest to Kanto containers.
+// Only Pod resources are supported, as the Kanto container management has no controllers for the other k8s workloads.
+func manifestPods(mf []*unstructured.Unstructured) ([]*pod, error) {
+	pods := []*pod{}
+	keys := map[podKey]bool{}
+	for _, u := range mf {
+		if u.GetAPIVersion() != "v1" || u.GetKind() != "Pod" {
+			return nil, log.NewErrorf("%s %s is not supported by the Kanto container management - only v1 Pod resources can be applied", u.GetKind(), u.GetName())
+		}
+		key := podKey{namespace: u.GetNamespace(), name: u.GetName()}
+		if key.namespace == "" {
+			key.namespace = metav1.NamespaceDefault
+		}
+		if keys[key] {
+			return nil, log.NewErrorf("more than one Pod %s in the YAML manifest", key)
+		}
+		keys[key] = true
+		containers, err := podContainers(u)
+		if err != nil {
+			return nil, err
+		}
+		pods = append(pods, &pod{key: key, resource: u, containers: containers})
+	}
+	return pods, nil
+}
+
+// podContainers translates the containers of a Pod resource to Kanto container definitions
+func podContainers(u *unstructured.Unstructured) ([]*types.Container, error) {
+	p := &corev1.Pod{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, p); err != nil {
+		return nil, log.NewErrorf("invalid Pod %s: %v", u.GetName(), err)
+	}
+	if p.Name == "" {
+		return nil, log.NewError("the Pod name is not set")
+	}
+	if p.Namespace == "" {
+		p.Namespace = metav1.NamespaceDefault
+	}
+	if len(p.Spec.InitContainers) > 0 {
+		return nil, log.NewErrorf("the init containers of Pod %s are not supported by the Kanto container management", p.Name)
+	}
+	if len(p.Spec.Containers) == 0 {
+		return nil, log.NewErrorf("the Pod %s has no containers", p.Name)
+	}
+	volumes := map[string]corev1.Volume{}
+	for _, volume := range p.Spec.Volumes {
+		if volume.HostPath == nil {
+			return nil, log.NewErrorf("the volume %s of Pod %s is not supported by the Kanto container management - only hostPath volumes can be mounted", volume.Name, p.Name)
+		}
+		volumes[volume.Name] = volume
+	}
+	restartPolicy, err := toRestartPolicy(p.Spec.RestartPolicy)
+	if err != nil {
+		return nil, err
+	}
+	networkMode := types.NetworkModeBridge
+	if p.Spec.HostNetwork {
+		networkMode = types.NetworkModeHost
+	}
+	hostName := p.Spec.Hostname
+	if hostName == "" {
+		hostName = p.Name
+	}
+	extraHosts := []string{}
+	for _, hostAlias := range p.Spec.HostAliases {
+		for _, host := range hostAlias.Hostnames {
+			extraHosts = append(extraHosts, host+":"+hostAlias.IP)
+		}
+	}
+
+	containers := []*types.Container{}
+	for _, c := range p.Spec.Containers {
+		if len(c.Command) > 0 || len(c.Args) > 0 {
+			return nil, log.NewErrorf("the command and args of container %s in Pod %s are not supported by the Kanto container management", c.Name, p.Name)
+		}
+		env := []string{}
+		for _, envVar := range c.Env {
+			if envVar.ValueFrom != nil {
+				return nil, log.NewErrorf("the environment variable %s of container %s in Pod %s must have a value", envVar.Name, c.Name, p.Name)
+			}
+			env = append(env, envVar.Name+"="+envVar.Value)
+		}
+		env = append(env, podNameEnv+"="+p.Name, podNamespaceEnv+"="+p.Namespace)
+
+		mounts := []types.MountPoint{}
+		for _, volumeMount := range c.VolumeMounts {
+			volume, ok := volumes[volumeMount.Name]
+			if !ok {
+				return nil, log.NewErrorf("the volume %s mounted in container %s is not defined in Pod %s", volumeMount.Name, c.Name, p.Name)
+			}
+			if volumeMount.ReadOnly {
+				log.Warn("the volume %s is mounted read-write in container %s of Pod %s, as read-only mounts are not supported by the Kanto container management", volumeMount.Name, c.Name, p.Name)
+			}
+			mounts = append(mounts, types.MountPoint{
+				Destination:     volumeMount.MountPath,
+				Source:          volume.HostPath.Path,
+				PropagationMode: toPropagationMode(volumeMount.MountPropagation),
+			})
+		}
+
+		portMappings := []types.PortMapping{}
+		if !p.Spec.HostNetwork {
+			for _, port := range c.Ports {
+				if port.HostPort == 0 {
+					continue
+				}
+				portMappings = append(portMappings, toPortMapping(port))
+			}
+		}
+
+		resources, err := toResources(c.Resources)
+		if err != nil {
+			return nil, log.NewErrorf("invalid resources of container %s in Pod %s: %v", c.Name, p.Name, err)
+		}
+
+		containers = append(containers, &types.Container{
+			Name:     strings.Join([]string{p.Namespace, p.Name, c.Name}, containerNameSeparator),
+			Image:    types.Image{Name: c.Image},
+			HostName: hostName,
+			Mounts:   mounts,
+			Config:   &types.ContainerConfiguration{Env: env},
+			HostConfig: &types.HostConfig{
+				NetworkMode:   networkMode,
+				Privileged:    c.SecurityContext != nil && c.SecurityContext.Privileged != nil && *c.SecurityContext.Privileged,
+				RestartPolicy: restartPolicy,
+				ExtraHosts:    extraHosts,
+				PortMappings:  portMappings,
+				Resources:     resources,
+			},
+		})
+	}
+	return containers, nil
+}
+
+func toRestartPolicy(restartPolicy corev1.RestartPolicy) (*types.RestartPolicy, error) {
+	switch restartPolicy {
+	case corev1.RestartPolicyAlways, "":
+		return &types.RestartPolicy{Type: types.Always}, nil
+	case corev1.RestartPolicyOnFailure:
+		return &types.RestartPolicy{Type: types.OnFailure}, nil
+	case corev1.RestartPolicyNever:
+		return &types.RestartPolicy{Type: types.No}, nil
+	default:
+		return nil, log.NewErrorf("unsupported restart policy %s", restartPolicy)
+	}
+}
+
+func toPropagationMode(mountPropagation *corev1.MountPropagationMode) string {
+	if mountPropagation == nil {
+		return types.RPrivatePropagationMode
+	}
+	switch *mountPropagation {
+	case corev1.MountPropagationHostToContainer:
+		return types.RSlavePropagationMode
+	case corev1.MountPropagationBidirectional:
+		return types.RSharedPropagationMode
+	default:
+		return types.RPrivatePropagationMode
+	}
+}
+
+// toPortMapping sets the defaults of the Kanto container management explicitly, so that the applied port mappings can be compared
+func toPortMapping(port corev1.ContainerPort) types.PortMapping {
+	proto := strings.ToLower(string(port.Protocol))
+	if proto == "" {
+		proto = protoDefault
+	}
+	hostIP := port.HostIP
+	if hostIP == "" {
+		hostIP = hostIPDefault
+	}
+	return types.PortMapping{
+		Proto:         proto,
+		ContainerPort: uint16(port.ContainerPort),
+		HostIP:        hostIP,
+		HostPort:      uint16(port.HostPort),
+		HostPortEnd:   uint16(port.HostPort),
+	}
+}
+
+// toResources converts the memory limit and request of the container, the CPU resources are not supported by the Kanto container management
+func toResources(requirements corev1.ResourceRequirements) (*types.Resources, error) {
+	limit, hasLimit := requirements.Limits[corev1.ResourceMemory]
+	request, hasRequest := requirements.Requests[corev1.ResourceMemory]
+	if !hasLimit && !hasRequest {
+		return nil, nil
+	}
+	resources := &types.Resources{}
+	if hasLimit {
+		resources.Memory = toSize(limit)
+	}
+	if hasRequest {
+		resources.MemoryReservation = toSize(request)
+	}
+	if hasLimit && hasRequest && request.Cmp(limit) > 0 {
+		return nil, log.NewErrorf("the memory request %s is greater than the limit %s", request.String(), limit.String())
+	}
+	return resources, nil
+}
+
+// toSize converts the quantity to the size format of the Kanto container management, which supports only k, m and g units
+func toSize(quantity resource.Quantity) string {
+	bytes := quantity.Value()
+	if bytes%mb == 0 {
+		return fmt.Sprintf("%dm", bytes/mb)
+	}
+	return fmt.Sprintf("%dk", (bytes+kb-1)/kb)
+}
+
+// containerPod returns the pod, to which the container belongs, or false if the container is not managed by the update manager
+func containerPod(ctr *types.Container) (podKey, bool) {
+	key := podKey{namespace: metav1.NamespaceDefault}
+	if ctr.Config == nil {
+		return key, false
+	}
+	managed := false
+	for _, env := range ctr.Config.Env {
+		if strings.HasPrefix(env, podNameEnv+"=") {
+			key.name = strings.TrimPrefix(env, podNameEnv+"=")
+			managed = true
+		} else if strings.HasPrefix(env, podNamespaceEnv+"=") {
+			key.namespace = strings.TrimPrefix(env, podNamespaceEnv+"=")
+		}
+	}
+	return key, managed
+}
+
+// containerSpec holds the container configuration set from the Pod resource, the rest is set by the Kanto container management
+type containerSpec struct {
+	Image             string
+	Env               []string
+	HostName          string
+	Mounts            []types.MountPoint
+	NetworkMode       types.NetworkMode
+	Privileged        bool
+	RestartPolicy     types.PolicyType
+	ExtraHosts        []string
+	PortMappings      []types.PortMapping
+	Memory            string
+	MemoryReservation string
+}
+
+func toContainerSpec(ctr *types.Container) *containerSpec {
+	spec := &containerSpec{
+		Image:    ctr.Image.Name,
+		HostName: ctr.HostName,
+	}
+	if len(ctr.Mounts) > 0 {
+		spec.Mounts = ctr.Mounts
+	}
+	if ctr.Config != nil && len(ctr.Config.Env) > 0 {
+		spec.Env = ctr.Config.Env
+	}
+	if hostConfig := ctr.HostConfig; hostConfig != nil {
+		spec.NetworkMode = hostConfig.NetworkMode
+		spec.Privileged = hostConfig.Privileged
+		if hostConfig.RestartPolicy != nil {
+			spec.RestartPolicy = hostConfig.RestartPolicy.Type
+		}
+		if len(hostConfig.ExtraHosts) > 0 {
+			spec.ExtraHosts = hostConfig.ExtraHosts
+		}
+		if len(hostConfig.PortMappings) > 0 {
+			spec.PortMappings = hostConfig.PortMappings
+		}
+		if hostConfig.Resources != nil {
+			spec.Memory = hostConfig.Resources.Memory
+			spec.MemoryReservation = hostConfig.Resources.MemoryReservation
+		}
+	}
+	return spec
+}
+
+// containerChanged checks if the existing container differs from the desired one and has to be recreated
+func containerChanged(existing, desired *types.Container) bool {
+	return !reflect.DeepEqual(toContainerSpec(existing), toContainerSpec(desired))
+}
+
+// toPodResource reports the containers of a pod as Pod resource with container statuses
+func toPodResource(key podKey, containers []*types.Container) *unstructured.Unstructured {
+	sort.Slice(containers, func(i, j int) bool {
+		return containers[i].Name < containers[j].Name
+	})
+	specContainers := []interface{}{}
+	containerStatuses := []interface{}{}
+	phases := map[corev1.PodPhase]bool{}
+	for _, ctr := range containers {
+		// the container name is the last part of the Kanto container name, as it cannot contain the separator
+		containerName := ctr.Name[strings.LastIndex(ctr.Name, containerNameSeparator)+1:]
+		specContainers = append(specContainers, map[string]interface{}{
+			"name":  containerName,
+			"image": ctr.Image.Name,
+		})
+		state, phase := toContainerState(ctr)
+		phases[phase] = true
+		containerStatuses = append(containerStatuses, map[string]interface{}{
+			"name":         containerName,
+			"image":        ctr.Image.Name,
+			"containerID":  ctr.ID,
+			"ready":        phase == corev1.PodRunning,
+			"restartCount": int64(ctr.RestartCount),
+			"state":        state,
+		})
+	}
+	u := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"containers": specContainers,
+		},
+		"status": map[string]interface{}{
+			"phase":             string(toPodPhase(phases)),
+			"containerStatuses": containerStatuses,
+		},
+	}}
+	u.SetAPIVersion("v1")
+	u.SetKind("Pod")
+	u.SetName(key.name)
+	u.SetNamespace(key.namespace)
+	return u
+}
+
+// toContainerState converts the Kanto container state to a k8s container state and the phase, which the container contributes to the pod
+func toContainerState(ctr *types.Container) (map[string]interface{}, corev1.PodPhase) {
+	if ctr.State == nil {
+		return map[string]interface{}{"waiting": map[string]interface{}{"reason": types.Unknown.String()}}, corev1.PodUnknown
+	}
+	switch ctr.State.Status {
+	case types.Running, types.Paused:
+		return map[string]interface{}{"running": map[string]interface{}{"startedAt": ctr.State.StartedAt}}, corev1.PodRunning
+	case types.Creating, types.Created:
+		return map[string]interface{}{"waiting": map[string]interface{}{"reason": ctr.State.Status.String()}}, corev1.PodPending
+	case types.Stopped, types.Exited, types.Dead:
+		terminated := map[string]interface{}{
+			"reason":     ctr.State.Status.String(),
+			"exitCode":   ctr.State.ExitCode,
+			"finishedAt": ctr.State.FinishedAt,
+		}
+		if ctr.State.Error != "" {
+			terminated["message"] = ctr.State.Error
+		}
+		if ctr.State.ExitCode != 0 || ctr.State.Status == types.Dead {
+			return map[string]interface{}{"terminated": terminated}, corev1.PodFailed
+		}
+		return map[string]interface{}{"terminated": terminated}, corev1.PodSucceeded
+	default:
+		return map[string]interface{}{"waiting": map[string]interface{}{"reason": ctr.State.Status.String()}}, corev1.PodUnknown
+	}
+}
+
+// toPodPhase summarizes the phases of the pod containers
+func toPodPhase(phases map[corev1.PodPhase]bool) corev1.PodPhase {
+	for _, phase := range []corev1.PodPhase{corev1.PodFailed, corev1.PodUnknown, corev1.PodPending, corev1.PodRunning} {
+		if phases[phase] {
+			return phase
+		}
+	}
+	return corev1.PodSucceeded
+}
@@ -0,0 +1,257 @@
+// Copyright (c) 2022 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Apache License 2.0 which is available at
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package kanto
+
+import (
+	"testing"
+
+	"github.com/eclipse-kanto/container-management/containerm/containers/types"
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/pkg/testutil"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+const testPod = `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: test-app
+spec:
+  restartPolicy: OnFailure
+  hostAliases:
+  - ip: 127.0.0.1
+    hostnames:
+    - databroker
+  volumes:
+  - name: data
+    hostPath:
+      path: /data/test-app
+  containers:
+  - name: app
+    image: ghcr.io/eclipse-leda/test-app:1.0.0
+    env:
+    - name: LOG_LEVEL
+      value: debug
+    ports:
+    - containerPort: 8080
+      hostPort: 30080
+    - containerPort: 9090
+    volumeMounts:
+    - name: data
+      mountPath: /data
+    resources:
+      limits:
+        memory: 256Mi
+      requests:
+        memory: 100M
+    securityContext:
+      privileged: true
+  - name: sidecar
+    image: ghcr.io/eclipse-leda/test-sidecar:1.0.0
+`
+
+func newTestPodResource(t *testing.T, content string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	testutil.AssertNil(t, yaml.Unmarshal([]byte(content), &u.Object))
+	return u
+}
+
+func TestPodContainers(t *testing.T) {
+	containers, err := podContainers(newTestPodResource(t, testPod))
+	testutil.AssertNil(t, err)
+	testutil.AssertEqual(t, 2, len(containers))
+
+	testutil.AssertEqual(t, &types.Container{
+		Name:     "default_test-app_app",
+		Image:    types.Image{Name: "ghcr.io/eclipse-leda/test-app:1.0.0"},
+		HostName: "test-app",
+		Mounts:   []types.MountPoint{{Destination: "/data", Source: "/data/test-app", PropagationMode: types.RPrivatePropagationMode}},
+		Config:   &types.ContainerConfiguration{Env: []string{"LOG_LEVEL=debug", "SDV_POD_NAME=test-app", "SDV_POD_NAMESPACE=default"}},
+		HostConfig: &types.HostConfig{
+			NetworkMode:   types.NetworkModeBridge,
+			Privileged:    true,
+			RestartPolicy: &types.RestartPolicy{Type: types.OnFailure},
+			ExtraHosts:    []string{"databroker:127.0.0.1"},
+			PortMappings:  []types.PortMapping{{Proto: "tcp", ContainerPort: 8080, HostIP: "0.0.0.0", HostPort: 30080, HostPortEnd: 30080}},
+			Resources:     &types.Resources{Memory: "256m", MemoryReservation: "97657k"},
+		},
+	}, containers[0])
+
+	testutil.AssertEqual(t, "default_test-app_sidecar", containers[1].Name)
+	testutil.AssertEqual(t, []string{"SDV_POD_NAME=test-app", "SDV_POD_NAMESPACE=default"}, containers[1].Config.Env)
+	testutil.AssertEqual(t, 0, len(containers[1].Mounts))
+	testutil.AssertNil(t, containers[1].HostConfig.Resources)
+
+	key, ok := containerPod(containers[1])
+	testutil.AssertTrue(t, ok)
+	testutil.AssertEqual(t, podKey{namespace: "default", name: "test-app"}, key)
+
+	// the containers created without the namespace belong to a pod in the default namespace
+	key, ok = containerPod(&types.Container{Name: "test-app_app", Config: &types.ContainerConfiguration{Env: []string{"SDV_POD_NAME=test-app"}}})
+	testutil.AssertTrue(t, ok)
+	testutil.AssertEqual(t, podKey{namespace: "default", name: "test-app"}, key)
+}
+
+func TestManifestPodsErrors(t *testing.T) {
+	tests := map[string]struct {
+		manifest    string
+		expectedErr string
+	}{
+		"test_unsupported_kind": {
+			manifest: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: test-app
+`,
+			expectedErr: "Deployment test-app is not supported by the Kanto container management",
+		},
+		"test_unsupported_volume": {
+			manifest: `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: test-app
+spec:
+  volumes:
+  - name: config
+    configMap:
+      name: test-config
+  containers:
+  - name: app
+    image: test-app:1.0.0
+`,
+			expectedErr: "the volume config of Pod test-app is not supported",
+		},
+		"test_unsupported_command": {
+			manifest: `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: test-app
+spec:
+  containers:
+  - name: app
+    image: test-app:1.0.0
+    command: ["/bin/sh"]
+`,
+			expectedErr: "the command and args of container app in Pod test-app are not supported",
+		},
+		"test_env_value_from": {
+			manifest: `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: test-app
+spec:
+  containers:
+  - name: app
+    image: test-app:1.0.0
+    env:
+    - name: NODE_NAME
+      valueFrom:
+        fieldRef:
+          fieldPath: spec.nodeName
+`,
+			expectedErr: "the environment variable NODE_NAME of container app in Pod test-app must have a value",
+		},
+		"test_no_containers": {
+			manifest: `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: test-app
+`,
+			expectedErr: "the Pod test-app has no containers",
+		},
+	}
+	for testName, testCase := range tests {
+		t.Run(testName, func(t *testing.T) {
+			t.Log(testName)
+			pods, err := manifestPods([]*unstructured.Unstructured{newTestPodResource(t, testCase.manifest)})
+			testutil.AssertNil(t, pods)
+			testutil.AssertNotNil(t, err)
+			testutil.AssertContainsString(t, err.Error(), testCase.expectedErr)
+		})
+	}
+}
+
+func TestManifestPodsDuplicate(t *testing.T) {
+	pod := newTestPodResource(t, testPod)
+	_, err := manifestPods([]*unstructured.Unstructured{pod, pod})
+	testutil.AssertNotNil(t, err)
+	testutil.AssertContainsString(t, err.Error(), "more than one Pod default/test-app")
+
+	// the pods with the same name in different namespaces are different pods
+	other := pod.DeepCopy()
+	other.SetNamespace("vehicle")
+	pods, err := manifestPods([]*unstructured.Unstructured{pod, other})
+	testutil.AssertNil(t, err)
+	testutil.AssertEqual(t, podKey{namespace: "vehicle", name: "test-app"}, pods[1].key)
+	testutil.AssertEqual(t, "vehicle_test-app_app", pods[1].containers[0].Name)
+}
+
+func TestToSize(t *testing.T) {
+	testutil.AssertEqual(t, "256m", toSize(resource.MustParse("256Mi")))
+	testutil.AssertEqual(t, "1024m", toSize(resource.MustParse("1Gi")))
+	testutil.AssertEqual(t, "977k", toSize(resource.MustParse("1M")))
+}
+
+func TestContainerChanged(t *testing.T) {
+	containers, err := podContainers(newTestPodResource(t, testPod))
+	testutil.AssertNil(t, err)
+	desired := containers[1]
+
+	// the defaults set by the Kanto container management are not compared
+	existing := &types.Container{
+		ID:         "test-id",
+		Name:       desired.Name,
+		Image:      desired.Image,
+		DomainName: "test-app_sidecar-domain",
+		HostName:   desired.HostName,
+		Mounts:     []types.MountPoint{},
+		Config:     &types.ContainerConfiguration{Env: desired.Config.Env},
+		HostConfig: &types.HostConfig{
+			NetworkMode:   types.NetworkModeBridge,
+			RestartPolicy: &types.RestartPolicy{Type: types.OnFailure},
+			ExtraHosts:    desired.HostConfig.ExtraHosts,
+			LogConfig:     &types.LogConfiguration{},
+		},
+		State: &types.State{Running: true, Status: types.Running},
+	}
+	testutil.AssertFalse(t, containerChanged(existing, desired))
+
+	existing.Image.Name = "ghcr.io/eclipse-leda/test-sidecar:0.9.0"
+	testutil.AssertTrue(t, containerChanged(existing, desired))
+}
+
+func TestToPodResource(t *testing.T) {
+	pod := toPodResource(podKey{namespace: "vehicle", name: "test-app"}, []*types.Container{
+		{ID: "id-2", Name: "vehicle_test-app_sidecar", Image: types.Image{Name: "sidecar:1.0.0"}, State: &types.State{Status: types.Exited, ExitCode: 1}, RestartCount: 2},
+		{ID: "id-1", Name: "vehicle_test-app_app", Image: types.Image{Name: "app:1.0.0"}, State: &types.State{Status: types.Running, Running: true}},
+	})
+	testutil.AssertEqual(t, "Pod", pod.GetKind())
+	testutil.AssertEqual(t, "test-app", pod.GetName())
+	testutil.AssertEqual(t, "vehicle", pod.GetNamespace())
+	phase, _, _ := unstructured.NestedString(pod.Object, "status", "phase")
+	testutil.AssertEqual(t, "Failed", phase)
+
+	statuses, _, _ := unstructured.NestedSlice(pod.Object, "status", "containerStatuses")
+	testutil.AssertEqual(t, 2, len(statuses))
+	testutil.AssertEqual(t, "app", statuses[0].(map[string]interface{})["name"])
+	testutil.AssertEqual(t, true, statuses[0].(map[string]interface{})["ready"])
+	testutil.AssertEqual(t, "sidecar", statuses[1].(map[string]interface{})["name"])
+	testutil.AssertEqual(t, int64(2), statuses[1].(map[string]interface{})["restartCount"])
+	exitCode, _, _ := unstructured.NestedInt64(statuses[1].(map[string]interface{}), "state", "terminated", "exitCode")
+	testutil.AssertEqual(t, int64(1), exitCode)
+}
@@ -0,0 +1,38 @@
+// Copyright (c) 2022 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Apache License 2.0 which is available at
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package kanto
+
+import (
+	"github.com/eclipse-kanto/container-management/containerm/client"
+	"github.com/eclipse-kanto/container-management/containerm/log"
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/orchestration"
+)
+
+// NewKantoUpdateManager instantiates a new update manager, which applies the Pod resources as Kanto containers
+func NewKantoUpdateManager(opts []MgrOpt) (orchestration.UpdateManager, error) {
+	var (
+		cfg = &mgrOpts{}
+	)
+	if err := applyOptsMgr(cfg, opts...); err != nil {
+		return nil, err
+	}
+
+	kantoClient, err := client.New(cfg.connectionAddress)
+	if err != nil {
+		return nil, log.NewErrorf("error connecting to the Kanto container management at %s: %v", cfg.connectionAddress, err)
+	}
+
+	return &kantoUpdateManager{
+		cfg:    cfg,
+		client: kantoClient,
+	}, nil
+}
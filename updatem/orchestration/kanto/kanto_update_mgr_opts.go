@@ -0,0 +1,71 @@
+// Copyright (c) 2022 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Apache License 2.0 which is available at
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package kanto
+
+import (
+	"time"
+
+	"github.com/eclipse-kanto/container-management/containerm/log"
+)
+
+// MgrOpt defines the creation configuration options for a Kanto container management orchestration implementation
+type MgrOpt func(mgrOptions *mgrOpts) error
+
+type mgrOpts struct {
+	connectionAddress string
+	stopTimeout       time.Duration
+	prune             bool
+}
+
+func applyOptsMgr(mgrOpts *mgrOpts, opts ...MgrOpt) error {
+	for _, o := range opts {
+		if err := o(mgrOpts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WithConnectionAddress configures the address of the Kanto container management local API, e.g. /run/container-management/container-management.sock
+func WithConnectionAddress(connectionAddress string) MgrOpt {
+	return func(mgrOptions *mgrOpts) error {
+		if connectionAddress == "" {
+			return log.NewError("the Kanto container management address is not set")
+		}
+		mgrOptions.connectionAddress = connectionAddress
+		return nil
+	}
+}
+
+// WithStopTimeout configures how long to wait for a container to stop gracefully before it is killed and removed
+func WithStopTimeout(stopTimeout string) MgrOpt {
+	return func(mgrOptions *mgrOpts) error {
+		if stopTimeout == "" {
+			mgrOptions.stopTimeout = 0
+			return nil
+		}
+		timeout, err := time.ParseDuration(stopTimeout)
+		if err != nil || timeout < 0 {
+			return log.NewErrorf("invalid stop timeout %s", stopTimeout)
+		}
+		mgrOptions.stopTimeout = timeout
+		return nil
+	}
+}
+
+// WithPrune configures whether the containers created by a previous manifest and missing from the current one are removed
+func WithPrune(prune bool) MgrOpt {
+	return func(mgrOptions *mgrOpts) error {
+		mgrOptions.prune = prune
+		return nil
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2022 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Apache License 2.0 which is available at
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package kanto
+
+import (
+	"testing"
+	"time"
+
+	"github.com/eclipse-kanto/container-management/containerm/log"
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/pkg/testutil"
+)
+
+func TestMgrOpts(t *testing.T) {
+	testCases := map[string]struct {
+		opts         []MgrOpt
+		expectedOpts *mgrOpts
+		expectedErr  error
+	}{
+		"test_no_error": {
+			opts: []MgrOpt{
+				WithConnectionAddress("/run/container-management/container-management.sock"),
+				WithStopTimeout("30s"),
+				WithPrune(true),
+			},
+			expectedOpts: &mgrOpts{
+				connectionAddress: "/run/container-management/container-management.sock",
+				stopTimeout:       30 * time.Second,
+				prune:             true,
+			},
+		},
+		"test_error_address": {
+			opts:         []MgrOpt{WithConnectionAddress("")},
+			expectedOpts: &mgrOpts{},
+			expectedErr:  log.NewError("the Kanto container management address is not set"),
+		},
+		"test_error_stop_timeout": {
+			opts:         []MgrOpt{WithStopTimeout("30 seconds")},
+			expectedOpts: &mgrOpts{},
+			expectedErr:  log.NewError("invalid stop timeout 30 seconds"),
+		},
+	}
+	for testCaseName, testCase := range testCases {
+		t.Run(testCaseName, func(t *testing.T) {
+			t.Log(testCaseName)
+			actualOpts := &mgrOpts{}
+			err := applyOptsMgr(actualOpts, testCase.opts...)
+			testutil.AssertError(t, testCase.expectedErr, err)
+			testutil.AssertEqual(t, testCase.expectedOpts, actualOpts)
+		})
+	}
+}
@@ -0,0 +1,230 @@
+// Copyright (c) 2022 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Apache License 2.0 which is available at
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package kanto
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/eclipse-kanto/container-management/containerm/containers/types"
+	"github.com/eclipse-kanto/container-management/containerm/log"
+	mocksclient "github.com/eclipse-kanto/container-management/containerm/pkg/testutil/mocks/client"
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/orchestration"
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/pkg/testutil"
+	"github.com/golang/mock/gomock"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func newTestContainer(t *testing.T, id, name string, running bool) *types.Container {
+	containers, err := podContainers(newTestPodResource(t, testPod))
+	testutil.AssertNil(t, err)
+	for _, ctr := range containers {
+		if ctr.Name == name {
+			ctr.ID = id
+			ctr.State = &types.State{Running: running, Status: types.Running}
+			return ctr
+		}
+	}
+	t.Fatalf("unknown test container %s", name)
+	return nil
+}
+
+func newTestKantoUpdateManager(mockClient *mocksclient.MockClient) *kantoUpdateManager {
+	return &kantoUpdateManager{
+		cfg:    &mgrOpts{stopTimeout: 10 * time.Second, prune: true},
+		client: mockClient,
+	}
+}
+
+func TestApply(t *testing.T) {
+	testPodResource := newTestPodResource(t, testPod)
+	obsoleteContainer := &types.Container{
+		ID:     "obsolete-id",
+		Name:   "default_obsolete-app_app",
+		Config: &types.ContainerConfiguration{Env: []string{"SDV_POD_NAME=obsolete-app", "SDV_POD_NAMESPACE=default"}},
+		State:  &types.State{Status: types.Exited},
+	}
+	otherContainer := &types.Container{ID: "other-id", Name: "other"}
+
+	tests := map[string]struct {
+		ctx              context.Context
+		noPrune          bool
+		existing         func(t *testing.T) []*types.Container
+		mockExecution    func(mockClient *mocksclient.MockClient)
+		expectedOutcomes []orchestration.ResourceOutcome
+		expectedErr      string
+	}{
+		"test_create": {
+			ctx: context.Background(),
+			existing: func(t *testing.T) []*types.Container {
+				return []*types.Container{otherContainer}
+			},
+			mockExecution: func(mockClient *mocksclient.MockClient) {
+				for _, name := range []string{"default_test-app_app", "default_test-app_sidecar"} {
+					name, id := name, name+"-id"
+					create := mockClient.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, ctr *types.Container) (*types.Container, error) {
+						testutil.AssertEqual(t, name, ctr.Name)
+						return &types.Container{ID: id, Name: ctr.Name}, nil
+					})
+					mockClient.EXPECT().Start(gomock.Any(), id).Return(nil).After(create)
+				}
+			},
+			expectedOutcomes: []orchestration.ResourceOutcome{orchestration.ResourceOutcomeCreated},
+		},
+		"test_unchanged_and_pruned": {
+			ctx: context.Background(),
+			existing: func(t *testing.T) []*types.Container {
+				return []*types.Container{
+					newTestContainer(t, "app-id", "default_test-app_app", true),
+					newTestContainer(t, "sidecar-id", "default_test-app_sidecar", true),
+					obsoleteContainer,
+					otherContainer,
+				}
+			},
+			mockExecution: func(mockClient *mocksclient.MockClient) {
+				mockClient.EXPECT().Remove(gomock.Any(), "obsolete-id", true).Return(nil)
+			},
+			expectedOutcomes: []orchestration.ResourceOutcome{orchestration.ResourceOutcomeUnchanged, orchestration.ResourceOutcomePruned},
+		},
+		"test_configured": {
+			ctx: context.Background(),
+			existing: func(t *testing.T) []*types.Container {
+				app := newTestContainer(t, "app-id", "default_test-app_app", true)
+				app.Image.Name = "ghcr.io/eclipse-leda/test-app:0.9.0"
+				return []*types.Container{app, newTestContainer(t, "sidecar-id", "default_test-app_sidecar", true)}
+			},
+			mockExecution: func(mockClient *mocksclient.MockClient) {
+				stop := mockClient.EXPECT().Stop(gomock.Any(), "app-id", &types.StopOpts{Timeout: 10, Force: true}).Return(nil)
+				remove := mockClient.EXPECT().Remove(gomock.Any(), "app-id", true).Return(nil).After(stop)
+				create := mockClient.EXPECT().Create(gomock.Any(), gomock.Any()).Return(&types.Container{ID: "new-app-id", Name: "default_test-app_app"}, nil).After(remove)
+				mockClient.EXPECT().Start(gomock.Any(), "new-app-id").Return(nil).After(create)
+			},
+			expectedOutcomes: []orchestration.ResourceOutcome{orchestration.ResourceOutcomeConfigured},
+		},
+		"test_dry_run": {
+			ctx: orchestration.SetUpdateMgrDryRunContext(context.Background()),
+			existing: func(t *testing.T) []*types.Container {
+				return []*types.Container{obsoleteContainer}
+			},
+			mockExecution:    func(mockClient *mocksclient.MockClient) {},
+			expectedOutcomes: []orchestration.ResourceOutcome{orchestration.ResourceOutcomeCreated, orchestration.ResourceOutcomePruned},
+		},
+		"test_reconcile_no_prune": {
+			ctx: orchestration.SetUpdateMgrReconcileContext(context.Background()),
+			existing: func(t *testing.T) []*types.Container {
+				return []*types.Container{
+					newTestContainer(t, "app-id", "default_test-app_app", true),
+					newTestContainer(t, "sidecar-id", "default_test-app_sidecar", true),
+					obsoleteContainer,
+				}
+			},
+			mockExecution:    func(mockClient *mocksclient.MockClient) {},
+			expectedOutcomes: []orchestration.ResourceOutcome{orchestration.ResourceOutcomeUnchanged},
+		},
+		"test_prune_disabled": {
+			ctx:     context.Background(),
+			noPrune: true,
+			existing: func(t *testing.T) []*types.Container {
+				return []*types.Container{
+					newTestContainer(t, "app-id", "default_test-app_app", true),
+					newTestContainer(t, "sidecar-id", "default_test-app_sidecar", true),
+					obsoleteContainer,
+				}
+			},
+			mockExecution:    func(mockClient *mocksclient.MockClient) {},
+			expectedOutcomes: []orchestration.ResourceOutcome{orchestration.ResourceOutcomeUnchanged},
+		},
+		"test_create_error": {
+			ctx: context.Background(),
+			existing: func(t *testing.T) []*types.Container {
+				return nil
+			},
+			mockExecution: func(mockClient *mocksclient.MockClient) {
+				mockClient.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil, log.NewError("image not found"))
+			},
+			expectedOutcomes: []orchestration.ResourceOutcome{orchestration.ResourceOutcomeFailed},
+			expectedErr:      "cannot apply the manifest: Pod default/test-app: cannot create container default_test-app_app: image not found",
+		},
+	}
+	for testName, testCase := range tests {
+		t.Run(testName, func(t *testing.T) {
+			t.Log(testName)
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			mockClient := mocksclient.NewMockClient(controller)
+			mockClient.EXPECT().List(gomock.Any()).Return(testCase.existing(t), nil)
+			testCase.mockExecution(mockClient)
+
+			updMgr := newTestKantoUpdateManager(mockClient)
+			updMgr.cfg.prune = !testCase.noPrune
+			applyResult := updMgr.Apply(testCase.ctx, []*unstructured.Unstructured{testPodResource}).(*orchestration.ApplyResult)
+			if testCase.expectedErr == "" {
+				testutil.AssertNil(t, applyResult.Err)
+			} else {
+				testutil.AssertNotNil(t, applyResult.Err)
+				testutil.AssertEqual(t, testCase.expectedErr, applyResult.Err.Error())
+			}
+			testutil.AssertEqual(t, len(testCase.expectedOutcomes), len(applyResult.Resources))
+			for i, outcome := range testCase.expectedOutcomes {
+				testutil.AssertEqual(t, outcome, applyResult.Resources[i].Outcome)
+			}
+		})
+	}
+}
+
+func TestApplyUnsupportedResource(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	mockClient := mocksclient.NewMockClient(controller)
+	deployment := &unstructured.Unstructured{}
+	deployment.SetAPIVersion("apps/v1")
+	deployment.SetKind("Deployment")
+	deployment.SetName("test-app")
+
+	applyResult := newTestKantoUpdateManager(mockClient).Apply(context.Background(), []*unstructured.Unstructured{deployment}).(*orchestration.ApplyResult)
+	testutil.AssertNotNil(t, applyResult.Err)
+	testutil.AssertEqual(t, 0, len(applyResult.Resources))
+}
+
+func TestGet(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	mockClient := mocksclient.NewMockClient(controller)
+	mockClient.EXPECT().List(gomock.Any()).Return([]*types.Container{
+		newTestContainer(t, "sidecar-id", "default_test-app_sidecar", true),
+		{ID: "other-id", Name: "other"},
+		newTestContainer(t, "app-id", "default_test-app_app", true),
+		{ID: "vehicle-app-id", Name: "vehicle_test-app_app", Config: &types.ContainerConfiguration{Env: []string{"SDV_POD_NAME=test-app", "SDV_POD_NAMESPACE=vehicle"}}},
+	}, nil)
+
+	result := newTestKantoUpdateManager(mockClient).Get(context.Background())
+	testutil.AssertEqual(t, 2, len(result))
+	testutil.AssertEqual(t, "test-app", result[0].GetName())
+	testutil.AssertEqual(t, "default", result[0].GetNamespace())
+	containers, _, _ := unstructured.NestedSlice(result[0].Object, "spec", "containers")
+	testutil.AssertEqual(t, 2, len(containers))
+	testutil.AssertEqual(t, "test-app", result[1].GetName())
+	testutil.AssertEqual(t, "vehicle", result[1].GetNamespace())
+}
+
+func TestGetError(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	mockClient := mocksclient.NewMockClient(controller)
+	mockClient.EXPECT().List(gomock.Any()).Return(nil, log.NewError("connection refused"))
+	testutil.AssertEqual(t, 0, len(newTestKantoUpdateManager(mockClient).Get(context.Background())))
+}
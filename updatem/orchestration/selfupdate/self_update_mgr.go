@@ -18,6 +18,7 @@ import (
 
 	"github.com/eclipse-kanto/container-management/containerm/log"
 	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/events"
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/orchestration"
 	mqtt "github.com/eclipse/paho.mqtt.golang"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
@@ -26,14 +27,19 @@ const (
 	topicSelfUpdateDesiredState         = "selfupdate/desiredstate"
 	topicSelfUpdateCurrentState         = "selfupdate/currentstate"
 	topicSelfUpdateDesiredStateFeedback = "selfupdate/desiredstatefeedback"
+	topicSelfUpdateDesiredStateCommand  = "selfupdate/desiredstate/command"
+
+	selfUpdateCommandCancel = "cancel"
 )
 
 type selfUpdateManager struct {
 	cfg                 *mgrOpts
 	applyLock           sync.Mutex
 	pahoClient          mqtt.Client
+	currentStateLock    sync.RWMutex
 	currentState        *unstructured.Unstructured
 	eventsMgr           events.UpdateEventsManager
+	operationLock       sync.Mutex
 	selfUpdateOperation *selfUpdateOperation
 }
 
@@ -53,14 +59,32 @@ func (suMgr *selfUpdateManager) Apply(ctx context.Context, mf []*unstructured.Un
 	var applyErr error
 	suApplyResult := &ApplyResult{}
 	defer func() {
-		suMgr.selfUpdateOperation = nil
+		suMgr.setSelfUpdateOperation(nil)
 		if applyErr != nil {
 			log.Error(applyErr.Error())
 		}
 		suMgr.applyLock.Unlock()
 	}()
 
-	suMgr.selfUpdateOperation = newSelfUpdateOperation()
+	// the bundle is validated before publishing it, as the self update agent reports the invalid bundles late or never
+	if applyErr = suMgr.Validate(ctx, mf); applyErr != nil {
+		suApplyResult.Result = SelfUpdateResultRejected
+		suApplyResult.Err = applyErr
+		return suApplyResult
+	}
+	if version, ok := suMgr.isCurrentVersion(mf[0]); ok {
+		log.Info("the self update bundle %s is already at version %s - skipping it", mf[0].GetName(), version)
+		suApplyResult.Result = SelfUpdateResultSkipped
+		return suApplyResult
+	}
+
+	operation := newSelfUpdateOperation()
+	operation.bundleName = mf[0].GetName()
+	operation.downloadOnly, _, _ = unstructured.NestedBool(mf[0].Object, "spec", "downloadOnly")
+	operation.progressHandler = func(progress *orchestration.Progress) {
+		suMgr.publishOrchestrationEvent(ctx, orchestration.EventActionOrchestrationRunning, progress)
+	}
+	suMgr.setSelfUpdateOperation(operation)
 	selfUpdateManifest, applyErr := suMgr.unmarshalUnstructured(mf[0])
 	if applyErr != nil {
 		suApplyResult.Result = SelfUpdateResultError
@@ -69,7 +93,7 @@ func (suMgr *selfUpdateManager) Apply(ctx context.Context, mf []*unstructured.Un
 	}
 	log.Debug("self update manifest: %s", string(selfUpdateManifest))
 
-	if token := suMgr.pahoClient.Subscribe(topicSelfUpdateDesiredStateFeedback, 1, suMgr.selfUpdateOperation.handleSelfUpdateDesiredStateFeedback); !token.WaitTimeout(suMgr.cfg.acknowledgeTimeout) {
+	if token := suMgr.pahoClient.Subscribe(topicSelfUpdateDesiredStateFeedback, 1, operation.handleSelfUpdateDesiredStateFeedback); !token.WaitTimeout(suMgr.cfg.acknowledgeTimeout) {
 		applyErr = log.NewErrorf("cannot subscribe for topic '%s' in '%v' seconds", topicSelfUpdateDesiredStateFeedback, suMgr.cfg.acknowledgeTimeout)
 		suApplyResult.Result = SelfUpdateResultError
 		suApplyResult.Err = applyErr
@@ -91,12 +115,12 @@ func (suMgr *selfUpdateManager) Apply(ctx context.Context, mf []*unstructured.Un
 		suApplyResult.Result = SelfUpdateResultTimeout
 		suApplyResult.Err = applyErr
 		return suApplyResult
-	case <-suMgr.selfUpdateOperation.done:
-		suApplyResult.Result = suMgr.selfUpdateOperation.result
-		if suMgr.selfUpdateOperation.result == SelfUpdateResultError {
-			applyErr = suMgr.selfUpdateOperation.err
+	case <-operation.done:
+		suApplyResult.Result = operation.result
+		if operation.result == SelfUpdateResultError || operation.result == SelfUpdateResultCancelled {
+			applyErr = operation.err
 			suApplyResult.Err = applyErr
-		} else if suMgr.selfUpdateOperation.result == SelfUpdateResultInstalled {
+		} else if operation.result == SelfUpdateResultInstalled {
 			if suMgr.cfg.enableReboot {
 				selfUpdateRebootTimeout := suMgr.convertStringToDuration(suMgr.cfg.rebootTimeout, time.Minute)
 				suApplyResult.RebootTimeout = selfUpdateRebootTimeout
@@ -109,8 +133,31 @@ func (suMgr *selfUpdateManager) Apply(ctx context.Context, mf []*unstructured.Un
 	}
 }
 
+// Cancel requests the self update agent to cancel the self update bundle in progress, if its current state allows it.
+// The cancelled operation is finished when the agent reports back that it is idle.
+func (suMgr *selfUpdateManager) Cancel(ctx context.Context) error {
+	operation := suMgr.getSelfUpdateOperation()
+	if operation == nil {
+		return log.NewErrorf("there is no self update operation in progress")
+	}
+	command, err := suMgr.newSelfUpdateCommand(operation.bundleName, selfUpdateCommandCancel)
+	if err != nil {
+		return err
+	}
+	// the cancellation is requested before the command is sent, so that the idle state reported right after it finishes the operation
+	if err := operation.requestCancel(); err != nil {
+		return err
+	}
+	log.Debug("cancelling self update bundle '%s'", operation.bundleName)
+	if token := suMgr.pahoClient.Publish(topicSelfUpdateDesiredStateCommand, 1, false, command); !token.WaitTimeout(suMgr.cfg.acknowledgeTimeout) {
+		operation.revokeCancel()
+		return log.NewErrorf("cannot send the self update cancel command to the local broker in '%v' seconds", suMgr.cfg.acknowledgeTimeout)
+	}
+	return nil
+}
+
 func (suMgr *selfUpdateManager) Get(ctx context.Context) []*unstructured.Unstructured {
-	return []*unstructured.Unstructured{suMgr.currentState}
+	return []*unstructured.Unstructured{suMgr.getCurrentState()}
 }
 
 func (suMgr *selfUpdateManager) Dispose(ctx context.Context) error {
@@ -25,7 +25,9 @@ func NewSelfUpdateManager(opts []MgrOpt, registryCtx *registry.ServiceRegistryCo
 	var (
 		cfg = &mgrOpts{}
 	)
-	applyOptsMgr(cfg, opts...)
+	if err := applyOptsMgr(cfg, opts...); err != nil {
+		return nil, err
+	}
 
 	eventsMgr, err := registryCtx.Get(registry.EventsManagerService)
 	if err != nil {
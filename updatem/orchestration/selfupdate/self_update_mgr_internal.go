@@ -17,12 +17,13 @@ import (
 
 	"github.com/eclipse-kanto/container-management/containerm/log"
 	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/events"
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/orchestration"
 	mqtt "github.com/eclipse/paho.mqtt.golang"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"sigs.k8s.io/yaml"
 )
 
-func (suMgr *selfUpdateManager) publishEvent(ctx context.Context, eventType events.EventType, eventAction events.EventAction, eventSource *unstructured.Unstructured, err error) {
+func (suMgr *selfUpdateManager) publishEvent(ctx context.Context, eventType events.EventType, eventAction events.EventAction, eventSource interface{}, err error) {
 	e := &events.Event{
 		Type:    eventType,
 		Action:  eventAction,
@@ -41,6 +42,10 @@ func (suMgr *selfUpdateManager) publishResourceEvent(ctx context.Context, eventA
 	suMgr.publishEvent(ctx, events.EventTypeResources, eventAction, eventSource, err)
 }
 
+func (suMgr *selfUpdateManager) publishOrchestrationEvent(ctx context.Context, eventAction events.EventAction, eventSource interface{}) {
+	suMgr.publishEvent(ctx, orchestration.EventTypeOrchestration, eventAction, eventSource, nil)
+}
+
 func (suMgr *selfUpdateManager) subscribeSelfUpdateCurrentState() error {
 	log.Debug("subscribing for '%s' topic", topicSelfUpdateCurrentState)
 	if token := suMgr.pahoClient.Subscribe(topicSelfUpdateCurrentState, 1, suMgr.handleSelfUpdateCurrentState); !token.WaitTimeout(suMgr.cfg.acknowledgeTimeout) {
@@ -56,7 +61,7 @@ func (suMgr *selfUpdateManager) handleSelfUpdateCurrentState(mqttClient mqtt.Cli
 		log.Error("error while parsing self update current state : %v", err)
 		return
 	}
-	suMgr.currentState = u[0]
+	suMgr.setCurrentState(u[0])
 	suMgr.publishResourceEvent(context.Background(), events.EventActionResourcesUpdated, u[0], nil)
 }
 
@@ -71,6 +76,38 @@ func (suMgr *selfUpdateManager) unmarshalUnstructured(u *unstructured.Unstructur
 	return yaml.JSONToYAML(jsonBytes)
 }
 
+func (suMgr *selfUpdateManager) setCurrentState(currentState *unstructured.Unstructured) {
+	suMgr.currentStateLock.Lock()
+	defer suMgr.currentStateLock.Unlock()
+	suMgr.currentState = currentState
+}
+
+func (suMgr *selfUpdateManager) getCurrentState() *unstructured.Unstructured {
+	suMgr.currentStateLock.RLock()
+	defer suMgr.currentStateLock.RUnlock()
+	return suMgr.currentState
+}
+
+func (suMgr *selfUpdateManager) setSelfUpdateOperation(operation *selfUpdateOperation) {
+	suMgr.operationLock.Lock()
+	defer suMgr.operationLock.Unlock()
+	suMgr.selfUpdateOperation = operation
+}
+
+func (suMgr *selfUpdateManager) getSelfUpdateOperation() *selfUpdateOperation {
+	suMgr.operationLock.Lock()
+	defer suMgr.operationLock.Unlock()
+	return suMgr.selfUpdateOperation
+}
+
+func (suMgr *selfUpdateManager) newSelfUpdateCommand(bundleName string, command string) ([]byte, error) {
+	u := &unstructured.Unstructured{Object: map[string]interface{}{"command": command}}
+	u.SetAPIVersion("sdv.eclipse.org/v1")
+	u.SetKind("SelfUpdateBundle")
+	u.SetName(bundleName)
+	return suMgr.unmarshalUnstructured(u)
+}
+
 func (suMgr *selfUpdateManager) convertStringToDuration(value string, defaultValue time.Duration) time.Duration {
 	durationValue, err := time.ParseDuration(value)
 	if err != nil {
@@ -13,6 +13,15 @@ package selfupdate
 
 import (
 	"time"
+
+	"github.com/eclipse-kanto/container-management/containerm/log"
+)
+
+const (
+	// SameVersionPolicySkip makes a bundle with the version currently reported by the self update agent be skipped as already installed
+	SameVersionPolicySkip = "skip"
+	// SameVersionPolicyReject makes a bundle with the version currently reported by the self update agent be rejected
+	SameVersionPolicyReject = "reject"
 )
 
 // MgrOpt defines the creation configuration options for a self update manager implementation
@@ -31,6 +40,7 @@ type mgrOpts struct {
 	acknowledgeTimeout time.Duration
 	subscribeTimeout   time.Duration
 	unsubscribeTimeout time.Duration
+	sameVersionPolicy  string
 }
 
 func applyOptsMgr(mgrOpts *mgrOpts, opts ...MgrOpt) error {
@@ -137,3 +147,14 @@ func WithConnectionUnsubscribeTimeout(unsubscribeTimeout time.Duration) MgrOpt {
 		return nil
 	}
 }
+
+// WithSameVersionPolicy configures how a bundle with the version currently reported by the self update agent is handled - possible values are skip and reject
+func WithSameVersionPolicy(sameVersionPolicy string) MgrOpt {
+	return func(mgrOptions *mgrOpts) error {
+		if sameVersionPolicy != SameVersionPolicySkip && sameVersionPolicy != SameVersionPolicyReject {
+			return log.NewErrorf("unsupported same version policy %s", sameVersionPolicy)
+		}
+		mgrOptions.sameVersionPolicy = sameVersionPolicy
+		return nil
+	}
+}
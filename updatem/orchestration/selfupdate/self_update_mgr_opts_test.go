@@ -47,6 +47,7 @@ func TestMgrOpts(t *testing.T) {
 				WithConnectionAcknowledgeTimeout(20000),
 				WithConnectionSubscribeTimeout(20000),
 				WithConnectionUnsubscribeTimeout(20000),
+				WithSameVersionPolicy(SameVersionPolicyReject),
 			},
 			expectedOpts: &mgrOpts{
 				enableReboot:       true,
@@ -61,9 +62,17 @@ func TestMgrOpts(t *testing.T) {
 				connectTimeout:     30000,
 				subscribeTimeout:   20000,
 				unsubscribeTimeout: 20000,
+				sameVersionPolicy:  SameVersionPolicyReject,
 			},
 			expectedErr: nil,
 		},
+		"test_unsupported_same_version_policy": {
+			opts: []MgrOpt{
+				WithSameVersionPolicy("ignore"),
+			},
+			expectedOpts: nil,
+			expectedErr:  log.NewError("unsupported same version policy ignore"),
+		},
 	}
 	for testCaseName, testCase := range testCases {
 		t.Run(testCaseName, func(t *testing.T) {
@@ -18,8 +18,12 @@ import (
 	"testing"
 	"time"
 
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/events"
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/orchestration"
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/pkg/testutil"
 	mocksevents "github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/pkg/testutil/mocks/events"
 	mocksmqtt "github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/pkg/testutil/mocks/mqtt"
+	mqtt "github.com/eclipse/paho.mqtt.golang"
 	"github.com/golang/mock/gomock"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
@@ -150,6 +154,39 @@ func TestApply(t *testing.T) {
 				selfUpdateOperation.done <- true
 			},
 		},
+		{
+			"apply_self_update_state_downloaded",
+			SelfUpdateResultDownloaded,
+			true,
+			"",
+			false,
+			false,
+			true,
+			func(controller *gomock.Controller, mockClient *mocksmqtt.MockClient) {
+				setupMockClient(controller, mockClient)
+			},
+			func(selfUpdateOperation *selfUpdateOperation) {
+				selfUpdateOperation.result = SelfUpdateResultDownloaded
+				selfUpdateOperation.done <- true
+			},
+		},
+		{
+			"apply_self_update_state_cancelled",
+			SelfUpdateResultCancelled,
+			false,
+			"",
+			true,
+			false,
+			true,
+			func(controller *gomock.Controller, mockClient *mocksmqtt.MockClient) {
+				setupMockClient(controller, mockClient)
+			},
+			func(selfUpdateOperation *selfUpdateOperation) {
+				selfUpdateOperation.result = SelfUpdateResultCancelled
+				selfUpdateOperation.err = fmt.Errorf("self update operation is cancelled")
+				selfUpdateOperation.done <- true
+			},
+		},
 		{
 			"apply_self_update_state_timeout",
 			SelfUpdateResultTimeout,
@@ -193,7 +230,7 @@ func TestApply(t *testing.T) {
 			}
 
 			if testValues.notifyResult != nil {
-				testValues.notifyResult(selfUpdateManager.selfUpdateOperation)
+				testValues.notifyResult(selfUpdateManager.getSelfUpdateOperation())
 			}
 
 			res := <-resChan
@@ -203,6 +240,123 @@ func TestApply(t *testing.T) {
 	}
 }
 
+func TestApplyDownloadOnly(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	mockClient := mocksmqtt.NewMockClient(controller)
+	setupMockClient(controller, mockClient)
+	mockEventsMgr := mocksevents.NewMockUpdateEventsManager(controller)
+	mockEventsMgr.EXPECT().Publish(gomock.Any(), gomock.Any()).Do(func(ctx context.Context, event *events.Event) {
+		testutil.AssertEqual(t, orchestration.EventTypeOrchestration, event.Type)
+		testutil.AssertEqual(t, orchestration.EventActionOrchestrationRunning, event.Action)
+		testutil.AssertEqual(t, orchestration.ProgressPhaseDownload, event.Source.(*orchestration.Progress).Phase)
+		testutil.AssertEqual(t, 100, event.Source.(*orchestration.Progress).Progress)
+	}).Return(nil)
+	selfUpdateManager := &selfUpdateManager{
+		eventsMgr:  mockEventsMgr,
+		pahoClient: mockClient,
+		cfg: &mgrOpts{
+			timeout:      "5s",
+			enableReboot: true,
+		},
+	}
+
+	resChan := make(chan interface{})
+	go func() {
+		_, u, _ := parseMultiYAML([]byte(selfUpdateManifest))
+		unstructured.SetNestedField(u[0].Object, true, "spec", "downloadOnly")
+		resChan <- selfUpdateManager.Apply(context.Background(), u)
+	}()
+	waitSelfUpdateOperationInitiated(selfUpdateManager)
+
+	operation := selfUpdateManager.getSelfUpdateOperation()
+	if operation.bundleName != "self-update-bundle-example-3" || !operation.downloadOnly {
+		t.Fail()
+	}
+	operation.handleSelfUpdateDesiredStateFeedback(mockClient, setupMockMessage(controller, selfUpdateDesiredStateFeedbackDownloaded))
+
+	applyResult := (<-resChan).(*ApplyResult)
+	assertSelfUpdateResult(t, SelfUpdateResultDownloaded, "", false, false, *applyResult)
+}
+
+func TestCancel(t *testing.T) {
+	type mockFunc func(*gomock.Controller, *mocksmqtt.MockClient)
+
+	tests := map[string]struct {
+		operationState          selfUpdateState
+		noOperation             bool
+		expectedError           bool
+		expectedCancelRequested bool
+		mockExec                mockFunc
+	}{
+		"test_cancel_downloading": {
+			operationState:          selfUpdateStateDownloading,
+			expectedCancelRequested: true,
+			mockExec: func(controller *gomock.Controller, mockClient *mocksmqtt.MockClient) {
+				mockPubToken := setupMockPubSubToken(controller)
+				mockClient.EXPECT().Publish(topicSelfUpdateDesiredStateCommand, gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+					func(topic string, qos byte, retained bool, payload interface{}) mqtt.Token {
+						_, u, err := parseMultiYAML(payload.([]byte))
+						if err != nil || u[0].GetName() != "self-update-bundle-example-3" || u[0].Object["command"] != selfUpdateCommandCancel {
+							t.Fail()
+						}
+						return mockPubToken
+					})
+			},
+		},
+		"test_cancel_no_operation": {
+			noOperation:   true,
+			expectedError: true,
+		},
+		"test_cancel_installing": {
+			operationState: selfUpdateStateInstalling,
+			expectedError:  true,
+		},
+		"test_cancel_publish_error": {
+			operationState: selfUpdateStateDownloaded,
+			expectedError:  true,
+			mockExec: func(controller *gomock.Controller, mockClient *mocksmqtt.MockClient) {
+				mockPubToken := mocksmqtt.NewMockToken(controller)
+				mockPubToken.EXPECT().WaitTimeout(gomock.Any()).Return(false)
+				mockClient.EXPECT().Publish(topicSelfUpdateDesiredStateCommand, gomock.Any(), gomock.Any(), gomock.Any()).Return(mockPubToken)
+			},
+		},
+	}
+
+	for testName, testCase := range tests {
+		t.Run(testName, func(t *testing.T) {
+			t.Log(testName)
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			mockClient := mocksmqtt.NewMockClient(controller)
+			if testCase.mockExec != nil {
+				testCase.mockExec(controller, mockClient)
+			}
+			selfUpdateManager := &selfUpdateManager{
+				pahoClient: mockClient,
+				cfg:        &mgrOpts{},
+			}
+			var operation *selfUpdateOperation
+			if !testCase.noOperation {
+				operation = newSelfUpdateOperation()
+				operation.bundleName = "self-update-bundle-example-3"
+				operation.updateState(testCase.operationState, map[string]interface{}{})
+				selfUpdateManager.setSelfUpdateOperation(operation)
+			}
+
+			err := selfUpdateManager.Cancel(context.Background())
+			if testCase.expectedError != (err != nil) {
+				t.Errorf("unexpected cancel error: %v", err)
+			}
+			if operation != nil && operation.cancelRequested != testCase.expectedCancelRequested {
+				t.Errorf("unexpected cancel request: %v", operation.cancelRequested)
+			}
+		})
+	}
+}
+
 func TestGet(t *testing.T) {
 	selfUpdateManager := selfUpdateManager{
 		currentState: &unstructured.Unstructured{},
@@ -237,7 +391,7 @@ func TestDispose(t *testing.T) {
 
 func waitSelfUpdateOperationInitiated(selfUpdateManager *selfUpdateManager) {
 	for {
-		if selfUpdateManager.selfUpdateOperation != nil {
+		if selfUpdateManager.getSelfUpdateOperation() != nil {
 			break
 		}
 		<-time.After(100 * time.Millisecond)
@@ -0,0 +1,133 @@
+// Copyright (c) 2022 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Apache License 2.0 which is available at
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package selfupdate
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/eclipse-kanto/container-management/containerm/log"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const selfUpdateBundleAPIVersion = "sdv.eclipse.org/v1"
+
+var (
+	bundleVersionRegexp = regexp.MustCompile(`^[0-9A-Za-z]+([._+-][0-9A-Za-z]+)*$`)
+	bundleHashRegexp    = regexp.MustCompile(`^[0-9a-f]+$`)
+	// bundleHashLengths holds the supported hash algorithms of the bundle and the length of their hex encoded digests
+	bundleHashLengths = map[string]int{
+		"sha256": 64,
+		"sha512": 128,
+	}
+)
+
+// Validate checks the structure of the SelfUpdateBundle resources of the manifest and returns all validation errors.
+// If the same version policy is reject, a bundle with the version currently reported by the self update agent is not valid either.
+func (suMgr *selfUpdateManager) Validate(ctx context.Context, mf []*unstructured.Unstructured) error {
+	validationErrors := []string{}
+	for _, u := range mf {
+		for _, err := range validateSelfUpdateBundle(u) {
+			validationErrors = append(validationErrors, fmt.Sprintf("SelfUpdateBundle %s: %s", u.GetName(), err))
+		}
+	}
+	if len(validationErrors) > 0 {
+		return log.NewErrorf("the self update bundle is not valid: %s", strings.Join(validationErrors, "; "))
+	}
+	if suMgr.cfg.sameVersionPolicy == SameVersionPolicyReject {
+		for _, u := range mf {
+			if version, ok := suMgr.isCurrentVersion(u); ok {
+				return log.NewErrorf("the self update bundle %s is already at version %s", u.GetName(), version)
+			}
+		}
+	}
+	return nil
+}
+
+func validateSelfUpdateBundle(u *unstructured.Unstructured) []string {
+	errs := []string{}
+	if u.GetAPIVersion() != selfUpdateBundleAPIVersion {
+		errs = append(errs, fmt.Sprintf("unsupported apiVersion %s - expected %s", u.GetAPIVersion(), selfUpdateBundleAPIVersion))
+	}
+	if u.GetKind() != "SelfUpdateBundle" {
+		errs = append(errs, fmt.Sprintf("unsupported kind %s", u.GetKind()))
+	}
+	if u.GetName() == "" {
+		errs = append(errs, "metadata.name is required")
+	}
+
+	version, found, err := unstructured.NestedString(u.Object, "spec", "bundleVersion")
+	switch {
+	case err != nil:
+		errs = append(errs, "spec.bundleVersion is not a string")
+	case !found || version == "":
+		errs = append(errs, "spec.bundleVersion is required")
+	case !bundleVersionRegexp.MatchString(version):
+		errs = append(errs, fmt.Sprintf("invalid spec.bundleVersion %s", version))
+	}
+
+	downloadURL, found, err := unstructured.NestedString(u.Object, "spec", "bundleDownloadUrl")
+	switch {
+	case err != nil:
+		errs = append(errs, "spec.bundleDownloadUrl is not a string")
+	case !found || downloadURL == "":
+		errs = append(errs, "spec.bundleDownloadUrl is required")
+	default:
+		if parsedURL, err := url.Parse(downloadURL); err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") || parsedURL.Host == "" {
+			errs = append(errs, fmt.Sprintf("invalid spec.bundleDownloadUrl %s - an absolute http or https URL is expected", downloadURL))
+		}
+	}
+
+	// the hash of the bundle is optional, in the <algorithm>:<hex digest> format
+	hash, found, err := unstructured.NestedString(u.Object, "spec", "bundleHash")
+	if err != nil {
+		errs = append(errs, "spec.bundleHash is not a string")
+	} else if found {
+		if hashErr := validateBundleHash(hash); hashErr != "" {
+			errs = append(errs, hashErr)
+		}
+	}
+
+	if _, _, err := unstructured.NestedBool(u.Object, "spec", "downloadOnly"); err != nil {
+		errs = append(errs, "spec.downloadOnly is not a boolean")
+	}
+	return errs
+}
+
+func validateBundleHash(hash string) string {
+	algorithm, digest := "", ""
+	if i := strings.Index(hash, ":"); i > 0 {
+		algorithm, digest = strings.ToLower(hash[:i]), strings.ToLower(hash[i+1:])
+	}
+	length, ok := bundleHashLengths[algorithm]
+	if !ok {
+		return fmt.Sprintf("invalid spec.bundleHash %s - the <algorithm>:<hex digest> format with sha256 or sha512 algorithm is expected", hash)
+	}
+	if len(digest) != length || !bundleHashRegexp.MatchString(digest) {
+		return fmt.Sprintf("invalid spec.bundleHash %s - %d hex digits are expected for %s", hash, length, algorithm)
+	}
+	return ""
+}
+
+// isCurrentVersion checks if the version of the bundle is the one currently reported by the self update agent
+func (suMgr *selfUpdateManager) isCurrentVersion(u *unstructured.Unstructured) (string, bool) {
+	currentState := suMgr.getCurrentState()
+	if currentState == nil {
+		return "", false
+	}
+	currentVersion, _, _ := unstructured.NestedString(currentState.Object, "spec", "bundleVersion")
+	version, _, _ := unstructured.NestedString(u.Object, "spec", "bundleVersion")
+	return version, currentVersion != "" && currentVersion == version
+}
@@ -0,0 +1,187 @@
+// Copyright (c) 2022 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Apache License 2.0 which is available at
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package selfupdate
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/pkg/testutil"
+	mocksmqtt "github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/pkg/testutil/mocks/mqtt"
+	"github.com/golang/mock/gomock"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestValidate(t *testing.T) {
+	tests := map[string]struct {
+		modify              func(u *unstructured.Unstructured)
+		currentVersion      string
+		sameVersionPolicy   string
+		expectedErrContains []string
+	}{
+		"test_valid": {},
+		"test_valid_hash": {
+			modify: func(u *unstructured.Unstructured) {
+				unstructured.SetNestedField(u.Object, "sha256:"+strings.Repeat("a1", 32), "spec", "bundleHash")
+			},
+		},
+		"test_valid_semantic_version": {
+			modify: func(u *unstructured.Unstructured) {
+				unstructured.SetNestedField(u.Object, "1.2.3-rc.1+build.42", "spec", "bundleVersion")
+			},
+		},
+		"test_invalid_api_version": {
+			modify: func(u *unstructured.Unstructured) {
+				u.SetAPIVersion("sdv.eclipse.org/v2")
+			},
+			expectedErrContains: []string{"unsupported apiVersion sdv.eclipse.org/v2"},
+		},
+		"test_missing_version_and_url": {
+			modify: func(u *unstructured.Unstructured) {
+				unstructured.RemoveNestedField(u.Object, "spec", "bundleVersion")
+				unstructured.RemoveNestedField(u.Object, "spec", "bundleDownloadUrl")
+			},
+			expectedErrContains: []string{"spec.bundleVersion is required", "spec.bundleDownloadUrl is required"},
+		},
+		"test_invalid_version": {
+			modify: func(u *unstructured.Unstructured) {
+				unstructured.SetNestedField(u.Object, "v1 beta/3", "spec", "bundleVersion")
+			},
+			expectedErrContains: []string{"invalid spec.bundleVersion v1 beta/3"},
+		},
+		"test_invalid_url": {
+			modify: func(u *unstructured.Unstructured) {
+				unstructured.SetNestedField(u.Object, "repository/base", "spec", "bundleDownloadUrl")
+			},
+			expectedErrContains: []string{"invalid spec.bundleDownloadUrl repository/base"},
+		},
+		"test_invalid_hash_algorithm": {
+			modify: func(u *unstructured.Unstructured) {
+				unstructured.SetNestedField(u.Object, "md5:0123456789abcdef0123456789abcdef", "spec", "bundleHash")
+			},
+			expectedErrContains: []string{"the <algorithm>:<hex digest> format with sha256 or sha512 algorithm is expected"},
+		},
+		"test_invalid_hash_digest": {
+			modify: func(u *unstructured.Unstructured) {
+				unstructured.SetNestedField(u.Object, "sha256:xyz", "spec", "bundleHash")
+			},
+			expectedErrContains: []string{"64 hex digits are expected for sha256"},
+		},
+		"test_invalid_download_only": {
+			modify: func(u *unstructured.Unstructured) {
+				unstructured.SetNestedField(u.Object, "yes", "spec", "downloadOnly")
+			},
+			expectedErrContains: []string{"spec.downloadOnly is not a boolean"},
+		},
+		"test_same_version_skip": {
+			currentVersion:    "v1beta3",
+			sameVersionPolicy: SameVersionPolicySkip,
+		},
+		"test_same_version_reject": {
+			currentVersion:      "v1beta3",
+			sameVersionPolicy:   SameVersionPolicyReject,
+			expectedErrContains: []string{"the self update bundle self-update-bundle-example-3 is already at version v1beta3"},
+		},
+		"test_other_version_reject": {
+			currentVersion:    "v1beta2",
+			sameVersionPolicy: SameVersionPolicyReject,
+		},
+	}
+
+	for testName, testCase := range tests {
+		t.Run(testName, func(t *testing.T) {
+			t.Log(testName)
+			selfUpdateManager := &selfUpdateManager{
+				cfg: &mgrOpts{sameVersionPolicy: testCase.sameVersionPolicy},
+			}
+			if testCase.currentVersion != "" {
+				selfUpdateManager.setCurrentState(newTestCurrentState(testCase.currentVersion))
+			}
+			_, u, _ := parseMultiYAML([]byte(selfUpdateManifest))
+			if testCase.modify != nil {
+				testCase.modify(u[0])
+			}
+
+			err := selfUpdateManager.Validate(context.Background(), u)
+			if len(testCase.expectedErrContains) == 0 {
+				testutil.AssertNil(t, err)
+				return
+			}
+			testutil.AssertNotNil(t, err)
+			for _, expected := range testCase.expectedErrContains {
+				testutil.AssertContainsString(t, err.Error(), expected)
+			}
+		})
+	}
+}
+
+func TestApplyValidation(t *testing.T) {
+	tests := map[string]struct {
+		modify            func(u *unstructured.Unstructured)
+		sameVersionPolicy string
+		expectedResult    OperationResult
+		expectedError     bool
+	}{
+		"test_apply_invalid_bundle": {
+			modify: func(u *unstructured.Unstructured) {
+				unstructured.RemoveNestedField(u.Object, "spec", "bundleDownloadUrl")
+			},
+			expectedResult: SelfUpdateResultRejected,
+			expectedError:  true,
+		},
+		"test_apply_same_version_skip": {
+			sameVersionPolicy: SameVersionPolicySkip,
+			expectedResult:    SelfUpdateResultSkipped,
+		},
+		"test_apply_same_version_default": {
+			expectedResult: SelfUpdateResultSkipped,
+		},
+		"test_apply_same_version_reject": {
+			sameVersionPolicy: SameVersionPolicyReject,
+			expectedResult:    SelfUpdateResultRejected,
+			expectedError:     true,
+		},
+	}
+
+	for testName, testCase := range tests {
+		t.Run(testName, func(t *testing.T) {
+			t.Log(testName)
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			// nothing is published to the self update agent
+			mockClient := mocksmqtt.NewMockClient(controller)
+			selfUpdateManager := &selfUpdateManager{
+				pahoClient: mockClient,
+				cfg: &mgrOpts{
+					timeout:           "500ms",
+					sameVersionPolicy: testCase.sameVersionPolicy,
+				},
+			}
+			selfUpdateManager.setCurrentState(newTestCurrentState("v1beta3"))
+			_, u, _ := parseMultiYAML([]byte(selfUpdateManifest))
+			if testCase.modify != nil {
+				testCase.modify(u[0])
+			}
+
+			applyResult := selfUpdateManager.Apply(context.Background(), u).(*ApplyResult)
+			assertSelfUpdateResult(t, testCase.expectedResult, "", false, testCase.expectedError, *applyResult)
+		})
+	}
+}
+
+func newTestCurrentState(version string) *unstructured.Unstructured {
+	_, u, _ := parseMultiYAML([]byte(selfUpdateManifest))
+	unstructured.SetNestedField(u[0].Object, version, "spec", "bundleVersion")
+	return u[0]
+}
@@ -12,7 +12,11 @@
 package selfupdate
 
 import (
+	"fmt"
+	"sync"
+
 	"github.com/eclipse-kanto/container-management/containerm/log"
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/orchestration"
 	mqtt "github.com/eclipse/paho.mqtt.golang"
 )
 
@@ -20,6 +24,19 @@ type selfUpdateOperation struct {
 	done   chan bool
 	err    error
 	result OperationResult
+
+	// bundleName is the name of the applied self update bundle
+	bundleName string
+	// downloadOnly defines if the operation is finished when the bundle is downloaded without installing it
+	downloadOnly bool
+	// progressHandler is notified about the download and installation progress reported by the self update agent
+	progressHandler func(progress *orchestration.Progress)
+
+	// stateLock guards the last reported state of the bundle and the cancellation request
+	stateLock       sync.Mutex
+	state           selfUpdateState
+	cancellable     bool
+	cancelRequested bool
 }
 
 // OperationResult holds the result of a self update operation
@@ -36,16 +53,23 @@ const (
 	SelfUpdateResultError
 	// SelfUpdateResultTimeout represents a self update operation failed with expired timeout
 	SelfUpdateResultTimeout
+	// SelfUpdateResultDownloaded represents a download-only self update operation finished successfully
+	SelfUpdateResultDownloaded
+	// SelfUpdateResultCancelled represents a cancelled self update operation
+	SelfUpdateResultCancelled
+	// SelfUpdateResultSkipped represents a self update operation skipped as the bundle version is already installed
+	SelfUpdateResultSkipped
 )
 
 type selfUpdateState string
 
 const (
-	// selfUpdateStateIdle          selfUpdateState = "idle"
+	selfUpdateStateIdle          selfUpdateState = "idle"
 	selfUpdateStateFailed        selfUpdateState = "failed"
 	selfUpdateStateInstalled     selfUpdateState = "installed"
 	selfUpdateStateInstalling    selfUpdateState = "installing"
 	selfUpdateStateDownloading   selfUpdateState = "downloading"
+	selfUpdateStateDownloaded    selfUpdateState = "downloaded"
 	selfUpdateStateUninitialized selfUpdateState = "uninitialized"
 )
 
@@ -71,8 +95,48 @@ var (
 
 func newSelfUpdateOperation() *selfUpdateOperation {
 	return &selfUpdateOperation{
-		done: make(chan bool, 1),
+		done:        make(chan bool, 1),
+		state:       selfUpdateStateIdle,
+		cancellable: true,
+	}
+}
+
+// requestCancel marks the operation as cancelled, if the last reported state of the bundle allows it
+func (su *selfUpdateOperation) requestCancel() error {
+	su.stateLock.Lock()
+	defer su.stateLock.Unlock()
+
+	if !su.cancellable {
+		return log.NewErrorf("the self update bundle %s cannot be cancelled in state '%s'", su.bundleName, su.state)
+	}
+	su.cancelRequested = true
+	return nil
+}
+
+// revokeCancel clears the cancellation request, if the cancel command cannot be sent to the self update agent
+func (su *selfUpdateOperation) revokeCancel() {
+	su.stateLock.Lock()
+	defer su.stateLock.Unlock()
+
+	su.cancelRequested = false
+}
+
+// updateState stores the reported state of the bundle and returns if the operation cancellation is requested.
+// The bundle is cancellable until its installation is started, unless the agent reports otherwise.
+func (su *selfUpdateOperation) updateState(stateName selfUpdateState, state map[string]interface{}) bool {
+	su.stateLock.Lock()
+	defer su.stateLock.Unlock()
+
+	su.state = stateName
+	su.cancellable = stateName == selfUpdateStateIdle || stateName == selfUpdateStateDownloading || stateName == selfUpdateStateDownloaded
+	if value, ok := state["cancellable"]; ok {
+		if cancellable, ok := value.(bool); ok {
+			su.cancellable = cancellable
+		} else {
+			log.Error("the self update state cancellable flag in the self update desiredstate feedback is not of type bool")
+		}
 	}
+	return su.cancelRequested
 }
 
 func (su *selfUpdateOperation) handleSelfUpdateDesiredStateFeedback(mqttClient mqtt.Client, message mqtt.Message) {
@@ -101,8 +165,29 @@ func (su *selfUpdateOperation) handleSelfUpdateDesiredStateFeedback(mqttClient m
 	}
 	stateName := selfUpdateState(stateNameStr)
 	log.Debug("received self update desiredstate feedback for bundle name '%s' and state '%s'", metadataName, stateName)
+	cancelRequested := su.updateState(stateName, state)
 
-	if stateName == selfUpdateStateUninitialized {
+	if stateName == selfUpdateStateIdle {
+		if !cancelRequested {
+			log.Debug("the self update agent is idle")
+			return
+		}
+		su.err = log.NewErrorf("self update operation is cancelled")
+		su.result = SelfUpdateResultCancelled
+		log.Info("the self update bundle '%s' is cancelled", metadataName)
+		su.done <- true
+	} else if stateName == selfUpdateStateDownloaded {
+		su.notifyProgress(orchestration.ProgressPhaseDownload, 100, "self update bundle %s is downloaded", metadataName)
+		if su.downloadOnly {
+			log.Info("the self update bundle '%s' is downloaded and staged for a later installation", metadataName)
+			su.result = SelfUpdateResultDownloaded
+			su.done <- true
+			return
+		}
+		log.Info("the self update bundle '%s' is downloaded", metadataName)
+		// the installation starts once the bundle is downloaded, even if the agent does not report its progress
+		su.notifyProgress(orchestration.ProgressPhaseInstall, 0, "self update bundle %s is installing", metadataName)
+	} else if stateName == selfUpdateStateUninitialized {
 		su.err = log.NewErrorf("cannot perform self update operation. SUA is not configured yet")
 		su.result = SelfUpdateResultError
 		log.Error(su.err.Error())
@@ -119,8 +204,10 @@ func (su *selfUpdateOperation) handleSelfUpdateDesiredStateFeedback(mqttClient m
 		}
 		if stateName == selfUpdateStateInstalling {
 			log.Info("self update bundle is installing with progress '%v'", progress)
+			su.notifyProgress(orchestration.ProgressPhaseInstall, progress, "self update bundle %s is installing", metadataName)
 		} else {
 			log.Info("self update bundle is downloading with progress '%v'", progress)
+			su.notifyProgress(orchestration.ProgressPhaseDownload, progress, "self update bundle %s is downloading", metadataName)
 		}
 	} else if stateName == selfUpdateStateFailed {
 		var ok bool
@@ -145,11 +232,23 @@ func (su *selfUpdateOperation) handleSelfUpdateDesiredStateFeedback(mqttClient m
 		su.done <- true
 	} else if stateName == selfUpdateStateInstalled {
 		log.Info("the self update bundle is installed successfully")
+		su.notifyProgress(orchestration.ProgressPhaseInstall, 100, "self update bundle %s is installed", metadataName)
 		su.result = SelfUpdateResultInstalled
 		su.done <- true
 	}
 }
 
+func (su *selfUpdateOperation) notifyProgress(phase orchestration.ProgressPhase, progress int64, messageFormat string, args ...interface{}) {
+	if su.progressHandler == nil {
+		return
+	}
+	su.progressHandler(&orchestration.Progress{
+		Phase:    phase,
+		Progress: int(progress),
+		Message:  fmt.Sprintf(messageFormat, args...),
+	})
+}
+
 func (su *selfUpdateOperation) checkStateProgress(progress int64) bool {
 	return (progress >= 0) && (progress <= 100)
 }
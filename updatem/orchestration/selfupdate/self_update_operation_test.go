@@ -14,10 +14,38 @@ package selfupdate
 import (
 	"testing"
 
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/orchestration"
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/pkg/testutil"
 	mocks "github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/pkg/testutil/mocks/mqtt"
 	"github.com/golang/mock/gomock"
 )
 
+const (
+	selfUpdateDesiredStateFeedbackIdle = `
+apiVersion: sdv.eclipse.org/v1
+kind: SelfUpdateBundle
+metadata:
+ name: self-update-bundle-example
+state:
+ message: "Self update bundle idle"
+ name: idle
+`
+	selfUpdateDesiredStateFeedbackDownloaded = `
+apiVersion: sdv.eclipse.org/v1
+kind: SelfUpdateBundle
+metadata:
+ name: self-update-bundle-example
+spec:
+ bundleDownloadUrl: baseURL
+ bundleName: swdv-arm64-build42
+ bundleTarget: base
+ bundleVersion: v1beta3
+state:
+ message: "Self update bundle downloaded"
+ name: downloaded
+`
+)
+
 func TestMalformedSelfUpdateDesiredStateFeedback(t *testing.T) {
 	var testData = []struct {
 		err                  string
@@ -454,15 +482,139 @@ state:
 
 	selfUpdateOperation := newSelfUpdateOperation()
 	mockClient := mocks.NewMockClient(controller)
+	progress := []*orchestration.Progress{}
+	selfUpdateOperation.progressHandler = func(p *orchestration.Progress) {
+		progress = append(progress, p)
+	}
 
 	selfUpdateOperation.handleSelfUpdateDesiredStateFeedback(mockClient, setupMockMessage(controller, selfUpdateDesiredStateFeedbackDownloading))
 	assertSelfUpdateOperationResult(t, SelfUpdateNoResult, false, selfUpdateOperation)
 
+	selfUpdateOperation.handleSelfUpdateDesiredStateFeedback(mockClient, setupMockMessage(controller, selfUpdateDesiredStateFeedbackDownloaded))
+	assertSelfUpdateOperationResult(t, SelfUpdateNoResult, false, selfUpdateOperation)
+
 	selfUpdateOperation.handleSelfUpdateDesiredStateFeedback(mockClient, setupMockMessage(controller, selfUpdateDesiredStateFeedbackInstalling))
 	assertSelfUpdateOperationResult(t, SelfUpdateNoResult, false, selfUpdateOperation)
 
 	selfUpdateOperation.handleSelfUpdateDesiredStateFeedback(mockClient, setupMockMessage(controller, selfUpdateDesiredStateFeedbackInstalled))
 	assertSelfUpdateOperationResult(t, SelfUpdateResultInstalled, false, selfUpdateOperation)
+
+	testutil.AssertEqual(t, []*orchestration.Progress{
+		{Phase: orchestration.ProgressPhaseDownload, Progress: 50, Message: "self update bundle self-update-bundle-example is downloading"},
+		{Phase: orchestration.ProgressPhaseDownload, Progress: 100, Message: "self update bundle self-update-bundle-example is downloaded"},
+		{Phase: orchestration.ProgressPhaseInstall, Progress: 0, Message: "self update bundle self-update-bundle-example is installing"},
+		{Phase: orchestration.ProgressPhaseInstall, Progress: 50, Message: "self update bundle self-update-bundle-example is installing"},
+		{Phase: orchestration.ProgressPhaseInstall, Progress: 100, Message: "self update bundle self-update-bundle-example is installed"},
+	}, progress)
+}
+
+func TestSelfUpdateOperationStateDownloaded(t *testing.T) {
+	var testData = []struct {
+		name          string
+		downloadOnly  bool
+		desiredResult OperationResult
+	}{
+		{
+			"download_only",
+			true,
+			SelfUpdateResultDownloaded,
+		},
+		{
+			"download_and_install",
+			false,
+			SelfUpdateNoResult,
+		},
+	}
+	controller := gomock.NewController(t)
+	for _, testValues := range testData {
+		t.Run(testValues.name, func(t *testing.T) {
+			selfUpdateOperation := newSelfUpdateOperation()
+			selfUpdateOperation.downloadOnly = testValues.downloadOnly
+			mockClient := mocks.NewMockClient(controller)
+			selfUpdateOperation.handleSelfUpdateDesiredStateFeedback(mockClient, setupMockMessage(controller, selfUpdateDesiredStateFeedbackDownloaded))
+			assertSelfUpdateOperationResult(t, testValues.desiredResult, false, selfUpdateOperation)
+			if selfUpdateOperation.state != selfUpdateStateDownloaded || !selfUpdateOperation.cancellable {
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestSelfUpdateOperationCancel(t *testing.T) {
+	selfUpdateDesiredStateFeedbackDownloading := `
+apiVersion: sdv.eclipse.org/v1
+kind: SelfUpdateBundle
+metadata:
+ name: self-update-bundle-example
+state:
+ message: "Self update bundle downloading"
+ name: downloading
+ progress: 50
+`
+	controller := gomock.NewController(t)
+
+	selfUpdateOperation := newSelfUpdateOperation()
+	mockClient := mocks.NewMockClient(controller)
+
+	selfUpdateOperation.handleSelfUpdateDesiredStateFeedback(mockClient, setupMockMessage(controller, selfUpdateDesiredStateFeedbackDownloading))
+	assertSelfUpdateOperationResult(t, SelfUpdateNoResult, false, selfUpdateOperation)
+
+	if err := selfUpdateOperation.requestCancel(); err != nil {
+		t.Fatalf("unexpected cancel error: %v", err)
+	}
+
+	selfUpdateOperation.handleSelfUpdateDesiredStateFeedback(mockClient, setupMockMessage(controller, selfUpdateDesiredStateFeedbackIdle))
+	assertSelfUpdateOperationResult(t, SelfUpdateResultCancelled, true, selfUpdateOperation)
+}
+
+func TestSelfUpdateOperationNotCancellable(t *testing.T) {
+	var testData = []struct {
+		name                 string
+		desiredStateFeedback string
+	}{
+		{
+			"state_installing",
+			`
+apiVersion: sdv.eclipse.org/v1
+kind: SelfUpdateBundle
+metadata:
+ name: self-update-bundle-example
+state:
+ message: "Self update bundle installing"
+ name: installing
+ progress: 50
+`,
+		},
+		{
+			"state_downloading_not_cancellable",
+			`
+apiVersion: sdv.eclipse.org/v1
+kind: SelfUpdateBundle
+metadata:
+ name: self-update-bundle-example
+state:
+ message: "Self update bundle downloading"
+ name: downloading
+ progress: 90
+ cancellable: false
+`,
+		},
+	}
+	controller := gomock.NewController(t)
+	for _, testValues := range testData {
+		t.Run(testValues.name, func(t *testing.T) {
+			selfUpdateOperation := newSelfUpdateOperation()
+			mockClient := mocks.NewMockClient(controller)
+			selfUpdateOperation.handleSelfUpdateDesiredStateFeedback(mockClient, setupMockMessage(controller, testValues.desiredStateFeedback))
+			if err := selfUpdateOperation.requestCancel(); err == nil {
+				t.Fail()
+			}
+
+			// the agent becoming idle does not finish an operation, which is not cancelled
+			selfUpdateOperation.handleSelfUpdateDesiredStateFeedback(mockClient, setupMockMessage(controller, selfUpdateDesiredStateFeedbackIdle))
+			assertSelfUpdateOperationResult(t, SelfUpdateNoResult, false, selfUpdateOperation)
+		})
+	}
 }
 
 func TestInvalidSelfUpdateStateTechCode(t *testing.T) {
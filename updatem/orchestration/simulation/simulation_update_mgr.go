@@ -0,0 +1,369 @@
+// Copyright (c) 2022 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Apache License 2.0 which is available at
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package simulation
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eclipse-kanto/container-management/containerm/log"
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/events"
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/orchestration"
+	"github.com/google/uuid"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+type simulationUpdateManager struct {
+	cfg       *mgrOpts
+	eventsMgr events.UpdateEventsManager
+	applyLock sync.Mutex
+
+	// stateLock guards the resources of the simulated cluster
+	stateLock       sync.RWMutex
+	resources       map[string]*unstructured.Unstructured
+	resourceVersion int64
+}
+
+// resourceChange is a change of the simulated cluster, which is published as a resource event
+type resourceChange struct {
+	action   events.EventAction
+	resource *unstructured.Unstructured
+}
+
+// Apply applies the manifest to the in-memory cluster state and simulates the status transitions of the applied Pod and Deployment resources
+func (updMgr *simulationUpdateManager) Apply(ctx context.Context, mf []*unstructured.Unstructured) interface{} {
+	updMgr.applyLock.Lock()
+	defer updMgr.applyLock.Unlock()
+
+	log.Debug("processing apply manifest - start")
+	dryRun := orchestration.IsUpdateMgrDryRunContext(ctx)
+	reconcile := orchestration.IsUpdateMgrReconcileContext(ctx)
+	if !dryRun {
+		if err := sleep(ctx, updMgr.cfg.applyDelay); err != nil {
+			return &orchestration.ApplyResult{Err: err}
+		}
+	}
+
+	applyResult := &orchestration.ApplyResult{}
+	changes := []*resourceChange{}
+	failed := []string{}
+	desired := map[string]bool{}
+	workloads := []string{}
+
+	updMgr.stateLock.Lock()
+	for _, u := range mf {
+		resource := normalize(u)
+		key := resourceKey(resource)
+		desired[key] = true
+		resourceResult := newResourceResult(resource)
+		if updMgr.cfg.failingResources[resourceName(resource)] {
+			resourceResult.Outcome = orchestration.ResourceOutcomeFailed
+			resourceResult.Message = "simulated apply failure"
+			failed = append(failed, resourceName(resource))
+		} else {
+			resourceResult.Outcome = updMgr.applyResource(resource, dryRun, &changes)
+			if !dryRun && resourceResult.Outcome != orchestration.ResourceOutcomeUnchanged && isWorkload(resource) {
+				workloads = append(workloads, key)
+			}
+		}
+		applyResult.Resources = append(applyResult.Resources, resourceResult)
+	}
+	// the reconciliation re-applies a subset of the desired manifest, so nothing must be pruned
+	if !reconcile {
+		for _, key := range updMgr.sortedKeys() {
+			resource := updMgr.resources[key]
+			if desired[key] || len(resource.GetOwnerReferences()) > 0 {
+				continue
+			}
+			resourceResult := newResourceResult(resource)
+			resourceResult.Outcome = orchestration.ResourceOutcomePruned
+			if !dryRun {
+				updMgr.deleteResource(key, &changes)
+			}
+			applyResult.Resources = append(applyResult.Resources, resourceResult)
+		}
+	}
+	updMgr.stateLock.Unlock()
+	updMgr.publishResourceEvents(ctx, changes)
+
+	if len(failed) > 0 {
+		applyResult.Err = log.NewErrorf("cannot apply the resource(s) %s: simulated apply failure", strings.Join(failed, ", "))
+		log.ErrorErr(applyResult.Err, "error while applying manifest")
+		return applyResult
+	}
+	if len(workloads) > 0 {
+		if err := updMgr.simulateReadiness(ctx, workloads, reconcile); err != nil {
+			log.ErrorErr(err, "error while waiting for the manifest resources to become ready")
+			applyResult.Err = err
+			return applyResult
+		}
+	}
+	log.Debug("finished applying manifest")
+	return applyResult
+}
+
+// Get returns the resources of the simulated cluster
+func (updMgr *simulationUpdateManager) Get(ctx context.Context) []*unstructured.Unstructured {
+	updMgr.stateLock.RLock()
+	defer updMgr.stateLock.RUnlock()
+
+	result := []*unstructured.Unstructured{}
+	for _, key := range updMgr.sortedKeys() {
+		result = append(result, updMgr.resources[key].DeepCopy())
+	}
+	return result
+}
+
+func (updMgr *simulationUpdateManager) Dispose(ctx context.Context) error {
+	return nil
+}
+
+// applyResource stores the resource, if it is not already applied, and returns the outcome
+func (updMgr *simulationUpdateManager) applyResource(resource *unstructured.Unstructured, dryRun bool, changes *[]*resourceChange) orchestration.ResourceOutcome {
+	key := resourceKey(resource)
+	existing, ok := updMgr.resources[key]
+	if ok && reflect.DeepEqual(desiredFields(existing), desiredFields(resource)) {
+		return orchestration.ResourceOutcomeUnchanged
+	}
+	outcome, action := orchestration.ResourceOutcomeCreated, events.EventActionResourcesAdded
+	if ok {
+		outcome, action = orchestration.ResourceOutcomeConfigured, events.EventActionResourcesUpdated
+	}
+	if dryRun {
+		return outcome
+	}
+
+	if ok {
+		resource.SetUID(existing.GetUID())
+		resource.SetCreationTimestamp(existing.GetCreationTimestamp())
+		resource.SetGeneration(existing.GetGeneration() + 1)
+	} else {
+		resource.SetUID(types.UID(uuid.New().String()))
+		resource.SetCreationTimestamp(metav1.Now())
+		resource.SetGeneration(1)
+	}
+	switch resource.GroupVersionKind().GroupKind() {
+	case podGroupKind:
+		setPodStatus(resource, podStatePending)
+	case deploymentGroupKind:
+		setDeploymentStatus(resource, 0)
+	}
+	updMgr.storeResource(resource, action, changes)
+
+	if resource.GroupVersionKind().GroupKind() == deploymentGroupKind {
+		updMgr.deleteOwnedResources(resource, changes)
+		for _, pod := range deploymentPods(resource) {
+			pod.SetUID(types.UID(uuid.New().String()))
+			pod.SetCreationTimestamp(metav1.Now())
+			updMgr.storeResource(pod, events.EventActionResourcesAdded, changes)
+		}
+	}
+	return outcome
+}
+
+func (updMgr *simulationUpdateManager) storeResource(resource *unstructured.Unstructured, action events.EventAction, changes *[]*resourceChange) {
+	updMgr.resourceVersion++
+	resource.SetResourceVersion(strconv.FormatInt(updMgr.resourceVersion, 10))
+	updMgr.resources[resourceKey(resource)] = resource
+	*changes = append(*changes, &resourceChange{action: action, resource: resource.DeepCopy()})
+}
+
+func (updMgr *simulationUpdateManager) deleteResource(key string, changes *[]*resourceChange) {
+	resource := updMgr.resources[key]
+	delete(updMgr.resources, key)
+	*changes = append(*changes, &resourceChange{action: events.EventActionResourcesDeleted, resource: resource.DeepCopy()})
+	updMgr.deleteOwnedResources(resource, changes)
+}
+
+// deleteOwnedResources deletes the resources controlled by the given owner, e.g. the pods of a deployment
+func (updMgr *simulationUpdateManager) deleteOwnedResources(owner *unstructured.Unstructured, changes *[]*resourceChange) {
+	for _, key := range updMgr.sortedKeys() {
+		// the owned resources of an already deleted resource are deleted along with it
+		if u, ok := updMgr.resources[key]; ok && isOwnedBy(u, owner) {
+			updMgr.deleteResource(key, changes)
+		}
+	}
+}
+
+// ownedPods returns the pods of the workload resource - a pod owns itself
+func (updMgr *simulationUpdateManager) ownedPods(workload *unstructured.Unstructured) []*unstructured.Unstructured {
+	if workload.GroupVersionKind().GroupKind() == podGroupKind {
+		return []*unstructured.Unstructured{workload}
+	}
+	pods := []*unstructured.Unstructured{}
+	for _, key := range updMgr.sortedKeys() {
+		if u := updMgr.resources[key]; u.GroupVersionKind().GroupKind() == podGroupKind && isOwnedBy(u, workload) {
+			pods = append(pods, u)
+		}
+	}
+	return pods
+}
+
+// simulateReadiness waits for the readiness delay and transitions the applied workload resources to running or crashing.
+// The reconciliation does not wait for the readiness, so the transitions are done immediately.
+func (updMgr *simulationUpdateManager) simulateReadiness(ctx context.Context, workloads []string, reconcile bool) error {
+	if !reconcile {
+		updMgr.publishOrchestrationEvent(ctx, orchestration.EventActionOrchestrationRunning, &orchestration.Progress{
+			Phase:    orchestration.ProgressPhaseReadiness,
+			Progress: 0,
+			Message:  fmt.Sprintf("0 of %d workload resource(s) ready", len(workloads)),
+		})
+		if err := sleep(ctx, updMgr.cfg.readinessDelay); err != nil {
+			return err
+		}
+	}
+
+	changes := []*resourceChange{}
+	crashed := []string{}
+	updMgr.stateLock.Lock()
+	for _, key := range workloads {
+		workload, ok := updMgr.resources[key]
+		if !ok {
+			continue
+		}
+		state := podStateRunning
+		if updMgr.cfg.crashingResources[resourceName(workload)] {
+			state = podStateCrashing
+			crashed = append(crashed, resourceName(workload))
+		}
+		for _, pod := range updMgr.ownedPods(workload) {
+			setPodStatus(pod, state)
+			if pod != workload {
+				updMgr.storeResource(pod, events.EventActionResourcesUpdated, &changes)
+			}
+		}
+		if workload.GroupVersionKind().GroupKind() == deploymentGroupKind {
+			readyReplicas := int64(0)
+			if state == podStateRunning {
+				readyReplicas = deploymentReplicas(workload)
+			}
+			setDeploymentStatus(workload, readyReplicas)
+		}
+		updMgr.storeResource(workload, events.EventActionResourcesUpdated, &changes)
+	}
+	updMgr.stateLock.Unlock()
+	updMgr.publishResourceEvents(ctx, changes)
+
+	if reconcile {
+		return nil
+	}
+	ready := len(workloads) - len(crashed)
+	updMgr.publishOrchestrationEvent(ctx, orchestration.EventActionOrchestrationRunning, &orchestration.Progress{
+		Phase:    orchestration.ProgressPhaseReadiness,
+		Progress: ready * 100 / len(workloads),
+		Message:  fmt.Sprintf("%d of %d workload resource(s) ready", ready, len(workloads)),
+	})
+	if len(crashed) > 0 {
+		return log.NewErrorf("the resource(s) %s have failed: simulated container crash", strings.Join(crashed, ", "))
+	}
+	return nil
+}
+
+func (updMgr *simulationUpdateManager) sortedKeys() []string {
+	keys := []string{}
+	for key := range updMgr.resources {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (updMgr *simulationUpdateManager) publishResourceEvents(ctx context.Context, changes []*resourceChange) {
+	for _, change := range changes {
+		e := &events.Event{
+			Type:    events.EventTypeResources,
+			Action:  change.action,
+			Source:  *change.resource,
+			Time:    time.Now().UTC().Unix(),
+			Context: ctx,
+		}
+		if pubErr := updMgr.eventsMgr.Publish(ctx, e); pubErr != nil {
+			log.ErrorErr(pubErr, "failed to publish resource event [%+v]", e)
+		}
+	}
+}
+
+func (updMgr *simulationUpdateManager) publishOrchestrationEvent(ctx context.Context, eventAction events.EventAction, eventSource interface{}) {
+	e := &events.Event{
+		Type:    orchestration.EventTypeOrchestration,
+		Action:  eventAction,
+		Source:  eventSource,
+		Time:    time.Now().UTC().Unix(),
+		Context: ctx,
+	}
+	if pubErr := updMgr.eventsMgr.Publish(ctx, e); pubErr != nil {
+		log.ErrorErr(pubErr, "failed to publish orchestration event [%+v]", e)
+	}
+}
+
+// normalize returns a copy of the manifest resource, which is namespaced to the default namespace if not set
+func normalize(u *unstructured.Unstructured) *unstructured.Unstructured {
+	resource := u.DeepCopy()
+	if resource.GetNamespace() == "" && !orchestration.IsClusterScoped(resource) {
+		resource.SetNamespace(metav1.NamespaceDefault)
+	}
+	return resource
+}
+
+func resourceKey(u *unstructured.Unstructured) string {
+	groupKind := u.GroupVersionKind().GroupKind()
+	return fmt.Sprintf("%s/%s/%s/%s", groupKind.Group, groupKind.Kind, u.GetNamespace(), u.GetName())
+}
+
+func resourceName(u *unstructured.Unstructured) string {
+	return fmt.Sprintf("%s/%s", strings.ToLower(u.GetKind()), u.GetName())
+}
+
+// desiredFields returns the fields set by the manifest - the status and the metadata set by the simulated cluster are excluded
+func desiredFields(u *unstructured.Unstructured) map[string]interface{} {
+	fields := map[string]interface{}{}
+	for field, value := range u.Object {
+		if field != "metadata" && field != "status" {
+			fields[field] = value
+		}
+	}
+	fields["labels"] = u.GetLabels()
+	fields["annotations"] = u.GetAnnotations()
+	return fields
+}
+
+func newResourceResult(u *unstructured.Unstructured) *orchestration.ResourceResult {
+	return &orchestration.ResourceResult{
+		APIVersion: u.GetAPIVersion(),
+		Kind:       u.GetKind(),
+		Namespace:  u.GetNamespace(),
+		Name:       u.GetName(),
+	}
+}
+
+// sleep waits for the given duration or until the context is done
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
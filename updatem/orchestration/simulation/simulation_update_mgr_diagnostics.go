@@ -0,0 +1,72 @@
+// Copyright (c) 2022 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Apache License 2.0 which is available at
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package simulation
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/orchestration"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Diagnose reports the crashing containers of the simulated workload resources of the manifest
+func (updMgr *simulationUpdateManager) Diagnose(ctx context.Context, mf []*unstructured.Unstructured) []*orchestration.ResourceDiagnostics {
+	updMgr.stateLock.RLock()
+	defer updMgr.stateLock.RUnlock()
+
+	result := []*orchestration.ResourceDiagnostics{}
+	for _, u := range mf {
+		workload, ok := updMgr.resources[resourceKey(normalize(u))]
+		if !ok || !isWorkload(workload) {
+			continue
+		}
+		diagnostics := &orchestration.ResourceDiagnostics{
+			APIVersion: workload.GetAPIVersion(),
+			Kind:       workload.GetKind(),
+			Namespace:  workload.GetNamespace(),
+			Name:       workload.GetName(),
+		}
+		for _, pod := range updMgr.ownedPods(workload) {
+			statuses, _, _ := unstructured.NestedSlice(pod.Object, "status", "containerStatuses")
+			for _, s := range statuses {
+				status, ok := s.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				reason, _, _ := unstructured.NestedString(status, "state", "waiting", "reason")
+				if reason != "CrashLoopBackOff" {
+					continue
+				}
+				message, _, _ := unstructured.NestedString(status, "state", "waiting", "message")
+				restartCount, _, _ := unstructured.NestedInt64(status, "restartCount")
+				diagnostics.Containers = append(diagnostics.Containers, &orchestration.ContainerDiagnostics{
+					Pod:          pod.GetName(),
+					Container:    fmt.Sprint(status["name"]),
+					State:        fmt.Sprintf("waiting: %s %s", reason, message),
+					RestartCount: int32(restartCount),
+					Logs:         []string{fmt.Sprintf("simulated crash with exit code %d", crashExitCode)},
+				})
+				diagnostics.Events = append(diagnostics.Events, &orchestration.DiagnosticsEvent{
+					Object:  "pod/" + pod.GetName(),
+					Reason:  "BackOff",
+					Message: message,
+					Count:   int32(restartCount),
+				})
+			}
+		}
+		if len(diagnostics.Containers) > 0 {
+			result = append(result, diagnostics)
+		}
+	}
+	return result
+}
@@ -0,0 +1,40 @@
+// Copyright (c) 2022 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Apache License 2.0 which is available at
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package simulation
+
+import (
+	"github.com/eclipse-kanto/container-management/containerm/registry"
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/events"
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/orchestration"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// NewSimulationUpdateManager instantiates a new update manager, which applies the manifests to an in-memory cluster state
+func NewSimulationUpdateManager(opts []MgrOpt, registryCtx *registry.ServiceRegistryContext) (orchestration.UpdateManager, error) {
+	var (
+		cfg = &mgrOpts{}
+	)
+	if err := applyOptsMgr(cfg, opts...); err != nil {
+		return nil, err
+	}
+
+	eventsManagerService, err := registryCtx.Get(registry.EventsManagerService)
+	if err != nil {
+		return nil, err
+	}
+
+	return &simulationUpdateManager{
+		cfg:       cfg,
+		eventsMgr: eventsManagerService.(events.UpdateEventsManager),
+		resources: map[string]*unstructured.Unstructured{},
+	}, nil
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2022 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Apache License 2.0 which is available at
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package simulation
+
+import (
+	"strings"
+	"time"
+
+	"github.com/eclipse-kanto/container-management/containerm/log"
+)
+
+// MgrOpt defines the creation configuration options for a simulation update manager implementation
+type MgrOpt func(mgrOptions *mgrOpts) error
+
+type mgrOpts struct {
+	applyDelay        time.Duration
+	readinessDelay    time.Duration
+	failingResources  map[string]bool
+	crashingResources map[string]bool
+}
+
+func applyOptsMgr(mgrOpts *mgrOpts, opts ...MgrOpt) error {
+	for _, o := range opts {
+		if err := o(mgrOpts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WithApplyDelay configures how long applying a manifest to the simulated cluster takes, e.g. 2s
+func WithApplyDelay(applyDelay string) MgrOpt {
+	return func(mgrOptions *mgrOpts) error {
+		delay, err := parseDelay(applyDelay)
+		if err != nil {
+			return log.NewErrorf("invalid apply delay %s", applyDelay)
+		}
+		mgrOptions.applyDelay = delay
+		return nil
+	}
+}
+
+// WithReadinessDelay configures how long the applied Pod and Deployment resources take to become ready, e.g. 10s
+func WithReadinessDelay(readinessDelay string) MgrOpt {
+	return func(mgrOptions *mgrOpts) error {
+		delay, err := parseDelay(readinessDelay)
+		if err != nil {
+			return log.NewErrorf("invalid readiness delay %s", readinessDelay)
+		}
+		mgrOptions.readinessDelay = delay
+		return nil
+	}
+}
+
+// WithFailingResources configures the resources in the <kind>/<name> format, e.g. deployment/hello-world, which fail to be applied
+func WithFailingResources(failingResources []string) MgrOpt {
+	return func(mgrOptions *mgrOpts) error {
+		resources, err := parseResources(failingResources)
+		if err != nil {
+			return err
+		}
+		mgrOptions.failingResources = resources
+		return nil
+	}
+}
+
+// WithCrashingResources configures the Pod and Deployment resources in the <kind>/<name> format, e.g. pod/hello-world,
+// whose containers crash after they are applied, so that the resources never become ready
+func WithCrashingResources(crashingResources []string) MgrOpt {
+	return func(mgrOptions *mgrOpts) error {
+		resources, err := parseResources(crashingResources)
+		if err != nil {
+			return err
+		}
+		mgrOptions.crashingResources = resources
+		return nil
+	}
+}
+
+func parseDelay(delay string) (time.Duration, error) {
+	if delay == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(delay)
+	if err != nil {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, log.NewErrorf("negative delay %s", delay)
+	}
+	return d, nil
+}
+
+func parseResources(resources []string) (map[string]bool, error) {
+	result := map[string]bool{}
+	for _, resource := range resources {
+		parts := strings.Split(resource, "/")
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, log.NewErrorf("invalid resource %s - the expected format is <kind>/<name>", resource)
+		}
+		result[strings.ToLower(parts[0])+"/"+parts[1]] = true
+	}
+	return result, nil
+}
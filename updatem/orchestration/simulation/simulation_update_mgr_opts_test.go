@@ -0,0 +1,76 @@
+// Copyright (c) 2022 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Apache License 2.0 which is available at
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package simulation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/eclipse-kanto/container-management/containerm/log"
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/pkg/testutil"
+)
+
+func TestMgrOpts(t *testing.T) {
+	testCases := map[string]struct {
+		opts         []MgrOpt
+		expectedOpts *mgrOpts
+		expectedErr  error
+	}{
+		"test_no_error": {
+			opts: []MgrOpt{
+				WithApplyDelay("2s"),
+				WithReadinessDelay("10s"),
+				WithFailingResources([]string{"Deployment/hello-world"}),
+				WithCrashingResources([]string{"pod/hello-world"}),
+			},
+			expectedOpts: &mgrOpts{
+				applyDelay:        2 * time.Second,
+				readinessDelay:    10 * time.Second,
+				failingResources:  map[string]bool{"deployment/hello-world": true},
+				crashingResources: map[string]bool{"pod/hello-world": true},
+			},
+		},
+		"test_empty_delays": {
+			opts:         []MgrOpt{WithApplyDelay(""), WithReadinessDelay("")},
+			expectedOpts: &mgrOpts{},
+		},
+		"test_error_apply_delay": {
+			opts:         []MgrOpt{WithApplyDelay("-2s")},
+			expectedOpts: &mgrOpts{},
+			expectedErr:  log.NewError("invalid apply delay -2s"),
+		},
+		"test_error_readiness_delay": {
+			opts:         []MgrOpt{WithReadinessDelay("10 seconds")},
+			expectedOpts: &mgrOpts{},
+			expectedErr:  log.NewError("invalid readiness delay 10 seconds"),
+		},
+		"test_error_failing_resources": {
+			opts:         []MgrOpt{WithFailingResources([]string{"hello-world"})},
+			expectedOpts: &mgrOpts{},
+			expectedErr:  log.NewError("invalid resource hello-world - the expected format is <kind>/<name>"),
+		},
+		"test_error_crashing_resources": {
+			opts:         []MgrOpt{WithCrashingResources([]string{"pod/"})},
+			expectedOpts: &mgrOpts{},
+			expectedErr:  log.NewError("invalid resource pod/ - the expected format is <kind>/<name>"),
+		},
+	}
+	for testCaseName, testCase := range testCases {
+		t.Run(testCaseName, func(t *testing.T) {
+			t.Log(testCaseName)
+			actualOpts := &mgrOpts{}
+			err := applyOptsMgr(actualOpts, testCase.opts...)
+			testutil.AssertError(t, testCase.expectedErr, err)
+			testutil.AssertEqual(t, testCase.expectedOpts, actualOpts)
+		})
+	}
+}
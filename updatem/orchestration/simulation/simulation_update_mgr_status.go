@@ -0,0 +1,195 @@
+// Copyright (c) 2022 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Apache License 2.0 which is available at
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package simulation
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	"github.com/eclipse-kanto/container-management/containerm/log"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// podState represents the simulated state of the containers of a pod
+type podState int
+
+const (
+	podStatePending podState = iota
+	podStateRunning
+	podStateCrashing
+)
+
+const (
+	crashRestartCount = int64(3)
+	crashExitCode     = int64(1)
+)
+
+var (
+	podGroupKind        = schema.GroupKind{Kind: "Pod"}
+	deploymentGroupKind = schema.GroupKind{Group: "apps", Kind: "Deployment"}
+)
+
+// isWorkload checks if the status of the resource is simulated
+func isWorkload(u *unstructured.Unstructured) bool {
+	groupKind := u.GroupVersionKind().GroupKind()
+	return groupKind == podGroupKind || groupKind == deploymentGroupKind
+}
+
+func now() string {
+	return time.Now().UTC().Format(time.RFC3339)
+}
+
+// setPodStatus sets the status of the pod and its containers to the given state
+func setPodStatus(pod *unstructured.Unstructured, state podState) {
+	containers, _, _ := unstructured.NestedSlice(pod.Object, "spec", "containers")
+	statuses := []interface{}{}
+	for _, c := range containers {
+		container, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		status := map[string]interface{}{
+			"name":         container["name"],
+			"image":        container["image"],
+			"ready":        state == podStateRunning,
+			"restartCount": int64(0),
+		}
+		switch state {
+		case podStatePending:
+			status["state"] = map[string]interface{}{"waiting": map[string]interface{}{"reason": "ContainerCreating"}}
+		case podStateRunning:
+			status["state"] = map[string]interface{}{"running": map[string]interface{}{"startedAt": now()}}
+		case podStateCrashing:
+			status["restartCount"] = crashRestartCount
+			status["state"] = map[string]interface{}{"waiting": map[string]interface{}{
+				"reason":  "CrashLoopBackOff",
+				"message": fmt.Sprintf("back-off restarting failed container %s in pod %s", container["name"], pod.GetName()),
+			}}
+			status["lastState"] = map[string]interface{}{"terminated": map[string]interface{}{
+				"reason":     "Error",
+				"exitCode":   crashExitCode,
+				"finishedAt": now(),
+			}}
+		}
+		statuses = append(statuses, status)
+	}
+
+	phase := "Running"
+	if state == podStatePending {
+		phase = "Pending"
+	}
+	ready := "False"
+	if state == podStateRunning {
+		ready = "True"
+	}
+	status := map[string]interface{}{
+		"phase": phase,
+		"conditions": []interface{}{
+			map[string]interface{}{"type": "PodScheduled", "status": "True"},
+			map[string]interface{}{"type": "Ready", "status": ready},
+		},
+		"containerStatuses": statuses,
+	}
+	if err := unstructured.SetNestedMap(pod.Object, status, "status"); err != nil {
+		log.ErrorErr(err, "cannot set the status of Pod %s", pod.GetName())
+	}
+}
+
+// setDeploymentStatus sets the replicas status of the deployment according to the number of its ready pods
+func setDeploymentStatus(deployment *unstructured.Unstructured, readyReplicas int64) {
+	replicas := deploymentReplicas(deployment)
+	available, reason := "False", "MinimumReplicasUnavailable"
+	if readyReplicas >= replicas {
+		available, reason = "True", "MinimumReplicasAvailable"
+	}
+	status := map[string]interface{}{
+		"observedGeneration": deployment.GetGeneration(),
+		"replicas":           replicas,
+		"updatedReplicas":    replicas,
+		"readyReplicas":      readyReplicas,
+		"availableReplicas":  readyReplicas,
+		"conditions": []interface{}{
+			map[string]interface{}{"type": "Available", "status": available, "reason": reason, "lastUpdateTime": now()},
+			map[string]interface{}{"type": "Progressing", "status": "True", "reason": "NewReplicaSetAvailable", "lastUpdateTime": now()},
+		},
+	}
+	if err := unstructured.SetNestedMap(deployment.Object, status, "status"); err != nil {
+		log.ErrorErr(err, "cannot set the status of Deployment %s", deployment.GetName())
+	}
+}
+
+func deploymentReplicas(deployment *unstructured.Unstructured) int64 {
+	replicas, found, _ := unstructured.NestedInt64(deployment.Object, "spec", "replicas")
+	if !found {
+		return 1
+	}
+	return replicas
+}
+
+// deploymentPods creates the pending pods of the deployment from its pod template
+func deploymentPods(deployment *unstructured.Unstructured) []*unstructured.Unstructured {
+	template, _, _ := unstructured.NestedMap(deployment.Object, "spec", "template")
+	templateHash := podTemplateHash(template)
+	pods := []*unstructured.Unstructured{}
+	for i := int64(0); i < deploymentReplicas(deployment); i++ {
+		pod := &unstructured.Unstructured{Object: map[string]interface{}{}}
+		if spec, ok := template["spec"].(map[string]interface{}); ok {
+			pod.Object["spec"] = runtimeDeepCopy(spec)
+		}
+		pod.SetAPIVersion("v1")
+		pod.SetKind("Pod")
+		pod.SetNamespace(deployment.GetNamespace())
+		pod.SetName(fmt.Sprintf("%s-%s-%d", deployment.GetName(), templateHash, i))
+		labels, _, _ := unstructured.NestedStringMap(template, "metadata", "labels")
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		labels["pod-template-hash"] = templateHash
+		pod.SetLabels(labels)
+		controller := true
+		pod.SetOwnerReferences([]metav1.OwnerReference{{
+			APIVersion: deployment.GetAPIVersion(),
+			Kind:       deployment.GetKind(),
+			Name:       deployment.GetName(),
+			UID:        deployment.GetUID(),
+			Controller: &controller,
+		}})
+		setPodStatus(pod, podStatePending)
+		pods = append(pods, pod)
+	}
+	return pods
+}
+
+func podTemplateHash(template map[string]interface{}) string {
+	data, _ := json.Marshal(template)
+	hasher := fnv.New32a()
+	hasher.Write(data)
+	return fmt.Sprintf("%08x", hasher.Sum32())
+}
+
+func runtimeDeepCopy(m map[string]interface{}) map[string]interface{} {
+	return (&unstructured.Unstructured{Object: m}).DeepCopy().Object
+}
+
+// isOwnedBy checks if the resource is controlled by the given owner
+func isOwnedBy(u *unstructured.Unstructured, owner *unstructured.Unstructured) bool {
+	for _, ref := range u.GetOwnerReferences() {
+		if ref.UID == owner.GetUID() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,209 @@
+// Copyright (c) 2022 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Apache License 2.0 which is available at
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package simulation
+
+import (
+	"context"
+	"testing"
+
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/events"
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/orchestration"
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/pkg/testutil"
+	eventsmock "github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/pkg/testutil/mocks/events"
+	"github.com/golang/mock/gomock"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	testDeployment = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: hello-world
+spec:
+  replicas: 2
+  selector:
+    matchLabels:
+      app: hello-world
+  template:
+    metadata:
+      labels:
+        app: hello-world
+    spec:
+      containers:
+      - name: hello-world
+        image: ghcr.io/eclipse-leda/hello-world:1.0.0
+`
+	testPod = `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: databroker
+spec:
+  containers:
+  - name: databroker
+    image: ghcr.io/eclipse/kuksa.val/databroker:0.2.5
+`
+)
+
+func newTestResource(t *testing.T, content string) *unstructured.Unstructured {
+	data, err := yaml.YAMLToJSON([]byte(content))
+	testutil.AssertNil(t, err)
+	u := &unstructured.Unstructured{}
+	testutil.AssertNil(t, u.UnmarshalJSON(data))
+	return u
+}
+
+// newTestSimulationUpdateManager creates an update manager, which records the published events
+func newTestSimulationUpdateManager(t *testing.T, cfg *mgrOpts) (*simulationUpdateManager, *[]*events.Event) {
+	published := &[]*events.Event{}
+	eventsMgr := eventsmock.NewMockUpdateEventsManager(gomock.NewController(t))
+	eventsMgr.EXPECT().Publish(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, e *events.Event) error {
+		*published = append(*published, e)
+		return nil
+	}).AnyTimes()
+	return &simulationUpdateManager{
+		cfg:       cfg,
+		eventsMgr: eventsMgr,
+		resources: map[string]*unstructured.Unstructured{},
+	}, published
+}
+
+func assertOutcomes(t *testing.T, expected []orchestration.ResourceOutcome, applyResult *orchestration.ApplyResult) {
+	testutil.AssertEqual(t, len(expected), len(applyResult.Resources))
+	for i, outcome := range expected {
+		testutil.AssertEqual(t, outcome, applyResult.Resources[i].Outcome)
+	}
+}
+
+func countEvents(published []*events.Event, eventType events.EventType, eventAction events.EventAction) int {
+	count := 0
+	for _, e := range published {
+		if e.Type == eventType && e.Action == eventAction {
+			count++
+		}
+	}
+	return count
+}
+
+func TestApply(t *testing.T) {
+	updMgr, published := newTestSimulationUpdateManager(t, &mgrOpts{})
+	deployment := newTestResource(t, testDeployment)
+	pod := newTestResource(t, testPod)
+
+	applyResult := updMgr.Apply(context.Background(), []*unstructured.Unstructured{deployment, pod}).(*orchestration.ApplyResult)
+	testutil.AssertNil(t, applyResult.Err)
+	assertOutcomes(t, []orchestration.ResourceOutcome{orchestration.ResourceOutcomeCreated, orchestration.ResourceOutcomeCreated}, applyResult)
+	testutil.AssertEqual(t, "default", applyResult.Resources[0].Namespace)
+	testutil.AssertEqual(t, 4, countEvents(*published, events.EventTypeResources, events.EventActionResourcesAdded))
+	testutil.AssertEqual(t, 4, countEvents(*published, events.EventTypeResources, events.EventActionResourcesUpdated))
+	testutil.AssertEqual(t, 2, countEvents(*published, orchestration.EventTypeOrchestration, orchestration.EventActionOrchestrationRunning))
+
+	current := updMgr.Get(context.Background())
+	testutil.AssertEqual(t, 4, len(current))
+	for _, u := range current {
+		if u.GetKind() == "Pod" {
+			phase, _, _ := unstructured.NestedString(u.Object, "status", "phase")
+			testutil.AssertEqual(t, "Running", phase)
+		} else {
+			readyReplicas, _, _ := unstructured.NestedInt64(u.Object, "status", "readyReplicas")
+			testutil.AssertEqual(t, int64(2), readyReplicas)
+		}
+	}
+
+	t.Log("test_unchanged")
+	*published = nil
+	applyResult = updMgr.Apply(context.Background(), []*unstructured.Unstructured{deployment, pod}).(*orchestration.ApplyResult)
+	testutil.AssertNil(t, applyResult.Err)
+	assertOutcomes(t, []orchestration.ResourceOutcome{orchestration.ResourceOutcomeUnchanged, orchestration.ResourceOutcomeUnchanged}, applyResult)
+	testutil.AssertEqual(t, 0, len(*published))
+
+	t.Log("test_dry_run")
+	updated := deployment.DeepCopy()
+	testutil.AssertNil(t, unstructured.SetNestedField(updated.Object, int64(1), "spec", "replicas"))
+	applyResult = updMgr.Apply(orchestration.SetUpdateMgrDryRunContext(context.Background()), []*unstructured.Unstructured{updated}).(*orchestration.ApplyResult)
+	testutil.AssertNil(t, applyResult.Err)
+	assertOutcomes(t, []orchestration.ResourceOutcome{orchestration.ResourceOutcomeConfigured, orchestration.ResourceOutcomePruned}, applyResult)
+	testutil.AssertEqual(t, 0, len(*published))
+	testutil.AssertEqual(t, 4, len(updMgr.Get(context.Background())))
+
+	t.Log("test_reconcile")
+	applyResult = updMgr.Apply(orchestration.SetUpdateMgrReconcileContext(context.Background()), []*unstructured.Unstructured{deployment}).(*orchestration.ApplyResult)
+	testutil.AssertNil(t, applyResult.Err)
+	assertOutcomes(t, []orchestration.ResourceOutcome{orchestration.ResourceOutcomeUnchanged}, applyResult)
+	testutil.AssertEqual(t, 4, len(updMgr.Get(context.Background())))
+
+	t.Log("test_configured_and_pruned")
+	applyResult = updMgr.Apply(context.Background(), []*unstructured.Unstructured{updated}).(*orchestration.ApplyResult)
+	testutil.AssertNil(t, applyResult.Err)
+	assertOutcomes(t, []orchestration.ResourceOutcome{orchestration.ResourceOutcomeConfigured, orchestration.ResourceOutcomePruned}, applyResult)
+	testutil.AssertEqual(t, "databroker", applyResult.Resources[1].Name)
+	current = updMgr.Get(context.Background())
+	testutil.AssertEqual(t, 2, len(current))
+	testutil.AssertEqual(t, "Deployment", current[1].GetKind())
+	testutil.AssertEqual(t, int64(2), current[1].GetGeneration())
+	testutil.AssertEqual(t, 3, countEvents(*published, events.EventTypeResources, events.EventActionResourcesDeleted))
+}
+
+func TestApplyFailures(t *testing.T) {
+	tests := map[string]struct {
+		cfg              *mgrOpts
+		expectedOutcomes []orchestration.ResourceOutcome
+		expectedErr      string
+		expectedCrashes  int
+	}{
+		"test_failing_resource": {
+			cfg:              &mgrOpts{failingResources: map[string]bool{"pod/databroker": true}},
+			expectedOutcomes: []orchestration.ResourceOutcome{orchestration.ResourceOutcomeCreated, orchestration.ResourceOutcomeFailed},
+			expectedErr:      "cannot apply the resource(s) pod/databroker: simulated apply failure",
+		},
+		"test_crashing_resource": {
+			cfg:              &mgrOpts{crashingResources: map[string]bool{"deployment/hello-world": true}},
+			expectedOutcomes: []orchestration.ResourceOutcome{orchestration.ResourceOutcomeCreated, orchestration.ResourceOutcomeCreated},
+			expectedErr:      "the resource(s) deployment/hello-world have failed: simulated container crash",
+			expectedCrashes:  2,
+		},
+	}
+	for testName, testCase := range tests {
+		t.Run(testName, func(t *testing.T) {
+			t.Log(testName)
+			updMgr, _ := newTestSimulationUpdateManager(t, testCase.cfg)
+			mf := []*unstructured.Unstructured{newTestResource(t, testDeployment), newTestResource(t, testPod)}
+
+			applyResult := updMgr.Apply(context.Background(), mf).(*orchestration.ApplyResult)
+			testutil.AssertNotNil(t, applyResult.Err)
+			testutil.AssertEqual(t, testCase.expectedErr, applyResult.Err.Error())
+			assertOutcomes(t, testCase.expectedOutcomes, applyResult)
+
+			diagnostics := updMgr.Diagnose(context.Background(), mf)
+			if testCase.expectedCrashes == 0 {
+				testutil.AssertEqual(t, 0, len(diagnostics))
+				return
+			}
+			testutil.AssertEqual(t, 1, len(diagnostics))
+			testutil.AssertEqual(t, "hello-world", diagnostics[0].Name)
+			testutil.AssertEqual(t, testCase.expectedCrashes, len(diagnostics[0].Containers))
+			testutil.AssertContainsString(t, diagnostics[0].Containers[0].State, "CrashLoopBackOff")
+			testutil.AssertEqual(t, int32(crashRestartCount), diagnostics[0].Containers[0].RestartCount)
+		})
+	}
+}
+
+func TestApplyCanceled(t *testing.T) {
+	updMgr, _ := newTestSimulationUpdateManager(t, &mgrOpts{applyDelay: 10 * 60 * 1e9})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	applyResult := updMgr.Apply(ctx, []*unstructured.Unstructured{newTestResource(t, testPod)}).(*orchestration.ApplyResult)
+	testutil.AssertEqual(t, context.Canceled, applyResult.Err)
+	testutil.AssertEqual(t, 0, len(updMgr.Get(context.Background())))
+}
@@ -28,6 +28,14 @@ const (
 	EventActionOrchestrationRunning events.EventAction = "running"
 	// EventActionOrchestrationFinished is emitted each time an orchestration process has finished
 	EventActionOrchestrationFinished events.EventAction = "finished"
+	// EventActionOrchestrationRollbackStarted is emitted each time a rollback to the last-known-good manifest is started
+	EventActionOrchestrationRollbackStarted events.EventAction = "rollback_started"
+	// EventActionOrchestrationRollbackFinished is emitted each time a rollback to the last-known-good manifest has finished
+	EventActionOrchestrationRollbackFinished events.EventAction = "rollback_finished"
+	// EventActionOrchestrationReconcileStarted is emitted each time a reconciliation of the desired manifest is started
+	EventActionOrchestrationReconcileStarted events.EventAction = "reconcile_started"
+	// EventActionOrchestrationReconcileFinished is emitted each time a reconciliation of the desired manifest has finished
+	EventActionOrchestrationReconcileFinished events.EventAction = "reconcile_finished"
 )
 
 // UpdateManager provides the orchestration management abstraction
@@ -36,3 +44,41 @@ type UpdateManager interface {
 	Get(ctx context.Context) []*unstructured.Unstructured
 	Dispose(ctx context.Context) error
 }
+
+// ImagePuller is implemented by the update managers that can pull the container images referenced in an update manifest before applying it
+type ImagePuller interface {
+	PullImages(ctx context.Context, mf []*unstructured.Unstructured) error
+}
+
+// ManifestValidator is implemented by the update managers that can validate an update manifest against the orchestrator schema without applying it
+type ManifestValidator interface {
+	Validate(ctx context.Context, mf []*unstructured.Unstructured) error
+}
+
+// DriftDetector is implemented by the update managers that can detect local modifications of the resources of an applied update manifest
+type DriftDetector interface {
+	Drift(ctx context.Context, mf []*unstructured.Unstructured) []*ResourceDrift
+}
+
+// DiagnosticsCollector is implemented by the update managers that can collect diagnostics about the workloads of a failed update manifest
+type DiagnosticsCollector interface {
+	Diagnose(ctx context.Context, mf []*unstructured.Unstructured) []*ResourceDiagnostics
+}
+
+// Canceler is implemented by the update managers that can cancel an update operation in progress
+type Canceler interface {
+	Cancel(ctx context.Context) error
+}
+
+// SelfUpdateVerifier is implemented by the update managers that verify a self update after the system is rebooted into the new OS.
+// VerifySelfUpdate waits for the current state of the self update agent and returns the outcome of the pending self update of the given origin.
+// The outcome is returned only once and it is nil if there is no such pending self update.
+type SelfUpdateVerifier interface {
+	VerifySelfUpdate(ctx context.Context, origin string) *SelfUpdateVerification
+}
+
+// ResourceRemover is implemented by the update managers that can delete the resources of an update manifest without changing the other applied resources,
+// e.g. the resources of an uninstalled Helm release. Remove returns *ApplyResult with the removed resources.
+type ResourceRemover interface {
+	Remove(ctx context.Context, mf []*unstructured.Unstructured) interface{}
+}
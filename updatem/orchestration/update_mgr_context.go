@@ -20,10 +20,22 @@ import (
 
 var (
 	contextKeyManifestInfo = &orchestrationCtxKey{}
+	contextKeyDryRun       = &orchestrationDryRunCtxKey{}
+	contextKeyReconcile    = &orchestrationReconcileCtxKey{}
+	contextKeyRemove       = &orchestrationRemoveCtxKey{}
+	contextKeyOperation    = &orchestrationOperationCtxKey{}
 )
 
 type orchestrationCtxKey struct{}
 
+type orchestrationDryRunCtxKey struct{}
+
+type orchestrationReconcileCtxKey struct{}
+
+type orchestrationRemoveCtxKey struct{}
+
+type orchestrationOperationCtxKey struct{}
+
 // SetUpdateMgrApplyContext ensures the context used throughout a running orchestration
 func SetUpdateMgrApplyContext(ctx context.Context, mf []*unstructured.Unstructured) context.Context {
 	if ctx == nil {
@@ -44,3 +56,63 @@ func GetUpdateMgrApplyContext(ctx context.Context) []*unstructured.Unstructured
 	}
 	return mfInfo
 }
+
+// SetUpdateMgrDryRunContext marks the context of an orchestration that must only be validated by the orchestration backend without persisting any changes
+func SetUpdateMgrDryRunContext(ctx context.Context) context.Context {
+	if ctx == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, contextKeyDryRun, true)
+}
+
+// IsUpdateMgrDryRunContext checks if the context is used throughout a dry-run orchestration
+func IsUpdateMgrDryRunContext(ctx context.Context) bool {
+	dryRun, ok := util.GetValue(ctx, contextKeyDryRun).(bool)
+	return ok && dryRun
+}
+
+// SetUpdateMgrReconcileContext marks the context of an orchestration that re-applies the desired manifest to revert the local modifications.
+// Such an orchestration does not prune any resources.
+func SetUpdateMgrReconcileContext(ctx context.Context) context.Context {
+	if ctx == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, contextKeyReconcile, true)
+}
+
+// IsUpdateMgrReconcileContext checks if the context is used throughout a reconciliation of the desired manifest
+func IsUpdateMgrReconcileContext(ctx context.Context) bool {
+	reconcile, ok := util.GetValue(ctx, contextKeyReconcile).(bool)
+	return ok && reconcile
+}
+
+// SetUpdateMgrRemoveContext marks the context of an orchestration that deletes the manifest resources instead of applying them
+func SetUpdateMgrRemoveContext(ctx context.Context) context.Context {
+	if ctx == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, contextKeyRemove, true)
+}
+
+// IsUpdateMgrRemoveContext checks if the context is used throughout a removal of the manifest resources
+func IsUpdateMgrRemoveContext(ctx context.Context) bool {
+	remove, ok := util.GetValue(ctx, contextKeyRemove).(bool)
+	return ok && remove
+}
+
+// SetUpdateMgrOperationContext sets the remote operation, which has triggered the orchestration
+func SetUpdateMgrOperationContext(ctx context.Context, operation *Operation) context.Context {
+	if ctx == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, contextKeyOperation, operation)
+}
+
+// GetUpdateMgrOperationContext retrieves the remote operation, which has triggered the orchestration, or nil if there is no such
+func GetUpdateMgrOperationContext(ctx context.Context) *Operation {
+	operation, ok := util.GetValue(ctx, contextKeyOperation).(*Operation)
+	if !ok {
+		return nil
+	}
+	return operation
+}
@@ -107,3 +107,93 @@ func TestGetOrchestrationMgrApplyContext(t *testing.T) {
 		})
 	}
 }
+
+func TestIsUpdateMgrDryRunContext(t *testing.T) {
+	testCases := map[string]struct {
+		ctx            context.Context
+		expectedDryRun bool
+	}{
+		"test_ctx_nil": {
+			ctx:            nil,
+			expectedDryRun: false,
+		},
+		"test_ctx_empty": {
+			ctx:            context.Background(),
+			expectedDryRun: false,
+		},
+		"test_ctx_wrong_value_type": {
+			ctx:            context.WithValue(context.Background(), contextKeyDryRun, "wrong-value"),
+			expectedDryRun: false,
+		},
+		"test_ctx_correct": {
+			ctx:            SetUpdateMgrDryRunContext(context.Background()),
+			expectedDryRun: true,
+		},
+	}
+	for tcName, tc := range testCases {
+		t.Run(tcName, func(t *testing.T) {
+			t.Log(tcName)
+			testutil.AssertEqual(t, tc.expectedDryRun, IsUpdateMgrDryRunContext(tc.ctx))
+		})
+	}
+}
+
+func TestIsUpdateMgrReconcileContext(t *testing.T) {
+	testCases := map[string]struct {
+		ctx               context.Context
+		expectedReconcile bool
+	}{
+		"test_ctx_nil": {
+			ctx:               nil,
+			expectedReconcile: false,
+		},
+		"test_ctx_empty": {
+			ctx:               context.Background(),
+			expectedReconcile: false,
+		},
+		"test_ctx_wrong_value_type": {
+			ctx:               context.WithValue(context.Background(), contextKeyReconcile, "wrong-value"),
+			expectedReconcile: false,
+		},
+		"test_ctx_correct": {
+			ctx:               SetUpdateMgrReconcileContext(context.Background()),
+			expectedReconcile: true,
+		},
+	}
+	for tcName, tc := range testCases {
+		t.Run(tcName, func(t *testing.T) {
+			t.Log(tcName)
+			testutil.AssertEqual(t, tc.expectedReconcile, IsUpdateMgrReconcileContext(tc.ctx))
+		})
+	}
+}
+
+func TestIsUpdateMgrRemoveContext(t *testing.T) {
+	testCases := map[string]struct {
+		ctx            context.Context
+		expectedRemove bool
+	}{
+		"test_ctx_nil": {
+			ctx:            nil,
+			expectedRemove: false,
+		},
+		"test_ctx_empty": {
+			ctx:            context.Background(),
+			expectedRemove: false,
+		},
+		"test_ctx_wrong_value_type": {
+			ctx:            context.WithValue(context.Background(), contextKeyRemove, "wrong-value"),
+			expectedRemove: false,
+		},
+		"test_ctx_correct": {
+			ctx:            SetUpdateMgrRemoveContext(context.Background()),
+			expectedRemove: true,
+		},
+	}
+	for tcName, tc := range testCases {
+		t.Run(tcName, func(t *testing.T) {
+			t.Log(tcName)
+			testutil.AssertEqual(t, tc.expectedRemove, IsUpdateMgrRemoveContext(tc.ctx))
+		})
+	}
+}
@@ -0,0 +1,41 @@
+// Copyright (c) 2022 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Apache License 2.0 which is available at
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package orchestration
+
+// ResourceDiagnostics holds the diagnostics collected for a workload resource of a failed update manifest
+type ResourceDiagnostics struct {
+	APIVersion string                  `json:"apiVersion"`
+	Kind       string                  `json:"kind"`
+	Namespace  string                  `json:"namespace,omitempty"`
+	Name       string                  `json:"name"`
+	Events     []*DiagnosticsEvent     `json:"events,omitempty"`
+	Containers []*ContainerDiagnostics `json:"containers,omitempty"`
+}
+
+// DiagnosticsEvent holds a warning event reported by the orchestrator for the workload or its pods
+type DiagnosticsEvent struct {
+	Object        string `json:"object"`
+	Reason        string `json:"reason,omitempty"`
+	Message       string `json:"message,omitempty"`
+	Count         int32  `json:"count,omitempty"`
+	LastTimestamp string `json:"lastTimestamp,omitempty"`
+}
+
+// ContainerDiagnostics holds the state and the last log lines of a failing container of the workload
+type ContainerDiagnostics struct {
+	Pod          string   `json:"pod"`
+	Container    string   `json:"container"`
+	State        string   `json:"state,omitempty"`
+	RestartCount int32    `json:"restartCount,omitempty"`
+	Logs         []string `json:"logs,omitempty"`
+	Error        string   `json:"error,omitempty"`
+}
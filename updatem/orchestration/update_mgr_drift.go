@@ -0,0 +1,32 @@
+// Copyright (c) 2022 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Apache License 2.0 which is available at
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package orchestration
+
+// DriftType represents how a resource of the applied update manifest is modified locally
+type DriftType string
+
+const (
+	// DriftTypeModified is used when fields of the live resource differ from the applied update manifest
+	DriftTypeModified DriftType = "modified"
+	// DriftTypeDeleted is used when the resource is deleted since the update manifest was applied
+	DriftTypeDeleted DriftType = "deleted"
+)
+
+// ResourceDrift holds the local modifications of a single resource from the applied update manifest
+type ResourceDrift struct {
+	APIVersion string    `json:"apiVersion"`
+	Kind       string    `json:"kind"`
+	Namespace  string    `json:"namespace,omitempty"`
+	Name       string    `json:"name"`
+	Type       DriftType `json:"type"`
+	Fields     []string  `json:"fields,omitempty"`
+}
@@ -0,0 +1,34 @@
+// Copyright (c) 2022 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Apache License 2.0 which is available at
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package orchestration
+
+// ProgressPhase represents the phase of a running orchestration
+type ProgressPhase string
+
+const (
+	// ProgressPhaseDownload is used while pulling the container images referenced in the update manifest
+	ProgressPhaseDownload ProgressPhase = "download"
+	// ProgressPhaseInstall is used when all downloads are completed and the update manifest is being applied
+	ProgressPhaseInstall ProgressPhase = "install"
+	// ProgressPhaseReadiness is used while waiting for the applied workload resources to become ready
+	ProgressPhaseReadiness ProgressPhase = "readiness"
+	// ProgressPhaseReboot is used when the system is rebooting into the self-updated OS, whose outcome is verified after the reboot
+	ProgressPhaseReboot ProgressPhase = "reboot"
+)
+
+// Progress holds the details about a running orchestration.
+// It is the source of the EventActionOrchestrationRunning events.
+type Progress struct {
+	Phase    ProgressPhase `json:"phase"`
+	Progress int           `json:"progress"`
+	Message  string        `json:"message,omitempty"`
+}
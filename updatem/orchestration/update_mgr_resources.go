@@ -0,0 +1,43 @@
+// Copyright (c) 2022 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Apache License 2.0 which is available at
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package orchestration
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+type resourceID struct {
+	groupKind schema.GroupKind
+	namespace string
+	name      string
+}
+
+func newResourceID(u *unstructured.Unstructured) resourceID {
+	return resourceID{groupKind: u.GroupVersionKind().GroupKind(), namespace: u.GetNamespace(), name: u.GetName()}
+}
+
+// WithoutResources returns the manifest resources, which are not among the removed ones.
+// The resources are matched by API group, kind, namespace and name, so that the removed ones may be of another version.
+func WithoutResources(mf, removed []*unstructured.Unstructured) []*unstructured.Unstructured {
+	removedIDs := map[resourceID]bool{}
+	for _, u := range removed {
+		removedIDs[newResourceID(u)] = true
+	}
+	result := []*unstructured.Unstructured{}
+	for _, u := range mf {
+		if !removedIDs[newResourceID(u)] {
+			result = append(result, u)
+		}
+	}
+	return result
+}
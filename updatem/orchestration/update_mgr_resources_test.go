@@ -0,0 +1,66 @@
+// Copyright (c) 2022 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Apache License 2.0 which is available at
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package orchestration
+
+import (
+	"testing"
+
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/pkg/testutil"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func newTestResource(apiVersion, kind, namespace, name string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetAPIVersion(apiVersion)
+	u.SetKind(kind)
+	u.SetNamespace(namespace)
+	u.SetName(name)
+	return u
+}
+
+func TestWithoutResources(t *testing.T) {
+	deployment := newTestResource("apps/v1", "Deployment", "default", "test-app")
+	configMap := newTestResource("v1", "ConfigMap", "default", "test-app")
+	otherNamespace := newTestResource("v1", "ConfigMap", "test-ns", "test-app")
+	mf := []*unstructured.Unstructured{deployment, configMap, otherNamespace}
+
+	testCases := map[string]struct {
+		removed  []*unstructured.Unstructured
+		expected []*unstructured.Unstructured
+	}{
+		"test_nothing_removed": {
+			expected: mf,
+		},
+		"test_same_name_other_kind": {
+			removed:  []*unstructured.Unstructured{newTestResource("v1", "ConfigMap", "default", "test-app")},
+			expected: []*unstructured.Unstructured{deployment, otherNamespace},
+		},
+		"test_other_version": {
+			removed:  []*unstructured.Unstructured{newTestResource("apps/v1beta1", "Deployment", "default", "test-app")},
+			expected: []*unstructured.Unstructured{configMap, otherNamespace},
+		},
+		"test_not_applied": {
+			removed:  []*unstructured.Unstructured{newTestResource("v1", "ConfigMap", "default", "test-other")},
+			expected: mf,
+		},
+		"test_all_removed": {
+			removed:  mf,
+			expected: []*unstructured.Unstructured{},
+		},
+	}
+	for tcName, tc := range testCases {
+		t.Run(tcName, func(t *testing.T) {
+			t.Log(tcName)
+			testutil.AssertEqual(t, tc.expected, WithoutResources(mf, tc.removed))
+		})
+	}
+}
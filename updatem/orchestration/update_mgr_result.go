@@ -0,0 +1,73 @@
+// Copyright (c) 2022 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Apache License 2.0 which is available at
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package orchestration
+
+// ResourceOutcome represents the outcome of processing a single resource from the update manifest
+type ResourceOutcome string
+
+const (
+	// ResourceOutcomeCreated is used when the resource does not exist and is created
+	ResourceOutcomeCreated ResourceOutcome = "created"
+	// ResourceOutcomeConfigured is used when the existing resource is modified
+	ResourceOutcomeConfigured ResourceOutcome = "configured"
+	// ResourceOutcomeUnchanged is used when the existing resource already matches the desired state
+	ResourceOutcomeUnchanged ResourceOutcome = "unchanged"
+	// ResourceOutcomePruned is used when the resource is no longer part of the update manifest and is deleted
+	ResourceOutcomePruned ResourceOutcome = "pruned"
+	// ResourceOutcomeConflict is used when the resource is not applied due to field ownership conflicts
+	ResourceOutcomeConflict ResourceOutcome = "conflict"
+	// ResourceOutcomeFailed is used when the resource cannot be applied
+	ResourceOutcomeFailed ResourceOutcome = "failed"
+)
+
+// FieldConflict holds the details about a single field that is owned by another field manager
+type FieldConflict struct {
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// ResourceResult holds the outcome of processing a single resource from the update manifest
+type ResourceResult struct {
+	APIVersion string           `json:"apiVersion"`
+	Kind       string           `json:"kind"`
+	Namespace  string           `json:"namespace,omitempty"`
+	Name       string           `json:"name"`
+	Outcome    ResourceOutcome  `json:"outcome"`
+	Message    string           `json:"message,omitempty"`
+	Conflicts  []*FieldConflict `json:"conflicts,omitempty"`
+}
+
+// ApplyResult holds the result of an update manifest apply.
+// It is also the source of the EventActionOrchestrationFinished events.
+type ApplyResult struct {
+	Resources   []*ResourceResult
+	Rollback    *RollbackResult
+	Diagnostics []*ResourceDiagnostics
+	// Downloaded is set when the self update bundle is only downloaded, so nothing from the update manifest is installed.
+	// The bundle is installed by applying the same update manifest again without spec.downloadOnly.
+	Downloaded bool
+	Err        error
+}
+
+// RollbackResult holds the result of re-applying the last-known-good manifest after a failed apply.
+// It is also the source of the EventActionOrchestrationRollbackFinished events.
+type RollbackResult struct {
+	Resources []*ResourceResult
+	Err       error
+}
+
+// ReconcileResult holds the result of re-applying the desired manifest to revert the local modifications.
+// It is also the source of the EventActionOrchestrationReconcileFinished events.
+type ReconcileResult struct {
+	Resources []*ResourceResult
+	Err       error
+}
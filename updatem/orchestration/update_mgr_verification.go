@@ -0,0 +1,35 @@
+// Copyright (c) 2022 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Apache License 2.0 which is available at
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package orchestration
+
+import "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+// Operation identifies the remote operation, which has triggered an orchestration.
+// It is persisted with a self update, which requires a reboot, so that its outcome can be reported after the reboot.
+type Operation struct {
+	CorrelationID string `json:"correlationId"`
+	// Origin is the ID of the feature, which has received the operation
+	Origin                string `json:"origin"`
+	SoftwareModuleName    string `json:"softwareModuleName,omitempty"`
+	SoftwareModuleVersion string `json:"softwareModuleVersion,omitempty"`
+}
+
+// SelfUpdateVerification holds the outcome of a self update, which is verified after the system is rebooted into the new OS
+type SelfUpdateVerification struct {
+	Operation *Operation
+	// Manifest is the update manifest, which has been applied before the reboot
+	Manifest        []*unstructured.Unstructured
+	Resources       []*ResourceResult
+	ExpectedVersion string
+	ActualVersion   string
+	Err             error
+}
@@ -0,0 +1,38 @@
+// Copyright (c) 2022 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Apache License 2.0 which is available at
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package orchestration
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// podSpecPaths locates the pod specification in the workload resources
+var podSpecPaths = map[schema.GroupKind][]string{
+	{Kind: "Pod"}:                        {"spec"},
+	{Group: "apps", Kind: "Deployment"}:  {"spec", "template", "spec"},
+	{Group: "apps", Kind: "StatefulSet"}: {"spec", "template", "spec"},
+	{Group: "apps", Kind: "DaemonSet"}:   {"spec", "template", "spec"},
+	{Group: "apps", Kind: "ReplicaSet"}:  {"spec", "template", "spec"},
+	{Group: "batch", Kind: "Job"}:        {"spec", "template", "spec"},
+	{Group: "batch", Kind: "CronJob"}:    {"spec", "jobTemplate", "spec", "template", "spec"},
+	{Kind: "ReplicationController"}:      {"spec", "template", "spec"},
+}
+
+// PodSpecPath returns the fields path to the pod specification of a workload resource or false if the resource is not a workload
+func PodSpecPath(u *unstructured.Unstructured) ([]string, bool) {
+	path, ok := podSpecPaths[u.GroupVersionKind().GroupKind()]
+	if !ok {
+		return nil, false
+	}
+	return append([]string{}, path...), true
+}
@@ -35,6 +35,11 @@ type updateOrchestrator struct {
 	k8sOrchestrationManager orchestration.UpdateManager
 	connectionStatus        bool
 	pahoClient              mqtt.Client
+
+	// desiredLock guards the desired manifest, which is reconciled if the reconciliation is enabled
+	desiredLock     sync.RWMutex
+	desiredManifest []*unstructured.Unstructured
+	cancelReconcile context.CancelFunc
 }
 
 func (upOrch *updateOrchestrator) Apply(ctx context.Context, mf []*unstructured.Unstructured) interface{} {
@@ -43,21 +48,23 @@ func (upOrch *updateOrchestrator) Apply(ctx context.Context, mf []*unstructured.
 
 	var applyErr error
 	var suApplyResult *selfupdate.ApplyResult
+	var pending *pendingSelfUpdate
+	applyResult := &orchestration.ApplyResult{}
 	applyCtx := orchestration.SetUpdateMgrApplyContext(ctx, mf)
 
 	defer func() {
 		upOrch.applyLock.Unlock()
 	}()
 
-	upOrch.publishOrchestrationEvent(applyCtx, orchestration.EventActionOrchestrationStarted, nil)
+	upOrch.publishOrchestrationEvent(applyCtx, orchestration.EventActionOrchestrationStarted, nil, nil)
 
 	var selfUpdateManifest *unstructured.Unstructured
 	manifest := []*unstructured.Unstructured{}
 	for i := 0; i < len(mf); i++ {
 		if mf[i].GetKind() == "SelfUpdateBundle" {
 			if selfUpdateManifest != nil {
-				applyErr = log.NewErrorf("more than one SelfUpdateBundle resource in the YAML manifest")
-				upOrch.publishOrchestrationEvent(applyCtx, orchestration.EventActionOrchestrationFinished, applyErr)
+				applyResult.Err = log.NewErrorf("more than one SelfUpdateBundle resource in the YAML manifest")
+				upOrch.publishOrchestrationEvent(applyCtx, orchestration.EventActionOrchestrationFinished, applyResult, applyResult.Err)
 				return nil
 			}
 			selfUpdateManifest = mf[i]
@@ -66,23 +73,92 @@ func (upOrch *updateOrchestrator) Apply(ctx context.Context, mf []*unstructured.
 		}
 	}
 
-	if selfUpdateManifest != nil {
+	if !orchestration.IsUpdateMgrDryRunContext(ctx) {
+		// all container images are downloaded before anything is changed
+		if err := upOrch.pullImages(applyCtx, manifest); err != nil {
+			applyResult.Err = err
+			upOrch.publishOrchestrationEvent(applyCtx, orchestration.EventActionOrchestrationFinished, applyResult, applyResult.Err)
+			return nil
+		}
+		// the self update manager publishes the install phase itself, as soon as the bundle is downloaded by the self update agent
+		if selfUpdateManifest == nil {
+			upOrch.publishInstallPhase(applyCtx)
+		}
+	}
+
+	if selfUpdateManifest != nil && orchestration.IsUpdateMgrDryRunContext(ctx) {
+		log.Debug("self update is not supported in dry-run mode - skipping it")
+	} else if selfUpdateManifest != nil {
 		log.Debug("processing self update")
+		var previousVersion string
+		if upOrch.selfUpdateVerificationEnabled(applyCtx) {
+			previousVersion = upOrch.selfUpdateVersion(applyCtx)
+		}
 		suApplyResult = upOrch.selfUpdateManager.Apply(applyCtx, []*unstructured.Unstructured{selfUpdateManifest}).(*selfupdate.ApplyResult)
 		applyErr = suApplyResult.Err
 		log.Debug("processing self update - done")
+		if applyErr == nil && suApplyResult.Result == selfupdate.SelfUpdateResultDownloaded {
+			// a download-only bundle leaves the system unchanged until the manifest is applied again without spec.downloadOnly
+			log.Debug("the self update bundle is downloaded only - skipping the installation")
+			applyResult.Downloaded = true
+		}
+		if applyErr == nil && suApplyResult.RebootRequired && upOrch.selfUpdateVerificationEnabled(applyCtx) {
+			expectedVersion, _, _ := unstructured.NestedString(selfUpdateManifest.Object, "spec", "bundleVersion")
+			pending = &pendingSelfUpdate{
+				Operation:       orchestration.GetUpdateMgrOperationContext(applyCtx),
+				Manifest:        mf,
+				BundleName:      selfUpdateManifest.GetName(),
+				ExpectedVersion: expectedVersion,
+				PreviousVersion: previousVersion,
+			}
+		}
 	}
 
-	if applyErr == nil && len(manifest) > 0 {
+	// a download-only self update bundle leaves the k8s resources unchanged
+	if applyErr == nil && !applyResult.Downloaded && len(manifest) > 0 {
 		log.Debug("processing apply manifest command")
-		err := upOrch.k8sOrchestrationManager.Apply(applyCtx, manifest)
-		if err != nil {
-			applyErr = err.(error)
+		switch k8sApplyResult := upOrch.k8sOrchestrationManager.Apply(applyCtx, manifest).(type) {
+		case *orchestration.ApplyResult:
+			applyResult.Resources = k8sApplyResult.Resources
+			applyErr = k8sApplyResult.Err
+		case error:
+			applyErr = k8sApplyResult
 		}
 		log.Debug("processing apply manifest command - done")
+
+		if applyErr != nil && !orchestration.IsUpdateMgrDryRunContext(ctx) {
+			// the diagnostics are collected before the rollback replaces the failing workloads
+			applyResult.Diagnostics = upOrch.diagnose(applyCtx, manifest)
+		}
+		if upOrch.rollbackEnabled() && !orchestration.IsUpdateMgrDryRunContext(ctx) {
+			if applyErr == nil {
+				if err := upOrch.storeLastKnownGoodManifest(manifest); err != nil {
+					log.ErrorErr(err, "cannot store the last-known-good manifest")
+				}
+			} else {
+				applyResult.Rollback = upOrch.rollback(applyCtx)
+			}
+		}
+		if upOrch.reconcileEnabled() && !orchestration.IsUpdateMgrDryRunContext(ctx) {
+			upOrch.setDesiredManifest(manifest, applyErr, applyResult.Rollback)
+		}
+		applyErr = withRollbackError(applyErr, applyResult.Rollback)
 	}
 
-	upOrch.publishOrchestrationEvent(applyCtx, orchestration.EventActionOrchestrationFinished, applyErr)
+	applyResult.Err = applyErr
+	if pending != nil && applyErr == nil {
+		// the outcome of the self update is known after the reboot into the new OS, so the operation is still running
+		pending.Resources = applyResult.Resources
+		if err := upOrch.storePendingSelfUpdate(pending); err != nil {
+			log.ErrorErr(err, "cannot store the pending self update")
+			pending = nil
+		}
+	}
+	if pending != nil && applyErr == nil {
+		upOrch.publishRebootPhase(applyCtx, pending)
+	} else {
+		upOrch.publishOrchestrationEvent(applyCtx, orchestration.EventActionOrchestrationFinished, applyResult, applyErr)
+	}
 
 	if suApplyResult != nil && suApplyResult.RebootRequired {
 		if err := upOrch.rebootManager.Reboot(suApplyResult.RebootTimeout); err != nil {
@@ -93,6 +169,60 @@ func (upOrch *updateOrchestrator) Apply(ctx context.Context, mf []*unstructured.
 	return nil
 }
 
+// Remove deletes the manifest resources by the k8s orchestration manager, e.g. the resources of an uninstalled Helm release,
+// leaving the other applied resources unchanged. The removed resources are dropped from the desired and the last-known-good manifests as well.
+func (upOrch *updateOrchestrator) Remove(ctx context.Context, mf []*unstructured.Unstructured) interface{} {
+	upOrch.applyLock.Lock()
+	defer upOrch.applyLock.Unlock()
+	log.Debug("performing remove operation...")
+
+	removeCtx := orchestration.SetUpdateMgrRemoveContext(orchestration.SetUpdateMgrApplyContext(ctx, mf))
+	upOrch.publishOrchestrationEvent(removeCtx, orchestration.EventActionOrchestrationStarted, nil, nil)
+
+	applyResult := &orchestration.ApplyResult{}
+	if remover, ok := upOrch.k8sOrchestrationManager.(orchestration.ResourceRemover); !ok {
+		applyResult.Err = log.NewErrorf("the k8s orchestration manager does not support removing resources")
+	} else {
+		switch k8sRemoveResult := remover.Remove(removeCtx, mf).(type) {
+		case *orchestration.ApplyResult:
+			applyResult.Resources = k8sRemoveResult.Resources
+			applyResult.Err = k8sRemoveResult.Err
+		case error:
+			applyResult.Err = k8sRemoveResult
+		}
+	}
+	if applyResult.Err == nil && !orchestration.IsUpdateMgrDryRunContext(ctx) {
+		upOrch.dropRemovedResources(mf)
+	}
+	upOrch.publishOrchestrationEvent(removeCtx, orchestration.EventActionOrchestrationFinished, applyResult, applyResult.Err)
+	return nil
+}
+
+// dropRemovedResources drops the removed resources from the desired and the last-known-good manifests, so that they are neither reconciled nor rolled back to
+func (upOrch *updateOrchestrator) dropRemovedResources(removed []*unstructured.Unstructured) {
+	if upOrch.reconcileEnabled() {
+		upOrch.desiredLock.Lock()
+		if upOrch.desiredManifest != nil {
+			upOrch.desiredManifest = orchestration.WithoutResources(upOrch.desiredManifest, removed)
+		}
+		upOrch.desiredLock.Unlock()
+	}
+	if !upOrch.rollbackEnabled() {
+		return
+	}
+	lastKnownGood, err := upOrch.loadLastKnownGoodManifest()
+	if err != nil {
+		log.ErrorErr(err, "cannot load the last-known-good manifest")
+		return
+	}
+	if len(lastKnownGood) == 0 {
+		return
+	}
+	if err := upOrch.storeLastKnownGoodManifest(orchestration.WithoutResources(lastKnownGood, removed)); err != nil {
+		log.ErrorErr(err, "cannot store the last-known-good manifest")
+	}
+}
+
 func (upOrch *updateOrchestrator) Get(ctx context.Context) []*unstructured.Unstructured {
 	result := []*unstructured.Unstructured{}
 	result = append(result, upOrch.k8sOrchestrationManager.Get(ctx)...)
@@ -101,5 +231,65 @@ func (upOrch *updateOrchestrator) Get(ctx context.Context) []*unstructured.Unstr
 }
 
 func (upOrch *updateOrchestrator) Dispose(ctx context.Context) error {
+	upOrch.stopReconcile()
+	return nil
+}
+
+// Cancel cancels the self update operation in progress, if the self update manager supports cancellation
+func (upOrch *updateOrchestrator) Cancel(ctx context.Context) error {
+	canceler, ok := upOrch.selfUpdateManager.(orchestration.Canceler)
+	if !ok {
+		return log.NewErrorf("the self update manager does not support cancellation")
+	}
+	return canceler.Cancel(ctx)
+}
+
+// Validate validates the SelfUpdateBundle resources by the self update manager and the other manifest resources by the k8s orchestration manager,
+// if they support validation
+func (upOrch *updateOrchestrator) Validate(ctx context.Context, mf []*unstructured.Unstructured) error {
+	selfUpdateManifest := []*unstructured.Unstructured{}
+	manifest := []*unstructured.Unstructured{}
+	for _, u := range mf {
+		if u.GetKind() == "SelfUpdateBundle" {
+			selfUpdateManifest = append(selfUpdateManifest, u)
+		} else {
+			manifest = append(manifest, u)
+		}
+	}
+	if validator, ok := upOrch.selfUpdateManager.(orchestration.ManifestValidator); ok && len(selfUpdateManifest) > 0 {
+		if err := validator.Validate(ctx, selfUpdateManifest); err != nil {
+			return err
+		}
+	}
+	if validator, ok := upOrch.k8sOrchestrationManager.(orchestration.ManifestValidator); ok && len(manifest) > 0 {
+		return validator.Validate(ctx, manifest)
+	}
 	return nil
 }
+
+// Drift detects the local modifications of the manifest resources applied by the k8s orchestration manager, if it supports drift detection
+func (upOrch *updateOrchestrator) Drift(ctx context.Context, mf []*unstructured.Unstructured) []*orchestration.ResourceDrift {
+	detector, ok := upOrch.k8sOrchestrationManager.(orchestration.DriftDetector)
+	if !ok {
+		return nil
+	}
+	manifest := []*unstructured.Unstructured{}
+	for _, u := range mf {
+		if u.GetKind() != "SelfUpdateBundle" {
+			manifest = append(manifest, u)
+		}
+	}
+	if len(manifest) == 0 {
+		return nil
+	}
+	return detector.Drift(ctx, manifest)
+}
+
+// diagnose collects the diagnostics of the failed manifest resources applied by the k8s orchestration manager, if it supports diagnostics
+func (upOrch *updateOrchestrator) diagnose(ctx context.Context, manifest []*unstructured.Unstructured) []*orchestration.ResourceDiagnostics {
+	collector, ok := upOrch.k8sOrchestrationManager.(orchestration.DiagnosticsCollector)
+	if !ok {
+		return nil
+	}
+	return collector.Diagnose(ctx, manifest)
+}
@@ -12,10 +12,14 @@
 package updateorchestrator
 
 import (
+	"context"
+
 	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/events"
 	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/orchestration"
 	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/orchestration/k8s"
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/orchestration/kanto"
 	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/orchestration/selfupdate"
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/orchestration/simulation"
 	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/util"
 
 	"github.com/eclipse-kanto/container-management/containerm/log"
@@ -53,11 +57,6 @@ func registryInit(registryCtx *registry.ServiceRegistryContext) (interface{}, er
 		return nil, log.NewErrorf("incompatible configuration provided: %s", registryCtx.Config)
 	}
 
-	k8sMgrInitOpts, ok := orchServiceConfig["k8s"].([]k8s.MgrOpt)
-	if !ok {
-		return nil, log.NewErrorf("incompatible configuration provided: %s", orchServiceConfig["k8s"])
-	}
-
 	suMgrInitOpts, ok := orchServiceConfig["self_update"].([]selfupdate.MgrOpt)
 	if !ok {
 		return nil, log.NewErrorf("incompatible configuration provided: %s", orchServiceConfig["self_update"])
@@ -70,14 +69,16 @@ func registryInit(registryCtx *registry.ServiceRegistryContext) (interface{}, er
 	var (
 		cfg = &mgrOpts{}
 	)
-	applyOptsMgr(cfg, updOrchInitOpts...)
+	if err := applyOptsMgr(cfg, updOrchInitOpts...); err != nil {
+		return nil, err
+	}
 
 	eventsManagerService, err := registryCtx.Get(registry.EventsManagerService)
 	if err != nil {
 		return nil, err
 	}
 
-	k8sOrchestrationManager, err := k8s.NewK8sUpdateManager(k8sMgrInitOpts, registryCtx)
+	k8sOrchestrationManager, err := newOrchestrationManager(cfg.backend, orchServiceConfig, registryCtx)
 	if err != nil {
 		return nil, err
 	}
@@ -108,6 +109,30 @@ func registryInit(registryCtx *registry.ServiceRegistryContext) (interface{}, er
 	if err := updOrch.subscribeRemoteConnectionStatus(); err != nil {
 		return nil, err
 	}
+	updOrch.startReconcile(context.Background())
 
 	return updOrch, nil
 }
+
+// newOrchestrationManager creates the update manager of the configured backend, which applies the manifests
+func newOrchestrationManager(backend string, orchServiceConfig map[string]interface{}, registryCtx *registry.ServiceRegistryContext) (orchestration.UpdateManager, error) {
+	switch backend {
+	case BackendKanto:
+		kantoMgrInitOpts, ok := orchServiceConfig["kanto"].([]kanto.MgrOpt)
+		if !ok {
+			return nil, log.NewErrorf("incompatible configuration provided: %s", orchServiceConfig["kanto"])
+		}
+		return kanto.NewKantoUpdateManager(kantoMgrInitOpts)
+	case BackendSimulation:
+		simulationMgrInitOpts, ok := orchServiceConfig["simulation"].([]simulation.MgrOpt)
+		if !ok {
+			return nil, log.NewErrorf("incompatible configuration provided: %s", orchServiceConfig["simulation"])
+		}
+		return simulation.NewSimulationUpdateManager(simulationMgrInitOpts, registryCtx)
+	}
+	k8sMgrInitOpts, ok := orchServiceConfig["k8s"].([]k8s.MgrOpt)
+	if !ok {
+		return nil, log.NewErrorf("incompatible configuration provided: %s", orchServiceConfig["k8s"])
+	}
+	return k8s.NewK8sUpdateManager(k8sMgrInitOpts, registryCtx)
+}
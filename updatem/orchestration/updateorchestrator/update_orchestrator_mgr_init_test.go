@@ -98,6 +98,58 @@ func TestRegistryInit(t *testing.T) {
 				"self_update": []selfupdate.MgrOpt{},
 			},
 		},
+		"test_missing_kanto_config": {
+			testServiceInfoSet: func() *registry.Set {
+				serviceInfoSet := registry.NewServiceInfoSet()
+				evenetsMgrRegistration := &registry.Registration{
+					ID:   events.EventsManagerServiceLocalID,
+					Type: registry.EventsManagerService,
+					InitFunc: func(registryCtx *registry.ServiceRegistryContext) (interface{}, error) {
+						return eventsMgrMock, nil
+					},
+				}
+				serviceInfo := evenetsMgrRegistration.Init(registry.NewContext(context.Background(), nil, evenetsMgrRegistration, serviceInfoSet))
+
+				serviceInfoSet.Add(serviceInfo)
+				return serviceInfoSet
+			},
+			config: map[string]interface{}{
+				"self_update":         []selfupdate.MgrOpt{},
+				"update_orchestrator": []MgrOpt{WithBackend(BackendKanto)},
+			},
+		},
+		"test_missing_simulation_config": {
+			testServiceInfoSet: func() *registry.Set {
+				serviceInfoSet := registry.NewServiceInfoSet()
+				evenetsMgrRegistration := &registry.Registration{
+					ID:   events.EventsManagerServiceLocalID,
+					Type: registry.EventsManagerService,
+					InitFunc: func(registryCtx *registry.ServiceRegistryContext) (interface{}, error) {
+						return eventsMgrMock, nil
+					},
+				}
+				serviceInfo := evenetsMgrRegistration.Init(registry.NewContext(context.Background(), nil, evenetsMgrRegistration, serviceInfoSet))
+
+				serviceInfoSet.Add(serviceInfo)
+				return serviceInfoSet
+			},
+			config: map[string]interface{}{
+				"self_update":         []selfupdate.MgrOpt{},
+				"update_orchestrator": []MgrOpt{WithBackend(BackendSimulation)},
+			},
+		},
+		"test_unsupported_backend": {
+			testServiceInfoSet: func() *registry.Set {
+				return registry.NewServiceInfoSet()
+			},
+			config: map[string]interface{}{
+				"k8s": []k8s.MgrOpt{
+					k8s.WithKubeConfig("../../pkg/testutil/testdata/k8s/k3s.yaml"),
+				},
+				"self_update":         []selfupdate.MgrOpt{},
+				"update_orchestrator": []MgrOpt{WithBackend("docker")},
+			},
+		},
 	}
 	for testName, testCase := range testCases {
 		t.Run(testName, func(t *testing.T) {
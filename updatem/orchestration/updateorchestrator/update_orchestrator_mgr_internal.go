@@ -14,6 +14,7 @@ package updateorchestrator
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"time"
 
 	"github.com/eclipse-kanto/container-management/containerm/log"
@@ -27,7 +28,7 @@ const (
 	topicRemoteStatus = "edge/connection/remote/status"
 )
 
-func (updOrch *updateOrchestrator) publishEvent(ctx context.Context, eventType events.EventType, eventAction events.EventAction, eventSource []*unstructured.Unstructured, err error) {
+func (updOrch *updateOrchestrator) publishEvent(ctx context.Context, eventType events.EventType, eventAction events.EventAction, eventSource interface{}, err error) {
 	e := &events.Event{
 		Type:    eventType,
 		Action:  eventAction,
@@ -42,14 +43,47 @@ func (updOrch *updateOrchestrator) publishEvent(ctx context.Context, eventType e
 	}
 }
 
-func (updOrch *updateOrchestrator) publishOrchestrationEvent(ctx context.Context, eventAction events.EventAction, err error) {
-	updOrch.publishEvent(ctx, orchestration.EventTypeOrchestration, eventAction, nil, err)
+func (updOrch *updateOrchestrator) publishOrchestrationEvent(ctx context.Context, eventAction events.EventAction, applyResult *orchestration.ApplyResult, err error) {
+	var eventSource interface{}
+	if applyResult != nil {
+		eventSource = applyResult
+	}
+	updOrch.publishEvent(ctx, orchestration.EventTypeOrchestration, eventAction, eventSource, err)
+}
+
+// publishInstallPhase notifies that all downloads are completed and the manifest is being applied
+func (updOrch *updateOrchestrator) publishInstallPhase(ctx context.Context) {
+	updOrch.publishEvent(ctx, orchestration.EventTypeOrchestration, orchestration.EventActionOrchestrationRunning, &orchestration.Progress{
+		Phase: orchestration.ProgressPhaseInstall,
+	}, nil)
+}
+
+// publishRebootPhase notifies that the system is rebooting into the self-updated OS, whose outcome is verified after the reboot
+func (updOrch *updateOrchestrator) publishRebootPhase(ctx context.Context, pending *pendingSelfUpdate) {
+	updOrch.publishEvent(ctx, orchestration.EventTypeOrchestration, orchestration.EventActionOrchestrationRunning, &orchestration.Progress{
+		Phase:   orchestration.ProgressPhaseReboot,
+		Message: fmt.Sprintf("rebooting to verify the self update bundle %s with version %s", pending.BundleName, pending.ExpectedVersion),
+	}, nil)
 }
 
 func (updOrch *updateOrchestrator) publishResourceEvent(ctx context.Context, eventAction events.EventAction, eventSource []*unstructured.Unstructured, err error) {
 	updOrch.publishEvent(ctx, events.EventTypeResources, eventAction, eventSource, err)
 }
 
+// pullImages pre-pulls the container images of the manifest if supported by the k8s orchestration manager
+func (updOrch *updateOrchestrator) pullImages(ctx context.Context, manifest []*unstructured.Unstructured) error {
+	imagePuller, ok := updOrch.k8sOrchestrationManager.(orchestration.ImagePuller)
+	if !ok || len(manifest) == 0 {
+		return nil
+	}
+	log.Debug("pre-pulling the container images of the manifest")
+	if err := imagePuller.PullImages(ctx, manifest); err != nil {
+		log.ErrorErr(err, "error while pre-pulling the container images of the manifest")
+		return err
+	}
+	return nil
+}
+
 func (updOrch *updateOrchestrator) handleConnectionStatus(mqttClient mqtt.Client, message mqtt.Message) {
 	log.Debug("received remote connection status = %s", string(message.Payload()))
 
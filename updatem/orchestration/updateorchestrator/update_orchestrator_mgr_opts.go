@@ -13,12 +13,24 @@ package updateorchestrator
 
 import (
 	"time"
+
+	"github.com/eclipse-kanto/container-management/containerm/log"
+)
+
+const (
+	// BackendK8s selects the Kubernetes orchestration backend
+	BackendK8s = "k8s"
+	// BackendKanto selects the Eclipse Kanto container management orchestration backend
+	BackendKanto = "kanto"
+	// BackendSimulation selects the in-memory simulation backend for demos and bench testing without a cluster
+	BackendSimulation = "simulation"
 )
 
 // MgrOpt defines the creation configuration options for a self update manager implementation
 type MgrOpt func(mgrOptions *mgrOpts) error
 
 type mgrOpts struct {
+	metaPath           string
 	broker             string
 	keepAlive          time.Duration
 	disconnectTimeout  time.Duration
@@ -28,6 +40,9 @@ type mgrOpts struct {
 	acknowledgeTimeout time.Duration
 	subscribeTimeout   time.Duration
 	unsubscribeTimeout time.Duration
+	reconcileInterval  time.Duration
+	reconcileBackoff   time.Duration
+	backend            string
 }
 
 func applyOptsMgr(mgrOpts *mgrOpts, opts ...MgrOpt) error {
@@ -110,3 +125,59 @@ func WithConnectionUnsubscribeTimeout(unsubscribeTimeout time.Duration) MgrOpt {
 		return nil
 	}
 }
+
+// WithMetaPath configures the directory where the last-known-good manifest and the pending self update are stored - if empty, no automatic rollback and no self update verification after the reboot are performed
+func WithMetaPath(metaPath string) MgrOpt {
+	return func(mgrOptions *mgrOpts) error {
+		mgrOptions.metaPath = metaPath
+		return nil
+	}
+}
+
+// WithReconcileInterval configures how often the desired manifest is re-applied to revert the local modifications - an empty or zero value disables the reconciliation
+func WithReconcileInterval(reconcileInterval string) MgrOpt {
+	return func(mgrOptions *mgrOpts) error {
+		if reconcileInterval == "" {
+			mgrOptions.reconcileInterval = 0
+			return nil
+		}
+		interval, err := time.ParseDuration(reconcileInterval)
+		if err != nil || interval < 0 {
+			return log.NewErrorf("invalid reconcile interval %s", reconcileInterval)
+		}
+		mgrOptions.reconcileInterval = interval
+		return nil
+	}
+}
+
+// WithReconcileBackoff configures the delay before reconciling a modified or deleted resource of the desired manifest.
+// The delay is doubled after each failed reconciliation up to the reconcile interval.
+func WithReconcileBackoff(reconcileBackoff string) MgrOpt {
+	return func(mgrOptions *mgrOpts) error {
+		if reconcileBackoff == "" {
+			mgrOptions.reconcileBackoff = 0
+			return nil
+		}
+		backoff, err := time.ParseDuration(reconcileBackoff)
+		if err != nil || backoff < 0 {
+			return log.NewErrorf("invalid reconcile backoff %s", reconcileBackoff)
+		}
+		mgrOptions.reconcileBackoff = backoff
+		return nil
+	}
+}
+
+// WithBackend configures the orchestration backend, which applies the manifests - an empty value selects the Kubernetes backend
+func WithBackend(backend string) MgrOpt {
+	return func(mgrOptions *mgrOpts) error {
+		switch backend {
+		case "":
+			mgrOptions.backend = BackendK8s
+		case BackendK8s, BackendKanto, BackendSimulation:
+			mgrOptions.backend = backend
+		default:
+			return log.NewErrorf("unsupported orchestration backend %s", backend)
+		}
+		return nil
+	}
+}
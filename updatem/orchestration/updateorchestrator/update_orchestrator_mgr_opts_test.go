@@ -13,6 +13,7 @@ package updateorchestrator
 
 import (
 	"testing"
+	"time"
 
 	"github.com/eclipse-kanto/container-management/containerm/log"
 	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/pkg/testutil"
@@ -44,8 +45,13 @@ func TestMgrOpts(t *testing.T) {
 				WithConnectionAcknowledgeTimeout(20000),
 				WithConnectionSubscribeTimeout(20000),
 				WithConnectionUnsubscribeTimeout(20000),
+				WithMetaPath("/var/lib/updatemanagerd"),
+				WithReconcileInterval("10m"),
+				WithReconcileBackoff("10s"),
+				WithBackend("kanto"),
 			},
 			expectedOpts: &mgrOpts{
+				metaPath:           "/var/lib/updatemanagerd",
 				broker:             "tcp://localhost:1883",
 				keepAlive:          10000,
 				disconnectTimeout:  200,
@@ -55,9 +61,28 @@ func TestMgrOpts(t *testing.T) {
 				connectTimeout:     30000,
 				subscribeTimeout:   20000,
 				unsubscribeTimeout: 20000,
+				reconcileInterval:  10 * time.Minute,
+				reconcileBackoff:   10 * time.Second,
+				backend:            BackendKanto,
 			},
 			expectedErr: nil,
 		},
+		"test_invalid_reconcile_interval": {
+			opts:        []MgrOpt{WithReconcileInterval("10 minutes")},
+			expectedErr: log.NewError("invalid reconcile interval 10 minutes"),
+		},
+		"test_invalid_reconcile_backoff": {
+			opts:        []MgrOpt{WithReconcileBackoff("-10s")},
+			expectedErr: log.NewError("invalid reconcile backoff -10s"),
+		},
+		"test_simulation_backend": {
+			opts:         []MgrOpt{WithBackend("simulation")},
+			expectedOpts: &mgrOpts{backend: BackendSimulation},
+		},
+		"test_unsupported_backend": {
+			opts:        []MgrOpt{WithBackend("docker")},
+			expectedErr: log.NewError("unsupported orchestration backend docker"),
+		},
 	}
 	for testCaseName, testCase := range testCases {
 		t.Run(testCaseName, func(t *testing.T) {
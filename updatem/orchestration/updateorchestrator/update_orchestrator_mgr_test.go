@@ -16,8 +16,11 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"os"
 	"sync"
 	"testing"
+	"time"
 
 	mocksevents "github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/pkg/testutil/mocks/events"
 	mocksorchmgr "github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/pkg/testutil/mocks/orchestration"
@@ -139,7 +142,7 @@ func TestApply(t *testing.T) {
 			"apply_self_update_manifest",
 			selfUpdateManifest,
 			func(mockEventsMgr *mocksevents.MockUpdateEventsManager, mockSelfUpdateMgr *mocksorchmgr.MockUpdateManager, mockK8sOrchestrationMgr *mocksorchmgr.MockUpdateManager, mockRebootMgr *mocksupdorchmgr.MockRebootManager, mf []*unstructured.Unstructured) {
-				setupEventsManager(t, mockEventsMgr, mf, nil)
+				setupEventsManager(t, mockEventsMgr, mf, false, nil)
 				mockSelfUpdateMgr.EXPECT().Apply(gomock.Any(), gomock.Any()).Return(&selfupdate.ApplyResult{})
 			},
 		},
@@ -147,7 +150,7 @@ func TestApply(t *testing.T) {
 			"apply_self_update_manifest_reboot_required",
 			selfUpdateManifest,
 			func(mockEventsMgr *mocksevents.MockUpdateEventsManager, mockSelfUpdateMgr *mocksorchmgr.MockUpdateManager, mockK8sOrchestrationMgr *mocksorchmgr.MockUpdateManager, mockRebootMgr *mocksupdorchmgr.MockRebootManager, mf []*unstructured.Unstructured) {
-				setupEventsManager(t, mockEventsMgr, mf, nil)
+				setupEventsManager(t, mockEventsMgr, mf, false, nil)
 				mockRebootMgr.EXPECT().Reboot(gomock.Any())
 				mockSelfUpdateMgr.EXPECT().Apply(gomock.Any(), gomock.Any()).Return(&selfupdate.ApplyResult{RebootRequired: true})
 			},
@@ -157,7 +160,7 @@ func TestApply(t *testing.T) {
 			selfUpdateManifest,
 			func(mockEventsMgr *mocksevents.MockUpdateEventsManager, mockSelfUpdateMgr *mocksorchmgr.MockUpdateManager, mockK8sOrchestrationMgr *mocksorchmgr.MockUpdateManager, mockRebootMgr *mocksupdorchmgr.MockRebootManager, mf []*unstructured.Unstructured) {
 				applyErr := fmt.Errorf("error applying self update manifest")
-				setupEventsManager(t, mockEventsMgr, mf, applyErr)
+				setupEventsManager(t, mockEventsMgr, mf, false, applyErr)
 				mockSelfUpdateMgr.EXPECT().Apply(gomock.Any(), gomock.Any()).Return(&selfupdate.ApplyResult{Err: applyErr})
 			},
 		},
@@ -165,31 +168,61 @@ func TestApply(t *testing.T) {
 			"apply_self_update_multiple_bundles",
 			selfUpdateMultipleBundlesManifest,
 			func(mockEventsMgr *mocksevents.MockUpdateEventsManager, mockSelfUpdateMgr *mocksorchmgr.MockUpdateManager, mockK8sOrchestrationMgr *mocksorchmgr.MockUpdateManager, mockRebootMgr *mocksupdorchmgr.MockRebootManager, mf []*unstructured.Unstructured) {
-				setupEventsManager(t, mockEventsMgr, mf, fmt.Errorf("more than one SelfUpdateBundle resource in the YAML manifest"))
+				setupEventsManager(t, mockEventsMgr, mf, false, fmt.Errorf("more than one SelfUpdateBundle resource in the YAML manifest"))
 			},
 		},
 		{
 			"apply_k8s_manifest",
 			k8sManifest,
 			func(mockEventsMgr *mocksevents.MockUpdateEventsManager, mockSelfUpdateMgr *mocksorchmgr.MockUpdateManager, mockK8sOrchestrationMgr *mocksorchmgr.MockUpdateManager, mockRebootMgr *mocksupdorchmgr.MockRebootManager, mf []*unstructured.Unstructured) {
-				setupEventsManager(t, mockEventsMgr, mf, nil)
+				setupEventsManager(t, mockEventsMgr, mf, true, nil)
 				mockK8sOrchestrationMgr.EXPECT().Apply(gomock.Any(), gomock.Any())
 			},
 		},
+		{
+			"apply_empty_manifest",
+			"",
+			func(mockEventsMgr *mocksevents.MockUpdateEventsManager, mockSelfUpdateMgr *mocksorchmgr.MockUpdateManager, mockK8sOrchestrationMgr *mocksorchmgr.MockUpdateManager, mockRebootMgr *mocksupdorchmgr.MockRebootManager, mf []*unstructured.Unstructured) {
+				// an empty manifest leaves the k8s resources unchanged
+				setupEventsManager(t, mockEventsMgr, mf, true, nil)
+			},
+		},
 		{
 			"apply_k8s_manifest_error",
 			k8sManifest,
 			func(mockEventsMgr *mocksevents.MockUpdateEventsManager, mockSelfUpdateMgr *mocksorchmgr.MockUpdateManager, mockK8sOrchestrationMgr *mocksorchmgr.MockUpdateManager, mockRebootMgr *mocksupdorchmgr.MockRebootManager, mf []*unstructured.Unstructured) {
 				applyErr := fmt.Errorf("error applying k8s manifest")
-				setupEventsManager(t, mockEventsMgr, mf, applyErr)
+				setupEventsManager(t, mockEventsMgr, mf, true, applyErr)
 				mockK8sOrchestrationMgr.EXPECT().Apply(gomock.Any(), gomock.Any()).Return(applyErr)
 			},
 		},
+		{
+			"apply_k8s_manifest_conflicts",
+			k8sManifest,
+			func(mockEventsMgr *mocksevents.MockUpdateEventsManager, mockSelfUpdateMgr *mocksorchmgr.MockUpdateManager, mockK8sOrchestrationMgr *mocksorchmgr.MockUpdateManager, mockRebootMgr *mocksupdorchmgr.MockRebootManager, mf []*unstructured.Unstructured) {
+				applyErr := fmt.Errorf("1 resource(s) not applied due to field ownership conflicts")
+				conflicts := []*orchestration.ResourceResult{{
+					APIVersion: "apps/v1",
+					Kind:       "Deployment",
+					Name:       "nginx-deployment",
+					Outcome:    orchestration.ResourceOutcomeConflict,
+				}}
+				gomock.InOrder(
+					mockEventsMgr.EXPECT().Publish(gomock.Any(), gomock.Any()).Return(nil).Times(2),
+					mockEventsMgr.EXPECT().Publish(gomock.Any(), gomock.Any()).Do(func(ctx context.Context, event *events.Event) {
+						testutil.AssertEqual(t, orchestration.EventActionOrchestrationFinished, event.Action)
+						testutil.AssertEqual(t, applyErr, event.Error)
+						testutil.AssertEqual(t, conflicts, event.Source.(*orchestration.ApplyResult).Resources)
+					}).Return(nil),
+				)
+				mockK8sOrchestrationMgr.EXPECT().Apply(gomock.Any(), gomock.Any()).Return(&orchestration.ApplyResult{Resources: conflicts, Err: applyErr})
+			},
+		},
 		{
 			"apply_k8s_and_self_update_manifest",
 			manifest,
 			func(mockEventsMgr *mocksevents.MockUpdateEventsManager, mockSelfUpdateMgr *mocksorchmgr.MockUpdateManager, mockK8sOrchestrationMgr *mocksorchmgr.MockUpdateManager, mockRebootMgr *mocksupdorchmgr.MockRebootManager, mf []*unstructured.Unstructured) {
-				setupEventsManager(t, mockEventsMgr, mf, nil)
+				setupEventsManager(t, mockEventsMgr, mf, false, nil)
 				mockSelfUpdateMgr.EXPECT().Apply(gomock.Any(), gomock.Any()).Return(&selfupdate.ApplyResult{})
 				mockK8sOrchestrationMgr.EXPECT().Apply(gomock.Any(), gomock.Any())
 			},
@@ -198,18 +231,33 @@ func TestApply(t *testing.T) {
 			"apply_k8s_and_self_update_manifest_reboot_required",
 			manifest,
 			func(mockEventsMgr *mocksevents.MockUpdateEventsManager, mockSelfUpdateMgr *mocksorchmgr.MockUpdateManager, mockK8sOrchestrationMgr *mocksorchmgr.MockUpdateManager, mockRebootMgr *mocksupdorchmgr.MockRebootManager, mf []*unstructured.Unstructured) {
-				setupEventsManager(t, mockEventsMgr, mf, nil)
+				setupEventsManager(t, mockEventsMgr, mf, false, nil)
 				mockRebootMgr.EXPECT().Reboot(gomock.Any())
 				mockSelfUpdateMgr.EXPECT().Apply(gomock.Any(), gomock.Any()).Return(&selfupdate.ApplyResult{RebootRequired: true})
 				mockK8sOrchestrationMgr.EXPECT().Apply(gomock.Any(), gomock.Any())
 			},
 		},
+		{
+			"apply_k8s_and_self_update_manifest_downloaded",
+			manifest,
+			func(mockEventsMgr *mocksevents.MockUpdateEventsManager, mockSelfUpdateMgr *mocksorchmgr.MockUpdateManager, mockK8sOrchestrationMgr *mocksorchmgr.MockUpdateManager, mockRebootMgr *mocksupdorchmgr.MockRebootManager, mf []*unstructured.Unstructured) {
+				gomock.InOrder(
+					mockEventsMgr.EXPECT().Publish(gomock.Any(), gomock.Any()).Return(nil),
+					mockEventsMgr.EXPECT().Publish(gomock.Any(), gomock.Any()).Do(func(ctx context.Context, event *events.Event) {
+						testutil.AssertEqual(t, orchestration.EventActionOrchestrationFinished, event.Action)
+						testutil.AssertNil(t, event.Error)
+						testutil.AssertEqual(t, &orchestration.ApplyResult{Downloaded: true}, event.Source)
+					}).Return(nil),
+				)
+				mockSelfUpdateMgr.EXPECT().Apply(gomock.Any(), gomock.Any()).Return(&selfupdate.ApplyResult{Result: selfupdate.SelfUpdateResultDownloaded})
+			},
+		},
 		{
 			"apply_k8s_and_self_update_manifest_error",
 			manifest,
 			func(mockEventsMgr *mocksevents.MockUpdateEventsManager, mockSelfUpdateMgr *mocksorchmgr.MockUpdateManager, mockK8sOrchestrationMgr *mocksorchmgr.MockUpdateManager, mockRebootMgr *mocksupdorchmgr.MockRebootManager, mf []*unstructured.Unstructured) {
 				applyErr := fmt.Errorf("error applying self update manifest")
-				setupEventsManager(t, mockEventsMgr, mf, applyErr)
+				setupEventsManager(t, mockEventsMgr, mf, false, applyErr)
 				mockSelfUpdateMgr.EXPECT().Apply(gomock.Any(), gomock.Any()).Return(&selfupdate.ApplyResult{Err: applyErr})
 			},
 		},
@@ -218,7 +266,7 @@ func TestApply(t *testing.T) {
 			manifest,
 			func(mockEventsMgr *mocksevents.MockUpdateEventsManager, mockSelfUpdateMgr *mocksorchmgr.MockUpdateManager, mockK8sOrchestrationMgr *mocksorchmgr.MockUpdateManager, mockRebootMgr *mocksupdorchmgr.MockRebootManager, mf []*unstructured.Unstructured) {
 				applyErr := fmt.Errorf("error applying k8s manifest")
-				setupEventsManager(t, mockEventsMgr, mf, applyErr)
+				setupEventsManager(t, mockEventsMgr, mf, false, applyErr)
 				mockRebootMgr.EXPECT().Reboot(gomock.Any())
 				mockSelfUpdateMgr.EXPECT().Apply(gomock.Any(), gomock.Any()).Return(&selfupdate.ApplyResult{RebootRequired: true})
 				mockK8sOrchestrationMgr.EXPECT().Apply(gomock.Any(), gomock.Any()).Return(applyErr)
@@ -242,6 +290,395 @@ func TestApply(t *testing.T) {
 	}
 }
 
+func TestApplyDryRun(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	mockEventsMgr := mocksevents.NewMockUpdateEventsManager(controller)
+	mockRebootMgr := mocksupdorchmgr.NewMockRebootManager(controller)
+	mockSelfUpdateMgr := mocksorchmgr.NewMockUpdateManager(controller)
+	mockK8sOrchestrationMgr := mocksorchmgr.NewMockUpdateManager(controller)
+
+	_, mf, _ := parseMultiYAML([]byte(manifest))
+	setupEventsManager(t, mockEventsMgr, mf, false, nil)
+	mockSelfUpdateMgr.EXPECT().Apply(gomock.Any(), gomock.Any()).Times(0)
+	mockRebootMgr.EXPECT().Reboot(gomock.Any()).Times(0)
+	mockK8sOrchestrationMgr.EXPECT().Apply(gomock.Any(), gomock.Len(len(mf)-1)).Do(func(ctx context.Context, mf []*unstructured.Unstructured) {
+		testutil.AssertTrue(t, orchestration.IsUpdateMgrDryRunContext(ctx))
+	}).Return(&orchestration.ApplyResult{})
+
+	orchMgr := createTestUpdateOrchestrator(mockEventsMgr, mockSelfUpdateMgr, mockK8sOrchestrationMgr, mockRebootMgr)
+	orchMgr.Apply(orchestration.SetUpdateMgrDryRunContext(context.Background()), mf)
+}
+
+// testImagePullerUpdateManager is a k8s orchestration manager mock supporting the image pre-pull
+type testImagePullerUpdateManager struct {
+	*mocksorchmgr.MockUpdateManager
+	*mocksorchmgr.MockImagePuller
+}
+
+func TestApplyPullImages(t *testing.T) {
+	tests := map[string]struct {
+		pullErr error
+	}{
+		"test_images_pulled": {},
+		"test_images_pull_error": {
+			pullErr: fmt.Errorf("cannot pull container image(s): test-image"),
+		},
+	}
+	for testName, testCase := range tests {
+		t.Run(testName, func(t *testing.T) {
+			t.Log(testName)
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			mockEventsMgr := mocksevents.NewMockUpdateEventsManager(controller)
+			mockRebootMgr := mocksupdorchmgr.NewMockRebootManager(controller)
+			mockSelfUpdateMgr := mocksorchmgr.NewMockUpdateManager(controller)
+			mockK8sOrchestrationMgr := &testImagePullerUpdateManager{
+				MockUpdateManager: mocksorchmgr.NewMockUpdateManager(controller),
+				MockImagePuller:   mocksorchmgr.NewMockImagePuller(controller),
+			}
+
+			_, mf, _ := parseMultiYAML([]byte(manifest))
+			setupEventsManager(t, mockEventsMgr, mf, false, testCase.pullErr)
+			pullImages := mockK8sOrchestrationMgr.MockImagePuller.EXPECT().PullImages(gomock.Any(), gomock.Len(len(mf)-1)).Return(testCase.pullErr)
+			if testCase.pullErr != nil {
+				mockSelfUpdateMgr.EXPECT().Apply(gomock.Any(), gomock.Any()).Times(0)
+				mockK8sOrchestrationMgr.MockUpdateManager.EXPECT().Apply(gomock.Any(), gomock.Any()).Times(0)
+			} else {
+				mockSelfUpdateMgr.EXPECT().Apply(gomock.Any(), gomock.Any()).Return(&selfupdate.ApplyResult{}).After(pullImages)
+				mockK8sOrchestrationMgr.MockUpdateManager.EXPECT().Apply(gomock.Any(), gomock.Any()).Return(&orchestration.ApplyResult{}).After(pullImages)
+			}
+
+			orchMgr := createTestUpdateOrchestrator(mockEventsMgr, mockSelfUpdateMgr, mockK8sOrchestrationMgr, mockRebootMgr)
+			orchMgr.Apply(context.Background(), mf)
+		})
+	}
+}
+
+type testValidatorUpdateManager struct {
+	*mocksorchmgr.MockUpdateManager
+	*mocksorchmgr.MockManifestValidator
+}
+
+func TestValidate(t *testing.T) {
+	tests := map[string]struct {
+		validateErr error
+	}{
+		"test_valid": {},
+		"test_invalid": {
+			validateErr: fmt.Errorf("the manifest is not valid: test error"),
+		},
+	}
+	for testName, testCase := range tests {
+		t.Run(testName, func(t *testing.T) {
+			t.Log(testName)
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			mockK8sOrchestrationMgr := &testValidatorUpdateManager{
+				MockUpdateManager:     mocksorchmgr.NewMockUpdateManager(controller),
+				MockManifestValidator: mocksorchmgr.NewMockManifestValidator(controller),
+			}
+			_, mf, _ := parseMultiYAML([]byte(manifest))
+			// the SelfUpdateBundle is not validated by the k8s orchestration manager
+			mockK8sOrchestrationMgr.MockManifestValidator.EXPECT().Validate(gomock.Any(), gomock.Len(len(mf)-1)).Return(testCase.validateErr)
+
+			orchMgr := createTestUpdateOrchestrator(nil, mocksorchmgr.NewMockUpdateManager(controller), mockK8sOrchestrationMgr, mocksupdorchmgr.NewMockRebootManager(controller))
+			testutil.AssertEqual(t, testCase.validateErr, orchMgr.(orchestration.ManifestValidator).Validate(context.Background(), mf))
+		})
+	}
+}
+
+func TestValidateSelfUpdate(t *testing.T) {
+	selfUpdateErr := fmt.Errorf("the self update bundle is not valid: test error")
+	tests := map[string]struct {
+		selfUpdateErr error
+		k8sErr        error
+		expectedErr   error
+	}{
+		"test_valid": {},
+		"test_invalid_self_update": {
+			selfUpdateErr: selfUpdateErr,
+			expectedErr:   selfUpdateErr,
+		},
+		"test_invalid_k8s": {
+			k8sErr:      fmt.Errorf("the manifest is not valid: test error"),
+			expectedErr: fmt.Errorf("the manifest is not valid: test error"),
+		},
+	}
+	for testName, testCase := range tests {
+		t.Run(testName, func(t *testing.T) {
+			t.Log(testName)
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			mockSelfUpdateMgr := &testValidatorUpdateManager{
+				MockUpdateManager:     mocksorchmgr.NewMockUpdateManager(controller),
+				MockManifestValidator: mocksorchmgr.NewMockManifestValidator(controller),
+			}
+			mockK8sOrchestrationMgr := &testValidatorUpdateManager{
+				MockUpdateManager:     mocksorchmgr.NewMockUpdateManager(controller),
+				MockManifestValidator: mocksorchmgr.NewMockManifestValidator(controller),
+			}
+			_, mf, _ := parseMultiYAML([]byte(manifest))
+			// only the SelfUpdateBundle is validated by the self update manager
+			mockSelfUpdateMgr.MockManifestValidator.EXPECT().Validate(gomock.Any(), gomock.Len(1)).Return(testCase.selfUpdateErr)
+			if testCase.selfUpdateErr == nil {
+				mockK8sOrchestrationMgr.MockManifestValidator.EXPECT().Validate(gomock.Any(), gomock.Len(len(mf)-1)).Return(testCase.k8sErr)
+			}
+
+			orchMgr := createTestUpdateOrchestrator(nil, mockSelfUpdateMgr, mockK8sOrchestrationMgr, mocksupdorchmgr.NewMockRebootManager(controller))
+			testutil.AssertEqual(t, testCase.expectedErr, orchMgr.(orchestration.ManifestValidator).Validate(context.Background(), mf))
+		})
+	}
+}
+
+func TestValidateNotSupported(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	_, mf, _ := parseMultiYAML([]byte(manifest))
+	orchMgr := createTestUpdateOrchestrator(nil, mocksorchmgr.NewMockUpdateManager(controller), mocksorchmgr.NewMockUpdateManager(controller), mocksupdorchmgr.NewMockRebootManager(controller))
+	testutil.AssertNil(t, orchMgr.(orchestration.ManifestValidator).Validate(context.Background(), mf))
+}
+
+type testDriftDetectorUpdateManager struct {
+	*mocksorchmgr.MockUpdateManager
+	*mocksorchmgr.MockDriftDetector
+}
+
+func TestDrift(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	mockK8sOrchestrationMgr := &testDriftDetectorUpdateManager{
+		MockUpdateManager: mocksorchmgr.NewMockUpdateManager(controller),
+		MockDriftDetector: mocksorchmgr.NewMockDriftDetector(controller),
+	}
+	_, mf, _ := parseMultiYAML([]byte(manifest))
+	drift := []*orchestration.ResourceDrift{{
+		APIVersion: "apps/v1",
+		Kind:       "Deployment",
+		Namespace:  "default",
+		Name:       "nginx-deployment",
+		Type:       orchestration.DriftTypeDeleted,
+	}}
+	// the SelfUpdateBundle is not checked by the k8s orchestration manager
+	mockK8sOrchestrationMgr.MockDriftDetector.EXPECT().Drift(gomock.Any(), gomock.Len(len(mf)-1)).Return(drift)
+
+	orchMgr := createTestUpdateOrchestrator(nil, mocksorchmgr.NewMockUpdateManager(controller), mockK8sOrchestrationMgr, mocksupdorchmgr.NewMockRebootManager(controller))
+	testutil.AssertEqual(t, drift, orchMgr.(orchestration.DriftDetector).Drift(context.Background(), mf))
+}
+
+func TestDriftNotSupported(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	_, mf, _ := parseMultiYAML([]byte(manifest))
+	orchMgr := createTestUpdateOrchestrator(nil, mocksorchmgr.NewMockUpdateManager(controller), mocksorchmgr.NewMockUpdateManager(controller), mocksupdorchmgr.NewMockRebootManager(controller))
+	testutil.AssertNil(t, orchMgr.(orchestration.DriftDetector).Drift(context.Background(), mf))
+}
+
+type testCancelerUpdateManager struct {
+	*mocksorchmgr.MockUpdateManager
+	*mocksorchmgr.MockCanceler
+}
+
+func TestCancel(t *testing.T) {
+	tests := map[string]struct {
+		cancelErr error
+	}{
+		"test_cancelled": {},
+		"test_cannot_cancel": {
+			cancelErr: fmt.Errorf("the self update bundle cannot be cancelled"),
+		},
+	}
+	for testName, testCase := range tests {
+		t.Run(testName, func(t *testing.T) {
+			t.Log(testName)
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			mockSelfUpdateMgr := &testCancelerUpdateManager{
+				MockUpdateManager: mocksorchmgr.NewMockUpdateManager(controller),
+				MockCanceler:      mocksorchmgr.NewMockCanceler(controller),
+			}
+			mockSelfUpdateMgr.MockCanceler.EXPECT().Cancel(gomock.Any()).Return(testCase.cancelErr)
+
+			orchMgr := createTestUpdateOrchestrator(nil, mockSelfUpdateMgr, mocksorchmgr.NewMockUpdateManager(controller), mocksupdorchmgr.NewMockRebootManager(controller))
+			testutil.AssertEqual(t, testCase.cancelErr, orchMgr.(orchestration.Canceler).Cancel(context.Background()))
+		})
+	}
+}
+
+func TestCancelNotSupported(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	orchMgr := createTestUpdateOrchestrator(nil, mocksorchmgr.NewMockUpdateManager(controller), mocksorchmgr.NewMockUpdateManager(controller), mocksupdorchmgr.NewMockRebootManager(controller))
+	testutil.AssertNotNil(t, orchMgr.(orchestration.Canceler).Cancel(context.Background()))
+}
+
+type testResourceRemoverUpdateManager struct {
+	*mocksorchmgr.MockUpdateManager
+	*mocksorchmgr.MockResourceRemover
+}
+
+func TestRemove(t *testing.T) {
+	removeErr := fmt.Errorf("error removing k8s resources")
+
+	tests := map[string]struct {
+		removeErr        error
+		expectedDesired  []string
+		expectedRemained []string
+	}{
+		"test_removed": {
+			expectedDesired:  []string{"test-kept"},
+			expectedRemained: []string{"test-kept"},
+		},
+		"test_remove_error": {
+			removeErr:        removeErr,
+			expectedDesired:  []string{"nginx-deployment", "test-kept"},
+			expectedRemained: []string{"nginx-deployment", "test-kept"},
+		},
+	}
+	for testName, testCase := range tests {
+		t.Run(testName, func(t *testing.T) {
+			t.Log(testName)
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			metaPath, err := ioutil.TempDir("", "updatem-remove")
+			testutil.AssertNil(t, err)
+			defer os.RemoveAll(metaPath)
+
+			mockEventsMgr := mocksevents.NewMockUpdateEventsManager(controller)
+			mockK8sOrchestrationMgr := &testResourceRemoverUpdateManager{
+				MockUpdateManager:   mocksorchmgr.NewMockUpdateManager(controller),
+				MockResourceRemover: mocksorchmgr.NewMockResourceRemover(controller),
+			}
+			upOrch := createTestUpdateOrchestrator(mockEventsMgr, mocksorchmgr.NewMockUpdateManager(controller), mockK8sOrchestrationMgr, mocksupdorchmgr.NewMockRebootManager(controller)).(*updateOrchestrator)
+			upOrch.cfg.metaPath = metaPath
+			upOrch.cfg.reconcileInterval = time.Minute
+
+			_, removed, _ := parseMultiYAML([]byte(k8sManifest))
+			kept := removed[0].DeepCopy()
+			kept.SetName("test-kept")
+			applied := []*unstructured.Unstructured{removed[0].DeepCopy(), kept}
+			upOrch.desiredManifest = applied
+			testutil.AssertNil(t, upOrch.storeLastKnownGoodManifest(applied))
+
+			gomock.InOrder(
+				mockEventsMgr.EXPECT().Publish(gomock.Any(), gomock.Any()).Do(func(ctx context.Context, event *events.Event) {
+					testutil.AssertEqual(t, orchestration.EventActionOrchestrationStarted, event.Action)
+					testutil.AssertTrue(t, orchestration.IsUpdateMgrRemoveContext(event.Context))
+					testutil.AssertEqual(t, removed, orchestration.GetUpdateMgrApplyContext(event.Context))
+				}).Return(nil),
+				mockK8sOrchestrationMgr.MockResourceRemover.EXPECT().Remove(gomock.Any(), removed).Return(&orchestration.ApplyResult{Err: testCase.removeErr}),
+				mockEventsMgr.EXPECT().Publish(gomock.Any(), gomock.Any()).Do(func(ctx context.Context, event *events.Event) {
+					testutil.AssertEqual(t, orchestration.EventActionOrchestrationFinished, event.Action)
+					testutil.AssertEqual(t, testCase.removeErr, event.Error)
+				}).Return(nil),
+			)
+			upOrch.Remove(context.Background(), removed)
+
+			names := func(mf []*unstructured.Unstructured) []string {
+				result := []string{}
+				for _, u := range mf {
+					result = append(result, u.GetName())
+				}
+				return result
+			}
+			testutil.AssertEqual(t, testCase.expectedDesired, names(upOrch.getDesiredManifest()))
+			lastKnownGood, err := upOrch.loadLastKnownGoodManifest()
+			testutil.AssertNil(t, err)
+			testutil.AssertEqual(t, testCase.expectedRemained, names(lastKnownGood))
+		})
+	}
+}
+
+func TestRemoveNotSupported(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	mockEventsMgr := mocksevents.NewMockUpdateEventsManager(controller)
+	orchMgr := createTestUpdateOrchestrator(mockEventsMgr, mocksorchmgr.NewMockUpdateManager(controller), mocksorchmgr.NewMockUpdateManager(controller), mocksupdorchmgr.NewMockRebootManager(controller))
+	gomock.InOrder(
+		mockEventsMgr.EXPECT().Publish(gomock.Any(), gomock.Any()).Return(nil),
+		mockEventsMgr.EXPECT().Publish(gomock.Any(), gomock.Any()).Do(func(ctx context.Context, event *events.Event) {
+			testutil.AssertEqual(t, orchestration.EventActionOrchestrationFinished, event.Action)
+			testutil.AssertNotNil(t, event.Error)
+		}).Return(nil),
+	)
+	orchMgr.(orchestration.ResourceRemover).Remove(context.Background(), []*unstructured.Unstructured{})
+}
+
+type testDiagnosticsCollectorUpdateManager struct {
+	*mocksorchmgr.MockUpdateManager
+	*mocksorchmgr.MockDiagnosticsCollector
+}
+
+func TestApplyDiagnostics(t *testing.T) {
+	diagnostics := []*orchestration.ResourceDiagnostics{{
+		APIVersion: "apps/v1",
+		Kind:       "Deployment",
+		Namespace:  "default",
+		Name:       "nginx-deployment",
+		Containers: []*orchestration.ContainerDiagnostics{{Pod: "nginx-deployment-1", Container: "nginx", State: "waiting: CrashLoopBackOff", Logs: []string{"error"}}},
+	}}
+	tests := map[string]struct {
+		applyErr            error
+		dryRun              bool
+		expectedDiagnostics []*orchestration.ResourceDiagnostics
+	}{
+		"test_apply_success": {},
+		"test_apply_error": {
+			applyErr:            fmt.Errorf("error applying k8s manifest"),
+			expectedDiagnostics: diagnostics,
+		},
+		"test_apply_error_dry_run": {
+			applyErr: fmt.Errorf("error applying k8s manifest"),
+			dryRun:   true,
+		},
+	}
+	for testName, testCase := range tests {
+		t.Run(testName, func(t *testing.T) {
+			t.Log(testName)
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			mockEventsMgr := mocksevents.NewMockUpdateEventsManager(controller)
+			mockK8sOrchestrationMgr := &testDiagnosticsCollectorUpdateManager{
+				MockUpdateManager:        mocksorchmgr.NewMockUpdateManager(controller),
+				MockDiagnosticsCollector: mocksorchmgr.NewMockDiagnosticsCollector(controller),
+			}
+			_, mf, _ := parseMultiYAML([]byte(k8sManifest))
+			ctx := context.Background()
+			if testCase.dryRun {
+				ctx = orchestration.SetUpdateMgrDryRunContext(ctx)
+			}
+
+			mockK8sOrchestrationMgr.MockUpdateManager.EXPECT().Apply(gomock.Any(), gomock.Any()).Return(&orchestration.ApplyResult{Err: testCase.applyErr})
+			if testCase.expectedDiagnostics != nil {
+				mockK8sOrchestrationMgr.MockDiagnosticsCollector.EXPECT().Diagnose(gomock.Any(), mf).Return(testCase.expectedDiagnostics)
+			}
+			var applyResult *orchestration.ApplyResult
+			mockEventsMgr.EXPECT().Publish(gomock.Any(), gomock.Any()).Do(func(ctx context.Context, event *events.Event) {
+				if event.Action == orchestration.EventActionOrchestrationFinished {
+					applyResult = event.Source.(*orchestration.ApplyResult)
+				}
+			}).Return(nil).AnyTimes()
+
+			orchMgr := createTestUpdateOrchestrator(mockEventsMgr, mocksorchmgr.NewMockUpdateManager(controller), mockK8sOrchestrationMgr, mocksupdorchmgr.NewMockRebootManager(controller))
+			orchMgr.Apply(ctx, mf)
+			testutil.AssertNotNil(t, applyResult)
+			testutil.AssertEqual(t, testCase.expectedDiagnostics, applyResult.Diagnostics)
+		})
+	}
+}
+
 func TestGet(t *testing.T) {
 	controller := gomock.NewController(t)
 
@@ -343,6 +780,7 @@ func createTestUpdateOrchestrator(mockEventsMgr events.UpdateEventsManager, mock
 	mockRebootMgr RebootManager) orchestration.UpdateManager {
 	return &updateOrchestrator{
 		applyLock:               sync.Mutex{},
+		cfg:                     &mgrOpts{},
 		rebootManager:           mockRebootMgr,
 		eventsManager:           mockEventsMgr,
 		selfUpdateManager:       mockSelfUpdateMgr,
@@ -350,7 +788,7 @@ func createTestUpdateOrchestrator(mockEventsMgr events.UpdateEventsManager, mock
 	}
 }
 
-func setupEventsManager(t *testing.T, mockEventsMgr *mocksevents.MockUpdateEventsManager, mf []*unstructured.Unstructured, expectedErr error) {
+func setupEventsManager(t *testing.T, mockEventsMgr *mocksevents.MockUpdateEventsManager, mf []*unstructured.Unstructured, expectedInstall bool, expectedErr error) {
 	assertCtx := func(ctx context.Context, mf []*unstructured.Unstructured) {
 		testutil.AssertEqual(t, mf, orchestration.GetUpdateMgrApplyContext(ctx))
 	}
@@ -359,18 +797,27 @@ func setupEventsManager(t *testing.T, mockEventsMgr *mocksevents.MockUpdateEvent
 		testutil.AssertEqual(t, expAction, event.Action)
 		assertError(t, expErr, event.Error)
 	}
-	gomock.InOrder(
+	calls := []*gomock.Call{
 		mockEventsMgr.EXPECT().Publish(gomock.Any(), gomock.Any()).Do(func(ctx context.Context, event *events.Event) {
 			assertCtx(ctx, mf)
 			assertCtx(event.Context, mf)
 			assertEvent(event, orchestration.EventActionOrchestrationStarted, nil)
 		},
 		).Return(nil),
-		mockEventsMgr.EXPECT().Publish(gomock.Any(), gomock.Any()).Do(func(ctx context.Context, event *events.Event) {
-			assertCtx(ctx, mf)
+	}
+	if expectedInstall {
+		calls = append(calls, mockEventsMgr.EXPECT().Publish(gomock.Any(), gomock.Any()).Do(func(ctx context.Context, event *events.Event) {
 			assertCtx(event.Context, mf)
-			assertEvent(event, orchestration.EventActionOrchestrationFinished, expectedErr)
+			assertEvent(event, orchestration.EventActionOrchestrationRunning, nil)
+			testutil.AssertEqual(t, &orchestration.Progress{Phase: orchestration.ProgressPhaseInstall}, event.Source)
 		},
-		).Return(nil),
-	)
+		).Return(nil))
+	}
+	calls = append(calls, mockEventsMgr.EXPECT().Publish(gomock.Any(), gomock.Any()).Do(func(ctx context.Context, event *events.Event) {
+		assertCtx(ctx, mf)
+		assertCtx(event.Context, mf)
+		assertEvent(event, orchestration.EventActionOrchestrationFinished, expectedErr)
+	},
+	).Return(nil))
+	gomock.InOrder(calls...)
 }
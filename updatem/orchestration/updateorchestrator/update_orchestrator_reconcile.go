@@ -0,0 +1,200 @@
+// Copyright (c) 2022 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Apache License 2.0 which is available at
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package updateorchestrator
+
+import (
+	"context"
+	"time"
+
+	"github.com/eclipse-kanto/container-management/containerm/log"
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/events"
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/orchestration"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Constants for the annotation that excludes a resource of the desired manifest from the reconciliation, so that its local modifications are kept
+const (
+	reconcileAnnotation         = "sdv.eclipse.org/reconcile"
+	reconcileAnnotationDisabled = "disabled"
+)
+
+func (upOrch *updateOrchestrator) reconcileEnabled() bool {
+	return upOrch.cfg != nil && upOrch.cfg.reconcileInterval > 0
+}
+
+// setDesiredManifest updates the manifest the vehicle is reconciled to after an apply.
+// The reconciliation is suspended if the apply failed and the last-known-good manifest is not restored.
+func (upOrch *updateOrchestrator) setDesiredManifest(manifest []*unstructured.Unstructured, applyErr error, rollbackResult *orchestration.RollbackResult) {
+	upOrch.desiredLock.Lock()
+	defer upOrch.desiredLock.Unlock()
+	if applyErr == nil {
+		upOrch.desiredManifest = manifest
+	} else if rollbackResult == nil || rollbackResult.Err != nil {
+		log.Warn("the desired manifest is not applied - suspending the reconciliation until the next successful apply")
+		upOrch.desiredManifest = nil
+	}
+}
+
+func (upOrch *updateOrchestrator) getDesiredManifest() []*unstructured.Unstructured {
+	upOrch.desiredLock.RLock()
+	defer upOrch.desiredLock.RUnlock()
+	return upOrch.desiredManifest
+}
+
+// startReconcile starts the reconciliation of the desired manifest if enabled.
+// The last-known-good manifest is the desired one until the next apply.
+func (upOrch *updateOrchestrator) startReconcile(ctx context.Context) {
+	if !upOrch.reconcileEnabled() {
+		return
+	}
+	if upOrch.rollbackEnabled() {
+		lastKnownGood, err := upOrch.loadLastKnownGoodManifest()
+		if err != nil {
+			log.ErrorErr(err, "cannot load the last-known-good manifest to reconcile")
+		}
+		upOrch.setDesiredManifest(lastKnownGood, nil, nil)
+	}
+	reconcileCtx, cancel := context.WithCancel(ctx)
+	upOrch.cancelReconcile = cancel
+	eventsChannel, errorChannel := upOrch.eventsManager.Subscribe(reconcileCtx)
+	log.Debug("reconciling the desired manifest every %s", upOrch.cfg.reconcileInterval)
+	go upOrch.loopReconcile(reconcileCtx, eventsChannel, errorChannel)
+}
+
+func (upOrch *updateOrchestrator) stopReconcile() {
+	if upOrch.cancelReconcile != nil {
+		upOrch.cancelReconcile()
+	}
+}
+
+// loopReconcile re-applies the desired manifest periodically and after a resource of it is modified or deleted.
+// A failed reconciliation is retried with an exponential backoff up to the reconcile interval.
+func (upOrch *updateOrchestrator) loopReconcile(ctx context.Context, eventsChannel <-chan *events.Event, errorChannel <-chan error) {
+	backoff := upOrch.cfg.reconcileBackoff
+	next := time.Now().Add(upOrch.cfg.reconcileInterval)
+	timer := time.NewTimer(upOrch.cfg.reconcileInterval)
+	defer timer.Stop()
+	onlyIfModified := false
+	for {
+		select {
+		case evt := <-eventsChannel:
+			if !upOrch.isDesiredResourceEvent(evt) {
+				continue
+			}
+			// a resource modified repeatedly must not postpone the scheduled reconciliation
+			if scheduled := time.Now().Add(backoff); scheduled.Before(next) {
+				resetTimer(timer, backoff)
+				next = scheduled
+				onlyIfModified = true
+			}
+		case err := <-errorChannel:
+			if ctx.Err() != nil {
+				return
+			}
+			log.ErrorErr(err, "received error from subscription")
+		case <-timer.C:
+			delay := upOrch.cfg.reconcileInterval
+			if err := upOrch.reconcile(ctx, onlyIfModified); err != nil {
+				if backoff > 0 {
+					delay = backoff
+					if backoff *= 2; backoff > upOrch.cfg.reconcileInterval {
+						backoff = upOrch.cfg.reconcileInterval
+					}
+				}
+			} else {
+				backoff = upOrch.cfg.reconcileBackoff
+			}
+			onlyIfModified = false
+			timer.Reset(delay)
+			next = time.Now().Add(delay)
+		case <-ctx.Done():
+			log.Debug("reconcile context is done - exiting reconcile loop")
+			return
+		}
+	}
+}
+
+func resetTimer(timer *time.Timer, d time.Duration) {
+	if !timer.Stop() {
+		select {
+		case <-timer.C:
+		default:
+		}
+	}
+	timer.Reset(d)
+}
+
+// isDesiredResourceEvent checks if the event reports a modified or deleted resource of the desired manifest
+func (upOrch *updateOrchestrator) isDesiredResourceEvent(evt *events.Event) bool {
+	if evt.Type != events.EventTypeResources ||
+		(evt.Action != events.EventActionResourcesUpdated && evt.Action != events.EventActionResourcesDeleted) {
+		return false
+	}
+	var resource *unstructured.Unstructured
+	switch source := evt.Source.(type) {
+	case unstructured.Unstructured:
+		resource = &source
+	case *unstructured.Unstructured:
+		resource = source
+	default:
+		return false
+	}
+	for _, u := range upOrch.getDesiredManifest() {
+		if u.GetKind() == resource.GetKind() && u.GetName() == resource.GetName() &&
+			(u.GetNamespace() == resource.GetNamespace() || u.GetNamespace() == "" && resource.GetNamespace() == "default") {
+			return true
+		}
+	}
+	return false
+}
+
+// reconcile re-applies the desired manifest without the resources excluded from the reconciliation.
+// If onlyIfModified is set, the manifest is re-applied only if the orchestration manager detects local modifications.
+func (upOrch *updateOrchestrator) reconcile(ctx context.Context, onlyIfModified bool) error {
+	upOrch.applyLock.Lock()
+	defer upOrch.applyLock.Unlock()
+
+	desired := upOrch.getDesiredManifest()
+	manifest := []*unstructured.Unstructured{}
+	for _, u := range desired {
+		if u.GetAnnotations()[reconcileAnnotation] == reconcileAnnotationDisabled {
+			continue
+		}
+		manifest = append(manifest, u)
+	}
+	if len(manifest) == 0 {
+		return nil
+	}
+	if detector, ok := upOrch.k8sOrchestrationManager.(orchestration.DriftDetector); ok && onlyIfModified && len(detector.Drift(ctx, manifest)) == 0 {
+		log.Debug("the desired manifest is not modified locally - skipping the reconciliation")
+		return nil
+	}
+
+	log.Debug("reconciling the desired manifest")
+	reconcileCtx := orchestration.SetUpdateMgrReconcileContext(orchestration.SetUpdateMgrApplyContext(ctx, desired))
+	upOrch.publishEvent(reconcileCtx, orchestration.EventTypeOrchestration, orchestration.EventActionOrchestrationReconcileStarted, nil, nil)
+
+	reconcileResult := &orchestration.ReconcileResult{}
+	switch k8sApplyResult := upOrch.k8sOrchestrationManager.Apply(reconcileCtx, manifest).(type) {
+	case *orchestration.ApplyResult:
+		reconcileResult.Resources = k8sApplyResult.Resources
+		reconcileResult.Err = k8sApplyResult.Err
+	case error:
+		reconcileResult.Err = k8sApplyResult
+	}
+	if reconcileResult.Err != nil {
+		log.ErrorErr(reconcileResult.Err, "cannot reconcile the desired manifest")
+	}
+	upOrch.publishEvent(reconcileCtx, orchestration.EventTypeOrchestration, orchestration.EventActionOrchestrationReconcileFinished, reconcileResult, reconcileResult.Err)
+	log.Debug("reconciling the desired manifest - done")
+	return reconcileResult.Err
+}
@@ -0,0 +1,230 @@
+// Copyright (c) 2022 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Apache License 2.0 which is available at
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package updateorchestrator
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/events"
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/orchestration"
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/pkg/testutil"
+	mocksevents "github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/pkg/testutil/mocks/events"
+	mocksorchmgr "github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/pkg/testutil/mocks/orchestration"
+	mocksupdorchmgr "github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/pkg/testutil/mocks/updateorchestrator"
+	"github.com/golang/mock/gomock"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func newTestDesiredManifest() []*unstructured.Unstructured {
+	_, mf, _ := parseMultiYAML([]byte(k8sManifest))
+	excluded := mf[0].DeepCopy()
+	excluded.SetName("excluded")
+	excluded.SetAnnotations(map[string]string{reconcileAnnotation: reconcileAnnotationDisabled})
+	return append(mf, excluded)
+}
+
+func TestReconcile(t *testing.T) {
+	reconcileErr := fmt.Errorf("error applying the desired manifest")
+	testDrift := []*orchestration.ResourceDrift{{Kind: "Deployment", Name: "nginx-deployment", Type: orchestration.DriftTypeDeleted}}
+
+	tests := map[string]struct {
+		onlyIfModified  bool
+		drift           []*orchestration.ResourceDrift
+		applyErr        error
+		expectedApplied bool
+	}{
+		"test_reconcile_periodic": {
+			expectedApplied: true,
+		},
+		"test_reconcile_modified": {
+			onlyIfModified:  true,
+			drift:           testDrift,
+			expectedApplied: true,
+		},
+		"test_reconcile_not_modified": {
+			onlyIfModified: true,
+		},
+		"test_reconcile_error": {
+			applyErr:        reconcileErr,
+			expectedApplied: true,
+		},
+	}
+	for testName, testCase := range tests {
+		t.Run(testName, func(t *testing.T) {
+			t.Log(testName)
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			mockEventsMgr := mocksevents.NewMockUpdateEventsManager(controller)
+			mockK8sOrchestrationMgr := &testDriftDetectorUpdateManager{
+				MockUpdateManager: mocksorchmgr.NewMockUpdateManager(controller),
+				MockDriftDetector: mocksorchmgr.NewMockDriftDetector(controller),
+			}
+			upOrch := createTestUpdateOrchestrator(mockEventsMgr, mocksorchmgr.NewMockUpdateManager(controller), mockK8sOrchestrationMgr,
+				mocksupdorchmgr.NewMockRebootManager(controller)).(*updateOrchestrator)
+			desired := newTestDesiredManifest()
+			upOrch.setDesiredManifest(desired, nil, nil)
+
+			if testCase.onlyIfModified {
+				mockK8sOrchestrationMgr.MockDriftDetector.EXPECT().Drift(gomock.Any(), gomock.Len(1)).Return(testCase.drift)
+			}
+			if testCase.expectedApplied {
+				publishedActions := []events.EventAction{}
+				mockEventsMgr.EXPECT().Publish(gomock.Any(), gomock.Any()).Do(func(ctx context.Context, event *events.Event) {
+					publishedActions = append(publishedActions, event.Action)
+					testutil.AssertTrue(t, orchestration.IsUpdateMgrReconcileContext(event.Context))
+					testutil.AssertEqual(t, desired, orchestration.GetUpdateMgrApplyContext(event.Context))
+					if event.Action == orchestration.EventActionOrchestrationReconcileFinished {
+						testutil.AssertEqual(t, testCase.applyErr, event.Error)
+						testutil.AssertEqual(t, testCase.applyErr, event.Source.(*orchestration.ReconcileResult).Err)
+					}
+				}).Return(nil).Times(2)
+				defer func() {
+					testutil.AssertEqual(t, []events.EventAction{
+						orchestration.EventActionOrchestrationReconcileStarted,
+						orchestration.EventActionOrchestrationReconcileFinished,
+					}, publishedActions)
+				}()
+				// the excluded resource is not reconciled
+				mockK8sOrchestrationMgr.MockUpdateManager.EXPECT().Apply(gomock.Any(), []*unstructured.Unstructured{desired[0]}).DoAndReturn(
+					func(ctx context.Context, mf []*unstructured.Unstructured) interface{} {
+						testutil.AssertTrue(t, orchestration.IsUpdateMgrReconcileContext(ctx))
+						return &orchestration.ApplyResult{Err: testCase.applyErr}
+					})
+			}
+
+			testutil.AssertEqual(t, testCase.applyErr, upOrch.reconcile(context.Background(), testCase.onlyIfModified))
+		})
+	}
+}
+
+func TestSetDesiredManifest(t *testing.T) {
+	applyErr := fmt.Errorf("error applying k8s manifest")
+	previous := newTestDesiredManifest()[:1]
+	applied := newTestDesiredManifest()
+
+	tests := map[string]struct {
+		applyErr        error
+		rollbackResult  *orchestration.RollbackResult
+		expectedDesired []*unstructured.Unstructured
+	}{
+		"test_applied": {
+			expectedDesired: applied,
+		},
+		"test_rolled_back": {
+			applyErr:        applyErr,
+			rollbackResult:  &orchestration.RollbackResult{},
+			expectedDesired: previous,
+		},
+		"test_rollback_error": {
+			applyErr:       applyErr,
+			rollbackResult: &orchestration.RollbackResult{Err: fmt.Errorf("error applying last-known-good manifest")},
+		},
+		"test_no_rollback": {
+			applyErr: applyErr,
+		},
+	}
+	for testName, testCase := range tests {
+		t.Run(testName, func(t *testing.T) {
+			t.Log(testName)
+			upOrch := &updateOrchestrator{cfg: &mgrOpts{}}
+			upOrch.setDesiredManifest(previous, nil, nil)
+			upOrch.setDesiredManifest(applied, testCase.applyErr, testCase.rollbackResult)
+			testutil.AssertEqual(t, testCase.expectedDesired, upOrch.getDesiredManifest())
+		})
+	}
+}
+
+func TestIsDesiredResourceEvent(t *testing.T) {
+	upOrch := &updateOrchestrator{cfg: &mgrOpts{}}
+	upOrch.setDesiredManifest(newTestDesiredManifest(), nil, nil)
+
+	newResource := func(kind, namespace, name string) unstructured.Unstructured {
+		u := unstructured.Unstructured{}
+		u.SetKind(kind)
+		u.SetNamespace(namespace)
+		u.SetName(name)
+		return u
+	}
+	tests := map[string]struct {
+		event    *events.Event
+		expected bool
+	}{
+		"test_desired_resource_deleted": {
+			event:    &events.Event{Type: events.EventTypeResources, Action: events.EventActionResourcesDeleted, Source: newResource("Deployment", "default", "nginx-deployment")},
+			expected: true,
+		},
+		"test_desired_resource_updated": {
+			event:    &events.Event{Type: events.EventTypeResources, Action: events.EventActionResourcesUpdated, Source: newResource("Deployment", "default", "nginx-deployment")},
+			expected: true,
+		},
+		"test_desired_resource_added": {
+			event: &events.Event{Type: events.EventTypeResources, Action: events.EventActionResourcesAdded, Source: newResource("Deployment", "default", "nginx-deployment")},
+		},
+		"test_other_namespace": {
+			event: &events.Event{Type: events.EventTypeResources, Action: events.EventActionResourcesDeleted, Source: newResource("Deployment", "sdv", "nginx-deployment")},
+		},
+		"test_other_resource": {
+			event: &events.Event{Type: events.EventTypeResources, Action: events.EventActionResourcesDeleted, Source: newResource("Pod", "default", "nginx-deployment-pod")},
+		},
+		"test_orchestration_event": {
+			event: &events.Event{Type: orchestration.EventTypeOrchestration, Action: orchestration.EventActionOrchestrationFinished},
+		},
+	}
+	for testName, testCase := range tests {
+		t.Run(testName, func(t *testing.T) {
+			t.Log(testName)
+			testutil.AssertEqual(t, testCase.expected, upOrch.isDesiredResourceEvent(testCase.event))
+		})
+	}
+}
+
+func TestLoopReconcileOnResourceEvent(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	mockEventsMgr := mocksevents.NewMockUpdateEventsManager(controller)
+	mockK8sOrchestrationMgr := mocksorchmgr.NewMockUpdateManager(controller)
+	upOrch := createTestUpdateOrchestrator(mockEventsMgr, mocksorchmgr.NewMockUpdateManager(controller), mockK8sOrchestrationMgr,
+		mocksupdorchmgr.NewMockRebootManager(controller)).(*updateOrchestrator)
+	upOrch.cfg.reconcileInterval = time.Hour
+	upOrch.cfg.reconcileBackoff = 10 * time.Millisecond
+	desired := newTestDesiredManifest()
+	upOrch.setDesiredManifest(desired, nil, nil)
+
+	eventsChannel := make(chan *events.Event, 1)
+	errorChannel := make(chan error, 1)
+	mockEventsMgr.EXPECT().Subscribe(gomock.Any()).Return(eventsChannel, errorChannel)
+	mockK8sOrchestrationMgr.EXPECT().Apply(gomock.Any(), []*unstructured.Unstructured{desired[0]}).Return(&orchestration.ApplyResult{})
+
+	testWg := &sync.WaitGroup{}
+	testWg.Add(1)
+	mockEventsMgr.EXPECT().Publish(gomock.Any(), gomock.Any()).Do(func(ctx context.Context, event *events.Event) {
+		if event.Action == orchestration.EventActionOrchestrationReconcileFinished {
+			testWg.Done()
+		}
+	}).Return(nil).Times(2)
+
+	upOrch.startReconcile(context.Background())
+	defer upOrch.Dispose(context.Background())
+
+	deleted := unstructured.Unstructured{}
+	deleted.SetKind("Deployment")
+	deleted.SetNamespace("default")
+	deleted.SetName("nginx-deployment")
+	eventsChannel <- &events.Event{Type: events.EventTypeResources, Action: events.EventActionResourcesDeleted, Source: deleted}
+	testutil.AssertWithTimeout(t, testWg, 5*time.Second)
+}
@@ -0,0 +1,109 @@
+// Copyright (c) 2022 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Apache License 2.0 which is available at
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package updateorchestrator
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/eclipse-kanto/container-management/containerm/log"
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/orchestration"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const lastKnownGoodManifestFile = "last-known-good-manifest.json"
+
+func (upOrch *updateOrchestrator) rollbackEnabled() bool {
+	return upOrch.cfg != nil && upOrch.cfg.metaPath != ""
+}
+
+func (upOrch *updateOrchestrator) lastKnownGoodManifestPath() string {
+	return filepath.Join(upOrch.cfg.metaPath, lastKnownGoodManifestFile)
+}
+
+// storeLastKnownGoodManifest persists the successfully applied k8s manifest, replacing the previous one atomically
+func (upOrch *updateOrchestrator) storeLastKnownGoodManifest(mf []*unstructured.Unstructured) error {
+	data, err := json.Marshal(mf)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(upOrch.cfg.metaPath, 0755); err != nil {
+		return err
+	}
+	tmpFile := upOrch.lastKnownGoodManifestPath() + ".tmp"
+	if err := ioutil.WriteFile(tmpFile, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile, upOrch.lastKnownGoodManifestPath())
+}
+
+// loadLastKnownGoodManifest returns the last successfully applied k8s manifest or nil if there is no such
+func (upOrch *updateOrchestrator) loadLastKnownGoodManifest() ([]*unstructured.Unstructured, error) {
+	data, err := ioutil.ReadFile(upOrch.lastKnownGoodManifestPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	mf := []*unstructured.Unstructured{}
+	if err := json.Unmarshal(data, &mf); err != nil {
+		return nil, err
+	}
+	return mf, nil
+}
+
+// rollback re-applies the last-known-good k8s manifest. It returns nil if there is nothing to roll back to.
+// Only the k8s resources are rolled back. A self update in the same manifest is applied before them, so if it fails nothing is changed in k8s,
+// while an installed self update is not rolled back by a failure of the k8s resources - the system boots into it and its outcome is verified after the reboot.
+func (upOrch *updateOrchestrator) rollback(ctx context.Context) *orchestration.RollbackResult {
+	lastKnownGood, err := upOrch.loadLastKnownGoodManifest()
+	if err != nil {
+		log.ErrorErr(err, "cannot load the last-known-good manifest")
+		return &orchestration.RollbackResult{Err: err}
+	}
+	if len(lastKnownGood) == 0 {
+		log.Warn("there is no last-known-good manifest - skipping the rollback")
+		return nil
+	}
+
+	log.Debug("rolling back to the last-known-good manifest")
+	upOrch.publishEvent(ctx, orchestration.EventTypeOrchestration, orchestration.EventActionOrchestrationRollbackStarted, nil, nil)
+
+	// the rollback is an orchestration of the last-known-good manifest, not of the failed one
+	rollbackCtx := orchestration.SetUpdateMgrApplyContext(ctx, lastKnownGood)
+	rollbackResult := &orchestration.RollbackResult{}
+	switch k8sApplyResult := upOrch.k8sOrchestrationManager.Apply(rollbackCtx, lastKnownGood).(type) {
+	case *orchestration.ApplyResult:
+		rollbackResult.Resources = k8sApplyResult.Resources
+		rollbackResult.Err = k8sApplyResult.Err
+	case error:
+		rollbackResult.Err = k8sApplyResult
+	}
+	upOrch.publishEvent(ctx, orchestration.EventTypeOrchestration, orchestration.EventActionOrchestrationRollbackFinished, rollbackResult, rollbackResult.Err)
+	log.Debug("rolling back to the last-known-good manifest - done")
+	return rollbackResult
+}
+
+// withRollbackError extends the apply error with the rollback outcome
+func withRollbackError(applyErr error, rollbackResult *orchestration.RollbackResult) error {
+	if rollbackResult == nil {
+		return applyErr
+	}
+	if rollbackResult.Err != nil {
+		return log.NewErrorf("%v - rollback to the last-known-good manifest failed: %v", applyErr, rollbackResult.Err)
+	}
+	return log.NewErrorf("%v - rolled back to the last-known-good manifest", applyErr)
+}
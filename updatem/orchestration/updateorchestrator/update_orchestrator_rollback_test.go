@@ -0,0 +1,210 @@
+// Copyright (c) 2022 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Apache License 2.0 which is available at
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package updateorchestrator
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/events"
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/orchestration"
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/orchestration/selfupdate"
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/pkg/testutil"
+	mocksevents "github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/pkg/testutil/mocks/events"
+	mocksorchmgr "github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/pkg/testutil/mocks/orchestration"
+	mocksupdorchmgr "github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/pkg/testutil/mocks/updateorchestrator"
+	"github.com/golang/mock/gomock"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestLastKnownGoodManifest(t *testing.T) {
+	metaPath, err := ioutil.TempDir("", "updatem-rollback")
+	testutil.AssertNil(t, err)
+	defer os.RemoveAll(metaPath)
+
+	upOrch := &updateOrchestrator{cfg: &mgrOpts{metaPath: metaPath}}
+
+	lastKnownGood, err := upOrch.loadLastKnownGoodManifest()
+	testutil.AssertNil(t, err)
+	testutil.AssertNil(t, lastKnownGood)
+
+	_, mf, _ := parseMultiYAML([]byte(k8sManifest))
+	testutil.AssertNil(t, upOrch.storeLastKnownGoodManifest(mf))
+
+	lastKnownGood, err = upOrch.loadLastKnownGoodManifest()
+	testutil.AssertNil(t, err)
+	testutil.AssertEqual(t, mf, lastKnownGood)
+}
+
+func TestApplyRollback(t *testing.T) {
+	applyErr := fmt.Errorf("error applying k8s manifest")
+	rollbackErr := fmt.Errorf("error applying last-known-good manifest")
+
+	tests := map[string]struct {
+		lastKnownGood       bool
+		applyErr            error
+		rollbackErr         error
+		expectedRollback    bool
+		expectedErrContains string
+	}{
+		"test_apply_stores_last_known_good": {
+			lastKnownGood: false,
+		},
+		"test_apply_error_no_last_known_good": {
+			applyErr:            applyErr,
+			expectedErrContains: applyErr.Error(),
+		},
+		"test_apply_error_rollback": {
+			lastKnownGood:       true,
+			applyErr:            applyErr,
+			expectedRollback:    true,
+			expectedErrContains: "rolled back to the last-known-good manifest",
+		},
+		"test_apply_error_rollback_error": {
+			lastKnownGood:       true,
+			applyErr:            applyErr,
+			rollbackErr:         rollbackErr,
+			expectedRollback:    true,
+			expectedErrContains: "rollback to the last-known-good manifest failed: " + rollbackErr.Error(),
+		},
+	}
+
+	for testName, testCase := range tests {
+		t.Run(testName, func(t *testing.T) {
+			t.Log(testName)
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			metaPath, err := ioutil.TempDir("", "updatem-rollback")
+			testutil.AssertNil(t, err)
+			defer os.RemoveAll(metaPath)
+
+			mockEventsMgr := mocksevents.NewMockUpdateEventsManager(controller)
+			mockRebootMgr := mocksupdorchmgr.NewMockRebootManager(controller)
+			mockSelfUpdateMgr := mocksorchmgr.NewMockUpdateManager(controller)
+			mockK8sOrchestrationMgr := mocksorchmgr.NewMockUpdateManager(controller)
+
+			upOrch := createTestUpdateOrchestrator(mockEventsMgr, mockSelfUpdateMgr, mockK8sOrchestrationMgr, mockRebootMgr).(*updateOrchestrator)
+			upOrch.cfg.metaPath = metaPath
+
+			_, mf, _ := parseMultiYAML([]byte(k8sManifest))
+			lastKnownGood := []*unstructured.Unstructured{mf[0].DeepCopy()}
+			lastKnownGood[0].SetName("last-known-good")
+			if testCase.lastKnownGood {
+				testutil.AssertNil(t, upOrch.storeLastKnownGoodManifest(lastKnownGood))
+			}
+
+			publishedActions := []events.EventAction{}
+			mockEventsMgr.EXPECT().Publish(gomock.Any(), gomock.Any()).Do(func(ctx context.Context, event *events.Event) {
+				publishedActions = append(publishedActions, event.Action)
+				if event.Action != orchestration.EventActionOrchestrationFinished {
+					return
+				}
+				applyResult := event.Source.(*orchestration.ApplyResult)
+				if testCase.expectedErrContains == "" {
+					testutil.AssertNil(t, event.Error)
+				} else {
+					testutil.AssertContainsString(t, event.Error.Error(), testCase.expectedErrContains)
+				}
+				if testCase.expectedRollback {
+					testutil.AssertNotNil(t, applyResult.Rollback)
+					testutil.AssertEqual(t, testCase.rollbackErr, applyResult.Rollback.Err)
+				} else {
+					testutil.AssertNil(t, applyResult.Rollback)
+				}
+			}).Return(nil).AnyTimes()
+
+			mockK8sOrchestrationMgr.EXPECT().Apply(gomock.Any(), mf).Return(&orchestration.ApplyResult{Err: testCase.applyErr})
+			if testCase.expectedRollback {
+				mockK8sOrchestrationMgr.EXPECT().Apply(gomock.Any(), lastKnownGood).Do(func(ctx context.Context, mf []*unstructured.Unstructured) {
+					testutil.AssertEqual(t, lastKnownGood, orchestration.GetUpdateMgrApplyContext(ctx))
+				}).Return(&orchestration.ApplyResult{Err: testCase.rollbackErr})
+			}
+
+			upOrch.Apply(context.Background(), mf)
+
+			if testCase.expectedRollback {
+				testutil.AssertEqual(t, []events.EventAction{
+					orchestration.EventActionOrchestrationStarted,
+					orchestration.EventActionOrchestrationRunning,
+					orchestration.EventActionOrchestrationRollbackStarted,
+					orchestration.EventActionOrchestrationRollbackFinished,
+					orchestration.EventActionOrchestrationFinished,
+				}, publishedActions)
+			} else {
+				testutil.AssertEqual(t, []events.EventAction{
+					orchestration.EventActionOrchestrationStarted,
+					orchestration.EventActionOrchestrationRunning,
+					orchestration.EventActionOrchestrationFinished,
+				}, publishedActions)
+			}
+
+			storedManifest, err := upOrch.loadLastKnownGoodManifest()
+			testutil.AssertNil(t, err)
+			switch {
+			case testCase.applyErr == nil:
+				testutil.AssertEqual(t, mf, storedManifest)
+			case testCase.lastKnownGood:
+				testutil.AssertEqual(t, lastKnownGood, storedManifest)
+			default:
+				testutil.AssertNil(t, storedManifest)
+			}
+		})
+	}
+}
+
+func TestApplySelfUpdateErrorNoRollback(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	metaPath, err := ioutil.TempDir("", "updatem-rollback")
+	testutil.AssertNil(t, err)
+	defer os.RemoveAll(metaPath)
+
+	mockEventsMgr := mocksevents.NewMockUpdateEventsManager(controller)
+	mockRebootMgr := mocksupdorchmgr.NewMockRebootManager(controller)
+	mockSelfUpdateMgr := mocksorchmgr.NewMockUpdateManager(controller)
+	mockK8sOrchestrationMgr := mocksorchmgr.NewMockUpdateManager(controller)
+
+	upOrch := createTestUpdateOrchestrator(mockEventsMgr, mockSelfUpdateMgr, mockK8sOrchestrationMgr, mockRebootMgr).(*updateOrchestrator)
+	upOrch.cfg.metaPath = metaPath
+
+	_, lastKnownGood, _ := parseMultiYAML([]byte(k8sManifest))
+	testutil.AssertNil(t, upOrch.storeLastKnownGoodManifest(lastKnownGood))
+	_, mf, _ := parseMultiYAML([]byte(manifest))
+
+	applyErr := fmt.Errorf("error applying self update manifest")
+	publishedActions := []events.EventAction{}
+	mockEventsMgr.EXPECT().Publish(gomock.Any(), gomock.Any()).Do(func(ctx context.Context, event *events.Event) {
+		publishedActions = append(publishedActions, event.Action)
+		if event.Action == orchestration.EventActionOrchestrationFinished {
+			testutil.AssertEqual(t, applyErr, event.Error)
+			testutil.AssertNil(t, event.Source.(*orchestration.ApplyResult).Rollback)
+		}
+	}).Return(nil).AnyTimes()
+	mockSelfUpdateMgr.EXPECT().Apply(gomock.Any(), gomock.Any()).Return(&selfupdate.ApplyResult{Err: applyErr})
+	// the failed self update leaves the k8s resources unchanged, so they are neither applied nor rolled back
+	mockK8sOrchestrationMgr.EXPECT().Apply(gomock.Any(), gomock.Any()).Times(0)
+
+	upOrch.Apply(context.Background(), mf)
+
+	testutil.AssertEqual(t, []events.EventAction{
+		orchestration.EventActionOrchestrationStarted,
+		orchestration.EventActionOrchestrationFinished,
+	}, publishedActions)
+	storedManifest, err := upOrch.loadLastKnownGoodManifest()
+	testutil.AssertNil(t, err)
+	testutil.AssertEqual(t, lastKnownGood, storedManifest)
+}
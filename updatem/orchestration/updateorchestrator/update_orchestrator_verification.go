@@ -0,0 +1,171 @@
+// Copyright (c) 2022 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Apache License 2.0 which is available at
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package updateorchestrator
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/eclipse-kanto/container-management/containerm/log"
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/events"
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/orchestration"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const pendingSelfUpdateFile = "pending-self-update.json"
+
+// pendingSelfUpdate holds the self update, which is verified after the system reboot
+type pendingSelfUpdate struct {
+	Operation       *orchestration.Operation        `json:"operation"`
+	Manifest        []*unstructured.Unstructured    `json:"manifest"`
+	Resources       []*orchestration.ResourceResult `json:"resources,omitempty"`
+	BundleName      string                          `json:"bundleName"`
+	ExpectedVersion string                          `json:"expectedVersion"`
+	PreviousVersion string                          `json:"previousVersion,omitempty"`
+}
+
+func (upOrch *updateOrchestrator) pendingSelfUpdatePath() string {
+	return filepath.Join(upOrch.cfg.metaPath, pendingSelfUpdateFile)
+}
+
+// selfUpdateVerificationEnabled checks if the outcome of the operations requiring a reboot can be verified after the reboot.
+// Only the self updates of remote operations are verified, as there is no one to report the outcome of the other ones to.
+func (upOrch *updateOrchestrator) selfUpdateVerificationEnabled(ctx context.Context) bool {
+	return upOrch.cfg != nil && upOrch.cfg.metaPath != "" && orchestration.GetUpdateMgrOperationContext(ctx) != nil
+}
+
+// selfUpdateVersion returns the bundle version reported by the self update agent or an empty string if it is not known yet
+func (upOrch *updateOrchestrator) selfUpdateVersion(ctx context.Context) string {
+	for _, u := range upOrch.selfUpdateManager.Get(ctx) {
+		if u != nil && u.GetKind() == "SelfUpdateBundle" {
+			version, _, _ := unstructured.NestedString(u.Object, "spec", "bundleVersion")
+			return version
+		}
+	}
+	return ""
+}
+
+// storePendingSelfUpdate persists the self update before the reboot, replacing the previous one atomically
+func (upOrch *updateOrchestrator) storePendingSelfUpdate(pending *pendingSelfUpdate) error {
+	data, err := json.Marshal(pending)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(upOrch.cfg.metaPath, 0755); err != nil {
+		return err
+	}
+	tmpFile := upOrch.pendingSelfUpdatePath() + ".tmp"
+	if err := ioutil.WriteFile(tmpFile, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile, upOrch.pendingSelfUpdatePath())
+}
+
+// loadPendingSelfUpdate returns the self update persisted before the reboot or nil if there is no such
+func (upOrch *updateOrchestrator) loadPendingSelfUpdate() (*pendingSelfUpdate, error) {
+	data, err := ioutil.ReadFile(upOrch.pendingSelfUpdatePath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	pending := &pendingSelfUpdate{}
+	if err := json.Unmarshal(data, pending); err != nil {
+		return nil, err
+	}
+	return pending, nil
+}
+
+// VerifySelfUpdate compares the bundle version reported by the self update agent after the reboot with the version of the pending self update.
+// The pending self update is removed once verified, so that its outcome is reported only once.
+func (upOrch *updateOrchestrator) VerifySelfUpdate(ctx context.Context, origin string) *orchestration.SelfUpdateVerification {
+	if upOrch.cfg == nil || upOrch.cfg.metaPath == "" {
+		return nil
+	}
+	pending, err := upOrch.loadPendingSelfUpdate()
+	if err != nil {
+		log.ErrorErr(err, "cannot load the pending self update")
+		return nil
+	}
+	if pending == nil || pending.Operation == nil || pending.Operation.Origin != origin {
+		return nil
+	}
+
+	log.Debug("waiting for the current state of the self update agent to verify the self update bundle %s", pending.BundleName)
+	actualVersion, ok := upOrch.waitSelfUpdateVersion(ctx)
+	if !ok {
+		return nil
+	}
+	if err := os.Remove(upOrch.pendingSelfUpdatePath()); err != nil {
+		if !os.IsNotExist(err) {
+			log.ErrorErr(err, "cannot remove the pending self update")
+		}
+		// the pending self update is already verified
+		return nil
+	}
+
+	verification := &orchestration.SelfUpdateVerification{
+		Operation:       pending.Operation,
+		Manifest:        pending.Manifest,
+		Resources:       pending.Resources,
+		ExpectedVersion: pending.ExpectedVersion,
+		ActualVersion:   actualVersion,
+	}
+	switch {
+	case actualVersion == pending.ExpectedVersion:
+		log.Info("the self update bundle %s is verified with version %s after the reboot", pending.BundleName, actualVersion)
+	case actualVersion == pending.PreviousVersion:
+		verification.Err = log.NewErrorf("boot into new slot failed - the self update bundle %s is still at version %s instead of %s",
+			pending.BundleName, actualVersion, pending.ExpectedVersion)
+	default:
+		verification.Err = log.NewErrorf("the self update bundle %s is at version %s instead of %s after the reboot",
+			pending.BundleName, actualVersion, pending.ExpectedVersion)
+	}
+	if verification.Err != nil {
+		log.Error(verification.Err.Error())
+	}
+	return verification
+}
+
+// waitSelfUpdateVersion waits until the self update agent reports its current state and returns its bundle version
+func (upOrch *updateOrchestrator) waitSelfUpdateVersion(ctx context.Context) (string, bool) {
+	subscribeCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	// the subscription precedes the check of the current state, so that no update of the current state is missed
+	eventsChannel, errorChannel := upOrch.eventsManager.Subscribe(subscribeCtx)
+
+	if version := upOrch.selfUpdateVersion(ctx); version != "" {
+		return version, true
+	}
+	for {
+		select {
+		case evt := <-eventsChannel:
+			if evt.Type != events.EventTypeResources {
+				continue
+			}
+			if u, ok := evt.Source.(*unstructured.Unstructured); ok && u.GetKind() == "SelfUpdateBundle" {
+				if version, _, _ := unstructured.NestedString(u.Object, "spec", "bundleVersion"); version != "" {
+					return version, true
+				}
+			}
+		case err := <-errorChannel:
+			log.ErrorErr(err, "received Error from subscription")
+		case <-ctx.Done():
+			log.Debug("the self update verification is cancelled")
+			return "", false
+		}
+	}
+}
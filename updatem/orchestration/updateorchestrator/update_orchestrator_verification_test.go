@@ -0,0 +1,242 @@
+// Copyright (c) 2022 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Apache License 2.0 which is available at
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package updateorchestrator
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/events"
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/orchestration"
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/orchestration/selfupdate"
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/pkg/testutil"
+	mocksevents "github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/pkg/testutil/mocks/events"
+	mocksorchmgr "github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/pkg/testutil/mocks/orchestration"
+	mocksupdorchmgr "github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/pkg/testutil/mocks/updateorchestrator"
+	"github.com/golang/mock/gomock"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func selfUpdateBundleState(version string) []*unstructured.Unstructured {
+	_, mf, _ := parseMultiYAML([]byte(selfUpdateManifest))
+	if err := unstructured.SetNestedField(mf[0].Object, version, "spec", "bundleVersion"); err != nil {
+		panic(err)
+	}
+	return mf
+}
+
+func TestPendingSelfUpdate(t *testing.T) {
+	metaPath, err := ioutil.TempDir("", "updatem-verification")
+	testutil.AssertNil(t, err)
+	defer os.RemoveAll(metaPath)
+
+	upOrch := &updateOrchestrator{cfg: &mgrOpts{metaPath: metaPath}}
+
+	pending, err := upOrch.loadPendingSelfUpdate()
+	testutil.AssertNil(t, err)
+	testutil.AssertNil(t, pending)
+
+	_, mf, _ := parseMultiYAML([]byte(selfUpdateManifest))
+	expected := &pendingSelfUpdate{
+		Operation:       &orchestration.Operation{CorrelationID: "test-correlation-id", Origin: "UpdateOrchestrator"},
+		Manifest:        mf,
+		BundleName:      "self-update-bundle-example-3",
+		ExpectedVersion: "v1beta3",
+		PreviousVersion: "v1beta2",
+	}
+	testutil.AssertNil(t, upOrch.storePendingSelfUpdate(expected))
+
+	pending, err = upOrch.loadPendingSelfUpdate()
+	testutil.AssertNil(t, err)
+	testutil.AssertEqual(t, expected, pending)
+}
+
+func TestApplySelfUpdateVerification(t *testing.T) {
+	operation := &orchestration.Operation{CorrelationID: "test-correlation-id", Origin: "UpdateOrchestrator"}
+
+	tests := map[string]struct {
+		operation       *orchestration.Operation
+		applyResult     *selfupdate.ApplyResult
+		expectedPending bool
+	}{
+		"test_self_update_reboot_pending": {
+			operation:       operation,
+			applyResult:     &selfupdate.ApplyResult{RebootRequired: true},
+			expectedPending: true,
+		},
+		"test_self_update_reboot_no_operation": {
+			applyResult: &selfupdate.ApplyResult{RebootRequired: true},
+		},
+		"test_self_update_no_reboot": {
+			operation:   operation,
+			applyResult: &selfupdate.ApplyResult{},
+		},
+	}
+
+	for testName, testCase := range tests {
+		t.Run(testName, func(t *testing.T) {
+			t.Log(testName)
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			metaPath, err := ioutil.TempDir("", "updatem-verification")
+			testutil.AssertNil(t, err)
+			defer os.RemoveAll(metaPath)
+
+			mockEventsMgr := mocksevents.NewMockUpdateEventsManager(controller)
+			mockRebootMgr := mocksupdorchmgr.NewMockRebootManager(controller)
+			mockSelfUpdateMgr := mocksorchmgr.NewMockUpdateManager(controller)
+			mockK8sOrchestrationMgr := mocksorchmgr.NewMockUpdateManager(controller)
+
+			upOrch := createTestUpdateOrchestrator(mockEventsMgr, mockSelfUpdateMgr, mockK8sOrchestrationMgr, mockRebootMgr).(*updateOrchestrator)
+			upOrch.cfg.metaPath = metaPath
+
+			ctx := context.Background()
+			if testCase.operation != nil {
+				ctx = orchestration.SetUpdateMgrOperationContext(ctx, testCase.operation)
+				mockSelfUpdateMgr.EXPECT().Get(gomock.Any()).Return(selfUpdateBundleState("v1beta2"))
+			}
+			mockSelfUpdateMgr.EXPECT().Apply(gomock.Any(), gomock.Any()).Return(testCase.applyResult)
+			if testCase.applyResult.RebootRequired {
+				mockRebootMgr.EXPECT().Reboot(gomock.Any())
+			}
+
+			published := []*events.Event{}
+			mockEventsMgr.EXPECT().Publish(gomock.Any(), gomock.Any()).Do(func(ctx context.Context, event *events.Event) {
+				published = append(published, event)
+			}).Return(nil).AnyTimes()
+
+			_, mf, _ := parseMultiYAML([]byte(selfUpdateManifest))
+			upOrch.Apply(ctx, mf)
+
+			lastEvent := published[len(published)-1]
+			pending, err := upOrch.loadPendingSelfUpdate()
+			testutil.AssertNil(t, err)
+			if testCase.expectedPending {
+				testutil.AssertEqual(t, orchestration.EventActionOrchestrationRunning, lastEvent.Action)
+				progress := lastEvent.Source.(*orchestration.Progress)
+				testutil.AssertEqual(t, orchestration.ProgressPhaseReboot, progress.Phase)
+				testutil.AssertEqual(t, &pendingSelfUpdate{
+					Operation:       operation,
+					Manifest:        mf,
+					BundleName:      "self-update-bundle-example-3",
+					ExpectedVersion: "v1beta3",
+					PreviousVersion: "v1beta2",
+				}, pending)
+			} else {
+				testutil.AssertEqual(t, orchestration.EventActionOrchestrationFinished, lastEvent.Action)
+				testutil.AssertNil(t, pending)
+			}
+		})
+	}
+}
+
+func TestVerifySelfUpdate(t *testing.T) {
+	tests := map[string]struct {
+		origin              string
+		currentState        []*unstructured.Unstructured
+		stateEvent          []*unstructured.Unstructured
+		expectedNil         bool
+		expectedErrContains string
+	}{
+		"test_verify_self_update_success": {
+			origin:       "UpdateOrchestrator",
+			currentState: selfUpdateBundleState("v1beta3"),
+		},
+		"test_verify_self_update_success_on_state_event": {
+			origin:     "UpdateOrchestrator",
+			stateEvent: selfUpdateBundleState("v1beta3"),
+		},
+		"test_verify_self_update_boot_failed": {
+			origin:              "UpdateOrchestrator",
+			currentState:        selfUpdateBundleState("v1beta2"),
+			expectedErrContains: "boot into new slot failed",
+		},
+		"test_verify_self_update_unexpected_version": {
+			origin:              "UpdateOrchestrator",
+			currentState:        selfUpdateBundleState("v1beta1"),
+			expectedErrContains: "is at version v1beta1 instead of v1beta3",
+		},
+		"test_verify_self_update_other_origin": {
+			origin:      "SoftwareUpdatable:manifest",
+			expectedNil: true,
+		},
+	}
+
+	for testName, testCase := range tests {
+		t.Run(testName, func(t *testing.T) {
+			t.Log(testName)
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			metaPath, err := ioutil.TempDir("", "updatem-verification")
+			testutil.AssertNil(t, err)
+			defer os.RemoveAll(metaPath)
+
+			mockEventsMgr := mocksevents.NewMockUpdateEventsManager(controller)
+			mockSelfUpdateMgr := mocksorchmgr.NewMockUpdateManager(controller)
+
+			upOrch := createTestUpdateOrchestrator(mockEventsMgr, mockSelfUpdateMgr, nil, nil).(*updateOrchestrator)
+			upOrch.cfg.metaPath = metaPath
+
+			_, mf, _ := parseMultiYAML([]byte(selfUpdateManifest))
+			operation := &orchestration.Operation{CorrelationID: "test-correlation-id", Origin: "UpdateOrchestrator"}
+			testutil.AssertNil(t, upOrch.storePendingSelfUpdate(&pendingSelfUpdate{
+				Operation:       operation,
+				Manifest:        mf,
+				BundleName:      "self-update-bundle-example-3",
+				ExpectedVersion: "v1beta3",
+				PreviousVersion: "v1beta2",
+			}))
+
+			if !testCase.expectedNil {
+				eventsChannel := make(chan *events.Event, 1)
+				mockEventsMgr.EXPECT().Subscribe(gomock.Any()).Return(eventsChannel, make(chan error))
+				mockSelfUpdateMgr.EXPECT().Get(gomock.Any()).Return(testCase.currentState)
+				if testCase.stateEvent != nil {
+					eventsChannel <- &events.Event{
+						Type:   events.EventTypeResources,
+						Action: events.EventActionResourcesUpdated,
+						Source: testCase.stateEvent[0],
+					}
+				}
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			verification := upOrch.VerifySelfUpdate(ctx, testCase.origin)
+
+			pending, err := upOrch.loadPendingSelfUpdate()
+			testutil.AssertNil(t, err)
+			if testCase.expectedNil {
+				testutil.AssertNil(t, verification)
+				testutil.AssertNotNil(t, pending)
+				return
+			}
+			testutil.AssertNil(t, pending)
+			testutil.AssertEqual(t, operation, verification.Operation)
+			testutil.AssertEqual(t, mf, verification.Manifest)
+			testutil.AssertEqual(t, "v1beta3", verification.ExpectedVersion)
+			if testCase.expectedErrContains == "" {
+				testutil.AssertNil(t, verification.Err)
+			} else {
+				testutil.AssertContainsString(t, verification.Err.Error(), testCase.expectedErrContains)
+			}
+
+			// the outcome is reported only once
+			testutil.AssertNil(t, upOrch.VerifySelfUpdate(ctx, testCase.origin))
+		})
+	}
+}
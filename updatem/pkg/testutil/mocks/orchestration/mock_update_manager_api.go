@@ -11,7 +11,7 @@
 //
 
 // Code generated by MockGen. DO NOT EDIT.
-// Source: github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/orchestration (interfaces: UpdateManager)
+// Source: github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/orchestration (interfaces: UpdateManager,ImagePuller,ManifestValidator,DriftDetector,DiagnosticsCollector,Canceler,SelfUpdateVerifier,ResourceRemover)
 
 // Package mocks is a generated GoMock package.
 package mocks
@@ -20,6 +20,7 @@ import (
 	context "context"
 	reflect "reflect"
 
+	orchestration "github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/orchestration"
 	gomock "github.com/golang/mock/gomock"
 	unstructured "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
@@ -86,3 +87,260 @@ func (mr *MockUpdateManagerMockRecorder) Get(ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockUpdateManager)(nil).Get), ctx)
 }
+
+// MockImagePuller is a mock of ImagePuller interface.
+type MockImagePuller struct {
+	ctrl     *gomock.Controller
+	recorder *MockImagePullerMockRecorder
+}
+
+// MockImagePullerMockRecorder is the mock recorder for MockImagePuller.
+type MockImagePullerMockRecorder struct {
+	mock *MockImagePuller
+}
+
+// NewMockImagePuller creates a new mock instance.
+func NewMockImagePuller(ctrl *gomock.Controller) *MockImagePuller {
+	mock := &MockImagePuller{ctrl: ctrl}
+	mock.recorder = &MockImagePullerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockImagePuller) EXPECT() *MockImagePullerMockRecorder {
+	return m.recorder
+}
+
+// PullImages mocks base method.
+func (m *MockImagePuller) PullImages(ctx context.Context, mf []*unstructured.Unstructured) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PullImages", ctx, mf)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PullImages indicates an expected call of PullImages.
+func (mr *MockImagePullerMockRecorder) PullImages(ctx, mf interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PullImages", reflect.TypeOf((*MockImagePuller)(nil).PullImages), ctx, mf)
+}
+
+// MockManifestValidator is a mock of ManifestValidator interface.
+type MockManifestValidator struct {
+	ctrl     *gomock.Controller
+	recorder *MockManifestValidatorMockRecorder
+}
+
+// MockManifestValidatorMockRecorder is the mock recorder for MockManifestValidator.
+type MockManifestValidatorMockRecorder struct {
+	mock *MockManifestValidator
+}
+
+// NewMockManifestValidator creates a new mock instance.
+func NewMockManifestValidator(ctrl *gomock.Controller) *MockManifestValidator {
+	mock := &MockManifestValidator{ctrl: ctrl}
+	mock.recorder = &MockManifestValidatorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockManifestValidator) EXPECT() *MockManifestValidatorMockRecorder {
+	return m.recorder
+}
+
+// Validate mocks base method.
+func (m *MockManifestValidator) Validate(ctx context.Context, mf []*unstructured.Unstructured) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Validate", ctx, mf)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Validate indicates an expected call of Validate.
+func (mr *MockManifestValidatorMockRecorder) Validate(ctx, mf interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Validate", reflect.TypeOf((*MockManifestValidator)(nil).Validate), ctx, mf)
+}
+
+// MockDriftDetector is a mock of DriftDetector interface.
+type MockDriftDetector struct {
+	ctrl     *gomock.Controller
+	recorder *MockDriftDetectorMockRecorder
+}
+
+// MockDriftDetectorMockRecorder is the mock recorder for MockDriftDetector.
+type MockDriftDetectorMockRecorder struct {
+	mock *MockDriftDetector
+}
+
+// NewMockDriftDetector creates a new mock instance.
+func NewMockDriftDetector(ctrl *gomock.Controller) *MockDriftDetector {
+	mock := &MockDriftDetector{ctrl: ctrl}
+	mock.recorder = &MockDriftDetectorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDriftDetector) EXPECT() *MockDriftDetectorMockRecorder {
+	return m.recorder
+}
+
+// Drift mocks base method.
+func (m *MockDriftDetector) Drift(ctx context.Context, mf []*unstructured.Unstructured) []*orchestration.ResourceDrift {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Drift", ctx, mf)
+	ret0, _ := ret[0].([]*orchestration.ResourceDrift)
+	return ret0
+}
+
+// Drift indicates an expected call of Drift.
+func (mr *MockDriftDetectorMockRecorder) Drift(ctx, mf interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Drift", reflect.TypeOf((*MockDriftDetector)(nil).Drift), ctx, mf)
+}
+
+// MockDiagnosticsCollector is a mock of DiagnosticsCollector interface.
+type MockDiagnosticsCollector struct {
+	ctrl     *gomock.Controller
+	recorder *MockDiagnosticsCollectorMockRecorder
+}
+
+// MockDiagnosticsCollectorMockRecorder is the mock recorder for MockDiagnosticsCollector.
+type MockDiagnosticsCollectorMockRecorder struct {
+	mock *MockDiagnosticsCollector
+}
+
+// NewMockDiagnosticsCollector creates a new mock instance.
+func NewMockDiagnosticsCollector(ctrl *gomock.Controller) *MockDiagnosticsCollector {
+	mock := &MockDiagnosticsCollector{ctrl: ctrl}
+	mock.recorder = &MockDiagnosticsCollectorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDiagnosticsCollector) EXPECT() *MockDiagnosticsCollectorMockRecorder {
+	return m.recorder
+}
+
+// Diagnose mocks base method.
+func (m *MockDiagnosticsCollector) Diagnose(ctx context.Context, mf []*unstructured.Unstructured) []*orchestration.ResourceDiagnostics {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Diagnose", ctx, mf)
+	ret0, _ := ret[0].([]*orchestration.ResourceDiagnostics)
+	return ret0
+}
+
+// Diagnose indicates an expected call of Diagnose.
+func (mr *MockDiagnosticsCollectorMockRecorder) Diagnose(ctx, mf interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Diagnose", reflect.TypeOf((*MockDiagnosticsCollector)(nil).Diagnose), ctx, mf)
+}
+
+// MockCanceler is a mock of Canceler interface.
+type MockCanceler struct {
+	ctrl     *gomock.Controller
+	recorder *MockCancelerMockRecorder
+}
+
+// MockCancelerMockRecorder is the mock recorder for MockCanceler.
+type MockCancelerMockRecorder struct {
+	mock *MockCanceler
+}
+
+// NewMockCanceler creates a new mock instance.
+func NewMockCanceler(ctrl *gomock.Controller) *MockCanceler {
+	mock := &MockCanceler{ctrl: ctrl}
+	mock.recorder = &MockCancelerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCanceler) EXPECT() *MockCancelerMockRecorder {
+	return m.recorder
+}
+
+// Cancel mocks base method.
+func (m *MockCanceler) Cancel(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Cancel", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Cancel indicates an expected call of Cancel.
+func (mr *MockCancelerMockRecorder) Cancel(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Cancel", reflect.TypeOf((*MockCanceler)(nil).Cancel), ctx)
+}
+
+// MockSelfUpdateVerifier is a mock of SelfUpdateVerifier interface.
+type MockSelfUpdateVerifier struct {
+	ctrl     *gomock.Controller
+	recorder *MockSelfUpdateVerifierMockRecorder
+}
+
+// MockSelfUpdateVerifierMockRecorder is the mock recorder for MockSelfUpdateVerifier.
+type MockSelfUpdateVerifierMockRecorder struct {
+	mock *MockSelfUpdateVerifier
+}
+
+// NewMockSelfUpdateVerifier creates a new mock instance.
+func NewMockSelfUpdateVerifier(ctrl *gomock.Controller) *MockSelfUpdateVerifier {
+	mock := &MockSelfUpdateVerifier{ctrl: ctrl}
+	mock.recorder = &MockSelfUpdateVerifierMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSelfUpdateVerifier) EXPECT() *MockSelfUpdateVerifierMockRecorder {
+	return m.recorder
+}
+
+// VerifySelfUpdate mocks base method.
+func (m *MockSelfUpdateVerifier) VerifySelfUpdate(ctx context.Context, origin string) *orchestration.SelfUpdateVerification {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifySelfUpdate", ctx, origin)
+	ret0, _ := ret[0].(*orchestration.SelfUpdateVerification)
+	return ret0
+}
+
+// VerifySelfUpdate indicates an expected call of VerifySelfUpdate.
+func (mr *MockSelfUpdateVerifierMockRecorder) VerifySelfUpdate(ctx, origin interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifySelfUpdate", reflect.TypeOf((*MockSelfUpdateVerifier)(nil).VerifySelfUpdate), ctx, origin)
+}
+
+// MockResourceRemover is a mock of ResourceRemover interface.
+type MockResourceRemover struct {
+	ctrl     *gomock.Controller
+	recorder *MockResourceRemoverMockRecorder
+}
+
+// MockResourceRemoverMockRecorder is the mock recorder for MockResourceRemover.
+type MockResourceRemoverMockRecorder struct {
+	mock *MockResourceRemover
+}
+
+// NewMockResourceRemover creates a new mock instance.
+func NewMockResourceRemover(ctrl *gomock.Controller) *MockResourceRemover {
+	mock := &MockResourceRemover{ctrl: ctrl}
+	mock.recorder = &MockResourceRemoverMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockResourceRemover) EXPECT() *MockResourceRemoverMockRecorder {
+	return m.recorder
+}
+
+// Remove mocks base method.
+func (m *MockResourceRemover) Remove(ctx context.Context, mf []*unstructured.Unstructured) interface{} {
+	m.ctrl.T.Helper()
+	return m.ctrl.Call(m, "Remove", ctx, mf)[0]
+}
+
+// Remove indicates an expected call of Remove.
+func (mr *MockResourceRemoverMockRecorder) Remove(ctx, mf interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Remove", reflect.TypeOf((*MockResourceRemover)(nil).Remove), ctx, mf)
+}
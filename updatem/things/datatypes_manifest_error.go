@@ -11,7 +11,10 @@
 
 package things
 
+import "github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/orchestration"
+
 type manifestError struct {
-	Code    int    `json:"code,omitempty"`
-	Message string `json:"message,omitempty"`
+	Code        int                                  `json:"code,omitempty"`
+	Message     string                               `json:"message,omitempty"`
+	Diagnostics []*orchestration.ResourceDiagnostics `json:"diagnostics,omitempty"`
 }
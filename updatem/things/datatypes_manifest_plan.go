@@ -0,0 +1,25 @@
+// Copyright (c) 2022 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Apache License 2.0 which is available at
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package things
+
+import (
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/orchestration"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+type manifestPlan struct {
+	Manifest      []*unstructured.Unstructured    `json:"manifest"`
+	Status        manifestStatus                  `json:"status"`
+	Resources     []*orchestration.ResourceResult `json:"resources,omitempty"`
+	Error         *manifestError                  `json:"error,omitempty"`
+	CorrelationID string                          `json:"correlationId"`
+}
@@ -11,11 +11,31 @@
 
 package things
 
-import "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+import (
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/orchestration"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
 
 type manifestState struct {
-	Manifest      []*unstructured.Unstructured `json:"manifest"`
-	Status        manifestStatus               `json:"status"`
-	Error         *manifestError               `json:"error,omitempty"`
-	CorrelationID string                       `json:"correlationId"`
+	Manifest      []*unstructured.Unstructured    `json:"manifest"`
+	Status        manifestStatus                  `json:"status"`
+	Progress      *orchestration.Progress         `json:"progress,omitempty"`
+	Error         *manifestError                  `json:"error,omitempty"`
+	Resources     []*orchestration.ResourceResult `json:"resources,omitempty"`
+	Rollback      *manifestRollback               `json:"rollback,omitempty"`
+	Drift         []*orchestration.ResourceDrift  `json:"drift,omitempty"`
+	Reconcile     *manifestReconcile              `json:"reconcile,omitempty"`
+	CorrelationID string                          `json:"correlationId"`
+}
+
+type manifestRollback struct {
+	Status    manifestStatus                  `json:"status"`
+	Error     *manifestError                  `json:"error,omitempty"`
+	Resources []*orchestration.ResourceResult `json:"resources,omitempty"`
+}
+
+type manifestReconcile struct {
+	Status    manifestStatus                  `json:"status"`
+	Error     *manifestError                  `json:"error,omitempty"`
+	Resources []*orchestration.ResourceResult `json:"resources,omitempty"`
 }
@@ -0,0 +1,347 @@
+// Copyright (c) 2022 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Apache License 2.0 which is available at
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package things
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/eclipse-kanto/container-management/containerm/log"
+	"github.com/eclipse-kanto/container-management/rollouts/api/datatypes"
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/orchestration"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/engine"
+	"helm.sh/helm/v3/pkg/releaseutil"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Constants that define how the Helm releases are rendered and tracked
+const (
+	helmReleasesFile = "helm-releases.json"
+
+	// helmReleaseNamespaceMetadataKey is the SoftwareModule metadata key selecting the namespace of the Helm release
+	helmReleaseNamespaceMetadataKey = "namespace"
+	helmReleaseNamespaceDefault     = "default"
+	helmDependencyType              = "helm"
+
+	helmReleaseNameAnnotation      = "meta.helm.sh/release-name"
+	helmReleaseNamespaceAnnotation = "meta.helm.sh/release-namespace"
+
+	helmNotesFileSuffix = "NOTES.txt"
+)
+
+// helmChartArchiveSuffixes identify the SoftwareArtifacts that are Helm chart archives
+var helmChartArchiveSuffixes = []string{".tgz", ".tar.gz"}
+
+// helmRelease is an installed revision of a Helm chart - its rendered manifest is part of the desired state until the release is uninstalled
+type helmRelease struct {
+	Name      string                       `json:"name"`
+	Namespace string                       `json:"namespace"`
+	Chart     string                       `json:"chart"`
+	Version   string                       `json:"version"`
+	Revision  int                          `json:"revision"`
+	Manifest  []*unstructured.Unstructured `json:"manifest"`
+}
+
+// helmReleases tracks the installed Helm releases, so that each chart can be upgraded or uninstalled without affecting the others.
+// A change is staged while it is applied and committed only if the apply succeeds.
+type helmReleases struct {
+	storagePath string
+	releases    map[string]*helmRelease
+	staged      map[string]*helmRelease
+	lock        sync.Mutex
+}
+
+func newHelmReleases(storagePath string) *helmReleases {
+	hr := &helmReleases{
+		storagePath: storagePath,
+		releases:    map[string]*helmRelease{},
+	}
+	if err := hr.load(); err != nil {
+		log.ErrorErr(err, "cannot load the installed Helm releases")
+	}
+	return hr
+}
+
+func (hr *helmReleases) path() string {
+	return filepath.Join(hr.storagePath, helmReleasesFile)
+}
+
+func (hr *helmReleases) load() error {
+	if hr.storagePath == "" {
+		return nil
+	}
+	data, err := ioutil.ReadFile(hr.path())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	releases := map[string]*helmRelease{}
+	if err := json.Unmarshal(data, &releases); err != nil {
+		return err
+	}
+	hr.releases = releases
+	return nil
+}
+
+// store persists the installed releases, replacing the previous ones atomically
+func (hr *helmReleases) store() error {
+	if hr.storagePath == "" {
+		return nil
+	}
+	data, err := json.Marshal(hr.releases)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(hr.storagePath, 0755); err != nil {
+		return err
+	}
+	tmpFile := hr.path() + ".tmp"
+	if err := ioutil.WriteFile(tmpFile, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile, hr.path())
+}
+
+// nextRevision returns the revision of the next install or upgrade of the release
+func (hr *helmReleases) nextRevision(name string) int {
+	hr.lock.Lock()
+	defer hr.lock.Unlock()
+	if release, ok := hr.releases[name]; ok {
+		return release.Revision + 1
+	}
+	return 1
+}
+
+// installed returns a copy of the installed releases
+func (hr *helmReleases) installed() map[string]*helmRelease {
+	hr.lock.Lock()
+	defer hr.lock.Unlock()
+	releases := map[string]*helmRelease{}
+	for name, r := range hr.releases {
+		releases[name] = r
+	}
+	return releases
+}
+
+// with returns the installed releases with the given release installed or upgraded
+func (hr *helmReleases) with(release *helmRelease) map[string]*helmRelease {
+	releases := hr.installed()
+	releases[release.Name] = release
+	return releases
+}
+
+// without returns the installed releases with the given releases uninstalled or an error if any of them is not installed
+func (hr *helmReleases) without(names []string) (map[string]*helmRelease, error) {
+	releases := hr.installed()
+	for _, name := range names {
+		if _, ok := releases[name]; !ok {
+			return nil, log.NewErrorf("the Helm release %s is not installed", name)
+		}
+		delete(releases, name)
+	}
+	return releases, nil
+}
+
+// manifest returns the rendered manifests of the given installed releases
+func (hr *helmReleases) manifest(names []string) []*unstructured.Unstructured {
+	hr.lock.Lock()
+	defer hr.lock.Unlock()
+	mf := []*unstructured.Unstructured{}
+	for _, name := range names {
+		if release, ok := hr.releases[name]; ok {
+			mf = append(mf, release.Manifest...)
+		}
+	}
+	return mf
+}
+
+// stage sets the releases to be committed once the desired state is applied successfully
+func (hr *helmReleases) stage(releases map[string]*helmRelease) {
+	hr.lock.Lock()
+	defer hr.lock.Unlock()
+	hr.staged = releases
+}
+
+// commit replaces the installed releases with the staged ones and returns true if they are changed
+func (hr *helmReleases) commit() bool {
+	hr.lock.Lock()
+	defer hr.lock.Unlock()
+	if hr.staged == nil {
+		return false
+	}
+	changed := len(hr.staged) != len(hr.releases)
+	for name, release := range hr.staged {
+		if hr.releases[name] != release {
+			changed = true
+		}
+	}
+	hr.releases = hr.staged
+	hr.staged = nil
+	if changed {
+		if err := hr.store(); err != nil {
+			log.ErrorErr(err, "cannot store the installed Helm releases")
+		}
+	}
+	return changed
+}
+
+func (hr *helmReleases) discard() {
+	hr.lock.Lock()
+	defer hr.lock.Unlock()
+	hr.staged = nil
+}
+
+// dependencies reports the installed releases as the installed dependencies of the SoftwareUpdatable feature
+func (hr *helmReleases) dependencies() map[string]*datatypes.DependencyDescription {
+	hr.lock.Lock()
+	defer hr.lock.Unlock()
+	dependencies := map[string]*datatypes.DependencyDescription{}
+	for name, release := range hr.releases {
+		dependencies[name] = &datatypes.DependencyDescription{
+			Group:   release.Namespace,
+			Name:    release.Name,
+			Version: release.Version,
+			Type:    helmDependencyType,
+		}
+	}
+	return dependencies
+}
+
+// helmDesiredState returns the manifests of all releases ordered by the release name
+func helmDesiredState(releases map[string]*helmRelease) []*unstructured.Unstructured {
+	names := []string{}
+	for name := range releases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	mf := []*unstructured.Unstructured{}
+	for _, name := range names {
+		mf = append(mf, releases[name].Manifest...)
+	}
+	return mf
+}
+
+// getHelmChartArtifact returns the Helm chart archive of the SoftwareModule or nil if its artifact is a plain manifest
+func getHelmChartArtifact(softMod *datatypes.SoftwareModuleAction) *datatypes.SoftwareArtifactAction {
+	for _, saa := range softMod.Artifacts {
+		if isHelmChartArchive(saa) {
+			return saa
+		}
+	}
+	return nil
+}
+
+// getHelmValuesArtifact returns the values file accompanying the Helm chart archive or nil if the chart defaults are used
+func getHelmValuesArtifact(softMod *datatypes.SoftwareModuleAction) *datatypes.SoftwareArtifactAction {
+	for _, saa := range softMod.Artifacts {
+		if saa != nil && !isHelmChartArchive(saa) {
+			return saa
+		}
+	}
+	return nil
+}
+
+func isHelmChartArchive(saa *datatypes.SoftwareArtifactAction) bool {
+	if saa == nil {
+		return false
+	}
+	for _, suffix := range helmChartArchiveSuffixes {
+		if strings.HasSuffix(strings.ToLower(saa.FileName), suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// newHelmRelease renders the chart archive with the values as the given release revision.
+// The chart hooks are not supported and are skipped.
+func newHelmRelease(name, namespace string, revision int, chartArchive, valuesData []byte) (*helmRelease, error) {
+	chrt, err := loader.LoadArchive(bytes.NewReader(chartArchive))
+	if err != nil {
+		return nil, log.NewErrorf("invalid Helm chart archive: %v", err)
+	}
+	values, err := chartutil.ReadValues(valuesData)
+	if err != nil {
+		return nil, log.NewErrorf("invalid Helm chart values: %v", err)
+	}
+	if err := chartutil.ProcessDependencies(chrt, values); err != nil {
+		return nil, log.NewErrorf("cannot process the dependencies of the Helm chart %s: %v", chrt.Name(), err)
+	}
+	caps := chartutil.DefaultCapabilities
+	renderValues, err := chartutil.ToRenderValues(chrt, values, chartutil.ReleaseOptions{
+		Name:      name,
+		Namespace: namespace,
+		Revision:  revision,
+		IsInstall: revision == 1,
+		IsUpgrade: revision > 1,
+	}, caps)
+	if err != nil {
+		return nil, log.NewErrorf("cannot prepare the values of the Helm chart %s: %v", chrt.Name(), err)
+	}
+	files, err := engine.Render(chrt, renderValues)
+	if err != nil {
+		return nil, log.NewErrorf("cannot render the Helm chart %s: %v", chrt.Name(), err)
+	}
+	for file := range files {
+		if strings.HasSuffix(file, helmNotesFileSuffix) {
+			delete(files, file)
+		}
+	}
+	hooks, manifests, err := releaseutil.SortManifests(files, caps.APIVersions, releaseutil.InstallOrder)
+	if err != nil {
+		return nil, log.NewErrorf("cannot parse the rendered Helm chart %s: %v", chrt.Name(), err)
+	}
+	if len(hooks) > 0 {
+		log.Warn("the %d hook(s) of the Helm chart %s are not supported and will not be applied", len(hooks), chrt.Name())
+	}
+	mf := []*unstructured.Unstructured{}
+	for _, manifest := range manifests {
+		_, resources, err := parseMultiYAML([]byte(manifest.Content))
+		if err != nil {
+			return nil, log.NewErrorf("invalid resource %s rendered from the Helm chart %s: %v", manifest.Name, chrt.Name(), err)
+		}
+		for _, u := range resources {
+			if u.GetNamespace() == "" && !orchestration.IsClusterScoped(u) {
+				u.SetNamespace(namespace)
+			}
+			annotations := u.GetAnnotations()
+			if annotations == nil {
+				annotations = map[string]string{}
+			}
+			annotations[helmReleaseNameAnnotation] = name
+			annotations[helmReleaseNamespaceAnnotation] = namespace
+			u.SetAnnotations(annotations)
+			mf = append(mf, u)
+		}
+	}
+	if len(mf) == 0 {
+		return nil, log.NewErrorf("the Helm chart %s renders no k8s resources", chrt.Name())
+	}
+	return &helmRelease{
+		Name:      name,
+		Namespace: namespace,
+		Chart:     chrt.Name(),
+		Version:   chrt.Metadata.Version,
+		Revision:  revision,
+		Manifest:  mf,
+	}, nil
+}
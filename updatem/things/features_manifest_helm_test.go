@@ -0,0 +1,331 @@
+// Copyright (c) 2022 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Apache License 2.0 which is available at
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package things
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/eclipse-kanto/container-management/containerm/log"
+	"github.com/eclipse-kanto/container-management/rollouts/api/datatypes"
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/events"
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/orchestration"
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/pkg/testutil"
+	mocksorchmgr "github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/pkg/testutil/mocks/orchestration"
+	"github.com/golang/mock/gomock"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	testHelmChartYAML = `apiVersion: v2
+name: test-chart
+version: 1.2.3
+`
+	testHelmChartValues = `image: ghcr.io/eclipse-leda/test-app:1.0.0
+`
+	testHelmChartDeployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ .Release.Name }}-app
+  labels:
+    revision: "{{ .Release.Revision }}"
+spec:
+  selector:
+    matchLabels:
+      app: {{ .Release.Name }}
+  template:
+    metadata:
+      labels:
+        app: {{ .Release.Name }}
+    spec:
+      containers:
+      - name: app
+        image: {{ .Values.image }}
+`
+	testHelmChartNamespace = `apiVersion: v1
+kind: Namespace
+metadata:
+  name: {{ .Release.Name }}-data
+`
+	testHelmChartHook = `apiVersion: batch/v1
+kind: Job
+metadata:
+  name: {{ .Release.Name }}-migrate
+  annotations:
+    helm.sh/hook: pre-upgrade
+spec:
+  template:
+    spec:
+      containers:
+      - name: migrate
+        image: {{ .Values.image }}
+`
+	testHelmChartNotes = `{{ .Release.Name }} is installed`
+
+	testHTTPServerChartURLPath  = "/chart/test-chart-1.2.3.tgz"
+	testHTTPServerValuesURLPath = "/chart/values.yaml"
+
+	testHTTPServerManifestURLPath = "/chart/manifest.yaml"
+	testPlainManifest             = `apiVersion: v1
+kind: Pod
+metadata:
+  name: test-plain-app
+spec:
+  containers:
+  - name: test-plain-app
+    image: ghcr.io/eclipse-leda/test-plain-app:1.0.0
+`
+)
+
+func newTestHelmChartArchive(t *testing.T) []byte {
+	buf := &bytes.Buffer{}
+	gzipWriter := gzip.NewWriter(buf)
+	tarWriter := tar.NewWriter(gzipWriter)
+	for name, content := range map[string]string{
+		"test-chart/Chart.yaml":                testHelmChartYAML,
+		"test-chart/values.yaml":               testHelmChartValues,
+		"test-chart/templates/deployment.yaml": testHelmChartDeployment,
+		"test-chart/templates/namespace.yaml":  testHelmChartNamespace,
+		"test-chart/templates/hook.yaml":       testHelmChartHook,
+		"test-chart/templates/NOTES.txt":       testHelmChartNotes,
+	} {
+		testutil.AssertNil(t, tarWriter.WriteHeader(&tar.Header{Name: name, Mode: 0600, Size: int64(len(content))}))
+		_, err := tarWriter.Write([]byte(content))
+		testutil.AssertNil(t, err)
+	}
+	testutil.AssertNil(t, tarWriter.Close())
+	testutil.AssertNil(t, gzipWriter.Close())
+	return buf.Bytes()
+}
+
+func TestNewHelmRelease(t *testing.T) {
+	chartArchive := newTestHelmChartArchive(t)
+
+	tests := map[string]struct {
+		chartArchive  []byte
+		values        string
+		revision      int
+		expectedImage string
+		expectedErr   bool
+	}{
+		"test_chart_values": {
+			chartArchive:  chartArchive,
+			revision:      1,
+			expectedImage: "ghcr.io/eclipse-leda/test-app:1.0.0",
+		},
+		"test_provided_values": {
+			chartArchive:  chartArchive,
+			values:        "image: ghcr.io/eclipse-leda/test-app:2.0.0\n",
+			revision:      2,
+			expectedImage: "ghcr.io/eclipse-leda/test-app:2.0.0",
+		},
+		"test_invalid_values": {
+			chartArchive: chartArchive,
+			values:       "image: [",
+			revision:     1,
+			expectedErr:  true,
+		},
+		"test_invalid_archive": {
+			chartArchive: []byte("test-invalid-archive"),
+			revision:     1,
+			expectedErr:  true,
+		},
+	}
+	for testName, testCase := range tests {
+		t.Run(testName, func(t *testing.T) {
+			t.Log(testName)
+			release, err := newHelmRelease("test-release", "vehicle", testCase.revision, testCase.chartArchive, []byte(testCase.values))
+			if testCase.expectedErr {
+				testutil.AssertNotNil(t, err)
+				return
+			}
+			testutil.AssertNil(t, err)
+			testutil.AssertEqual(t, "test-chart", release.Chart)
+			testutil.AssertEqual(t, "1.2.3", release.Version)
+			testutil.AssertEqual(t, testCase.revision, release.Revision)
+			// the hook and the notes are not applied
+			testutil.AssertEqual(t, 2, len(release.Manifest))
+
+			namespace := release.Manifest[0]
+			testutil.AssertEqual(t, "Namespace", namespace.GetKind())
+			testutil.AssertEqual(t, "", namespace.GetNamespace())
+
+			deployment := release.Manifest[1]
+			testutil.AssertEqual(t, "test-release-app", deployment.GetName())
+			testutil.AssertEqual(t, "vehicle", deployment.GetNamespace())
+			testutil.AssertEqual(t, "test-release", deployment.GetAnnotations()[helmReleaseNameAnnotation])
+			testutil.AssertEqual(t, "vehicle", deployment.GetAnnotations()[helmReleaseNamespaceAnnotation])
+			containers, _, _ := unstructured.NestedSlice(deployment.Object, "spec", "template", "spec", "containers")
+			testutil.AssertEqual(t, testCase.expectedImage, containers[0].(map[string]interface{})["image"])
+		})
+	}
+}
+
+func TestHelmReleases(t *testing.T) {
+	storageDir, err := ioutil.TempDir("", "updatem-helm")
+	testutil.AssertNil(t, err)
+	defer os.RemoveAll(storageDir)
+
+	newTestRelease := func(name string, revision int) *helmRelease {
+		return &helmRelease{Name: name, Namespace: "default", Chart: "test-chart", Version: "1.2.3", Revision: revision,
+			Manifest: []*unstructured.Unstructured{newTestPolicyResource("v1", "ConfigMap", "default", name)}}
+	}
+
+	releases := newHelmReleases(storageDir)
+	testutil.AssertEqual(t, 1, releases.nextRevision("app-b"))
+
+	// a discarded change is not committed
+	releases.stage(releases.with(newTestRelease("app-b", 1)))
+	releases.discard()
+	testutil.AssertFalse(t, releases.commit())
+	testutil.AssertEqual(t, 0, len(releases.dependencies()))
+
+	releases.stage(releases.with(newTestRelease("app-b", 1)))
+	testutil.AssertTrue(t, releases.commit())
+	releases.stage(releases.with(newTestRelease("app-a", 1)))
+	testutil.AssertTrue(t, releases.commit())
+	testutil.AssertEqual(t, 2, releases.nextRevision("app-b"))
+
+	// the desired state is ordered by the release name
+	desiredState := helmDesiredState(releases.with(newTestRelease("app-b", 2)))
+	testutil.AssertEqual(t, 2, len(desiredState))
+	testutil.AssertEqual(t, "app-a", desiredState[0].GetName())
+	testutil.AssertEqual(t, "app-b", desiredState[1].GetName())
+
+	_, err = releases.without([]string{"app-c"})
+	testutil.AssertNotNil(t, err)
+	remaining, err := releases.without([]string{"app-a"})
+	testutil.AssertNil(t, err)
+	testutil.AssertEqual(t, 1, len(remaining))
+
+	// the installed releases are restored from the storage
+	restored := newHelmReleases(storageDir)
+	testutil.AssertEqual(t, &datatypes.DependencyDescription{Group: "default", Name: "app-a", Version: "1.2.3", Type: helmDependencyType}, restored.dependencies()["app-a"])
+	testutil.AssertEqual(t, 2, len(restored.dependencies()))
+	testutil.AssertEqual(t, 2, restored.nextRevision("app-a"))
+}
+
+type testResourceRemoverUpdateManager struct {
+	*mocksorchmgr.MockUpdateManager
+	*mocksorchmgr.MockResourceRemover
+}
+
+func TestSUMfInstallAndRemoveHelmChart(t *testing.T) {
+	chartArchive := newTestHelmChartArchive(t)
+	setupDummyHTTPServerForTests(true, map[string]func(http.ResponseWriter, *http.Request){
+		testHTTPServerChartURLPath: func(writer http.ResponseWriter, request *http.Request) {
+			_, _ = writer.Write(chartArchive)
+		},
+		testHTTPServerValuesURLPath: func(writer http.ResponseWriter, request *http.Request) {
+			_, _ = writer.Write([]byte("image: ghcr.io/eclipse-leda/test-app:2.0.0\n"))
+		},
+		testHTTPServerManifestURLPath: func(writer http.ResponseWriter, request *http.Request) {
+			_, _ = writer.Write([]byte(testPlainManifest))
+		},
+	})
+	defer mockHTTPServer.Close()
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	setupThingMock(controller)
+	setupUpdateManagerMock(controller)
+	setupEventsManagerMock(controller)
+
+	mockRemover := mocksorchmgr.NewMockResourceRemover(controller)
+	orchMgr := &testResourceRemoverUpdateManager{MockUpdateManager: mockUpdateManager, MockResourceRemover: mockRemover}
+	testSuMf := newSoftwareUpdatableManifests(mockThing, mockEventsManager, orchMgr, newManifestTemplate(nil, ""), newManifestPolicy(""), newHelmReleases(""), "").(*softwareUpdatableManifests)
+	newTestChartModule := func(name string, artifacts ...string) *datatypes.SoftwareModuleAction {
+		softMod := &datatypes.SoftwareModuleAction{SoftwareModule: &datatypes.SoftwareModuleID{Name: name, Version: "1.2.3"}}
+		for _, artifact := range artifacts {
+			softMod.Artifacts = append(softMod.Artifacts, &datatypes.SoftwareArtifactAction{
+				FileName: artifact[len("/chart/"):],
+				Download: map[datatypes.Protocol]*datatypes.Links{datatypes.HTTP: {URL: mockHTTPServer.URL + artifact}},
+			})
+		}
+		return softMod
+	}
+
+	var (
+		applyCtx context.Context
+		applied  []*unstructured.Unstructured
+		removed  []*unstructured.Unstructured
+	)
+	mockThing.EXPECT().SetFeatureProperty(SoftwareUpdatableManifestsFeatureID, gomock.Any(), gomock.Any()).AnyTimes()
+	mockUpdateManager.EXPECT().Apply(gomock.Any(), gomock.Any()).Do(func(ctx context.Context, mf []*unstructured.Unstructured) {
+		applyCtx = ctx
+		applied = mf
+	}).AnyTimes()
+	mockRemover.EXPECT().Remove(gomock.Any(), gomock.Any()).Do(func(ctx context.Context, mf []*unstructured.Unstructured) {
+		applyCtx = ctx
+		removed = mf
+	}).AnyTimes()
+	finishApply := func(err error) {
+		testSuMf.handleEventFinished(&events.Event{Type: orchestration.EventTypeOrchestration, Action: orchestration.EventActionOrchestrationFinished, Context: applyCtx, Error: err})
+	}
+
+	// install two releases
+	testSuMf.installModule(newTestChartModule("app-a", testHTTPServerChartURLPath), "test-install-a")
+	testutil.AssertEqual(t, 2, len(applied))
+	finishApply(nil)
+	testSuMf.installModule(newTestChartModule("app-b", testHTTPServerChartURLPath), "test-install-b")
+	testutil.AssertEqual(t, 4, len(applied))
+	finishApply(nil)
+	testutil.AssertEqual(t, 2, len(testSuMf.status.InstalledDependencies))
+
+	// a failed upgrade is not tracked
+	testSuMf.installModule(newTestChartModule("app-a", testHTTPServerChartURLPath, testHTTPServerValuesURLPath), "test-upgrade-a")
+	finishApply(log.NewError("test error"))
+	testutil.AssertEqual(t, 2, testSuMf.helmReleases.nextRevision("app-a"))
+
+	// upgrade the release with the provided values, keeping the other release unchanged
+	testSuMf.installModule(newTestChartModule("app-a", testHTTPServerChartURLPath, testHTTPServerValuesURLPath), "test-upgrade-a")
+	testutil.AssertEqual(t, 4, len(applied))
+	testutil.AssertEqual(t, "app-a-app", applied[1].GetName())
+	testutil.AssertEqual(t, "2", applied[1].GetLabels()["revision"])
+	testutil.AssertEqual(t, "1", applied[3].GetLabels()["revision"])
+	finishApply(nil)
+	testutil.AssertEqual(t, datatypes.FinishedSuccess, testSuMf.getLastOperation().Status)
+
+	// uninstall the release, removing only its own resources
+	testSuMf.processRemoveAction(datatypes.RemoveAction{CorrelationID: "test-remove-a", Software: []*datatypes.DependencyDescription{{Name: "app-a"}}})
+	testutil.AssertEqual(t, 2, len(removed))
+	testutil.AssertEqual(t, "app-a-app", removed[1].GetName())
+	testutil.AssertEqual(t, datatypes.Removing, testSuMf.getLastOperation().Status)
+	finishApply(nil)
+	testutil.AssertEqual(t, datatypes.FinishedSuccess, testSuMf.getLastOperation().Status)
+	testutil.AssertEqual(t, 1, len(testSuMf.status.InstalledDependencies))
+	testutil.AssertNotNil(t, testSuMf.status.InstalledDependencies["app-b"])
+
+	// a plain manifest is applied together with the installed release
+	testSuMf.installModule(newTestChartModule("app-plain", testHTTPServerManifestURLPath), "test-install-plain")
+	testutil.AssertEqual(t, 3, len(applied))
+	testutil.AssertEqual(t, "test-plain-app", applied[0].GetName())
+	testutil.AssertEqual(t, "app-b-app", applied[2].GetName())
+	finishApply(nil)
+	testutil.AssertEqual(t, datatypes.FinishedSuccess, testSuMf.getLastOperation().Status)
+	testutil.AssertEqual(t, 1, len(testSuMf.status.InstalledDependencies))
+
+	// uninstalling the last release keeps the plain manifest resources
+	testSuMf.processRemoveAction(datatypes.RemoveAction{CorrelationID: "test-remove-b", Software: []*datatypes.DependencyDescription{{Name: "app-b"}}})
+	testutil.AssertEqual(t, 2, len(removed))
+	testutil.AssertEqual(t, "app-b-app", removed[1].GetName())
+	testutil.AssertEqual(t, datatypes.Removing, testSuMf.getLastOperation().Status)
+	finishApply(nil)
+	testutil.AssertEqual(t, datatypes.FinishedSuccess, testSuMf.getLastOperation().Status)
+	testutil.AssertEqual(t, 0, len(testSuMf.status.InstalledDependencies))
+}
@@ -0,0 +1,221 @@
+// Copyright (c) 2022 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Apache License 2.0 which is available at
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package things
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/eclipse-kanto/container-management/containerm/log"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/kustomize/api/konfig"
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// kustomizeOverlaysDir contains the overlays of the vehicle variants in a kustomization archive, e.g. overlays/<variant>/kustomization.yaml
+	kustomizeOverlaysDir = "overlays"
+	// kustomizationArchiveMaxSize limits the total size of the decompressed files of a kustomization archive
+	kustomizationArchiveMaxSize = 16 << 20
+)
+
+// kustomizationSourceFields list the kustomization fields referencing other kustomizations or resources, which must be contained in the archive
+var kustomizationSourceFields = []string{"resources", "bases", "components"}
+
+// isTarball checks if the artifact is a gzip compressed archive
+func isTarball(data []byte) bool {
+	return len(data) > 2 && data[0] == 0x1f && data[1] == 0x8b
+}
+
+// readTarball reads the regular files of a gzip compressed archive, keyed by their absolute path within the archive.
+// An archive, whose files exceed the given total size once decompressed, is not read.
+func readTarball(data []byte, maxSize int64) (map[string][]byte, error) {
+	gzipReader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer gzipReader.Close()
+	files := map[string][]byte{}
+	remaining := maxSize
+	tarReader := tar.NewReader(gzipReader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if header.Size > remaining {
+			return nil, log.NewErrorf("the decompressed archive exceeds the maximum size of %d bytes", maxSize)
+		}
+		name := path.Clean("/" + header.Name)
+		content, err := ioutil.ReadAll(io.LimitReader(tarReader, header.Size))
+		if err != nil {
+			return nil, err
+		}
+		remaining -= int64(len(content))
+		files[name] = content
+	}
+	return files, nil
+}
+
+func isKustomizationFile(name string) bool {
+	for _, kustomizationFileName := range konfig.RecognizedKustomizationFileNames() {
+		if path.Base(name) == kustomizationFileName {
+			return true
+		}
+	}
+	return false
+}
+
+// isKustomizationArchive checks if the artifact is an archive containing a kustomization
+func isKustomizationArchive(data []byte) bool {
+	if !isTarball(data) {
+		return false
+	}
+	gzipReader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return false
+	}
+	defer gzipReader.Close()
+	// only the file names are checked, so the file contents are skipped without being read into memory
+	tarReader := tar.NewReader(gzipReader)
+	for {
+		header, err := tarReader.Next()
+		if err != nil {
+			return false
+		}
+		if header.Typeflag == tar.TypeReg && isKustomizationFile(header.Name) {
+			return true
+		}
+	}
+}
+
+// buildKustomization builds the kustomization archive on the device without network access.
+// The overlay of the vehicle variant is built if a variant is configured, otherwise the kustomization in the archive root.
+func buildKustomization(data []byte, variant string) ([]*unstructured.Unstructured, error) {
+	files, err := readTarball(data, kustomizationArchiveMaxSize)
+	if err != nil {
+		return nil, log.NewErrorf("invalid kustomization archive: %v", err)
+	}
+	fSys := filesys.MakeFsInMemory()
+	for name, content := range files {
+		if err := fSys.WriteFile(name, content); err != nil {
+			return nil, err
+		}
+	}
+	buildPath := "/"
+	if variant != "" {
+		buildPath = path.Join("/", kustomizeOverlaysDir, variant)
+	}
+	if !hasKustomization(fSys, buildPath) {
+		if variant != "" {
+			return nil, log.NewErrorf("the kustomization archive has no overlay for the vehicle variant %s", variant)
+		}
+		return nil, log.NewError("the kustomization archive has no root kustomization and no vehicle variant is configured")
+	}
+	if err := validateKustomizationSources(fSys, files); err != nil {
+		return nil, err
+	}
+	resMap, err := krusty.MakeKustomizer(krusty.MakeDefaultOptions()).Run(fSys, buildPath)
+	if err != nil {
+		return nil, log.NewErrorf("cannot build the kustomization %s: %v", buildPath, err)
+	}
+	mfBytes, err := resMap.AsYaml()
+	if err != nil {
+		return nil, err
+	}
+	_, mf, err := parseMultiYAML(mfBytes)
+	return mf, err
+}
+
+func hasKustomization(fSys filesys.FileSystem, dir string) bool {
+	for _, kustomizationFileName := range konfig.RecognizedKustomizationFileNames() {
+		if fSys.Exists(path.Join(dir, kustomizationFileName)) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateKustomizationSources ensures that the kustomizations reference only files contained in the archive,
+// as the remote bases and resources would be fetched over the network
+func validateKustomizationSources(fSys filesys.FileSystem, files map[string][]byte) error {
+	names := []string{}
+	for name := range files {
+		if isKustomizationFile(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	violations := []string{}
+	for _, name := range names {
+		content := files[name]
+		kustomization := map[string]interface{}{}
+		if err := yaml.Unmarshal(content, &kustomization); err != nil {
+			return log.NewErrorf("invalid kustomization %s: %v", name, err)
+		}
+		for _, field := range kustomizationSourceFields {
+			sources, _ := kustomization[field].([]interface{})
+			for _, source := range sources {
+				sourcePath, _ := source.(string)
+				if !fSys.Exists(path.Join(path.Dir(name), sourcePath)) {
+					violations = append(violations, fmt.Sprintf("%s: %s %s is not contained in the archive", name, field, sourcePath))
+				}
+			}
+		}
+		for _, value := range stringValues(kustomization) {
+			if u, err := url.Parse(value); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+				violations = append(violations, fmt.Sprintf("%s: remote file %s is not allowed", name, value))
+			}
+		}
+	}
+	if len(violations) > 0 {
+		return log.NewErrorf("the kustomization archive is not self-contained: %s", strings.Join(violations, "; "))
+	}
+	return nil
+}
+
+func stringValues(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case map[string]interface{}:
+		values := []string{}
+		for _, item := range v {
+			values = append(values, stringValues(item)...)
+		}
+		return values
+	case []interface{}:
+		values := []string{}
+		for _, item := range v {
+			values = append(values, stringValues(item)...)
+		}
+		return values
+	default:
+		return nil
+	}
+}
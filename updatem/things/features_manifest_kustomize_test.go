@@ -0,0 +1,233 @@
+// Copyright (c) 2022 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Apache License 2.0 which is available at
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package things
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"testing"
+
+	"github.com/eclipse-kanto/container-management/rollouts/api/datatypes"
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/pkg/testutil"
+	"github.com/golang/mock/gomock"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	testKustomizationBase = `resources:
+- deployment.yaml
+`
+	testKustomizationDeployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: test-app
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      app: test-app
+  template:
+    metadata:
+      labels:
+        app: test-app
+    spec:
+      containers:
+      - name: app
+        image: ghcr.io/eclipse-leda/test-app:1.0.0
+`
+	testKustomizationOverlaySedan = `resources:
+- ../../base
+namePrefix: sedan-
+`
+	testKustomizationOverlaySUV = `resources:
+- ../../base
+namePrefix: suv-
+replicas:
+- name: test-app
+  count: 2
+`
+	testKustomizationOverlayRemote = `resources:
+- ../../base
+- https://example.com/remote.yaml
+- github.com/eclipse-leda/test-repo//base
+`
+)
+
+func newTestKustomizationArchive(t *testing.T, files map[string]string) []byte {
+	buf := &bytes.Buffer{}
+	gzipWriter := gzip.NewWriter(buf)
+	tarWriter := tar.NewWriter(gzipWriter)
+	for name, content := range files {
+		testutil.AssertNil(t, tarWriter.WriteHeader(&tar.Header{Name: name, Mode: 0600, Size: int64(len(content))}))
+		_, err := tarWriter.Write([]byte(content))
+		testutil.AssertNil(t, err)
+	}
+	testutil.AssertNil(t, tarWriter.Close())
+	testutil.AssertNil(t, gzipWriter.Close())
+	return buf.Bytes()
+}
+
+func newTestVariantsArchive(t *testing.T) []byte {
+	return newTestKustomizationArchive(t, map[string]string{
+		"./base/kustomization.yaml":           testKustomizationBase,
+		"./base/deployment.yaml":              testKustomizationDeployment,
+		"./overlays/sedan/kustomization.yaml": testKustomizationOverlaySedan,
+		"./overlays/suv/kustomization.yaml":   testKustomizationOverlaySUV,
+	})
+}
+
+func TestBuildKustomization(t *testing.T) {
+	variantsArchive := newTestVariantsArchive(t)
+
+	tests := map[string]struct {
+		archive          []byte
+		variant          string
+		expectedName     string
+		expectedReplicas int64
+		expectedErr      string
+	}{
+		"test_variant_sedan": {
+			archive:          variantsArchive,
+			variant:          "sedan",
+			expectedName:     "sedan-test-app",
+			expectedReplicas: 1,
+		},
+		"test_variant_suv": {
+			archive:          variantsArchive,
+			variant:          "suv",
+			expectedName:     "suv-test-app",
+			expectedReplicas: 2,
+		},
+		"test_root_kustomization": {
+			archive: newTestKustomizationArchive(t, map[string]string{
+				"kustomization.yaml": testKustomizationBase,
+				"deployment.yaml":    testKustomizationDeployment,
+			}),
+			expectedName:     "test-app",
+			expectedReplicas: 1,
+		},
+		"test_unknown_variant": {
+			archive:     variantsArchive,
+			variant:     "coupe",
+			expectedErr: "no overlay for the vehicle variant coupe",
+		},
+		"test_no_variant": {
+			archive:     variantsArchive,
+			expectedErr: "no root kustomization",
+		},
+		"test_remote_sources": {
+			archive: newTestKustomizationArchive(t, map[string]string{
+				"base/kustomization.yaml":           testKustomizationBase,
+				"base/deployment.yaml":              testKustomizationDeployment,
+				"overlays/sedan/kustomization.yaml": testKustomizationOverlayRemote,
+			}),
+			variant:     "sedan",
+			expectedErr: "remote file https://example.com/remote.yaml is not allowed",
+		},
+		"test_invalid_archive": {
+			archive:     []byte{0x1f, 0x8b, 0x00},
+			expectedErr: "invalid kustomization archive",
+		},
+	}
+	for testName, testCase := range tests {
+		t.Run(testName, func(t *testing.T) {
+			t.Log(testName)
+			mf, err := buildKustomization(testCase.archive, testCase.variant)
+			if testCase.expectedErr != "" {
+				testutil.AssertNotNil(t, err)
+				testutil.AssertContainsString(t, err.Error(), testCase.expectedErr)
+				return
+			}
+			testutil.AssertNil(t, err)
+			testutil.AssertEqual(t, 1, len(mf))
+			testutil.AssertEqual(t, testCase.expectedName, mf[0].GetName())
+			replicas, _, _ := unstructured.NestedInt64(mf[0].Object, "spec", "replicas")
+			testutil.AssertEqual(t, testCase.expectedReplicas, replicas)
+		})
+	}
+}
+
+func TestValidateKustomizationSourcesGitBase(t *testing.T) {
+	_, err := buildKustomization(newTestKustomizationArchive(t, map[string]string{
+		"base/kustomization.yaml":           testKustomizationBase,
+		"base/deployment.yaml":              testKustomizationDeployment,
+		"overlays/sedan/kustomization.yaml": testKustomizationOverlayRemote,
+	}), "sedan")
+	testutil.AssertNotNil(t, err)
+	testutil.AssertContainsString(t, err.Error(), "resources github.com/eclipse-leda/test-repo//base is not contained in the archive")
+}
+
+func TestSUMfInstallKustomizationArchive(t *testing.T) {
+	variantsArchive := newTestVariantsArchive(t)
+	setupDummyHTTPServerForTests(true, map[string]func(http.ResponseWriter, *http.Request){
+		"/kustomization/app.tar.gz": func(writer http.ResponseWriter, request *http.Request) {
+			_, _ = writer.Write(variantsArchive)
+		},
+		"/kustomization/app": func(writer http.ResponseWriter, request *http.Request) {
+			_, _ = writer.Write(variantsArchive)
+		},
+	})
+	defer mockHTTPServer.Close()
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	setupThingMock(controller)
+	setupUpdateManagerMock(controller)
+	setupEventsManagerMock(controller)
+
+	testSuMf := newSoftwareUpdatableManifests(mockThing, mockEventsManager, mockUpdateManager, newManifestTemplate(nil, ""), newManifestPolicy(""), newHelmReleases(""), "suv").(*softwareUpdatableManifests)
+
+	// the kustomization archive is recognized by its content, regardless of the artifact name
+	for _, fileName := range []string{"app.tar.gz", "app"} {
+		mf, rejected, err := testSuMf.getModuleManifest(&datatypes.SoftwareModuleAction{
+			SoftwareModule: &datatypes.SoftwareModuleID{Name: "test-app", Version: "1.0.0"},
+			Artifacts: []*datatypes.SoftwareArtifactAction{{
+				FileName: fileName,
+				Download: map[datatypes.Protocol]*datatypes.Links{datatypes.HTTP: {URL: mockHTTPServer.URL + "/kustomization/" + fileName}},
+			}},
+		})
+		testutil.AssertNil(t, err)
+		testutil.AssertFalse(t, rejected)
+		testutil.AssertEqual(t, 1, len(mf))
+		testutil.AssertEqual(t, "suv-test-app", mf[0].GetName())
+	}
+
+	// a kustomization that cannot be built for the vehicle variant is rejected
+	testSuMf.mfVariant = "coupe"
+	_, rejected, err := testSuMf.getModuleManifest(&datatypes.SoftwareModuleAction{
+		SoftwareModule: &datatypes.SoftwareModuleID{Name: "test-app", Version: "1.0.0"},
+		Artifacts: []*datatypes.SoftwareArtifactAction{{
+			FileName: "app.tar.gz",
+			Download: map[datatypes.Protocol]*datatypes.Links{datatypes.HTTP: {URL: mockHTTPServer.URL + "/kustomization/app.tar.gz"}},
+		}},
+	})
+	testutil.AssertNotNil(t, err)
+	testutil.AssertTrue(t, rejected)
+}
+
+func TestReadTarballMaxSize(t *testing.T) {
+	archive := newTestKustomizationArchive(t, map[string]string{
+		"kustomization.yaml": testKustomizationBase,
+		"deployment.yaml":    testKustomizationDeployment,
+	})
+	maxSize := int64(len(testKustomizationBase) + len(testKustomizationDeployment))
+
+	files, err := readTarball(archive, maxSize)
+	testutil.AssertNil(t, err)
+	testutil.AssertEqual(t, 2, len(files))
+
+	_, err = readTarball(archive, maxSize-1)
+	testutil.AssertNotNil(t, err)
+	testutil.AssertContainsString(t, err.Error(), "exceeds the maximum size")
+}
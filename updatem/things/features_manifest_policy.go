@@ -0,0 +1,199 @@
+// Copyright (c) 2022 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Apache License 2.0 which is available at
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package things
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/eclipse-kanto/container-management/containerm/log"
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/orchestration"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	manifestPolicyDefaultNamespace = "default"
+	manifestPolicyDefaultRegistry  = "docker.io"
+	manifestPolicyDefaultLibrary   = "library"
+)
+
+// manifestPolicyRules defines what the update manifests may touch - an empty list allows any value
+type manifestPolicyRules struct {
+	Namespaces       []string `json:"namespaces,omitempty"`
+	Kinds            []string `json:"kinds,omitempty"`
+	APIGroups        []string `json:"apiGroups,omitempty"`
+	Registries       []string `json:"registries,omitempty"`
+	AllowPrivileged  bool     `json:"allowPrivileged,omitempty"`
+	AllowHostPath    bool     `json:"allowHostPath,omitempty"`
+	AllowHostNetwork bool     `json:"allowHostNetwork,omitempty"`
+}
+
+// manifestPolicy admits the update manifests according to the device-side policy file.
+// The policy file is read on each admission - no policy file allows any manifest.
+type manifestPolicy struct {
+	policyFile string
+}
+
+func newManifestPolicy(policyFile string) *manifestPolicy {
+	return &manifestPolicy{
+		policyFile: policyFile,
+	}
+}
+
+// admit returns an error with an explanation for each violated rule, if the manifest is not allowed by the policy
+func (mfPolicy *manifestPolicy) admit(mf []*unstructured.Unstructured) error {
+	if mfPolicy.policyFile == "" {
+		return nil
+	}
+	rules, err := loadManifestPolicyRules(mfPolicy.policyFile)
+	if err != nil {
+		return err
+	}
+	violations := []string{}
+	for _, u := range mf {
+		for _, violation := range rules.violations(u) {
+			violations = append(violations, fmt.Sprintf("%s %s: %s", u.GetKind(), resourceName(u), violation))
+		}
+	}
+	if len(violations) > 0 {
+		return log.NewErrorf("the manifest violates the admission policy: %s", strings.Join(violations, "; "))
+	}
+	return nil
+}
+
+// loadManifestPolicyRules reads the policy rules from a JSON or YAML file - a policy file that cannot be read rejects all manifests
+func loadManifestPolicyRules(policyFile string) (*manifestPolicyRules, error) {
+	data, err := ioutil.ReadFile(policyFile)
+	if err != nil {
+		return nil, log.NewErrorf("cannot read the admission policy file %s: %v", policyFile, err)
+	}
+	rules := &manifestPolicyRules{}
+	if err := yaml.UnmarshalStrict(data, rules); err != nil {
+		return nil, log.NewErrorf("invalid admission policy file %s: %v", policyFile, err)
+	}
+	return rules, nil
+}
+
+func (rules *manifestPolicyRules) violations(u *unstructured.Unstructured) []string {
+	violations := []string{}
+	gvk := u.GroupVersionKind()
+	if !allowedValue(rules.APIGroups, gvk.Group) {
+		violations = append(violations, fmt.Sprintf("API group %q is not allowed", gvk.Group))
+	}
+	if !allowedValue(rules.Kinds, gvk.Kind) {
+		violations = append(violations, fmt.Sprintf("kind %s is not allowed", gvk.Kind))
+	}
+	if namespace, ok := resourceNamespace(u); ok && !allowedValue(rules.Namespaces, namespace) {
+		violations = append(violations, fmt.Sprintf("namespace %s is not allowed", namespace))
+	}
+	path, ok := orchestration.PodSpecPath(u)
+	if !ok {
+		return violations
+	}
+	podSpec, _, _ := unstructured.NestedMap(u.Object, path...)
+	if hostNetwork, _, _ := unstructured.NestedBool(podSpec, "hostNetwork"); hostNetwork && !rules.AllowHostNetwork {
+		violations = append(violations, "host networking is not allowed")
+	}
+	if !rules.AllowHostPath {
+		volumes, _, _ := unstructured.NestedSlice(podSpec, "volumes")
+		for _, volume := range volumes {
+			volumeMap, _ := volume.(map[string]interface{})
+			if _, ok := volumeMap["hostPath"]; ok {
+				violations = append(violations, fmt.Sprintf("hostPath volume %v is not allowed", volumeMap["name"]))
+			}
+		}
+	}
+	for _, field := range []string{"initContainers", "containers", "ephemeralContainers"} {
+		containers, _, _ := unstructured.NestedSlice(podSpec, field)
+		for _, container := range containers {
+			containerMap, _ := container.(map[string]interface{})
+			if privileged, _, _ := unstructured.NestedBool(containerMap, "securityContext", "privileged"); privileged && !rules.AllowPrivileged {
+				violations = append(violations, fmt.Sprintf("privileged container %v is not allowed", containerMap["name"]))
+			}
+			if image, _, _ := unstructured.NestedString(containerMap, "image"); !allowedRegistry(rules.Registries, image) {
+				violations = append(violations, fmt.Sprintf("image %s of container %v is not from an allowed registry", image, containerMap["name"]))
+			}
+		}
+	}
+	return violations
+}
+
+// resourceNamespace returns the namespace the resource is applied to or false for the cluster-scoped resources
+func resourceNamespace(u *unstructured.Unstructured) (string, bool) {
+	gk := u.GroupVersionKind().GroupKind()
+	if gk == (schema.GroupKind{Kind: "Namespace"}) {
+		return u.GetName(), true
+	}
+	if orchestration.IsClusterScoped(u) {
+		return "", false
+	}
+	if u.GetNamespace() == "" {
+		return manifestPolicyDefaultNamespace, true
+	}
+	return u.GetNamespace(), true
+}
+
+func resourceName(u *unstructured.Unstructured) string {
+	if u.GetNamespace() == "" {
+		return u.GetName()
+	}
+	return u.GetNamespace() + "/" + u.GetName()
+}
+
+func allowedValue(allowed []string, value string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	for _, a := range allowed {
+		if a == value {
+			return true
+		}
+	}
+	return false
+}
+
+// allowedRegistry checks if the image is from an allowed registry - a registry may be restricted to a repository path, e.g. ghcr.io/eclipse-leda
+func allowedRegistry(allowed []string, image string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	repository := imageRepository(image)
+	for _, registry := range allowed {
+		registry = strings.TrimSuffix(registry, "/")
+		if repository == registry || strings.HasPrefix(repository, registry+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// imageRepository returns the image repository including its registry, which defaults to docker.io with the library path for the official images
+func imageRepository(image string) string {
+	repository := image
+	if i := strings.Index(repository, "@"); i >= 0 {
+		repository = repository[:i]
+	}
+	if i := strings.LastIndex(repository, ":"); i > strings.LastIndex(repository, "/") {
+		repository = repository[:i]
+	}
+	registry, path := manifestPolicyDefaultRegistry, repository
+	if parts := strings.SplitN(repository, "/", 2); len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		registry, path = parts[0], parts[1]
+	}
+	if registry == manifestPolicyDefaultRegistry && !strings.Contains(path, "/") {
+		path = manifestPolicyDefaultLibrary + "/" + path
+	}
+	return registry + "/" + path
+}
@@ -0,0 +1,204 @@
+// Copyright (c) 2022 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Apache License 2.0 which is available at
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package things
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/pkg/testutil"
+	"github.com/golang/mock/gomock"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const testManifestPolicy = `namespaces: [default, apps]
+kinds: [Namespace, Deployment, ConfigMap]
+apiGroups: ["", apps]
+registries: [ghcr.io/eclipse-leda, docker.io/library]
+allowHostNetwork: true
+`
+
+func newTestPolicyDeployment(namespace string, podSpec map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"name": "test-app", "namespace": namespace},
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{"spec": podSpec},
+		},
+	}}
+}
+
+func newTestPolicyResource(apiVersion, kind, namespace, name string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	u.SetAPIVersion(apiVersion)
+	u.SetKind(kind)
+	u.SetNamespace(namespace)
+	u.SetName(name)
+	return u
+}
+
+func TestManifestPolicyAdmit(t *testing.T) {
+	policyDir, err := ioutil.TempDir("", "updatem-policy")
+	testutil.AssertNil(t, err)
+	defer os.RemoveAll(policyDir)
+
+	policyFile := filepath.Join(policyDir, "policy.yaml")
+	testutil.AssertNil(t, ioutil.WriteFile(policyFile, []byte(testManifestPolicy), 0600))
+	invalidPolicyFile := filepath.Join(policyDir, "invalid-policy.yaml")
+	testutil.AssertNil(t, ioutil.WriteFile(invalidPolicyFile, []byte("allowPrivileged: maybe\n"), 0600))
+
+	tests := map[string]struct {
+		policyFile         string
+		mf                 []*unstructured.Unstructured
+		expectedViolations []string
+	}{
+		"test_no_policy": {
+			mf: []*unstructured.Unstructured{newTestPolicyResource("rbac.authorization.k8s.io/v1", "ClusterRoleBinding", "", "test-admin")},
+		},
+		"test_allowed": {
+			policyFile: policyFile,
+			mf: []*unstructured.Unstructured{
+				newTestPolicyResource("v1", "Namespace", "", "apps"),
+				newTestPolicyResource("v1", "ConfigMap", "", "test-config"),
+				newTestPolicyDeployment("apps", map[string]interface{}{
+					"hostNetwork": true,
+					"containers": []interface{}{
+						map[string]interface{}{"name": "app", "image": "ghcr.io/eclipse-leda/app:1.0"},
+						map[string]interface{}{"name": "sidecar", "image": "nginx:1.23@sha256:abcd"},
+					},
+				}),
+			},
+		},
+		"test_resource_rules": {
+			policyFile: policyFile,
+			mf: []*unstructured.Unstructured{
+				newTestPolicyResource("v1", "Namespace", "", "kube-system"),
+				newTestPolicyResource("v1", "Secret", "kube-system", "test-secret"),
+				newTestPolicyResource("rbac.authorization.k8s.io/v1", "ClusterRole", "", "test-admin"),
+			},
+			expectedViolations: []string{
+				"Namespace kube-system: namespace kube-system is not allowed",
+				"Secret kube-system/test-secret: kind Secret is not allowed",
+				"Secret kube-system/test-secret: namespace kube-system is not allowed",
+				"ClusterRole test-admin: API group \"rbac.authorization.k8s.io\" is not allowed",
+				"ClusterRole test-admin: kind ClusterRole is not allowed",
+			},
+		},
+		"test_custom_resource_of_cluster_scoped_kind": {
+			policyFile: policyFile,
+			mf: []*unstructured.Unstructured{
+				newTestPolicyResource("example.com/v1", "Node", "kube-system", "test-node"),
+			},
+			expectedViolations: []string{
+				"Node kube-system/test-node: API group \"example.com\" is not allowed",
+				"Node kube-system/test-node: kind Node is not allowed",
+				"Node kube-system/test-node: namespace kube-system is not allowed",
+			},
+		},
+		"test_pod_rules": {
+			policyFile: policyFile,
+			mf: []*unstructured.Unstructured{
+				newTestPolicyDeployment("default", map[string]interface{}{
+					"volumes": []interface{}{
+						map[string]interface{}{"name": "host", "hostPath": map[string]interface{}{"path": "/"}},
+						map[string]interface{}{"name": "data", "emptyDir": map[string]interface{}{}},
+					},
+					"initContainers": []interface{}{
+						map[string]interface{}{"name": "init", "image": "ghcr.io/eclipse-leda-fork/init:1.0"},
+					},
+					"containers": []interface{}{
+						map[string]interface{}{"name": "app", "image": "ghcr.io/eclipse-leda/app:1.0", "securityContext": map[string]interface{}{"privileged": true}},
+						map[string]interface{}{"name": "tool", "image": "attacker/tool"},
+					},
+				}),
+			},
+			expectedViolations: []string{
+				"Deployment default/test-app: hostPath volume host is not allowed",
+				"Deployment default/test-app: image ghcr.io/eclipse-leda-fork/init:1.0 of container init is not from an allowed registry",
+				"Deployment default/test-app: privileged container app is not allowed",
+				"Deployment default/test-app: image attacker/tool of container tool is not from an allowed registry",
+			},
+		},
+		"test_missing_policy_file": {
+			policyFile:         filepath.Join(policyDir, "missing.yaml"),
+			mf:                 []*unstructured.Unstructured{newTestPolicyResource("v1", "ConfigMap", "", "test-config")},
+			expectedViolations: []string{"cannot read the admission policy file"},
+		},
+		"test_invalid_policy_file": {
+			policyFile:         invalidPolicyFile,
+			mf:                 []*unstructured.Unstructured{newTestPolicyResource("v1", "ConfigMap", "", "test-config")},
+			expectedViolations: []string{"invalid admission policy file"},
+		},
+	}
+	for testName, testCase := range tests {
+		t.Run(testName, func(t *testing.T) {
+			t.Log(testName)
+			err := newManifestPolicy(testCase.policyFile).admit(testCase.mf)
+			if len(testCase.expectedViolations) == 0 {
+				testutil.AssertNil(t, err)
+				return
+			}
+			testutil.AssertNotNil(t, err)
+			for _, violation := range testCase.expectedViolations {
+				testutil.AssertContainsString(t, err.Error(), violation)
+			}
+		})
+	}
+}
+
+func TestImageRepository(t *testing.T) {
+	tests := map[string]string{
+		"nginx":                             "docker.io/library/nginx",
+		"docker.io/nginx:1.23":              "docker.io/library/nginx",
+		"library/nginx:1.23":                "docker.io/library/nginx",
+		"localhost/app":                     "localhost/app",
+		"localhost:5000/app:1.0":            "localhost:5000/app",
+		"ghcr.io/eclipse-leda/app@sha256:a": "ghcr.io/eclipse-leda/app",
+	}
+	for image, expected := range tests {
+		t.Run(image, func(t *testing.T) {
+			testutil.AssertEqual(t, expected, imageRepository(image))
+		})
+	}
+}
+
+func TestUpdateOrchestratorProcessApplyPolicyViolation(t *testing.T) {
+	policyDir, err := ioutil.TempDir("", "updatem-policy")
+	testutil.AssertNil(t, err)
+	defer os.RemoveAll(policyDir)
+	policyFile := filepath.Join(policyDir, "policy.yaml")
+	testutil.AssertNil(t, ioutil.WriteFile(policyFile, []byte(testManifestPolicy), 0600))
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	setupEventsManagerMock(controller)
+	setupThingMock(controller)
+	setupUpdateManagerMock(controller)
+
+	testUpdOrchestrator := newUpdateOrchestratorFeature(mockThing, mockEventsManager, mockUpdateManager, newManifestTemplate(nil, ""), newManifestPolicy(policyFile)).(*updateOrchestratorFeature)
+	testManifest := []*unstructured.Unstructured{newTestPolicyResource("v1", "Secret", "default", "test-secret")}
+
+	mockUpdateManager.EXPECT().Apply(gomock.Any(), gomock.Any()).Times(0)
+	mockThing.EXPECT().SetFeatureProperty(UpdateOrchestratorFeatureID, updateOrchestratorFeaturePropertyStatusState, gomock.Any()).Do(
+		func(featureID, path string, state *manifestState) {
+			testutil.AssertEqual(t, manifestStatusFinishedRejected, state.Status)
+			testutil.AssertEqual(t, "test-correlation-id", state.CorrelationID)
+			testutil.AssertContainsString(t, state.Error.Message, "kind Secret is not allowed")
+		}).Times(1)
+
+	testUpdOrchestrator.processApply(setApplyCorrelationIDContext(context.Background(), "test-correlation-id"), testManifest)
+}
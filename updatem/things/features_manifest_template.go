@@ -0,0 +1,159 @@
+// Copyright (c) 2022 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Apache License 2.0 which is available at
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package things
+
+import (
+	"io/ioutil"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/eclipse-kanto/container-management/containerm/log"
+	"github.com/eclipse-kanto/container-management/things/api/model"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+// Constants that define the built-in manifest variables resolved from the update thing
+const (
+	manifestVariableThingID        = "thing.id"
+	manifestVariableThingNamespace = "thing.namespace"
+	manifestVariableThingName      = "thing.name"
+)
+
+// manifestVariablePattern matches the ${name} placeholders - a placeholder escaped as $${name} is rendered as the literal ${name}
+var manifestVariablePattern = regexp.MustCompile(`\$?\$\{([^}]*)\}`)
+
+// manifestTemplate substitutes the vehicle-specific variables in the string values of the update manifest resources.
+// The variables are resolved from the device-side values file and from the update thing. The manifests are rendered only if a values file is configured,
+// so that the shell-style ${name} references, e.g. in the scripts of a ConfigMap, are left untouched by default.
+type manifestTemplate struct {
+	thingID    model.NamespacedID
+	valuesFile string
+}
+
+func newManifestTemplate(thingID model.NamespacedID, valuesFile string) *manifestTemplate {
+	return &manifestTemplate{
+		thingID:    thingID,
+		valuesFile: valuesFile,
+	}
+}
+
+// render returns a copy of the manifest with the known variables substituted - the placeholders of unknown variables are left untouched.
+// The manifest is returned as is if no values file is configured.
+func (mfTemplate *manifestTemplate) render(mf []*unstructured.Unstructured) ([]*unstructured.Unstructured, error) {
+	if mfTemplate.valuesFile == "" {
+		return mf, nil
+	}
+	variables, err := mfTemplate.variables()
+	if err != nil {
+		return nil, err
+	}
+	unresolved := map[string]bool{}
+	rendered := make([]*unstructured.Unstructured, len(mf))
+	for i, u := range mf {
+		rendered[i] = &unstructured.Unstructured{Object: renderValue(u.Object, variables, unresolved).(map[string]interface{})}
+	}
+	if len(unresolved) > 0 {
+		names := []string{}
+		for name := range unresolved {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		log.Debug("the manifest placeholders of the unknown variable(s) %s are left untouched", strings.Join(names, ", "))
+	}
+	return rendered, nil
+}
+
+// variables loads the values file and adds the built-in variables of the update thing, which cannot be overridden
+func (mfTemplate *manifestTemplate) variables() (map[string]string, error) {
+	variables, err := loadManifestValues(mfTemplate.valuesFile)
+	if err != nil {
+		return nil, err
+	}
+	if mfTemplate.thingID != nil {
+		for name, value := range map[string]string{
+			manifestVariableThingID:        mfTemplate.thingID.String(),
+			manifestVariableThingNamespace: mfTemplate.thingID.GetNamespace(),
+			manifestVariableThingName:      mfTemplate.thingID.GetName(),
+		} {
+			if _, ok := variables[name]; ok {
+				log.Warn("the manifest variable %s from the values file is overridden by the update thing", name)
+			}
+			variables[name] = value
+		}
+	}
+	return variables, nil
+}
+
+// loadManifestValues reads the flat map of manifest variables from a JSON or YAML file - a missing file provides no variables
+func loadManifestValues(valuesFile string) (map[string]string, error) {
+	variables := map[string]string{}
+	data, err := ioutil.ReadFile(valuesFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			log.Warn("the manifest values file %s does not exist", valuesFile)
+			return variables, nil
+		}
+		return nil, err
+	}
+	values := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, log.NewErrorf("invalid manifest values file %s: %v", valuesFile, err)
+	}
+	for name, value := range values {
+		switch v := value.(type) {
+		case string:
+			variables[name] = v
+		case bool:
+			variables[name] = strconv.FormatBool(v)
+		case float64:
+			variables[name] = strconv.FormatFloat(v, 'f', -1, 64)
+		default:
+			return nil, log.NewErrorf("invalid manifest values file %s: the value of %s is not a scalar", valuesFile, name)
+		}
+	}
+	return variables, nil
+}
+
+func renderValue(value interface{}, variables map[string]string, unresolved map[string]bool) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		rendered := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			rendered[key] = renderValue(item, variables, unresolved)
+		}
+		return rendered
+	case []interface{}:
+		rendered := make([]interface{}, len(v))
+		for i, item := range v {
+			rendered[i] = renderValue(item, variables, unresolved)
+		}
+		return rendered
+	case string:
+		return manifestVariablePattern.ReplaceAllStringFunc(v, func(placeholder string) string {
+			if strings.HasPrefix(placeholder, "$$") {
+				return placeholder[1:]
+			}
+			name := strings.TrimSpace(placeholder[2 : len(placeholder)-1])
+			resolved, ok := variables[name]
+			if !ok {
+				unresolved[name] = true
+				return placeholder
+			}
+			return resolved
+		})
+	}
+	return value
+}
@@ -0,0 +1,166 @@
+// Copyright (c) 2022 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Apache License 2.0 which is available at
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package things
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/eclipse-kanto/container-management/things/client"
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/pkg/testutil"
+	"github.com/golang/mock/gomock"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func newTestTemplateManifest(env map[string]interface{}) []*unstructured.Unstructured {
+	return []*unstructured.Unstructured{{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata":   map[string]interface{}{"name": "test-app"},
+		"spec": map[string]interface{}{
+			"containers": []interface{}{
+				map[string]interface{}{"name": "test-name", "env": []interface{}{env}},
+			},
+		},
+	}}}
+}
+
+func TestManifestTemplateRender(t *testing.T) {
+	valuesDir, err := ioutil.TempDir("", "updatem-values")
+	testutil.AssertNil(t, err)
+	defer os.RemoveAll(valuesDir)
+
+	valuesFile := filepath.Join(valuesDir, "values.yaml")
+	testutil.AssertNil(t, ioutil.WriteFile(valuesFile, []byte("vin: WVW123\nregion.endpoint: https://eu.example.com\nfeature.enabled: true\nthing.id: overridden\n"), 0600))
+	invalidValuesFile := filepath.Join(valuesDir, "invalid-values.yaml")
+	testutil.AssertNil(t, ioutil.WriteFile(invalidValuesFile, []byte("vin:\n  nested: value\n"), 0600))
+
+	thingID := client.NewNamespacedID("test.namespace", "edge:update")
+
+	tests := map[string]struct {
+		valuesFile  string
+		env         map[string]interface{}
+		expectedEnv map[string]interface{}
+		expectedErr bool
+	}{
+		"test_values_file": {
+			valuesFile:  valuesFile,
+			env:         map[string]interface{}{"name": "VIN", "value": "${vin}@${ region.endpoint }?enabled=${feature.enabled}"},
+			expectedEnv: map[string]interface{}{"name": "VIN", "value": "WVW123@https://eu.example.com?enabled=true"},
+		},
+		"test_thing_variables": {
+			valuesFile:  valuesFile,
+			env:         map[string]interface{}{"name": "THING", "value": "${thing.id} ${thing.namespace} ${thing.name}"},
+			expectedEnv: map[string]interface{}{"name": "THING", "value": "test.namespace:edge:update test.namespace edge:update"},
+		},
+		"test_escaped_placeholder": {
+			valuesFile:  valuesFile,
+			env:         map[string]interface{}{"name": "VIN", "value": "$${vin}"},
+			expectedEnv: map[string]interface{}{"name": "VIN", "value": "${vin}"},
+		},
+		"test_no_values_file": {
+			env:         map[string]interface{}{"name": "VIN", "value": "${vin} $${HOME} ${thing.id}"},
+			expectedEnv: map[string]interface{}{"name": "VIN", "value": "${vin} $${HOME} ${thing.id}"},
+		},
+		"test_no_placeholders": {
+			env:         map[string]interface{}{"name": "PORT", "value": "$8080", "count": int64(1)},
+			expectedEnv: map[string]interface{}{"name": "PORT", "value": "$8080", "count": int64(1)},
+		},
+		"test_unknown_variable": {
+			valuesFile:  valuesFile,
+			env:         map[string]interface{}{"name": "REGION", "value": "${HOME}/${region}@${vin}"},
+			expectedEnv: map[string]interface{}{"name": "REGION", "value": "${HOME}/${region}@WVW123"},
+		},
+		"test_missing_values_file": {
+			valuesFile:  filepath.Join(valuesDir, "missing.yaml"),
+			env:         map[string]interface{}{"name": "VIN", "value": "${vin}@${thing.name}"},
+			expectedEnv: map[string]interface{}{"name": "VIN", "value": "${vin}@edge:update"},
+		},
+		"test_invalid_values_file": {
+			valuesFile:  invalidValuesFile,
+			env:         map[string]interface{}{"name": "VIN", "value": "VIN"},
+			expectedErr: true,
+		},
+	}
+	for testName, testCase := range tests {
+		t.Run(testName, func(t *testing.T) {
+			t.Log(testName)
+			mf := newTestTemplateManifest(testCase.env)
+			rendered, err := newManifestTemplate(thingID, testCase.valuesFile).render(mf)
+			if testCase.expectedErr {
+				testutil.AssertNotNil(t, err)
+				return
+			}
+			testutil.AssertNil(t, err)
+			testutil.AssertEqual(t, newTestTemplateManifest(testCase.expectedEnv), rendered)
+			// the original manifest is not modified
+			testutil.AssertEqual(t, newTestTemplateManifest(testCase.env), mf)
+		})
+	}
+}
+
+func TestUpdateOrchestratorProcessApplyRejected(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	setupEventsManagerMock(controller)
+	setupThingMock(controller)
+	setupUpdateManagerMock(controller)
+
+	valuesDir, err := ioutil.TempDir("", "updatem-values")
+	testutil.AssertNil(t, err)
+	defer os.RemoveAll(valuesDir)
+	valuesFile := filepath.Join(valuesDir, "values.yaml")
+	testutil.AssertNil(t, ioutil.WriteFile(valuesFile, []byte("vin:\n  nested: value\n"), 0600))
+
+	testUpdOrchestrator := newUpdateOrchestratorFeature(mockThing, mockEventsManager, mockUpdateManager, newManifestTemplate(nil, valuesFile), newManifestPolicy("")).(*updateOrchestratorFeature)
+	testManifest := newTestTemplateManifest(map[string]interface{}{"name": "VIN", "value": "${vin}"})
+
+	mockUpdateManager.EXPECT().Apply(gomock.Any(), gomock.Any()).Times(0)
+	mockThing.EXPECT().SetFeatureProperty(UpdateOrchestratorFeatureID, updateOrchestratorFeaturePropertyStatusState, gomock.Any()).Do(
+		func(featureID, path string, state *manifestState) {
+			testutil.AssertEqual(t, manifestStatusFinishedRejected, state.Status)
+			testutil.AssertEqual(t, "test-correlation-id", state.CorrelationID)
+			testutil.AssertEqual(t, testManifest, state.Manifest)
+			testutil.AssertContainsString(t, state.Error.Message, "vin")
+		}).Times(1)
+
+	testUpdOrchestrator.processApply(setApplyCorrelationIDContext(context.Background(), "test-correlation-id"), testManifest)
+}
+
+func TestUpdateOrchestratorProcessApplyShellVariables(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	setupEventsManagerMock(controller)
+	setupThingMock(controller)
+	setupUpdateManagerMock(controller)
+
+	testUpdOrchestrator := newUpdateOrchestratorFeature(mockThing, mockEventsManager, mockUpdateManager, newManifestTemplate(nil, ""), newManifestPolicy("")).(*updateOrchestratorFeature)
+	testManifest := []*unstructured.Unstructured{{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]interface{}{"name": "test-scripts"},
+		"data":       map[string]interface{}{"start.sh": "#!/bin/sh\ncd ${HOME} && exec ./app --user $${USER}\n"},
+	}}}
+
+	mockThing.EXPECT().SetFeatureProperty(UpdateOrchestratorFeatureID, updateOrchestratorFeaturePropertyStatusState, gomock.Any()).Times(0)
+	mockUpdateManager.EXPECT().Apply(gomock.Any(), gomock.Any()).Do(func(ctx context.Context, mf []*unstructured.Unstructured) {
+		// the shell-style variables are applied as they are
+		testutil.AssertEqual(t, "#!/bin/sh\ncd ${HOME} && exec ./app --user $${USER}\n", mf[0].Object["data"].(map[string]interface{})["start.sh"])
+	}).Times(1)
+
+	testUpdOrchestrator.processApply(setApplyCorrelationIDContext(context.Background(), "test-correlation-id"), testManifest)
+}
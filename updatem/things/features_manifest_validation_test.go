@@ -0,0 +1,52 @@
+// Copyright (c) 2022 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Apache License 2.0 which is available at
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package things
+
+import (
+	"context"
+	"testing"
+
+	"github.com/eclipse-kanto/container-management/containerm/log"
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/pkg/testutil"
+	mocksorchmgr "github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/pkg/testutil/mocks/orchestration"
+	"github.com/golang/mock/gomock"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+type testValidatorUpdateManager struct {
+	*mocksorchmgr.MockUpdateManager
+	*mocksorchmgr.MockManifestValidator
+}
+
+func TestUpdateOrchestratorProcessApplyInvalid(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	setupEventsManagerMock(controller)
+	setupThingMock(controller)
+	setupUpdateManagerMock(controller)
+
+	testValidator := mocksorchmgr.NewMockManifestValidator(controller)
+	testUpdOrchestrator := newUpdateOrchestratorFeature(mockThing, mockEventsManager, &testValidatorUpdateManager{mockUpdateManager, testValidator},
+		newManifestTemplate(nil, ""), newManifestPolicy("")).(*updateOrchestratorFeature)
+	testManifest := []*unstructured.Unstructured{newTestPolicyResource("apps/v1", "Deployment", "default", "test-app")}
+
+	testValidator.EXPECT().Validate(gomock.Any(), testManifest).Return(log.NewError("the manifest is not valid: Deployment default/test-app: missing required field \"selector\""))
+	mockUpdateManager.EXPECT().Apply(gomock.Any(), gomock.Any()).Times(0)
+	mockThing.EXPECT().SetFeatureProperty(UpdateOrchestratorFeatureID, updateOrchestratorFeaturePropertyStatusState, gomock.Any()).Do(
+		func(featureID, path string, state *manifestState) {
+			testutil.AssertEqual(t, manifestStatusFinishedRejected, state.Status)
+			testutil.AssertContainsString(t, state.Error.Message, "missing required field \"selector\"")
+		}).Times(1)
+
+	testUpdOrchestrator.processApply(setApplyCorrelationIDContext(context.Background(), "test-correlation-id"), testManifest)
+}
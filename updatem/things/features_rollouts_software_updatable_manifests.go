@@ -13,6 +13,7 @@ package things
 
 import (
 	"context"
+	"strings"
 	"sync"
 
 	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/events"
@@ -36,9 +37,11 @@ const (
 	softwareUpdatableDefinitionVersion   = "2.0.0"
 	softwareUpdatablePropertyNameStatus  = "status"
 
-	softwareUpdatablePropertyLastOperation       = softwareUpdatablePropertyNameStatus + "/lastOperation"
-	softwareUpdatablePropertyLastFailedOperation = softwareUpdatablePropertyNameStatus + "/lastFailedOperation"
-	softwareUpdatableOperationInstall            = "install"
+	softwareUpdatablePropertyLastOperation         = softwareUpdatablePropertyNameStatus + "/lastOperation"
+	softwareUpdatablePropertyLastFailedOperation   = softwareUpdatablePropertyNameStatus + "/lastFailedOperation"
+	softwareUpdatablePropertyInstalledDependencies = softwareUpdatablePropertyNameStatus + "/installedDependencies"
+	softwareUpdatableOperationInstall              = "install"
+	softwareUpdatableOperationRemove               = "remove"
 )
 
 type softwareUpdatableManifests struct {
@@ -46,6 +49,10 @@ type softwareUpdatableManifests struct {
 	status                *features.SoftwareUpdatableStatus
 	eventsMgr             events.UpdateEventsManager
 	orchMgr               orchestration.UpdateManager
+	mfTemplate            *manifestTemplate
+	mfPolicy              *manifestPolicy
+	helmReleases          *helmReleases
+	mfVariant             string
 	processOperationsLock sync.Mutex
 	statusUpdatesLock     sync.RWMutex
 	cancelEventsHandler   context.CancelFunc
@@ -59,15 +66,20 @@ func (suMf *softwareUpdatableManifests) createFeature() model.Feature {
 	return feature
 }
 
-func newSoftwareUpdatableManifests(rootThing model.Thing, eventsMgr events.UpdateEventsManager, orchMgr orchestration.UpdateManager) managedFeature {
+func newSoftwareUpdatableManifests(rootThing model.Thing, eventsMgr events.UpdateEventsManager, orchMgr orchestration.UpdateManager, mfTemplate *manifestTemplate, mfPolicy *manifestPolicy, helmReleases *helmReleases, mfVariant string) managedFeature {
 	supStatus := &features.SoftwareUpdatableStatus{
-		SoftwareModuleType: updateSoftwareUpdatableManifestsAgentType,
+		SoftwareModuleType:    updateSoftwareUpdatableManifestsAgentType,
+		InstalledDependencies: helmReleases.dependencies(),
 	}
 	return &softwareUpdatableManifests{
-		rootThing: rootThing,
-		status:    supStatus,
-		eventsMgr: eventsMgr,
-		orchMgr:   orchMgr,
+		rootThing:    rootThing,
+		status:       supStatus,
+		eventsMgr:    eventsMgr,
+		orchMgr:      orchMgr,
+		mfTemplate:   mfTemplate,
+		mfPolicy:     mfPolicy,
+		helmReleases: helmReleases,
+		mfVariant:    mfVariant,
 	}
 }
 
@@ -99,6 +111,12 @@ func (suMf *softwareUpdatableManifests) operationsHandler(operationName string,
 			return nil, client.NewMessagesParameterInvalidError(err.Error())
 		}
 		return nil, suMf.install(ua)
+	case softwareUpdatableOperationRemove:
+		ra, err := convertToRemoveAction(args)
+		if err != nil {
+			return nil, client.NewMessagesParameterInvalidError(err.Error())
+		}
+		return nil, suMf.remove(ra)
 	default:
 		err := log.NewErrorf("unsupported operation called [operationId = %s]", operationName)
 		log.ErrorErr(err, "unsupported operation")
@@ -114,6 +132,15 @@ func (suMf *softwareUpdatableManifests) install(updateAction datatypes.UpdateAct
 	return nil
 }
 
+func (suMf *softwareUpdatableManifests) remove(removeAction datatypes.RemoveAction) error {
+	log.Debug("will perform removal...")
+	if err := validateSoftwareRemoveAction(removeAction); err != nil {
+		return client.NewMessagesParameterInvalidError(err.Error())
+	}
+	go suMf.processRemoveAction(removeAction)
+	return nil
+}
+
 func (suMf *softwareUpdatableManifests) updateLastOperation(operationStatus *datatypes.OperationStatus) {
 	suMf.statusUpdatesLock.Lock()
 	defer suMf.statusUpdatesLock.Unlock()
@@ -133,6 +160,18 @@ func (suMf *softwareUpdatableManifests) updateLastFailedOperation(operationStatu
 		log.ErrorErr(err, "error while updating lastFailedOperation property")
 	}
 }
+
+// updateInstalledDependencies reports the installed Helm releases
+func (suMf *softwareUpdatableManifests) updateInstalledDependencies() {
+	suMf.statusUpdatesLock.Lock()
+	defer suMf.statusUpdatesLock.Unlock()
+	suMf.status.InstalledDependencies = suMf.helmReleases.dependencies()
+	err := suMf.rootThing.SetFeatureProperty(SoftwareUpdatableManifestsFeatureID, softwareUpdatablePropertyInstalledDependencies, suMf.status.InstalledDependencies)
+	if err != nil {
+		log.ErrorErr(err, "error while updating installedDependencies property")
+	}
+}
+
 func (suMf *softwareUpdatableManifests) getLastOperation() *datatypes.OperationStatus {
 	suMf.statusUpdatesLock.RLock()
 	defer suMf.statusUpdatesLock.RUnlock()
@@ -186,7 +225,12 @@ func (suMf *softwareUpdatableManifests) installModule(softMod *datatypes.Softwar
 	operationStatus.Status = datatypes.Downloading
 	suMf.updateLastOperation(operationStatus)
 
-	if mf, rejected, installError = getUpdateManifest(softMod.Artifacts[0]); installError != nil {
+	mf, rejected, installError = suMf.getModuleManifest(softMod)
+	if installError == nil {
+		mf, installError = suMf.admitManifest(mf)
+		rejected = installError != nil
+	}
+	if installError != nil {
 		log.ErrorErr(installError, "failed to create update manifest from the provided SoftwareArtifact [FileName] = [%s]", softMod.Artifacts[0].FileName)
 		operationStatus.Message = installError.Error()
 		if rejected {
@@ -194,15 +238,126 @@ func (suMf *softwareUpdatableManifests) installModule(softMod *datatypes.Softwar
 		} else {
 			operationStatus.Status = datatypes.FinishedError
 		}
+		suMf.helmReleases.discard()
+		suMf.updateLastFailedOperation(operationStatus)
+		suMf.updateLastOperation(operationStatus)
+		return
+	}
+
+	// Downloaded and Installing are reported on the orchestration events, once the container images are pulled
+	ctx := suMf.setOperationContext(context.Background())
+	ctx = orchestration.SetUpdateMgrOperationContext(ctx, &orchestration.Operation{
+		CorrelationID:         correlationID,
+		Origin:                SoftwareUpdatableManifestsFeatureID,
+		SoftwareModuleName:    softMod.SoftwareModule.Name,
+		SoftwareModuleVersion: softMod.SoftwareModule.Version,
+	})
+	suMf.orchMgr.Apply(ctx, mf)
+}
+
+// admitManifest renders the desired state - a manifest violating the admission policy or not matching the orchestrator schema is rejected
+func (suMf *softwareUpdatableManifests) admitManifest(mf []*unstructured.Unstructured) ([]*unstructured.Unstructured, error) {
+	rendered, err := suMf.mfTemplate.render(mf)
+	if err != nil {
+		return nil, err
+	}
+	if err := suMf.mfPolicy.admit(rendered); err != nil {
+		return nil, err
+	}
+	if err := validateManifest(context.Background(), suMf.orchMgr, rendered); err != nil {
+		return nil, err
+	}
+	return rendered, nil
+}
+
+// getModuleManifest returns the desired state to be applied for the SoftwareModule.
+// A Helm chart is rendered as a new release revision and applied together with the other installed releases,
+// while a plain manifest or a kustomization replaces the previous one and is applied together with the installed releases.
+func (suMf *softwareUpdatableManifests) getModuleManifest(softMod *datatypes.SoftwareModuleAction) ([]*unstructured.Unstructured, bool, error) {
+	chartArtifact := getHelmChartArtifact(softMod)
+	if chartArtifact == nil {
+		mf, rejected, err := getUpdateManifest(softMod.Artifacts[0], suMf.mfVariant)
+		if err != nil {
+			return nil, rejected, err
+		}
+		return append(mf, helmDesiredState(suMf.helmReleases.installed())...), false, nil
+	}
+	chartArchive, rejected, err := downloadSoftwareArtifact(chartArtifact)
+	if err != nil {
+		return nil, rejected, err
+	}
+	if isKustomizationArchive(chartArchive) {
+		if len(softMod.Artifacts) != 1 {
+			return nil, true, log.NewError("a kustomization archive must be the only SoftwareArtifact of the SoftwareModule")
+		}
+		mf, err := buildKustomization(chartArchive, suMf.mfVariant)
+		if err != nil {
+			// a kustomization that cannot be built is rejected
+			return nil, true, err
+		}
+		return append(mf, helmDesiredState(suMf.helmReleases.installed())...), false, nil
+	}
+	var valuesData []byte
+	if valuesArtifact := getHelmValuesArtifact(softMod); valuesArtifact != nil {
+		if valuesData, rejected, err = downloadSoftwareArtifact(valuesArtifact); err != nil {
+			return nil, rejected, err
+		}
+	}
+	namespace := softMod.MetaData[helmReleaseNamespaceMetadataKey]
+	if namespace == "" {
+		namespace = helmReleaseNamespaceDefault
+	}
+	name := softMod.SoftwareModule.Name
+	release, err := newHelmRelease(name, namespace, suMf.helmReleases.nextRevision(name), chartArchive, valuesData)
+	if err != nil {
+		// a chart that cannot be rendered is rejected
+		return nil, true, err
+	}
+	releases := suMf.helmReleases.with(release)
+	suMf.helmReleases.stage(releases)
+	return helmDesiredState(releases), false, nil
+}
+
+func (suMf *softwareUpdatableManifests) processRemoveAction(removeAction datatypes.RemoveAction) {
+	suMf.processOperationsLock.Lock()
+	defer suMf.processOperationsLock.Unlock()
+
+	names := []string{}
+	for _, software := range removeAction.Software {
+		names = append(names, software.Name)
+	}
+	log.Debug("will perform removal of Helm release(s) %s", strings.Join(names, ", "))
+
+	operationStatus := &datatypes.OperationStatus{
+		Status:        datatypes.Started,
+		CorrelationID: removeAction.CorrelationID,
+		Software:      removeAction.Software,
+	}
+	suMf.updateLastOperation(operationStatus)
+
+	var mf []*unstructured.Unstructured
+	remover, ok := suMf.orchMgr.(orchestration.ResourceRemover)
+	releases, err := suMf.helmReleases.without(names)
+	if err == nil && !ok {
+		err = log.NewError("the orchestrator does not support removing the resources of the Helm releases")
+	}
+	if err == nil {
+		// only the resources of the removed releases are deleted, they are identified by their rendered names
+		mf, err = suMf.mfTemplate.render(suMf.helmReleases.manifest(names))
+	}
+	if err != nil {
+		log.ErrorErr(err, "failed to remove Helm release(s) %s", strings.Join(names, ", "))
+		operationStatus.Message = err.Error()
+		operationStatus.Status = datatypes.FinishedRejected
 		suMf.updateLastFailedOperation(operationStatus)
 		suMf.updateLastOperation(operationStatus)
 		return
 	}
 
-	// Downloaded
-	operationStatus.Status = datatypes.Downloaded
+	operationStatus.Status = datatypes.Removing
 	suMf.updateLastOperation(operationStatus)
 
+	suMf.helmReleases.stage(releases)
 	ctx := suMf.setOperationContext(context.Background())
-	suMf.orchMgr.Apply(ctx, mf)
+	remover.Remove(ctx, mf)
 }
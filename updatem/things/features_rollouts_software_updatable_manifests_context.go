@@ -28,17 +28,23 @@ type suOperationContextValue struct {
 	correlationID         string
 	softwareModuleName    string
 	softwareModuleVersion string
+	// software is set instead of the SoftwareModule for the remove operations
+	software []*datatypes.DependencyDescription
 }
 
 func setSUInstallContext(ctx context.Context, opStatus *datatypes.OperationStatus) context.Context {
 	if ctx == nil {
 		return ctx
 	}
-	return context.WithValue(ctx, contextKeyOperationStatus, &suOperationContextValue{
-		correlationID:         opStatus.CorrelationID,
-		softwareModuleName:    opStatus.SoftwareModule.Name,
-		softwareModuleVersion: opStatus.SoftwareModule.Version,
-	})
+	ctxValue := &suOperationContextValue{
+		correlationID: opStatus.CorrelationID,
+		software:      opStatus.Software,
+	}
+	if opStatus.SoftwareModule != nil {
+		ctxValue.softwareModuleName = opStatus.SoftwareModule.Name
+		ctxValue.softwareModuleVersion = opStatus.SoftwareModule.Version
+	}
+	return context.WithValue(ctx, contextKeyOperationStatus, ctxValue)
 }
 
 func validateSUInstallContext(ctx context.Context, expectedOpStatus *datatypes.OperationStatus) bool {
@@ -62,6 +68,12 @@ func getSUInstallContext(ctx context.Context) *datatypes.OperationStatus {
 	if !ok {
 		return nil
 	}
+	if opStatus.software != nil {
+		return &datatypes.OperationStatus{
+			CorrelationID: opStatus.correlationID,
+			Software:      opStatus.software,
+		}
+	}
 	return &datatypes.OperationStatus{
 		CorrelationID: opStatus.correlationID,
 		SoftwareModule: &datatypes.SoftwareModuleID{
@@ -70,3 +82,8 @@ func getSUInstallContext(ctx context.Context) *datatypes.OperationStatus {
 		},
 	}
 }
+
+// isSURemoveOperation checks if the operation status is of a remove operation
+func isSURemoveOperation(opStatus *datatypes.OperationStatus) bool {
+	return opStatus.SoftwareModule == nil
+}
@@ -33,8 +33,8 @@ func (suMf *softwareUpdatableManifests) handleOrchestrationEvents(ctx context.Co
 				if evt.Type == orchestration.EventTypeOrchestration {
 					if suMf.validateOperationContext(evt.Context) {
 						switch evt.Action {
-						case orchestration.EventActionOrchestrationStarted:
-							suMf.handleEventStarted(evt)
+						case orchestration.EventActionOrchestrationRunning:
+							suMf.handleEventRunning(evt)
 						case orchestration.EventActionOrchestrationFinished:
 							suMf.handleEventFinished(evt)
 						default:
@@ -54,12 +54,79 @@ func (suMf *softwareUpdatableManifests) handleOrchestrationEvents(ctx context.Co
 		}
 		return nil
 	}(subscribeCtx)
+	go suMf.handleSelfUpdateVerification(subscribeCtx)
 }
 
-func (suMf *softwareUpdatableManifests) handleEventStarted(event *events.Event) {
+// handleSelfUpdateVerification reports the final outcome of the SoftwareModule installed with a self update before the last reboot
+func (suMf *softwareUpdatableManifests) handleSelfUpdateVerification(ctx context.Context) {
+	verifier, ok := suMf.orchMgr.(orchestration.SelfUpdateVerifier)
+	if !ok {
+		return
+	}
+	verification := verifier.VerifySelfUpdate(ctx, SoftwareUpdatableManifestsFeatureID)
+	if verification == nil {
+		return
+	}
+	opStatus := &datatypes.OperationStatus{
+		CorrelationID: verification.Operation.CorrelationID,
+		SoftwareModule: &datatypes.SoftwareModuleID{
+			Name:    verification.Operation.SoftwareModuleName,
+			Version: verification.Operation.SoftwareModuleVersion,
+		},
+	}
+	if verification.Err != nil {
+		log.Debug("the self update has failed after the reboot - will update lastFailedOperation property")
+		opStatus.Message = verification.Err.Error()
+		opStatus.Status = datatypes.FinishedError
+		suMf.updateLastFailedOperation(opStatus)
+	} else {
+		log.Debug("the self update has succeeded after the reboot - will update lastOperation property to Installed")
+		opStatus.Status = datatypes.Installed
+		suMf.updateLastOperation(opStatus)
+		opStatus.Status = datatypes.FinishedSuccess
+	}
+	suMf.updateLastOperation(opStatus)
+}
+
+func (suMf *softwareUpdatableManifests) handleEventRunning(event *events.Event) {
+	progress, ok := event.Source.(*orchestration.Progress)
+	if !ok {
+		log.Debug("a running event without progress has been received - will not process it")
+		return
+	}
 	ctxOpStatus := getSUInstallContext(event.Context)
-	ctxOpStatus.Status = datatypes.Installing
-	suMf.updateLastOperation(ctxOpStatus)
+	if isSURemoveOperation(ctxOpStatus) {
+		log.Debug("a running event of a remove operation is not related to SoftwareUpdatable:manifest status reporting")
+		return
+	}
+	switch progress.Phase {
+	case orchestration.ProgressPhaseDownload:
+		// the container images or the self update bundle are being downloaded
+		ctxOpStatus.Status = datatypes.Downloading
+		ctxOpStatus.Progress = progress.Progress
+		ctxOpStatus.Message = progress.Message
+		suMf.updateLastOperation(ctxOpStatus)
+	case orchestration.ProgressPhaseInstall:
+		// all downloads are completed and the manifest is being applied, e.g. the self update bundle is being installed
+		if lastOperation := suMf.getLastOperation(); lastOperation == nil || lastOperation.Status != datatypes.Installing {
+			ctxOpStatus.Status = datatypes.Downloaded
+			suMf.updateLastOperation(ctxOpStatus)
+		}
+		ctxOpStatus.Status = datatypes.Installing
+		ctxOpStatus.Progress = progress.Progress
+		ctxOpStatus.Message = progress.Message
+		suMf.updateLastOperation(ctxOpStatus)
+	case orchestration.ProgressPhaseReboot:
+		// the operation is still installing until the self update is verified after the reboot, while the applied Helm releases are kept
+		if suMf.helmReleases.commit() {
+			suMf.updateInstalledDependencies()
+		}
+		ctxOpStatus.Status = datatypes.Installing
+		ctxOpStatus.Message = progress.Message
+		suMf.updateLastOperation(ctxOpStatus)
+	default:
+		log.Debug("a running event in phase %s is not related to SoftwareUpdatable:manifest status reporting", progress.Phase)
+	}
 }
 
 func (suMf *softwareUpdatableManifests) handleEventFinished(event *events.Event) {
@@ -67,12 +134,26 @@ func (suMf *softwareUpdatableManifests) handleEventFinished(event *events.Event)
 	ctxOpStatus := getSUInstallContext(event.Context)
 	if event.Error != nil {
 		log.Debug("last operation has failed - will update lastFailedOperation property")
+		suMf.helmReleases.discard()
 		ctxOpStatus.Message = event.Error.Error()
 		ctxOpStatus.Status = datatypes.FinishedError
 		suMf.updateLastFailedOperation(ctxOpStatus)
+	} else if applyResult, ok := event.Source.(*orchestration.ApplyResult); ok && applyResult.Downloaded {
+		// nothing is installed until the SoftwareModule is installed again without the download-only self update bundle
+		log.Debug("last operation has only downloaded the self update bundle - will update lastOperation property to Downloaded")
+		suMf.helmReleases.discard()
+		ctxOpStatus.Status = datatypes.Downloaded
 	} else {
-		log.Debug("last operation has succeeded - will update lastOperation property to Installed")
-		ctxOpStatus.Status = datatypes.Installed
+		if suMf.helmReleases.commit() {
+			suMf.updateInstalledDependencies()
+		}
+		if isSURemoveOperation(ctxOpStatus) {
+			log.Debug("last operation has succeeded - will update lastOperation property to Removed")
+			ctxOpStatus.Status = datatypes.Removed
+		} else {
+			log.Debug("last operation has succeeded - will update lastOperation property to Installed")
+			ctxOpStatus.Status = datatypes.Installed
+		}
 		suMf.updateLastOperation(ctxOpStatus)
 		ctxOpStatus.Status = datatypes.FinishedSuccess
 	}
@@ -22,6 +22,7 @@ import (
 	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/events"
 	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/orchestration"
 	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/pkg/testutil"
+	mocksorchmgr "github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/pkg/testutil/mocks/orchestration"
 	"github.com/golang/mock/gomock"
 )
 
@@ -53,7 +54,7 @@ func TestSoftwareUpdatableManifestsHandleEvents(t *testing.T) {
 	setupUpdateManagerMock(controller)
 	setupThingMock(controller)
 
-	testSuMfEvents := newSoftwareUpdatableManifests(mockThing, mockEventsManager, mockUpdateManager)
+	testSuMfEvents := newSoftwareUpdatableManifests(mockThing, mockEventsManager, mockUpdateManager, newManifestTemplate(nil, ""), newManifestPolicy(""), newHelmReleases(""), "")
 	testSuMfInternal := testSuMfEvents.(*softwareUpdatableManifests)
 
 	defer func() {
@@ -88,11 +89,12 @@ func TestSoftwareUpdatableManifestsHandleEvents(t *testing.T) {
 		lastOperation *datatypes.OperationStatus
 		mockExecution mockExec
 	}{
-		"test_things_orchestration_events_started": {
+		"test_things_orchestration_events_running_download": {
 			chanEvent: &events.Event{
 				Type:    orchestration.EventTypeOrchestration,
-				Action:  orchestration.EventActionOrchestrationStarted,
+				Action:  orchestration.EventActionOrchestrationRunning,
 				Context: commonEventContext,
+				Source:  &orchestration.Progress{Phase: orchestration.ProgressPhaseDownload, Progress: 50, Message: "1 of 2 container image(s) pulled"},
 			},
 			lastOperation: commonTestOperationStatus,
 			mockExecution: func(t *testing.T) *sync.WaitGroup {
@@ -103,7 +105,82 @@ func TestSoftwareUpdatableManifestsHandleEvents(t *testing.T) {
 						Name:    testSoftwareModuleName,
 						Version: testSoftwareModuleVersion,
 					},
-					Status: datatypes.Installing,
+					Status:  datatypes.Downloading,
+					Message: "1 of 2 container image(s) pulled",
+				}, wg)
+				return wg
+			},
+		},
+		"test_things_orchestration_events_running_install": {
+			chanEvent: &events.Event{
+				Type:    orchestration.EventTypeOrchestration,
+				Action:  orchestration.EventActionOrchestrationRunning,
+				Context: commonEventContext,
+				Source:  &orchestration.Progress{Phase: orchestration.ProgressPhaseInstall},
+			},
+			lastOperation: commonTestOperationStatus,
+			mockExecution: func(t *testing.T) *sync.WaitGroup {
+				wg := &sync.WaitGroup{}
+				wg.Add(2)
+				gomock.InOrder(
+					mockThing.EXPECT().SetFeatureProperty(SoftwareUpdatableManifestsFeatureID, softwareUpdatablePropertyLastOperation, gomock.Any()).
+						Do(func(fId, propPath string, value *datatypes.OperationStatus) {
+							testutil.AssertEqual(t, datatypes.Downloaded, value.Status)
+							wg.Done()
+						}).Times(1),
+					mockThing.EXPECT().SetFeatureProperty(SoftwareUpdatableManifestsFeatureID, softwareUpdatablePropertyLastOperation, gomock.Any()).
+						Do(func(fId, propPath string, value *datatypes.OperationStatus) {
+							testutil.AssertEqual(t, datatypes.Installing, value.Status)
+							wg.Done()
+						}).Times(1),
+				)
+				return wg
+			},
+		},
+		"test_things_orchestration_events_running_install_progress": {
+			chanEvent: &events.Event{
+				Type:    orchestration.EventTypeOrchestration,
+				Action:  orchestration.EventActionOrchestrationRunning,
+				Context: commonEventContext,
+				Source:  &orchestration.Progress{Phase: orchestration.ProgressPhaseInstall, Progress: 40, Message: "self update bundle is installing"},
+			},
+			lastOperation: &datatypes.OperationStatus{
+				CorrelationID:  testCorrelationID,
+				SoftwareModule: commonTestOperationStatus.SoftwareModule,
+				Status:         datatypes.Installing,
+			},
+			mockExecution: func(t *testing.T) *sync.WaitGroup {
+				wg := &sync.WaitGroup{}
+				wg.Add(1)
+				mockThing.EXPECT().SetFeatureProperty(SoftwareUpdatableManifestsFeatureID, softwareUpdatablePropertyLastOperation, gomock.Any()).
+					Do(func(fId, propPath string, value *datatypes.OperationStatus) {
+						testutil.AssertEqual(t, datatypes.Installing, value.Status)
+						testutil.AssertEqual(t, 40, value.Progress)
+						testutil.AssertEqual(t, "self update bundle is installing", value.Message)
+						wg.Done()
+					}).Times(1)
+				return wg
+			},
+		},
+		"test_things_orchestration_events_running_reboot": {
+			chanEvent: &events.Event{
+				Type:    orchestration.EventTypeOrchestration,
+				Action:  orchestration.EventActionOrchestrationRunning,
+				Context: commonEventContext,
+				Source:  &orchestration.Progress{Phase: orchestration.ProgressPhaseReboot, Message: "rebooting to verify the self update"},
+			},
+			lastOperation: &datatypes.OperationStatus{
+				CorrelationID:  testCorrelationID,
+				SoftwareModule: commonTestOperationStatus.SoftwareModule,
+				Status:         datatypes.Installing,
+			},
+			mockExecution: func(t *testing.T) *sync.WaitGroup {
+				wg := &sync.WaitGroup{}
+				mockPropertyChangedEvent(t, softwareUpdatablePropertyLastOperation, &datatypes.OperationStatus{
+					CorrelationID:  testCorrelationID,
+					SoftwareModule: commonTestOperationStatus.SoftwareModule,
+					Status:         datatypes.Installing,
+					Message:        "rebooting to verify the self update",
 				}, wg)
 				return wg
 			},
@@ -137,6 +214,27 @@ func TestSoftwareUpdatableManifestsHandleEvents(t *testing.T) {
 				return wg
 			},
 		},
+		"test_things_orchestration_events_finished_downloaded": {
+			chanEvent: &events.Event{
+				Type:    orchestration.EventTypeOrchestration,
+				Action:  orchestration.EventActionOrchestrationFinished,
+				Context: commonEventContext,
+				Source:  &orchestration.ApplyResult{Downloaded: true},
+			},
+			lastOperation: commonTestOperationStatus,
+			mockExecution: func(t *testing.T) *sync.WaitGroup {
+				wg := &sync.WaitGroup{}
+				mockPropertyChangedEvent(t, softwareUpdatablePropertyLastOperation, &datatypes.OperationStatus{
+					CorrelationID: testCorrelationID,
+					SoftwareModule: &datatypes.SoftwareModuleID{
+						Name:    testSoftwareModuleName,
+						Version: testSoftwareModuleVersion,
+					},
+					Status: datatypes.Downloaded,
+				}, wg)
+				return wg
+			},
+		},
 		"test_things_orchestration_events_finished_error": {
 			chanEvent: &events.Event{
 				Type:    orchestration.EventTypeOrchestration,
@@ -162,10 +260,23 @@ func TestSoftwareUpdatableManifestsHandleEvents(t *testing.T) {
 			},
 		},
 		"test_things_orchestration_events_irrelevant": {
+			chanEvent: &events.Event{
+				Type:    orchestration.EventTypeOrchestration,
+				Action:  orchestration.EventActionOrchestrationStarted,
+				Context: commonEventContext,
+			},
+			lastOperation: commonTestOperationStatus,
+			mockExecution: func(t *testing.T) *sync.WaitGroup {
+				mockThing.EXPECT().SetFeatureProperty(SoftwareUpdatableManifestsFeatureID, gomock.Any(), gomock.Any()).Times(0)
+				return nil
+			},
+		},
+		"test_things_orchestration_events_running_readiness": {
 			chanEvent: &events.Event{
 				Type:    orchestration.EventTypeOrchestration,
 				Action:  orchestration.EventActionOrchestrationRunning,
 				Context: commonEventContext,
+				Source:  &orchestration.Progress{Phase: orchestration.ProgressPhaseReadiness},
 			},
 			lastOperation: commonTestOperationStatus,
 			mockExecution: func(t *testing.T) *sync.WaitGroup {
@@ -226,3 +337,69 @@ func TestSoftwareUpdatableManifestsHandleEvents(t *testing.T) {
 		})
 	}
 }
+
+type testSelfUpdateVerifierUpdateManager struct {
+	*mocksorchmgr.MockUpdateManager
+	*mocksorchmgr.MockSelfUpdateVerifier
+}
+
+func TestSoftwareUpdatableManifestsSelfUpdateVerification(t *testing.T) {
+	operation := &orchestration.Operation{
+		CorrelationID:         "test-correlation-id",
+		Origin:                SoftwareUpdatableManifestsFeatureID,
+		SoftwareModuleName:    "test-software-module-name",
+		SoftwareModuleVersion: "1.0.0",
+	}
+
+	tests := map[string]struct {
+		verification     *orchestration.SelfUpdateVerification
+		expectedStatuses []datatypes.Status
+		expectedFailed   bool
+	}{
+		"test_self_update_verification_success": {
+			verification:     &orchestration.SelfUpdateVerification{Operation: operation},
+			expectedStatuses: []datatypes.Status{datatypes.Installed, datatypes.FinishedSuccess},
+		},
+		"test_self_update_verification_error": {
+			verification:     &orchestration.SelfUpdateVerification{Operation: operation, Err: log.NewError("boot into new slot failed")},
+			expectedStatuses: []datatypes.Status{datatypes.FinishedError},
+			expectedFailed:   true,
+		},
+		"test_self_update_verification_none": {},
+	}
+
+	for testName, testCase := range tests {
+		t.Run(testName, func(t *testing.T) {
+			t.Log(testName)
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			setupThingMock(controller)
+			testVerifier := mocksorchmgr.NewMockSelfUpdateVerifier(controller)
+			testUpdateManager := &testSelfUpdateVerifierUpdateManager{mocksorchmgr.NewMockUpdateManager(controller), testVerifier}
+			testSuMf := newSoftwareUpdatableManifests(mockThing, nil, testUpdateManager, newManifestTemplate(nil, ""), newManifestPolicy(""), newHelmReleases(""), "").(*softwareUpdatableManifests)
+
+			testVerifier.EXPECT().VerifySelfUpdate(gomock.Any(), SoftwareUpdatableManifestsFeatureID).Return(testCase.verification)
+			assertOperationStatus := func(status datatypes.Status) func(fId, propPath string, value *datatypes.OperationStatus) {
+				return func(fId, propPath string, value *datatypes.OperationStatus) {
+					testutil.AssertEqual(t, operation.CorrelationID, value.CorrelationID)
+					testutil.AssertEqual(t, operation.SoftwareModuleName, value.SoftwareModule.Name)
+					testutil.AssertEqual(t, operation.SoftwareModuleVersion, value.SoftwareModule.Version)
+					testutil.AssertEqual(t, status, value.Status)
+				}
+			}
+			if testCase.expectedFailed {
+				mockThing.EXPECT().SetFeatureProperty(SoftwareUpdatableManifestsFeatureID, softwareUpdatablePropertyLastFailedOperation, gomock.Any()).
+					Do(assertOperationStatus(datatypes.FinishedError))
+			}
+			calls := []*gomock.Call{}
+			for _, status := range testCase.expectedStatuses {
+				calls = append(calls, mockThing.EXPECT().SetFeatureProperty(SoftwareUpdatableManifestsFeatureID, softwareUpdatablePropertyLastOperation, gomock.Any()).
+					Do(assertOperationStatus(status)))
+			}
+			gomock.InOrder(calls...)
+
+			testSuMf.handleSelfUpdateVerification(context.Background())
+		})
+	}
+}
@@ -43,7 +43,7 @@ func TestCreateSUPMfFeature(t *testing.T) {
 	setupThingMock(controller)
 	setupUpdateManagerMock(controller)
 	setupEventsManagerMock(controller)
-	testSuMf = newSoftwareUpdatableManifests(mockThing, mockEventsManager, mockUpdateManager)
+	testSuMf = newSoftwareUpdatableManifests(mockThing, mockEventsManager, mockUpdateManager, newManifestTemplate(nil, ""), newManifestPolicy(""), newHelmReleases(""), "")
 
 	defer func() {
 		controller.Finish()
@@ -161,12 +161,15 @@ spec:
 					func(ctx context.Context, mf []*unstructured.Unstructured) {
 						assertApplyCtx(t, testCorrelationID, testSWModule.Name, testSWModule.Version, ctx)
 						eventChan <- &events.Event{Type: orchestration.EventTypeOrchestration, Action: orchestration.EventActionOrchestrationStarted, Context: ctx}
-						eventChan <- &events.Event{Type: orchestration.EventTypeOrchestration, Action: orchestration.EventActionOrchestrationRunning, Context: ctx}
+						eventChan <- &events.Event{Type: orchestration.EventTypeOrchestration, Action: orchestration.EventActionOrchestrationRunning, Context: ctx,
+							Source: &orchestration.Progress{Phase: orchestration.ProgressPhaseDownload, Progress: 50}}
+						eventChan <- &events.Event{Type: orchestration.EventTypeOrchestration, Action: orchestration.EventActionOrchestrationRunning, Context: ctx,
+							Source: &orchestration.Progress{Phase: orchestration.ProgressPhaseInstall}}
 						eventChan <- &events.Event{Type: orchestration.EventTypeOrchestration, Action: orchestration.EventActionOrchestrationFinished, Context: ctx}
 					}).Times(1)
 
 				wg := &sync.WaitGroup{}
-				wg.Add(3) // Installing, Installed, Finished Success
+				wg.Add(5) // Downloading, Downloaded, Installing, Installed, Finished Success
 				gomock.InOrder(
 					// Started
 					mockThing.EXPECT().SetFeatureProperty(testSUMfFeature.GetID(), softwareUpdatablePropertyLastOperation, gomock.Any()).Do(func(fId, propertyPath string, status *datatypes.OperationStatus) {
@@ -176,9 +179,16 @@ spec:
 					mockThing.EXPECT().SetFeatureProperty(testSUMfFeature.GetID(), softwareUpdatablePropertyLastOperation, gomock.Any()).Do(func(fId, propertyPath string, status *datatypes.OperationStatus) {
 						assertStatesEqual(t, status, datatypes.Downloading)
 					}).Times(1).Return(nil),
+					// Downloading the container images
+					mockThing.EXPECT().SetFeatureProperty(testSUMfFeature.GetID(), softwareUpdatablePropertyLastOperation, gomock.Any()).Do(func(fId, propertyPath string, status *datatypes.OperationStatus) {
+						assertStatesEqual(t, status, datatypes.Downloading)
+						testutil.AssertEqual(t, 50, status.Progress)
+						wg.Done()
+					}).Times(1).Return(nil),
 					// Downloaded
 					mockThing.EXPECT().SetFeatureProperty(testSUMfFeature.GetID(), softwareUpdatablePropertyLastOperation, gomock.Any()).Do(func(fId, propertyPath string, status *datatypes.OperationStatus) {
 						assertStatesEqual(t, status, datatypes.Downloaded)
+						wg.Done()
 					}).Times(1).Return(nil),
 					// Installing
 					mockThing.EXPECT().SetFeatureProperty(testSUMfFeature.GetID(), softwareUpdatablePropertyLastOperation, gomock.Any()).Do(func(fId, propertyPath string, status *datatypes.OperationStatus) {
@@ -361,7 +371,7 @@ spec:
 				mockThing.EXPECT().SetFeature(SoftwareUpdatableManifestsFeatureID, gomock.Any())
 				mockUpdateManager.EXPECT().Apply(gomock.Any(), gomock.Any()).Times(0)
 				mockThing.EXPECT().SetFeatureProperty(testSUMfFeature.GetID(), gomock.Any(), gomock.Any()).Times(0).Return(nil)
-				return nil, client.NewMessagesParameterInvalidError("the number of SoftwareArtifacts referenced for SoftwareModule [Name.version] = [%s.%s] must be exactly 1", testSWModule.Name, testSWModule.Version)
+				return nil, client.NewMessagesParameterInvalidError("the number of SoftwareArtifacts referenced for SoftwareModule [Name.version] = [%s.%s] must be exactly 1, or 2 for a Helm chart archive with its values file", testSWModule.Name, testSWModule.Version)
 			},
 		},
 		"test_su_feature_operations_handler_install_no_modules": {
@@ -409,12 +419,15 @@ spec:
 					func(ctx context.Context, mf []*unstructured.Unstructured) {
 						assertApplyCtx(t, testCorrelationID, testSWModule.Name, testSWModule.Version, ctx)
 						eventChan <- &events.Event{Type: orchestration.EventTypeOrchestration, Action: orchestration.EventActionOrchestrationStarted, Context: ctx}
-						eventChan <- &events.Event{Type: orchestration.EventTypeOrchestration, Action: orchestration.EventActionOrchestrationRunning, Context: ctx}
+						eventChan <- &events.Event{Type: orchestration.EventTypeOrchestration, Action: orchestration.EventActionOrchestrationRunning, Context: ctx,
+							Source: &orchestration.Progress{Phase: orchestration.ProgressPhaseDownload, Progress: 50}}
+						eventChan <- &events.Event{Type: orchestration.EventTypeOrchestration, Action: orchestration.EventActionOrchestrationRunning, Context: ctx,
+							Source: &orchestration.Progress{Phase: orchestration.ProgressPhaseInstall}}
 						eventChan <- &events.Event{Type: orchestration.EventTypeOrchestration, Action: orchestration.EventActionOrchestrationFinished, Context: ctx, Error: log.NewError("test error")}
 					}).Times(1)
 
 				wg := &sync.WaitGroup{}
-				wg.Add(3) // Installing, Finished Error - last operation, last failed operation
+				wg.Add(5) // Downloading, Downloaded, Installing, Finished Error - last operation, last failed operation
 				gomock.InOrder(
 					// Started
 					mockThing.EXPECT().SetFeatureProperty(testSUMfFeature.GetID(), softwareUpdatablePropertyLastOperation, gomock.Any()).Do(func(fId, propertyPath string, status *datatypes.OperationStatus) {
@@ -424,9 +437,16 @@ spec:
 					mockThing.EXPECT().SetFeatureProperty(testSUMfFeature.GetID(), softwareUpdatablePropertyLastOperation, gomock.Any()).Do(func(fId, propertyPath string, status *datatypes.OperationStatus) {
 						assertStatesEqual(t, status, datatypes.Downloading)
 					}).Times(1).Return(nil),
+					// Downloading the container images
+					mockThing.EXPECT().SetFeatureProperty(testSUMfFeature.GetID(), softwareUpdatablePropertyLastOperation, gomock.Any()).Do(func(fId, propertyPath string, status *datatypes.OperationStatus) {
+						assertStatesEqual(t, status, datatypes.Downloading)
+						testutil.AssertEqual(t, 50, status.Progress)
+						wg.Done()
+					}).Times(1).Return(nil),
 					// Downloaded
 					mockThing.EXPECT().SetFeatureProperty(testSUMfFeature.GetID(), softwareUpdatablePropertyLastOperation, gomock.Any()).Do(func(fId, propertyPath string, status *datatypes.OperationStatus) {
 						assertStatesEqual(t, status, datatypes.Downloaded)
+						wg.Done()
 					}).Times(1).Return(nil),
 					// Installing
 					mockThing.EXPECT().SetFeatureProperty(testSUMfFeature.GetID(), softwareUpdatablePropertyLastOperation, gomock.Any()).Do(func(fId, propertyPath string, status *datatypes.OperationStatus) {
@@ -475,7 +495,7 @@ spec:
 			setupUpdateManagerMock(controller)
 			setupEventsManagerMock(controller)
 
-			testSuMf = newSoftwareUpdatableManifests(mockThing, mockEventsManager, mockUpdateManager)
+			testSuMf = newSoftwareUpdatableManifests(mockThing, mockEventsManager, mockUpdateManager, newManifestTemplate(nil, ""), newManifestPolicy(""), newHelmReleases(""), "")
 			testSUMfFeature = testSuMf.(*softwareUpdatableManifests).createFeature()
 
 			defer func() {
@@ -39,24 +39,53 @@ func convertToUpdateAction(args interface{}) (datatypes.UpdateAction, error) {
 	return uas, err
 }
 
-func getUpdateManifest(saa *datatypes.SoftwareArtifactAction) ([]*unstructured.Unstructured, bool, error) {
+func convertToRemoveAction(args interface{}) (datatypes.RemoveAction, error) {
+	bytes, err := json.Marshal(args)
+	if err != nil {
+		return datatypes.RemoveAction{}, err
+	}
+	var ra datatypes.RemoveAction
+	err = json.Unmarshal(bytes, &ra)
+	return ra, err
+}
+
+func getUpdateManifest(saa *datatypes.SoftwareArtifactAction, variant string) ([]*unstructured.Unstructured, bool, error) {
+	mfBytes, rejected, err := downloadSoftwareArtifact(saa)
+	if err != nil {
+		return nil, rejected, err
+	}
+	manifest, err := parseUpdateManifest(mfBytes, variant)
+	// a kustomization archive that cannot be built is rejected
+	return manifest, err != nil && isTarball(mfBytes), err
+}
+
+// parseUpdateManifest parses a multi-document YAML artifact or builds a kustomization archive for the vehicle variant
+func parseUpdateManifest(mfBytes []byte, variant string) ([]*unstructured.Unstructured, error) {
+	if isTarball(mfBytes) {
+		return buildKustomization(mfBytes, variant)
+	}
+	_, manifest, err := parseMultiYAML(mfBytes)
+	return manifest, err
+}
+
+// downloadSoftwareArtifact downloads the artifact and verifies its checksum - a checksum mismatch rejects the artifact
+func downloadSoftwareArtifact(saa *datatypes.SoftwareArtifactAction) ([]byte, bool, error) {
 	downloadURL := saa.Download[datatypes.HTTP]
 
 	if downloadURL == nil {
 		downloadURL = saa.Download[datatypes.HTTPS]
 	}
 
-	mfBytes, err := downloadManifestDescription(downloadURL.URL)
+	data, err := downloadManifestDescription(downloadURL.URL)
 	if err != nil {
 		return nil, false, err
 	}
 
-	if err = validateSoftareArtifactHash(mfBytes, saa.Checksums); err != nil {
+	if err = validateSoftareArtifactHash(data, saa.Checksums); err != nil {
 		// status should be FinishedRejected
 		return nil, true, err
 	}
-	_, manifest, err := parseMultiYAML(mfBytes)
-	return manifest, false, err
+	return data, false, nil
 }
 
 func parseMultiYAML(multiYamlData []byte) ([][]byte, []*unstructured.Unstructured, error) {
@@ -200,8 +229,25 @@ func validateSoftwareUpdateActionManifests(updateAction datatypes.UpdateAction)
 	if len(updateAction.SoftwareModules) != 1 {
 		return log.NewError("the number of SoftwareModules must be exactly 1")
 	}
-	if len(updateAction.SoftwareModules[0].Artifacts) != 1 {
-		return log.NewErrorf("the number of SoftwareArtifacts referenced for SoftwareModule [Name.version] = [%s.%s] must be exactly 1", updateAction.SoftwareModules[0].SoftwareModule.Name, updateAction.SoftwareModules[0].SoftwareModule.Version)
+	softMod := updateAction.SoftwareModules[0]
+	if len(softMod.Artifacts) == 2 && getHelmChartArtifact(softMod) != nil {
+		// a Helm chart archive may be accompanied by its values file
+		return nil
+	}
+	if len(softMod.Artifacts) != 1 {
+		return log.NewErrorf("the number of SoftwareArtifacts referenced for SoftwareModule [Name.version] = [%s.%s] must be exactly 1, or 2 for a Helm chart archive with its values file", softMod.SoftwareModule.Name, softMod.SoftwareModule.Version)
+	}
+	return nil
+}
+
+func validateSoftwareRemoveAction(removeAction datatypes.RemoveAction) error {
+	if len(removeAction.Software) == 0 {
+		return log.NewError("at least one software to be removed must be provided")
+	}
+	for _, software := range removeAction.Software {
+		if software == nil || software.Name == "" {
+			return log.NewError("the name of the software to be removed must be provided")
+		}
 	}
 	return nil
 }
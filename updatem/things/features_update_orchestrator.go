@@ -30,7 +30,10 @@ const (
 	updateOrchestratorFeaturePropertyStatus             = "status"
 	updateOrchestratorFeaturePropertyStatusState        = updateOrchestratorFeaturePropertyStatus + "/state"
 	updateOrchestratorFeaturePropertyStatusCurrentState = updateOrchestratorFeaturePropertyStatus + "/currentState"
+	updateOrchestratorFeaturePropertyStatusPlan         = updateOrchestratorFeaturePropertyStatus + "/plan"
 	updateOrchestratorFeatureOperationApply             = "apply"
+	updateOrchestratorFeatureOperationPlan              = "plan"
+	updateOrchestratorFeatureOperationCancel            = "cancel"
 )
 
 var (
@@ -40,12 +43,15 @@ var (
 type updateOrchestratorFeatureStatus struct {
 	State        *manifestState               `json:"state"`
 	CurrentState []*unstructured.Unstructured `json:"currentState"`
+	Plan         *manifestPlan                `json:"plan,omitempty"`
 }
 
 type updateOrchestratorFeature struct {
 	status                *updateOrchestratorFeatureStatus
 	orchMgr               orchestration.UpdateManager
 	eventsMgr             events.UpdateEventsManager
+	mfTemplate            *manifestTemplate
+	mfPolicy              *manifestPolicy
 	rootThing             model.Thing
 	cancelEventsHandler   context.CancelFunc
 	eventsHandlingLock    sync.Mutex
@@ -54,11 +60,13 @@ type updateOrchestratorFeature struct {
 	currentStateTimer     *time.Timer
 }
 
-func newUpdateOrchestratorFeature(rootThing model.Thing, eventsMgr events.UpdateEventsManager, orchMgr orchestration.UpdateManager) managedFeature {
+func newUpdateOrchestratorFeature(rootThing model.Thing, eventsMgr events.UpdateEventsManager, orchMgr orchestration.UpdateManager, mfTemplate *manifestTemplate, mfPolicy *manifestPolicy) managedFeature {
 	return &updateOrchestratorFeature{
-		rootThing: rootThing,
-		orchMgr:   orchMgr,
-		eventsMgr: eventsMgr,
+		rootThing:  rootThing,
+		orchMgr:    orchMgr,
+		eventsMgr:  eventsMgr,
+		mfTemplate: mfTemplate,
+		mfPolicy:   mfPolicy,
 	}
 }
 
@@ -86,36 +94,60 @@ func (updOrchFeature *updateOrchestratorFeature) dispose() {
 
 func (updOrchFeature *updateOrchestratorFeature) featureOperationsHandler(operationName string, args interface{}) (interface{}, error) {
 	ctx := context.Background()
-	if operationName == updateOrchestratorFeatureOperationApply {
+	switch operationName {
+	case updateOrchestratorFeatureOperationApply:
 		log.Debug("received orchestrator manifest apply command")
-		var ok bool
-		var argsMap map[string]interface{}
-		if argsMap, ok = args.(map[string]interface{}); !ok {
-			return nil, client.NewMessagesParameterInvalidError("the parameter is not JSON object")
-		}
-		var correlationID string
-		if correlationID, ok = argsMap["correlationId"].(string); !ok {
-			return nil, client.NewMessagesParameterInvalidError("the correlation id is not string")
-		}
-		if correlationID == "" {
-			return nil, client.NewMessagesParameterInvalidError("missing correlation id")
-		}
-		var yamlContent string
-		if yamlContent, ok = argsMap["payload"].(string); !ok {
-			return nil, client.NewMessagesParameterInvalidError("the YAML content is not string")
-		}
-		_, manifest, err := parseMultiYAML([]byte(yamlContent))
+		correlationID, manifest, err := parseManifestOperationArgs(args)
 		if err != nil {
-			return nil, client.NewMessagesParameterInvalidError(err.Error())
+			return nil, err
 		}
 		ctx := setApplyCorrelationIDContext(ctx, correlationID)
+		ctx = orchestration.SetUpdateMgrOperationContext(ctx, &orchestration.Operation{
+			CorrelationID: correlationID,
+			Origin:        UpdateOrchestratorFeatureID,
+		})
 		return nil, updOrchFeature.apply(ctx, manifest)
+	case updateOrchestratorFeatureOperationPlan:
+		log.Debug("received orchestrator manifest plan command")
+		correlationID, manifest, err := parseManifestOperationArgs(args)
+		if err != nil {
+			return nil, err
+		}
+		ctx := setApplyCorrelationIDContext(ctx, correlationID)
+		return nil, updOrchFeature.plan(ctx, manifest)
+	case updateOrchestratorFeatureOperationCancel:
+		log.Debug("received orchestrator cancel command")
+		return nil, updOrchFeature.cancel(ctx)
 	}
 	err := log.NewErrorf("unsupported operation %s", operationName)
 	log.ErrorErr(err, "unsupported operation %s", operationName)
 	return nil, client.NewMessagesSubjectNotFound(err.Error())
 }
 
+func parseManifestOperationArgs(args interface{}) (string, []*unstructured.Unstructured, error) {
+	var ok bool
+	var argsMap map[string]interface{}
+	if argsMap, ok = args.(map[string]interface{}); !ok {
+		return "", nil, client.NewMessagesParameterInvalidError("the parameter is not JSON object")
+	}
+	var correlationID string
+	if correlationID, ok = argsMap["correlationId"].(string); !ok {
+		return "", nil, client.NewMessagesParameterInvalidError("the correlation id is not string")
+	}
+	if correlationID == "" {
+		return "", nil, client.NewMessagesParameterInvalidError("missing correlation id")
+	}
+	var yamlContent string
+	if yamlContent, ok = argsMap["payload"].(string); !ok {
+		return "", nil, client.NewMessagesParameterInvalidError("the YAML content is not string")
+	}
+	_, manifest, err := parseMultiYAML([]byte(yamlContent))
+	if err != nil {
+		return "", nil, client.NewMessagesParameterInvalidError(err.Error())
+	}
+	return correlationID, manifest, nil
+}
+
 func (updOrchFeature *updateOrchestratorFeature) apply(ctx context.Context, mf []*unstructured.Unstructured) error {
 	go updOrchFeature.processApply(ctx, mf)
 	return nil
@@ -126,10 +158,69 @@ func (updOrchFeature *updateOrchestratorFeature) processApply(ctx context.Contex
 	defer updOrchFeature.processOperationsLock.Unlock()
 
 	log.Debug("processing apply manifest command")
-	updOrchFeature.orchMgr.Apply(ctx, mf)
+	rendered, err := updOrchFeature.admitManifest(ctx, mf)
+	if err != nil {
+		log.ErrorErr(err, "rejecting the manifest")
+		updOrchFeature.rejectState(mf, err, getApplyCorrelationIDContext(ctx))
+		return
+	}
+	updOrchFeature.orchMgr.Apply(ctx, rendered)
 	log.Debug("processing apply manifest command - done")
 }
 
+func (updOrchFeature *updateOrchestratorFeature) plan(ctx context.Context, mf []*unstructured.Unstructured) error {
+	go updOrchFeature.processPlan(ctx, mf)
+	return nil
+}
+
+func (updOrchFeature *updateOrchestratorFeature) processPlan(ctx context.Context, mf []*unstructured.Unstructured) {
+	updOrchFeature.processOperationsLock.Lock()
+	defer updOrchFeature.processOperationsLock.Unlock()
+
+	log.Debug("processing plan manifest command")
+	rendered, err := updOrchFeature.admitManifest(ctx, mf)
+	if err != nil {
+		log.ErrorErr(err, "rejecting the manifest plan")
+		updOrchFeature.updatePlan(&manifestPlan{
+			Manifest:      mf,
+			Status:        manifestStatusFinishedRejected,
+			Error:         &manifestError{Code: 400, Message: err.Error()},
+			CorrelationID: getApplyCorrelationIDContext(ctx),
+		})
+		return
+	}
+	updOrchFeature.orchMgr.Apply(orchestration.SetUpdateMgrDryRunContext(ctx), rendered)
+	log.Debug("processing plan manifest command - done")
+}
+
+// cancel is not guarded by the operations lock, as it has to interrupt the apply operation in progress
+func (updOrchFeature *updateOrchestratorFeature) cancel(ctx context.Context) error {
+	canceler, ok := updOrchFeature.orchMgr.(orchestration.Canceler)
+	if !ok {
+		return client.NewMessagesSubjectNotFound("the update manager does not support cancellation")
+	}
+	if err := canceler.Cancel(ctx); err != nil {
+		log.ErrorErr(err, "cannot cancel the update operation in progress")
+		return client.NewMessagesInternalError(err.Error())
+	}
+	return nil
+}
+
+// admitManifest renders the manifest and checks it against the admission policy and the orchestrator schema - an error rejects the manifest
+func (updOrchFeature *updateOrchestratorFeature) admitManifest(ctx context.Context, mf []*unstructured.Unstructured) ([]*unstructured.Unstructured, error) {
+	rendered, err := updOrchFeature.mfTemplate.render(mf)
+	if err != nil {
+		return nil, err
+	}
+	if err := updOrchFeature.mfPolicy.admit(rendered); err != nil {
+		return nil, err
+	}
+	if err := validateManifest(ctx, updOrchFeature.orchMgr, rendered); err != nil {
+		return nil, err
+	}
+	return rendered, nil
+}
+
 func (updOrchFeature *updateOrchestratorFeature) createFeature() model.Feature {
 	return client.NewFeature(UpdateOrchestratorFeatureID,
 		client.WithFeatureProperty(updateOrchestratorFeaturePropertyStatus, updOrchFeature.status),
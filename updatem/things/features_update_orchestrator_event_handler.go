@@ -48,9 +48,39 @@ func (updOrchFeature *updateOrchestratorFeature) handleEvents(ctx context.Contex
 		}
 		return nil
 	}(subscribeCtx)
+	go updOrchFeature.handleSelfUpdateVerification(subscribeCtx)
+}
+
+// handleSelfUpdateVerification reports the final outcome of the self update applied via the feature before the last reboot
+func (updOrchFeature *updateOrchestratorFeature) handleSelfUpdateVerification(ctx context.Context) {
+	verifier, ok := updOrchFeature.orchMgr.(orchestration.SelfUpdateVerifier)
+	if !ok {
+		return
+	}
+	verification := verifier.VerifySelfUpdate(ctx, UpdateOrchestratorFeatureID)
+	if verification == nil {
+		return
+	}
+	updOrchFeature.eventsHandlingLock.Lock()
+	defer updOrchFeature.eventsHandlingLock.Unlock()
+	updOrchFeature.updateState(verification.Manifest)
+	updOrchFeature.updateResources(verification.Resources)
+	if verification.Err != nil {
+		updOrchFeature.updateStatus(manifestStatusFinishedError, &manifestError{
+			Code:    500,
+			Message: verification.Err.Error(),
+		}, verification.Operation.CorrelationID)
+	} else {
+		updOrchFeature.updateStatus(manifestStatusFinishedSuccess, nil, verification.Operation.CorrelationID)
+	}
+	updOrchFeature.updateCurrentState(ctx)
 }
 
 func (updOrchFeature *updateOrchestratorFeature) handleOrchestrationEvent(evt *events.Event) {
+	if orchestration.IsUpdateMgrDryRunContext(evt.Context) {
+		updOrchFeature.handlePlanEvent(evt)
+		return
+	}
 	switch evt.Action {
 	case orchestration.EventActionOrchestrationStarted:
 		updOrchFeature.handleOrchestrationStartedEvent(evt)
@@ -58,6 +88,14 @@ func (updOrchFeature *updateOrchestratorFeature) handleOrchestrationEvent(evt *e
 		updOrchFeature.handleOrchestrationRunningEvent(evt)
 	case orchestration.EventActionOrchestrationFinished:
 		updOrchFeature.handleOrchestrationFinishedEvent(evt)
+	case orchestration.EventActionOrchestrationRollbackStarted:
+		updOrchFeature.handleOrchestrationRollbackStartedEvent(evt)
+	case orchestration.EventActionOrchestrationRollbackFinished:
+		updOrchFeature.handleOrchestrationRollbackFinishedEvent(evt)
+	case orchestration.EventActionOrchestrationReconcileStarted:
+		updOrchFeature.handleOrchestrationReconcileStartedEvent(evt)
+	case orchestration.EventActionOrchestrationReconcileFinished:
+		updOrchFeature.handleOrchestrationReconcileFinishedEvent(evt)
 	default:
 		log.Debug("event received that does not affect the UpdateOrchestrator feature")
 	}
@@ -78,7 +116,12 @@ func (updOrchFeature *updateOrchestratorFeature) handleOrchestrationStartedEvent
 	updOrchFeature.eventsHandlingLock.Lock()
 	defer updOrchFeature.eventsHandlingLock.Unlock()
 	correlationID := getApplyCorrelationIDContext(event.Context)
-	updOrchFeature.updateState(orchestration.GetUpdateMgrApplyContext(event.Context))
+	if orchestration.IsUpdateMgrRemoveContext(event.Context) {
+		// the removed resources are dropped from the applied manifest, the rest of it is left unchanged
+		updOrchFeature.updateState(orchestration.WithoutResources(updOrchFeature.getStateManifest(), orchestration.GetUpdateMgrApplyContext(event.Context)))
+	} else {
+		updOrchFeature.updateState(orchestration.GetUpdateMgrApplyContext(event.Context))
+	}
 	updOrchFeature.updateStatus(manifestStatusStarted, nil, correlationID)
 }
 
@@ -86,22 +129,114 @@ func (updOrchFeature *updateOrchestratorFeature) handleOrchestrationRunningEvent
 	updOrchFeature.eventsHandlingLock.Lock()
 	defer updOrchFeature.eventsHandlingLock.Unlock()
 	correlationID := getApplyCorrelationIDContext(event.Context)
+	if progress, ok := event.Source.(*orchestration.Progress); ok {
+		updOrchFeature.updateProgress(progress)
+	}
 	updOrchFeature.updateStatus(manifestStatusRunning, nil, correlationID)
 }
+
 func (updOrchFeature *updateOrchestratorFeature) handleOrchestrationFinishedEvent(event *events.Event) {
 	updOrchFeature.eventsHandlingLock.Lock()
 	defer updOrchFeature.eventsHandlingLock.Unlock()
 	correlationID := getApplyCorrelationIDContext(event.Context)
+	var diagnostics []*orchestration.ResourceDiagnostics
+	if applyResult, ok := event.Source.(*orchestration.ApplyResult); ok {
+		updOrchFeature.updateResources(applyResult.Resources)
+		diagnostics = applyResult.Diagnostics
+	}
+	updOrchFeature.updateProgress(nil)
 	if event.Error != nil {
 		updOrchFeature.updateStatus(manifestStatusFinishedError, &manifestError{
-			Code:    500,
-			Message: event.Error.Error(),
+			Code:        500,
+			Message:     event.Error.Error(),
+			Diagnostics: diagnostics,
 		}, correlationID)
 	} else {
 		updOrchFeature.updateStatus(manifestStatusFinishedSuccess, nil, correlationID)
 	}
 	updOrchFeature.updateCurrentState(event.Context)
 }
+
+func (updOrchFeature *updateOrchestratorFeature) handleOrchestrationRollbackStartedEvent(event *events.Event) {
+	updOrchFeature.eventsHandlingLock.Lock()
+	defer updOrchFeature.eventsHandlingLock.Unlock()
+	updOrchFeature.updateRollbackStatus(manifestStatusStarted, nil, nil)
+}
+
+func (updOrchFeature *updateOrchestratorFeature) handleOrchestrationRollbackFinishedEvent(event *events.Event) {
+	updOrchFeature.eventsHandlingLock.Lock()
+	defer updOrchFeature.eventsHandlingLock.Unlock()
+	var resources []*orchestration.ResourceResult
+	if rollbackResult, ok := event.Source.(*orchestration.RollbackResult); ok {
+		resources = rollbackResult.Resources
+	}
+	if event.Error != nil {
+		updOrchFeature.updateRollbackStatus(manifestStatusFinishedError, &manifestError{
+			Code:    500,
+			Message: event.Error.Error(),
+		}, resources)
+	} else {
+		updOrchFeature.updateRollbackStatus(manifestStatusFinishedSuccess, nil, resources)
+	}
+}
+
+func (updOrchFeature *updateOrchestratorFeature) handleOrchestrationReconcileStartedEvent(event *events.Event) {
+	updOrchFeature.eventsHandlingLock.Lock()
+	defer updOrchFeature.eventsHandlingLock.Unlock()
+	updOrchFeature.updateReconcileStatus(manifestStatusStarted, nil, nil)
+}
+
+func (updOrchFeature *updateOrchestratorFeature) handleOrchestrationReconcileFinishedEvent(event *events.Event) {
+	updOrchFeature.eventsHandlingLock.Lock()
+	defer updOrchFeature.eventsHandlingLock.Unlock()
+	var resources []*orchestration.ResourceResult
+	if reconcileResult, ok := event.Source.(*orchestration.ReconcileResult); ok {
+		resources = reconcileResult.Resources
+	}
+	if event.Error != nil {
+		updOrchFeature.updateReconcileStatus(manifestStatusFinishedError, &manifestError{
+			Code:    500,
+			Message: event.Error.Error(),
+		}, resources)
+	} else {
+		updOrchFeature.updateReconcileStatus(manifestStatusFinishedSuccess, nil, resources)
+	}
+	updOrchFeature.updateCurrentState(event.Context)
+	updOrchFeature.updateDrift(event.Context)
+}
+
+func (updOrchFeature *updateOrchestratorFeature) handlePlanEvent(event *events.Event) {
+	updOrchFeature.eventsHandlingLock.Lock()
+	defer updOrchFeature.eventsHandlingLock.Unlock()
+	plan := &manifestPlan{
+		Manifest:      orchestration.GetUpdateMgrApplyContext(event.Context),
+		CorrelationID: getApplyCorrelationIDContext(event.Context),
+	}
+	switch event.Action {
+	case orchestration.EventActionOrchestrationStarted:
+		plan.Status = manifestStatusStarted
+	case orchestration.EventActionOrchestrationRunning:
+		plan.Status = manifestStatusRunning
+	case orchestration.EventActionOrchestrationFinished:
+		if applyResult, ok := event.Source.(*orchestration.ApplyResult); ok {
+			plan.Resources = applyResult.Resources
+		}
+		if event.Error != nil {
+			plan.Status = manifestStatusFinishedError
+			plan.Error = &manifestError{
+				Code:    500,
+				Message: event.Error.Error(),
+			}
+		} else {
+			plan.Status = manifestStatusFinishedSuccess
+		}
+	default:
+		log.Debug("plan event received that does not affect the UpdateOrchestrator feature")
+		return
+	}
+	updOrchFeature.updatePlan(plan)
+}
+
 func (updOrchFeature *updateOrchestratorFeature) handleResourceEvent(event *events.Event) {
 	updOrchFeature.eventsHandlingLock.Lock()
 	defer updOrchFeature.eventsHandlingLock.Unlock()
@@ -121,9 +256,11 @@ func (updOrchFeature *updateOrchestratorFeature) handleResourceEvent(event *even
 	}
 	if flagUpdateState {
 		updOrchFeature.updateCurrentState(event.Context)
+		updOrchFeature.updateDrift(event.Context)
 		return
 	}
 	updOrchFeature.currentStateTimer = time.AfterFunc(defaultCurrentStateDelay, func() {
 		updOrchFeature.updateCurrentState(event.Context)
+		updOrchFeature.updateDrift(event.Context)
 	})
 }
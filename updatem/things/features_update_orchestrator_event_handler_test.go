@@ -21,6 +21,7 @@ import (
 	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/events"
 	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/orchestration"
 	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/pkg/testutil"
+	mocksorchmgr "github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/pkg/testutil/mocks/orchestration"
 	"github.com/golang/mock/gomock"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
@@ -34,7 +35,7 @@ func TestUpdateOrchestratorHandleOrchestrationEvents(t *testing.T) {
 	)
 	controller := setUpMocks(t)
 
-	testCtrOrchestrator := newUpdateOrchestratorFeature(mockThing, mockEventsManager, mockUpdateManager)
+	testCtrOrchestrator := newUpdateOrchestratorFeature(mockThing, mockEventsManager, mockUpdateManager, newManifestTemplate(nil, ""), newManifestPolicy(""))
 	testManifest := getTestManifest()
 	testStatus := &updateOrchestratorFeatureStatus{State: &manifestState{
 		Manifest: testManifest,
@@ -54,11 +55,12 @@ func TestUpdateOrchestratorHandleOrchestrationEvents(t *testing.T) {
 		testCtrOrchestrator.dispose()
 		controller.Finish()
 	}()
-	eventChan := make(chan *events.Event, 1)
+	// the events channel is not buffered, so that sending an irrelevant event waits for the previous event to be handled
+	eventChan := make(chan *events.Event)
 	errorChan := make(chan error, 1)
 
 	mockEventsManager.EXPECT().Subscribe(gomock.Any()).Times(1).Return(eventChan, errorChan)
-	mockUpdateManager.EXPECT().Get(gomock.Any()).Return(nil).Times(2)
+	mockUpdateManager.EXPECT().Get(gomock.Any()).Return(nil).Times(3)
 	testCtrOrchestrator.(*updateOrchestratorFeature).handleEvents(context.Background())
 
 	tests := map[string]struct {
@@ -90,6 +92,7 @@ func TestUpdateOrchestratorHandleOrchestrationEvents(t *testing.T) {
 				Type:    orchestration.EventTypeOrchestration,
 				Action:  orchestration.EventActionOrchestrationRunning,
 				Context: orchestration.SetUpdateMgrApplyContext(context.Background(), testStatus.State.Manifest),
+				Source:  &orchestration.Progress{Phase: orchestration.ProgressPhaseInstall, Progress: 40, Message: "self update bundle is installing"},
 			},
 			mockExecution: func(t *testing.T, evt *events.Event, testWg *sync.WaitGroup) {
 				testWg.Add(1)
@@ -98,6 +101,7 @@ func TestUpdateOrchestratorHandleOrchestrationEvents(t *testing.T) {
 					func(id, path string, state *manifestState) {
 						testutil.AssertEqual(t, testCorrelationID, state.CorrelationID)
 						assertStatesEqual(t, testManifest, manifestStatusRunning, state)
+						testutil.AssertEqual(t, evt.Source, state.Progress)
 						testWg.Done()
 					})
 			},
@@ -116,6 +120,7 @@ func TestUpdateOrchestratorHandleOrchestrationEvents(t *testing.T) {
 					func(id, path string, state *manifestState) {
 						testutil.AssertEqual(t, testCorrelationID, state.CorrelationID)
 						assertStatesEqual(t, testManifest, manifestStatusFinishedSuccess, state)
+						testutil.AssertNil(t, state.Progress)
 						testWg.Done()
 					})
 				mockThing.EXPECT().SetFeatureProperty(UpdateOrchestratorFeatureID, updateOrchestratorFeaturePropertyStatusCurrentState, gomock.Any()).Do(
@@ -132,6 +137,20 @@ func TestUpdateOrchestratorHandleOrchestrationEvents(t *testing.T) {
 				Action:  orchestration.EventActionOrchestrationFinished,
 				Context: orchestration.SetUpdateMgrApplyContext(context.Background(), testManifest),
 				Error:   log.NewError("test error"),
+				Source: &orchestration.ApplyResult{
+					Resources: []*orchestration.ResourceResult{
+						{APIVersion: "v1", Kind: "ConfigMap", Name: "test-config", Outcome: orchestration.ResourceOutcomeCreated},
+						{APIVersion: "v1", Kind: "Pod", Name: "test-pod", Outcome: orchestration.ResourceOutcomeFailed, Message: "test error"},
+					},
+					Diagnostics: []*orchestration.ResourceDiagnostics{{
+						APIVersion: "v1",
+						Kind:       "Pod",
+						Namespace:  "default",
+						Name:       "test-pod",
+						Events:     []*orchestration.DiagnosticsEvent{{Object: "pod/test-pod", Reason: "BackOff", Message: "Back-off restarting failed container"}},
+						Containers: []*orchestration.ContainerDiagnostics{{Pod: "test-pod", Container: "app", State: "waiting: CrashLoopBackOff", Logs: []string{"test error"}}},
+					}},
+				},
 			},
 			mockExecution: func(t *testing.T, evt *events.Event, testWg *sync.WaitGroup) {
 				testWg.Add(2)
@@ -140,6 +159,76 @@ func TestUpdateOrchestratorHandleOrchestrationEvents(t *testing.T) {
 					func(id, path string, state *manifestState) {
 						testutil.AssertEqual(t, testCorrelationID, state.CorrelationID)
 						assertStatesEqual(t, testManifest, manifestStatusFinishedError, state)
+						testutil.AssertEqual(t, evt.Source.(*orchestration.ApplyResult).Resources, state.Resources)
+						testutil.AssertEqual(t, evt.Source.(*orchestration.ApplyResult).Diagnostics, state.Error.Diagnostics)
+						testWg.Done()
+					})
+				mockThing.EXPECT().SetFeatureProperty(UpdateOrchestratorFeatureID, updateOrchestratorFeaturePropertyStatusCurrentState, gomock.Any()).Do(
+					func(id, path string, unstructured []*unstructured.Unstructured) {
+						testutil.AssertNil(t, unstructured)
+						testWg.Done()
+					})
+			},
+		},
+		"test_things_orchestration_rollback_started": {
+			stat: testStatus,
+			chanEvent: &events.Event{
+				Type:    orchestration.EventTypeOrchestration,
+				Action:  orchestration.EventActionOrchestrationRollbackStarted,
+				Context: orchestration.SetUpdateMgrApplyContext(context.Background(), testManifest),
+			},
+			mockExecution: func(t *testing.T, evt *events.Event, testWg *sync.WaitGroup) {
+				testWg.Add(1)
+				mockThing.EXPECT().SetFeatureProperty(UpdateOrchestratorFeatureID, updateOrchestratorFeaturePropertyStatusState, gomock.Any()).Do(
+					func(id, path string, state *manifestState) {
+						testutil.AssertEqual(t, &manifestRollback{Status: manifestStatusStarted}, state.Rollback)
+						testWg.Done()
+					})
+			},
+		},
+		"test_things_orchestration_rollback_finished_error": {
+			stat: testStatus,
+			chanEvent: &events.Event{
+				Type:    orchestration.EventTypeOrchestration,
+				Action:  orchestration.EventActionOrchestrationRollbackFinished,
+				Context: orchestration.SetUpdateMgrApplyContext(context.Background(), testManifest),
+				Error:   log.NewError("test rollback error"),
+				Source: &orchestration.RollbackResult{Resources: []*orchestration.ResourceResult{
+					{APIVersion: "v1", Kind: "Pod", Name: "test-pod", Outcome: orchestration.ResourceOutcomeConfigured},
+				}},
+			},
+			mockExecution: func(t *testing.T, evt *events.Event, testWg *sync.WaitGroup) {
+				testWg.Add(1)
+				mockThing.EXPECT().SetFeatureProperty(UpdateOrchestratorFeatureID, updateOrchestratorFeaturePropertyStatusState, gomock.Any()).Do(
+					func(id, path string, state *manifestState) {
+						testutil.AssertEqual(t, &manifestRollback{
+							Status:    manifestStatusFinishedError,
+							Error:     &manifestError{Code: 500, Message: "test rollback error"},
+							Resources: evt.Source.(*orchestration.RollbackResult).Resources,
+						}, state.Rollback)
+						testWg.Done()
+					})
+			},
+		},
+		"test_things_orchestration_reconcile_finished": {
+			stat: testStatus,
+			chanEvent: &events.Event{
+				Type:    orchestration.EventTypeOrchestration,
+				Action:  orchestration.EventActionOrchestrationReconcileFinished,
+				Context: orchestration.SetUpdateMgrReconcileContext(orchestration.SetUpdateMgrApplyContext(context.Background(), testManifest)),
+				Source: &orchestration.ReconcileResult{Resources: []*orchestration.ResourceResult{
+					{APIVersion: "v1", Kind: "Pod", Name: "test-pod", Outcome: orchestration.ResourceOutcomeCreated},
+				}},
+			},
+			mockExecution: func(t *testing.T, evt *events.Event, testWg *sync.WaitGroup) {
+				testWg.Add(2)
+				mockThing.EXPECT().SetFeatureProperty(UpdateOrchestratorFeatureID, updateOrchestratorFeaturePropertyStatusState, gomock.Any()).Do(
+					func(id, path string, state *manifestState) {
+						testutil.AssertEqual(t, testManifest, state.Manifest)
+						testutil.AssertEqual(t, &manifestReconcile{
+							Status:    manifestStatusFinishedSuccess,
+							Resources: evt.Source.(*orchestration.ReconcileResult).Resources,
+						}, state.Reconcile)
 						testWg.Done()
 					})
 				mockThing.EXPECT().SetFeatureProperty(UpdateOrchestratorFeatureID, updateOrchestratorFeaturePropertyStatusCurrentState, gomock.Any()).Do(
@@ -149,6 +238,57 @@ func TestUpdateOrchestratorHandleOrchestrationEvents(t *testing.T) {
 					})
 			},
 		},
+		"test_things_orchestration_plan_started": {
+			stat: testStatus,
+			chanEvent: &events.Event{
+				Type:    orchestration.EventTypeOrchestration,
+				Action:  orchestration.EventActionOrchestrationStarted,
+				Context: orchestration.SetUpdateMgrDryRunContext(orchestration.SetUpdateMgrApplyContext(context.Background(), testManifest)),
+			},
+			mockExecution: func(t *testing.T, evt *events.Event, testWg *sync.WaitGroup) {
+				testWg.Add(1)
+				evt.Context = setApplyCorrelationIDContext(evt.Context, testCorrelationID)
+				mockThing.EXPECT().SetFeatureProperty(UpdateOrchestratorFeatureID, updateOrchestratorFeaturePropertyStatusState, gomock.Any()).Times(0)
+				mockThing.EXPECT().SetFeatureProperty(UpdateOrchestratorFeatureID, updateOrchestratorFeaturePropertyStatusPlan, gomock.Any()).Do(
+					func(id, path string, plan *manifestPlan) {
+						testutil.AssertEqual(t, &manifestPlan{
+							Manifest:      testManifest,
+							Status:        manifestStatusStarted,
+							CorrelationID: testCorrelationID,
+						}, plan)
+						testWg.Done()
+					})
+			},
+		},
+		"test_things_orchestration_plan_finished": {
+			stat: testStatus,
+			chanEvent: &events.Event{
+				Type:    orchestration.EventTypeOrchestration,
+				Action:  orchestration.EventActionOrchestrationFinished,
+				Context: orchestration.SetUpdateMgrDryRunContext(orchestration.SetUpdateMgrApplyContext(context.Background(), testManifest)),
+				Source: &orchestration.ApplyResult{Resources: []*orchestration.ResourceResult{{
+					APIVersion: "v1",
+					Kind:       "Pod",
+					Name:       "test-pod",
+					Outcome:    orchestration.ResourceOutcomeCreated,
+				}}},
+			},
+			mockExecution: func(t *testing.T, evt *events.Event, testWg *sync.WaitGroup) {
+				testWg.Add(1)
+				evt.Context = setApplyCorrelationIDContext(evt.Context, testCorrelationID)
+				mockThing.EXPECT().SetFeatureProperty(UpdateOrchestratorFeatureID, updateOrchestratorFeaturePropertyStatusState, gomock.Any()).Times(0)
+				mockThing.EXPECT().SetFeatureProperty(UpdateOrchestratorFeatureID, updateOrchestratorFeaturePropertyStatusPlan, gomock.Any()).Do(
+					func(id, path string, plan *manifestPlan) {
+						testutil.AssertEqual(t, &manifestPlan{
+							Manifest:      testManifest,
+							Status:        manifestStatusFinishedSuccess,
+							Resources:     evt.Source.(*orchestration.ApplyResult).Resources,
+							CorrelationID: testCorrelationID,
+						}, plan)
+						testWg.Done()
+					})
+			},
+		},
 		"test_things_orchestration_running_no_cfg": {
 			stat: nil,
 			chanEvent: &events.Event{
@@ -174,6 +314,7 @@ func TestUpdateOrchestratorHandleOrchestrationEvents(t *testing.T) {
 			testCase.mockExecution(t, testCase.chanEvent, testWg)
 			eventChan <- testCase.chanEvent
 			testutil.AssertWithTimeout(t, testWg, testEventsTimeout)
+			eventChan <- &events.Event{}
 		})
 	}
 }
@@ -183,7 +324,7 @@ func TestUpdateOrchestratorHandleResourceEvents(t *testing.T) {
 
 	controller := setUpMocks(t)
 
-	testCtrOrchestrator := newUpdateOrchestratorFeature(mockThing, mockEventsManager, mockUpdateManager)
+	testCtrOrchestrator := newUpdateOrchestratorFeature(mockThing, mockEventsManager, mockUpdateManager, newManifestTemplate(nil, ""), newManifestPolicy(""))
 	type mockUpdateEventOrchestrator func(t *testing.T, ctrEvent *events.Event, testWg *sync.WaitGroup)
 
 	defer func() {
@@ -305,6 +446,149 @@ func TestUpdateOrchestratorHandleResourceEvents(t *testing.T) {
 	}
 }
 
+type testDriftDetectorUpdateManager struct {
+	*mocksorchmgr.MockUpdateManager
+	*mocksorchmgr.MockDriftDetector
+}
+
+func TestUpdateOrchestratorUpdateDrift(t *testing.T) {
+	testDrift := []*orchestration.ResourceDrift{{
+		APIVersion: "apps/v1",
+		Kind:       "Deployment",
+		Namespace:  "default",
+		Name:       "test-app",
+		Type:       orchestration.DriftTypeModified,
+		Fields:     []string{"spec.replicas"},
+	}}
+	tests := map[string]struct {
+		status         manifestStatus
+		drift          []*orchestration.ResourceDrift
+		checked        bool
+		expectedStatus manifestStatus
+		expectedDrift  []*orchestration.ResourceDrift
+		published      bool
+	}{
+		"test_modified_locally": {
+			status:         manifestStatusFinishedSuccess,
+			drift:          testDrift,
+			checked:        true,
+			expectedStatus: manifestStatusModifiedLocally,
+			expectedDrift:  testDrift,
+			published:      true,
+		},
+		"test_modified_locally_unchanged": {
+			status:         manifestStatusModifiedLocally,
+			drift:          testDrift,
+			checked:        true,
+			expectedStatus: manifestStatusModifiedLocally,
+			expectedDrift:  testDrift,
+		},
+		"test_modifications_undone": {
+			status:         manifestStatusModifiedLocally,
+			checked:        true,
+			expectedStatus: manifestStatusFinishedSuccess,
+			published:      true,
+		},
+		"test_no_modifications": {
+			status:         manifestStatusFinishedSuccess,
+			checked:        true,
+			expectedStatus: manifestStatusFinishedSuccess,
+		},
+		"test_not_applied_successfully": {
+			status:         manifestStatusFinishedError,
+			expectedStatus: manifestStatusFinishedError,
+		},
+		"test_apply_in_progress": {
+			status:         manifestStatusRunning,
+			expectedStatus: manifestStatusRunning,
+		},
+	}
+	for testName, testCase := range tests {
+		t.Run(testName, func(t *testing.T) {
+			t.Log(testName)
+			controller := setUpMocks(t)
+			defer controller.Finish()
+
+			testDriftDetector := mocksorchmgr.NewMockDriftDetector(controller)
+			testUpdOrchestrator := newUpdateOrchestratorFeature(mockThing, mockEventsManager, &testDriftDetectorUpdateManager{mockUpdateManager, testDriftDetector},
+				newManifestTemplate(nil, ""), newManifestPolicy("")).(*updateOrchestratorFeature)
+			testManifest := []*unstructured.Unstructured{newTestPolicyResource("apps/v1", "Deployment", "default", "test-app")}
+			testUpdOrchestrator.updateState(testManifest)
+			testUpdOrchestrator.status.State.Status = testCase.status
+			if testCase.status == manifestStatusModifiedLocally {
+				testUpdOrchestrator.status.State.Drift = testDrift
+			}
+
+			if testCase.checked {
+				testDriftDetector.EXPECT().Drift(gomock.Any(), testManifest).Return(testCase.drift)
+			}
+			if testCase.published {
+				mockThing.EXPECT().SetFeatureProperty(UpdateOrchestratorFeatureID, updateOrchestratorFeaturePropertyStatusState, gomock.Any()).Do(
+					func(id, path string, state *manifestState) {
+						testutil.AssertEqual(t, testCase.expectedStatus, state.Status)
+						testutil.AssertEqual(t, testCase.expectedDrift, state.Drift)
+					})
+			}
+
+			testUpdOrchestrator.updateDrift(context.Background())
+			testutil.AssertEqual(t, testCase.expectedStatus, testUpdOrchestrator.status.State.Status)
+			testutil.AssertEqual(t, testCase.expectedDrift, testUpdOrchestrator.status.State.Drift)
+		})
+	}
+}
+
+func TestUpdateOrchestratorSelfUpdateVerification(t *testing.T) {
+	operation := &orchestration.Operation{CorrelationID: "test-correlation-id", Origin: UpdateOrchestratorFeatureID}
+	testManifest := []*unstructured.Unstructured{newTestPolicyResource("sdv.eclipse.org/v1", "SelfUpdateBundle", "", "self-update-bundle")}
+
+	tests := map[string]struct {
+		verification   *orchestration.SelfUpdateVerification
+		expectedStatus manifestStatus
+		expectedError  *manifestError
+	}{
+		"test_self_update_verification_success": {
+			verification:   &orchestration.SelfUpdateVerification{Operation: operation, Manifest: testManifest},
+			expectedStatus: manifestStatusFinishedSuccess,
+		},
+		"test_self_update_verification_boot_failed": {
+			verification: &orchestration.SelfUpdateVerification{
+				Operation: operation,
+				Manifest:  testManifest,
+				Err:       log.NewError("boot into new slot failed"),
+			},
+			expectedStatus: manifestStatusFinishedError,
+			expectedError:  &manifestError{Code: 500, Message: "boot into new slot failed"},
+		},
+		"test_self_update_verification_none": {},
+	}
+	for testName, testCase := range tests {
+		t.Run(testName, func(t *testing.T) {
+			t.Log(testName)
+			controller := setUpMocks(t)
+			defer controller.Finish()
+
+			testVerifier := mocksorchmgr.NewMockSelfUpdateVerifier(controller)
+			testUpdOrchestrator := newUpdateOrchestratorFeature(mockThing, mockEventsManager, &testSelfUpdateVerifierUpdateManager{mockUpdateManager, testVerifier},
+				newManifestTemplate(nil, ""), newManifestPolicy("")).(*updateOrchestratorFeature)
+
+			testVerifier.EXPECT().VerifySelfUpdate(gomock.Any(), UpdateOrchestratorFeatureID).Return(testCase.verification)
+			if testCase.verification != nil {
+				mockThing.EXPECT().SetFeatureProperty(UpdateOrchestratorFeatureID, updateOrchestratorFeaturePropertyStatusState, gomock.Any()).Do(
+					func(id, path string, state *manifestState) {
+						testutil.AssertEqual(t, testManifest, state.Manifest)
+						testutil.AssertEqual(t, testCase.expectedStatus, state.Status)
+						testutil.AssertEqual(t, testCase.expectedError, state.Error)
+						testutil.AssertEqual(t, operation.CorrelationID, state.CorrelationID)
+					})
+				mockUpdateManager.EXPECT().Get(gomock.Any()).Return(testManifest)
+				mockThing.EXPECT().SetFeatureProperty(UpdateOrchestratorFeatureID, updateOrchestratorFeaturePropertyStatusCurrentState, gomock.Any())
+			}
+
+			testUpdOrchestrator.handleSelfUpdateVerification(context.Background())
+		})
+	}
+}
+
 func setUpMocks(t *testing.T) *gomock.Controller {
 	controller := gomock.NewController(t)
 
@@ -13,15 +13,17 @@ package things
 
 import (
 	"context"
+	"reflect"
 
 	"github.com/eclipse-kanto/container-management/containerm/log"
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/orchestration"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
 func (updOrchFeature *updateOrchestratorFeature) updateStatus(mfStatus manifestStatus, mfError *manifestError, correlationID string) {
 	updOrchFeature.updatesLock.Lock()
 	defer updOrchFeature.updatesLock.Unlock()
-	if updOrchFeature.status == nil {
+	if updOrchFeature.status == nil || updOrchFeature.status.State == nil {
 		log.Debug("no configured manifest - skipping update")
 		return
 	}
@@ -34,6 +36,62 @@ func (updOrchFeature *updateOrchestratorFeature) updateStatus(mfStatus manifestS
 	}
 }
 
+// updateResources sets the per-resource outcomes of the applied manifest, which are published with the next status update
+func (updOrchFeature *updateOrchestratorFeature) updateResources(resources []*orchestration.ResourceResult) {
+	updOrchFeature.updatesLock.Lock()
+	defer updOrchFeature.updatesLock.Unlock()
+	if updOrchFeature.status == nil || updOrchFeature.status.State == nil {
+		return
+	}
+	updOrchFeature.status.State.Resources = resources
+}
+
+// updateProgress sets the progress of the running manifest, which is published with the next status update
+func (updOrchFeature *updateOrchestratorFeature) updateProgress(progress *orchestration.Progress) {
+	updOrchFeature.updatesLock.Lock()
+	defer updOrchFeature.updatesLock.Unlock()
+	if updOrchFeature.status == nil || updOrchFeature.status.State == nil {
+		return
+	}
+	updOrchFeature.status.State.Progress = progress
+}
+
+func (updOrchFeature *updateOrchestratorFeature) updateRollbackStatus(mfStatus manifestStatus, mfError *manifestError, resources []*orchestration.ResourceResult) {
+	updOrchFeature.updatesLock.Lock()
+	defer updOrchFeature.updatesLock.Unlock()
+	if updOrchFeature.status == nil || updOrchFeature.status.State == nil {
+		log.Debug("no configured manifest - skipping rollback update")
+		return
+	}
+	updOrchFeature.status.State.Rollback = &manifestRollback{
+		Status:    mfStatus,
+		Error:     mfError,
+		Resources: resources,
+	}
+
+	if err := updOrchFeature.rootThing.SetFeatureProperty(UpdateOrchestratorFeatureID, updateOrchestratorFeaturePropertyStatusState, updOrchFeature.status.State); err != nil {
+		log.Error("could not update the UpdateOrchestrator feature status property: %v", err)
+	}
+}
+
+func (updOrchFeature *updateOrchestratorFeature) updateReconcileStatus(mfStatus manifestStatus, mfError *manifestError, resources []*orchestration.ResourceResult) {
+	updOrchFeature.updatesLock.Lock()
+	defer updOrchFeature.updatesLock.Unlock()
+	if updOrchFeature.status == nil || updOrchFeature.status.State == nil {
+		log.Debug("no configured manifest - skipping reconcile update")
+		return
+	}
+	updOrchFeature.status.State.Reconcile = &manifestReconcile{
+		Status:    mfStatus,
+		Error:     mfError,
+		Resources: resources,
+	}
+
+	if err := updOrchFeature.rootThing.SetFeatureProperty(UpdateOrchestratorFeatureID, updateOrchestratorFeaturePropertyStatusState, updOrchFeature.status.State); err != nil {
+		log.Error("could not update the UpdateOrchestrator feature status property: %v", err)
+	}
+}
+
 func (updOrchFeature *updateOrchestratorFeature) updateCurrentState(ctx context.Context) {
 	updOrchFeature.updatesLock.Lock()
 	defer updOrchFeature.updatesLock.Unlock()
@@ -46,11 +104,85 @@ func (updOrchFeature *updateOrchestratorFeature) updateCurrentState(ctx context.
 	}
 }
 
+// updateDrift compares the live resources against the last successfully applied manifest and reports the local modifications.
+// The status is reverted to FINISHED_SUCCESS once the local modifications are undone.
+func (updOrchFeature *updateOrchestratorFeature) updateDrift(ctx context.Context) {
+	detector, ok := updOrchFeature.orchMgr.(orchestration.DriftDetector)
+	if !ok {
+		return
+	}
+	updOrchFeature.updatesLock.Lock()
+	defer updOrchFeature.updatesLock.Unlock()
+	if updOrchFeature.status == nil || updOrchFeature.status.State == nil {
+		return
+	}
+	state := updOrchFeature.status.State
+	if state.Status != manifestStatusFinishedSuccess && state.Status != manifestStatusModifiedLocally {
+		return
+	}
+	drift := detector.Drift(ctx, state.Manifest)
+	if len(drift) == 0 {
+		if state.Status == manifestStatusFinishedSuccess {
+			return
+		}
+		log.Info("the local modifications of the applied manifest are undone")
+		state.Status = manifestStatusFinishedSuccess
+		state.Drift = nil
+	} else {
+		if state.Status == manifestStatusModifiedLocally && reflect.DeepEqual(state.Drift, drift) {
+			return
+		}
+		log.Warn("%d resource(s) of the applied manifest are modified locally", len(drift))
+		state.Status = manifestStatusModifiedLocally
+		state.Drift = drift
+	}
+
+	if err := updOrchFeature.rootThing.SetFeatureProperty(UpdateOrchestratorFeatureID, updateOrchestratorFeaturePropertyStatusState, state); err != nil {
+		log.Error("could not update the UpdateOrchestrator feature status property: %v", err)
+	}
+}
+
 func (updOrchFeature *updateOrchestratorFeature) updateState(mf []*unstructured.Unstructured) {
 	updOrchFeature.updatesLock.Lock()
 	defer updOrchFeature.updatesLock.Unlock()
 
+	var plan *manifestPlan
+	if updOrchFeature.status != nil {
+		plan = updOrchFeature.status.Plan
+	}
 	updOrchFeature.status = &updateOrchestratorFeatureStatus{State: &manifestState{
 		Manifest: mf,
-	}}
+	}, Plan: plan}
+}
+
+// getStateManifest returns the manifest of the last operation or nil if there is no such
+func (updOrchFeature *updateOrchestratorFeature) getStateManifest() []*unstructured.Unstructured {
+	updOrchFeature.updatesLock.Lock()
+	defer updOrchFeature.updatesLock.Unlock()
+	if updOrchFeature.status == nil || updOrchFeature.status.State == nil {
+		return nil
+	}
+	return updOrchFeature.status.State.Manifest
+}
+
+// rejectState reports a manifest that is rejected before being applied
+func (updOrchFeature *updateOrchestratorFeature) rejectState(mf []*unstructured.Unstructured, err error, correlationID string) {
+	updOrchFeature.updateState(mf)
+	updOrchFeature.updateStatus(manifestStatusFinishedRejected, &manifestError{
+		Code:    400,
+		Message: err.Error(),
+	}, correlationID)
+}
+
+func (updOrchFeature *updateOrchestratorFeature) updatePlan(plan *manifestPlan) {
+	updOrchFeature.updatesLock.Lock()
+	defer updOrchFeature.updatesLock.Unlock()
+	if updOrchFeature.status == nil {
+		updOrchFeature.status = &updateOrchestratorFeatureStatus{}
+	}
+	updOrchFeature.status.Plan = plan
+
+	if err := updOrchFeature.rootThing.SetFeatureProperty(UpdateOrchestratorFeatureID, updateOrchestratorFeaturePropertyStatusPlan, plan); err != nil {
+		log.Error("could not update the UpdateOrchestrator feature status/plan property: %v", err)
+	}
 }
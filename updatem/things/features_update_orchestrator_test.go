@@ -20,7 +20,9 @@ import (
 
 	"github.com/eclipse-kanto/container-management/things/api/model"
 	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/events"
+	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/orchestration"
 	"github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/pkg/testutil"
+	mocksorchmgr "github.com/eclipse-leda/leda-contrib-vehicle-update-manager/updatem/pkg/testutil/mocks/orchestration"
 
 	"github.com/golang/mock/gomock"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -33,7 +35,7 @@ func TestUpdateOrchestratorCreateFeature(t *testing.T) {
 	setupThingMock(controller)
 	setupUpdateManagerMock(controller)
 
-	testUpdOrchestrator := newUpdateOrchestratorFeature(mockThing, mockEventsManager, mockUpdateManager)
+	testUpdOrchestrator := newUpdateOrchestratorFeature(mockThing, mockEventsManager, mockUpdateManager, newManifestTemplate(nil, ""), newManifestPolicy(""))
 
 	defer func() {
 		testUpdOrchestrator.dispose()
@@ -56,7 +58,7 @@ func TestUpdateOrchestratorRegister(t *testing.T) {
 	setupThingMock(controller)
 	setupUpdateManagerMock(controller)
 
-	testUpdOrchestrator := newUpdateOrchestratorFeature(mockThing, mockEventsManager, mockUpdateManager)
+	testUpdOrchestrator := newUpdateOrchestratorFeature(mockThing, mockEventsManager, mockUpdateManager, newManifestTemplate(nil, ""), newManifestPolicy(""))
 
 	defer func() {
 		testUpdOrchestrator.dispose()
@@ -90,7 +92,7 @@ func TestUpdateOrchestratorOperationsHandlerNegative(t *testing.T) {
 	setupThingMock(controller)
 	setupUpdateManagerMock(controller)
 
-	testUpdOrchestrator := newUpdateOrchestratorFeature(mockThing, mockEventsManager, mockUpdateManager)
+	testUpdOrchestrator := newUpdateOrchestratorFeature(mockThing, mockEventsManager, mockUpdateManager, newManifestTemplate(nil, ""), newManifestPolicy(""))
 
 	defer func() {
 		testUpdOrchestrator.dispose()
@@ -120,6 +122,14 @@ func TestUpdateOrchestratorOperationsHandlerNegative(t *testing.T) {
 			command: updateOrchestratorFeatureOperationApply,
 			args:    map[string]interface{}{"correlationId": "test-correlation-id"},
 		},
+		"test_plan_args_no_correlation_id": {
+			command: updateOrchestratorFeatureOperationPlan,
+			args:    map[string]interface{}{"something": 20},
+		},
+		"test_plan_args_no_payload": {
+			command: updateOrchestratorFeatureOperationPlan,
+			args:    map[string]interface{}{"correlationId": "test-correlation-id"},
+		},
 	}
 
 	// execute tests
@@ -141,7 +151,7 @@ func TestUpdateOrchestratorApplyCalled(t *testing.T) {
 	setupThingMock(controller)
 	setupUpdateManagerMock(controller)
 
-	testUpdOrchestrator := newUpdateOrchestratorFeature(mockThing, mockEventsManager, mockUpdateManager)
+	testUpdOrchestrator := newUpdateOrchestratorFeature(mockThing, mockEventsManager, mockUpdateManager, newManifestTemplate(nil, ""), newManifestPolicy(""))
 
 	defer func() {
 		testUpdOrchestrator.dispose()
@@ -162,6 +172,97 @@ func TestUpdateOrchestratorApplyCalled(t *testing.T) {
 	testutil.AssertWithTimeout(t, testWg, 5*time.Second)
 }
 
+func TestUpdateOrchestratorPlanCalled(t *testing.T) {
+	controller := gomock.NewController(t)
+
+	setupEventsManagerMock(controller)
+	setupThingMock(controller)
+	setupUpdateManagerMock(controller)
+
+	testUpdOrchestrator := newUpdateOrchestratorFeature(mockThing, mockEventsManager, mockUpdateManager, newManifestTemplate(nil, ""), newManifestPolicy(""))
+
+	defer func() {
+		testUpdOrchestrator.dispose()
+		controller.Finish()
+	}()
+
+	planConfig := make(map[string]interface{})
+	planConfig["correlationId"] = "test-correlation-id"
+	planConfig["payload"] = "test-payload"
+
+	testWg := &sync.WaitGroup{}
+	testWg.Add(1)
+	mockUpdateManager.EXPECT().Apply(gomock.Any(), gomock.Any()).Do(func(ctx context.Context, mf []*unstructured.Unstructured) {
+		testutil.AssertTrue(t, orchestration.IsUpdateMgrDryRunContext(ctx))
+		testutil.AssertEqual(t, "test-correlation-id", getApplyCorrelationIDContext(ctx))
+		testWg.Done()
+	}).Times(1)
+
+	res, err := testUpdOrchestrator.(*updateOrchestratorFeature).featureOperationsHandler(updateOrchestratorFeatureOperationPlan, planConfig)
+	testutil.AssertNil(t, res)
+	testutil.AssertNil(t, err)
+	testutil.AssertWithTimeout(t, testWg, 5*time.Second)
+}
+
+type testCancelerUpdateManager struct {
+	*mocksorchmgr.MockUpdateManager
+	*mocksorchmgr.MockCanceler
+}
+
+func TestUpdateOrchestratorCancel(t *testing.T) {
+	tests := map[string]struct {
+		cancelErr error
+	}{
+		"test_cancel_no_err": {},
+		"test_cancel_err": {
+			cancelErr: fmt.Errorf("the self update bundle cannot be cancelled in state 'installing'"),
+		},
+	}
+
+	for testName, testCase := range tests {
+		t.Run(testName, func(t *testing.T) {
+			t.Log(testName)
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			setupEventsManagerMock(controller)
+			setupThingMock(controller)
+			setupUpdateManagerMock(controller)
+
+			testCanceler := mocksorchmgr.NewMockCanceler(controller)
+			testUpdOrchestrator := newUpdateOrchestratorFeature(mockThing, mockEventsManager, &testCancelerUpdateManager{mockUpdateManager, testCanceler},
+				newManifestTemplate(nil, ""), newManifestPolicy("")).(*updateOrchestratorFeature)
+
+			// the cancel operation must not wait for the apply operation in progress
+			testUpdOrchestrator.processOperationsLock.Lock()
+			defer testUpdOrchestrator.processOperationsLock.Unlock()
+
+			testCanceler.EXPECT().Cancel(gomock.Any()).Return(testCase.cancelErr)
+			res, err := testUpdOrchestrator.featureOperationsHandler(updateOrchestratorFeatureOperationCancel, nil)
+			testutil.AssertNil(t, res)
+			if testCase.cancelErr != nil {
+				testutil.AssertContainsString(t, err.Error(), testCase.cancelErr.Error())
+			} else {
+				testutil.AssertNil(t, err)
+			}
+		})
+	}
+}
+
+func TestUpdateOrchestratorCancelNotSupported(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	setupEventsManagerMock(controller)
+	setupThingMock(controller)
+	setupUpdateManagerMock(controller)
+
+	testUpdOrchestrator := newUpdateOrchestratorFeature(mockThing, mockEventsManager, mockUpdateManager, newManifestTemplate(nil, ""), newManifestPolicy(""))
+	res, err := testUpdOrchestrator.(*updateOrchestratorFeature).featureOperationsHandler(updateOrchestratorFeatureOperationCancel, nil)
+	testutil.AssertNil(t, res)
+	testutil.AssertNotNil(t, err)
+}
+
 func TestUpdateOrchestratorOperationsHandlerProcessApply(t *testing.T) {
 	controller := gomock.NewController(t)
 
@@ -169,7 +270,7 @@ func TestUpdateOrchestratorOperationsHandlerProcessApply(t *testing.T) {
 	setupThingMock(controller)
 	setupUpdateManagerMock(controller)
 
-	testUpdOrchestrator := newUpdateOrchestratorFeature(mockThing, mockEventsManager, mockUpdateManager)
+	testUpdOrchestrator := newUpdateOrchestratorFeature(mockThing, mockEventsManager, mockUpdateManager, newManifestTemplate(nil, ""), newManifestPolicy(""))
 	testManifest := getTestManifest()
 
 	defer controller.Finish()
@@ -73,6 +73,9 @@ func setupThingsUpdateManager(controller *gomock.Controller) {
 		0,
 		0,
 		0,
+		"",
+		"",
+		"",
 	)
 }
 
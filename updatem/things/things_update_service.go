@@ -48,10 +48,13 @@ type UpdateThingsManager interface {
 }
 
 type updateThingsMgr struct {
-	enabledFeatureIds []string
-	storageRoot       string
-	updOrchMgr        orchestration.UpdateManager
-	eventsMgr         events.UpdateEventsManager
+	enabledFeatureIds  []string
+	storageRoot        string
+	manifestValuesFile string
+	manifestPolicyFile string
+	manifestVariant    string
+	updOrchMgr         orchestration.UpdateManager
+	eventsMgr          events.UpdateEventsManager
 
 	thingsClient *client.Client
 
@@ -37,13 +37,19 @@ func newThingsUpdateManager(mgr orchestration.UpdateManager, eventsMgr events.Up
 	connectTimeout time.Duration,
 	acknowledgeTimeout time.Duration,
 	subscribeTimeout time.Duration,
-	unsubscribeTimeout time.Duration) *updateThingsMgr {
+	unsubscribeTimeout time.Duration,
+	manifestValuesFile string,
+	manifestPolicyFile string,
+	manifestVariant string) *updateThingsMgr {
 	thingsMgr := &updateThingsMgr{
-		storageRoot:       storagePath,
-		updOrchMgr:        mgr,
-		eventsMgr:         eventsMgr,
-		enabledFeatureIds: enabledFeatureIds,
-		managedFeatures:   map[string]managedFeature{},
+		storageRoot:        storagePath,
+		manifestValuesFile: manifestValuesFile,
+		manifestPolicyFile: manifestPolicyFile,
+		manifestVariant:    manifestVariant,
+		updOrchMgr:         mgr,
+		eventsMgr:          eventsMgr,
+		enabledFeatureIds:  enabledFeatureIds,
+		managedFeatures:    map[string]managedFeature{},
 	}
 
 	thingsClientOpts := client.NewConfiguration()
@@ -93,5 +99,8 @@ func registryInit(registryCtx *registry.ServiceRegistryContext) (interface{}, er
 		tOpts.connectTimeout,
 		tOpts.acknowledgeTimeout,
 		tOpts.subscribeTimeout,
-		tOpts.unsubscribeTimeout), nil
+		tOpts.unsubscribeTimeout,
+		tOpts.manifestValuesFile,
+		tOpts.manifestPolicyFile,
+		tOpts.manifestVariant), nil
 }
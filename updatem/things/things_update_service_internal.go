@@ -54,8 +54,11 @@ func (tMgr *updateThingsMgr) thingsClientInitializedHandler(cl *client.Client, c
 }
 
 func (tMgr *updateThingsMgr) processThing(thing model.Thing) {
-	if thing.GetID().String() == tMgr.updateThingID {
+	thingID := thing.GetID()
+	if thingID.String() == tMgr.updateThingID {
 		ctx := context.Background()
+		mfTemplate := newManifestTemplate(thingID, tMgr.manifestValuesFile)
+		mfPolicy := newManifestPolicy(tMgr.manifestPolicyFile)
 
 		// dispose all features(their event handlers would be closed)
 		tMgr.disposeFeatures()
@@ -64,7 +67,7 @@ func (tMgr *updateThingsMgr) processThing(thing model.Thing) {
 		// handle UpdateOrchestrator
 		if tMgr.isFeatureEnabled(UpdateOrchestratorFeatureID) {
 			log.Debug("registering %s feature", UpdateOrchestratorFeatureID)
-			updOrchestrator := newUpdateOrchestratorFeature(thing, tMgr.eventsMgr, tMgr.updOrchMgr)
+			updOrchestrator := newUpdateOrchestratorFeature(thing, tMgr.eventsMgr, tMgr.updOrchMgr, mfTemplate, mfPolicy)
 			tMgr.managedFeatures[UpdateOrchestratorFeatureID] = updOrchestrator
 		} else {
 			log.Debug("%s feature is NOT enabled and will not be registered", UpdateOrchestratorFeatureID)
@@ -73,7 +76,7 @@ func (tMgr *updateThingsMgr) processThing(thing model.Thing) {
 		// handle SoftwareUpdatable:manifest
 		if tMgr.isFeatureEnabled(SoftwareUpdatableManifestsFeatureID) {
 			log.Debug("registering %s feature", SoftwareUpdatableManifestsFeatureID)
-			suMf := newSoftwareUpdatableManifests(thing, tMgr.eventsMgr, tMgr.updOrchMgr)
+			suMf := newSoftwareUpdatableManifests(thing, tMgr.eventsMgr, tMgr.updOrchMgr, mfTemplate, mfPolicy, newHelmReleases(tMgr.storageRoot), tMgr.manifestVariant)
 			tMgr.managedFeatures[SoftwareUpdatableManifestsFeatureID] = suMf
 		} else {
 			log.Debug("%s feature is NOT enabled and will not be registered", SoftwareUpdatableManifestsFeatureID)
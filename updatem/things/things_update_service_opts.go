@@ -28,6 +28,9 @@ type thingsOpts struct {
 	acknowledgeTimeout time.Duration
 	subscribeTimeout   time.Duration
 	unsubscribeTimeout time.Duration
+	manifestValuesFile string
+	manifestPolicyFile string
+	manifestVariant    string
 }
 
 func applyOptsThings(thingsOpts *thingsOpts, opts ...UpdateThingsManagerOpt) error {
@@ -126,3 +129,26 @@ func WithConnectionUnsubscribeTimeout(unsubscribeTimeout time.Duration) UpdateTh
 		return nil
 	}
 }
+
+func WithManifestValuesFile(manifestValuesFile string) UpdateThingsManagerOpt {
+	return func(thingsOptions *thingsOpts) error {
+		thingsOptions.manifestValuesFile = manifestValuesFile
+		return nil
+	}
+}
+
+// WithManifestPolicyFile configures the admission policy file, which restricts what the update manifests may touch
+func WithManifestPolicyFile(manifestPolicyFile string) UpdateThingsManagerOpt {
+	return func(thingsOptions *thingsOpts) error {
+		thingsOptions.manifestPolicyFile = manifestPolicyFile
+		return nil
+	}
+}
+
+// WithManifestVariant configures the vehicle variant, whose overlay is built from the kustomization archives
+func WithManifestVariant(manifestVariant string) UpdateThingsManagerOpt {
+	return func(thingsOptions *thingsOpts) error {
+		thingsOptions.manifestVariant = manifestVariant
+		return nil
+	}
+}
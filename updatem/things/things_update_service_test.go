@@ -46,7 +46,10 @@ func TestThingsUpdateServiceConnectWithCredentials(t *testing.T) {
 		0,
 		0,
 		0,
-		0)
+		0,
+		"",
+		"",
+		"")
 	setupThingMock(controller)
 
 	listener, err := net.Listen("tcp4", testMQTTBrokerURL)
@@ -94,7 +97,10 @@ func TestThingsUpdateServiceConnectNoCredentials(t *testing.T) {
 		0,
 		0,
 		0,
-		0)
+		0,
+		"",
+		"",
+		"")
 	setupThingMock(controller)
 
 	listener, err := net.Listen("tcp4", testMQTTBrokerURL)